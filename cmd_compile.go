@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"willofdaedalus/mime/internal/engine/cache"
+)
+
+// mime compile [-o schema.mimec] a.mime b.mime ...
+func runCompile(args []string) error {
+	fs := flag.NewFlagSet("compile", flag.ContinueOnError)
+	out := fs.String("o", "schema"+cache.Ext, "where to write the compiled schema")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("expected at least one .mime file")
+	}
+
+	s, hit, err := cache.Compile(*out, fs.Args())
+	if err != nil {
+		return err
+	}
+	if hit {
+		fmt.Printf("%s is up to date\n", *out)
+		return nil
+	}
+	fmt.Printf("compiled %d entities into %s\n", len(s.Entities), *out)
+	return nil
+}
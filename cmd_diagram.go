@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/diagram"
+)
+
+// mime diagram [-format mermaid|dot] [-focus entity] [-depth n] [-o file] schema.mime
+func runDiagram(args []string) error {
+	names := slices.Sorted(maps.Keys(diagram.Formats))
+	fs := flag.NewFlagSet("diagram", flag.ContinueOnError)
+	format := fs.String("format", "mermaid", "how to draw the schema: "+strings.Join(names, ", "))
+	focus := fs.String("focus", "", "only draw the entities around this one")
+	depth := fs.Int("depth", 1, "how many references away from -focus to draw")
+	out := fs.String("o", "", "where to write the diagram instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected a single .mime file")
+	}
+	draw, ok := diagram.Formats[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %s; expected one of %s", *format, strings.Join(names, ", "))
+	}
+
+	s, err := loadSchema(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	code, err := draw(s, diagram.Options{Focus: *focus, Depth: *depth})
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		fmt.Print(code)
+		return nil
+	}
+	return os.WriteFile(*out, []byte(code), 0o644)
+}
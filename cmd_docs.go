@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/docs"
+)
+
+// mime docs [-format html|markdown] [-out site] schema.mime
+func runDocs(args []string) error {
+	names := slices.Sorted(maps.Keys(docs.Formats))
+	fs := flag.NewFlagSet("docs", flag.ContinueOnError)
+	format := fs.String("format", "html", "what to write the pages as: "+strings.Join(names, ", "))
+	out := fs.String("out", "site", "the directory the pages are written to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected a single .mime file")
+	}
+	render, ok := docs.Formats[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %s; expected one of %s", *format, strings.Join(names, ", "))
+	}
+
+	s, err := loadSchema(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	pages, err := render(s)
+	if err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(pages)) {
+		path := filepath.Join(*out, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(pages[name]), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"willofdaedalus/mime/internal/engine/ir"
+)
+
+// mime fingerprint [-entities] [-json] schema.mime
+func runFingerprint(args []string) error {
+	fs := flag.NewFlagSet("fingerprint", flag.ContinueOnError)
+	entities := fs.Bool("entities", false, "also print each entity's hash")
+	asJSON := fs.Bool("json", false, "print the fingerprint as json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected a single .mime file")
+	}
+
+	s, err := loadSchema(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	fp := ir.Hash(s)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(fp)
+	}
+
+	fmt.Println(fp.Schema)
+	if *entities {
+		names := make([]string, 0, len(fp.Entities))
+		for name := range fp.Entities {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			fmt.Printf("%s %s\n", fp.Entities[name], name)
+		}
+	}
+	return nil
+}
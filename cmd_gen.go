@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/codegen"
+	"willofdaedalus/mime/internal/engine/ddl"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// a generator adds the flags its target takes and returns what writes the
+// target once they're parsed. what it writes is keyed by file name; targets
+// that write a single file use ""
+type generator func(fs *flag.FlagSet) func(s *types.Schema) (map[string]string, error)
+
+var generators = map[string]generator{
+	"go":         genGo,
+	"graphql":    genGraphQL,
+	"jsonschema": genJSONSchema,
+	"openapi":    genOpenAPI,
+	"proto":      genProto,
+	"python":     genPython,
+	"ts":         genTS,
+}
+
+// single adapts a target that writes one file
+func single(gen func(s *types.Schema) (string, error)) func(s *types.Schema) (map[string]string, error) {
+	return func(s *types.Schema) (map[string]string, error) {
+		out, err := gen(s)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"": out}, nil
+	}
+}
+
+func genGo(fs *flag.FlagSet) func(s *types.Schema) (map[string]string, error) {
+	names := slices.Sorted(maps.Keys(ddl.Dialects))
+	pkg := fs.String("package", "models", "the package the code belongs to")
+	dialect := fs.String("dialect", "sqlite", "the database the repositories query: "+strings.Join(names, ", "))
+	return single(func(s *types.Schema) (string, error) {
+		return codegen.Go(s, *pkg, *dialect)
+	})
+}
+
+func genTS(*flag.FlagSet) func(s *types.Schema) (map[string]string, error) {
+	return single(codegen.TypeScript)
+}
+
+func genGraphQL(*flag.FlagSet) func(s *types.Schema) (map[string]string, error) {
+	return single(codegen.GraphQL)
+}
+
+func genPython(*flag.FlagSet) func(s *types.Schema) (map[string]string, error) {
+	return single(codegen.Python)
+}
+
+func genOpenAPI(fs *flag.FlagSet) func(s *types.Schema) (map[string]string, error) {
+	title := fs.String("title", "api", "the api's title")
+	version := fs.String("version", "", "the api's version; defaults to the start of the schema's fingerprint")
+	return single(func(s *types.Schema) (string, error) {
+		return codegen.OpenAPI(s, *title, *version)
+	})
+}
+
+func genJSONSchema(*flag.FlagSet) func(s *types.Schema) (map[string]string, error) {
+	return codegen.JSONSchema
+}
+
+// proto numbers have to outlive a single run so they're kept in a lock file
+// that's read before generating and written back after
+func genProto(fs *flag.FlagSet) func(s *types.Schema) (map[string]string, error) {
+	pkg := fs.String("package", "api", "the proto package the file declares")
+	path := fs.String("lock", "mime.proto.lock", "the file the field and enum numbers are kept in")
+	return func(s *types.Schema) (map[string]string, error) {
+		var lock codegen.ProtoLock
+		data, err := os.ReadFile(*path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, err
+		default:
+			if err := json.Unmarshal(data, &lock); err != nil {
+				return nil, fmt.Errorf("%s: %w", *path, err)
+			}
+		}
+
+		out, err := codegen.Proto(s, *pkg, &lock)
+		if err != nil {
+			return nil, err
+		}
+		data, err = json.MarshalIndent(lock, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(*path, append(data, '\n'), 0o644); err != nil {
+			return nil, err
+		}
+		return map[string]string{"": out}, nil
+	}
+}
+
+// mime gen <target> [-o file|dir] [target flags] schema.mime
+func runGen(args []string) error {
+	names := slices.Sorted(maps.Keys(generators))
+	if len(args) == 0 {
+		return fmt.Errorf("expected a target to generate: %s", strings.Join(names, ", "))
+	}
+	gen, ok := generators[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown target %s; expected one of %s", args[0], strings.Join(names, ", "))
+	}
+
+	fs := flag.NewFlagSet("gen "+args[0], flag.ContinueOnError)
+	out := fs.String("o", "", "where to write the code instead of stdout; a directory for targets that write several files")
+	write := gen(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected a single .mime file")
+	}
+
+	s, err := loadSchema(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	files, err := write(s)
+	if err != nil {
+		return err
+	}
+
+	if code, ok := files[""]; ok {
+		if *out == "" {
+			fmt.Print(code)
+			return nil
+		}
+		return os.WriteFile(*out, []byte(code), 0o644)
+	}
+
+	dir := *out
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(files[name]), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
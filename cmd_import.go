@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/importer"
+)
+
+// each importer takes the file's name and contents and returns .mime source
+var importers = map[string]func(name string, src []byte) (string, error){
+	"sql":        importer.SQL,
+	"jsonschema": importer.JSONSchema,
+	"openapi":    importer.OpenAPI,
+}
+
+// mime import sql|jsonschema|openapi [-o schema.mime] file
+func runImport(args []string) error {
+	names := slices.Sorted(maps.Keys(importers))
+	if len(args) == 0 {
+		return fmt.Errorf("expected a format to import: %s", strings.Join(names, ", "))
+	}
+	imp, ok := importers[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown format %s; expected one of %s", args[0], strings.Join(names, ", "))
+	}
+
+	fs := flag.NewFlagSet("import "+args[0], flag.ContinueOnError)
+	out := fs.String("o", "", "where to write the schema instead of stdout")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected a single file to import")
+	}
+
+	src, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	mime, err := imp(filepath.Base(fs.Arg(0)), src)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		fmt.Print(mime)
+		return nil
+	}
+	return os.WriteFile(*out, []byte(mime), 0o644)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"willofdaedalus/mime/internal/engine/ir"
+)
+
+// mime ir schema.mime > schema.json
+func runIR(args []string) error {
+	fs := flag.NewFlagSet("ir", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected a single .mime file")
+	}
+
+	s, err := loadSchema(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return ir.Encode(os.Stdout, s)
+}
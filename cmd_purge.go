@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/ddl"
+	"willofdaedalus/mime/internal/engine/query"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// mime purge [-older-than 30] [-dialect sqlite] [-now time] schema.mime
+func runPurge(args []string) error {
+	names := slices.Sorted(maps.Keys(ddl.Dialects))
+	fs := flag.NewFlagSet("purge", flag.ContinueOnError)
+	days := fs.Int("older-than", 30, "how many days a row has to have been deleted for before it's removed")
+	dialect := fs.String("dialect", "sqlite", "the database to write for: "+strings.Join(names, ", "))
+	now := fs.String("now", "", "the rfc 3339 time the days are counted back from; defaults to the current time")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected a single .mime file")
+	}
+	if *days < 0 {
+		return fmt.Errorf("-older-than: %d is negative", *days)
+	}
+	d, ok := ddl.Dialects[*dialect]
+	if !ok {
+		return fmt.Errorf("unknown dialect %s; expected one of %s", *dialect, strings.Join(names, ", "))
+	}
+	at := time.Now()
+	if *now != "" {
+		t, err := time.Parse(time.RFC3339, *now)
+		if err != nil {
+			return fmt.Errorf("-now: %w", err)
+		}
+		at = t
+	}
+
+	s, err := loadSchema(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return purge(os.Stdout, s, d, time.Duration(*days)*24*time.Hour, at)
+}
+
+// purge writes the statements with the cutoff in place of their placeholder,
+// in the layout mime seed writes timestamps in, so they can be piped
+// straight into the database
+func purge(w io.Writer, s *types.Schema, d ddl.Dialect, olderThan time.Duration, now time.Time) error {
+	stmts := query.PurgeAll(s, d, olderThan, now)
+	if len(stmts) == 0 {
+		return errors.New("no entity in the schema is soft deleted")
+	}
+	for _, stmt := range stmts {
+		cutoff := stmt.Args[0].(time.Time).UTC().Format(d.TimeLayout())
+		if _, err := fmt.Fprintf(w, "%s;\n", strings.Replace(stmt.SQL, "?", "'"+cutoff+"'", 1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
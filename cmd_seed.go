@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/ddl"
+	"willofdaedalus/mime/internal/engine/seed"
+)
+
+// mime seed [-count 10] [-seed 1] [-format jsonl|sql] [-dialect sqlite] [-now time] [-o file] schema.mime
+func runSeed(args []string) error {
+	formats := slices.Sorted(maps.Keys(seed.Formats))
+	dialects := slices.Sorted(maps.Keys(ddl.Dialects))
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	count := fs.Int("count", 10, "how many rows to generate per entity")
+	seedValue := fs.Uint64("seed", 1, "the seed; the same seed always gives the same rows")
+	format := fs.String("format", "jsonl", "how to write the rows: "+strings.Join(formats, ", "))
+	dialect := fs.String("dialect", "sqlite", "the database -format sql writes for: "+strings.Join(dialects, ", "))
+	now := fs.String("now", "2025-01-01T00:00:00Z", "the rfc 3339 time generated timestamps lead up to")
+	out := fs.String("o", "", "where to write the rows instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected a single .mime file")
+	}
+	write, ok := seed.Formats[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %s; expected one of %s", *format, strings.Join(formats, ", "))
+	}
+	at, err := time.Parse(time.RFC3339, *now)
+	if err != nil {
+		return fmt.Errorf("-now: %w", err)
+	}
+
+	s, err := loadSchema(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	rows, err := write(s, seed.Options{Count: *count, Seed: *seedValue, Now: at, Dialect: *dialect})
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		fmt.Print(rows)
+		return nil
+	}
+	return os.WriteFile(*out, []byte(rows), 0o644)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/ddl"
+)
+
+// mime sql [-dialect sqlite|postgres|mysql] schema.mime
+func runSQL(args []string) error {
+	names := slices.Sorted(maps.Keys(ddl.Dialects))
+	fs := flag.NewFlagSet("sql", flag.ContinueOnError)
+	dialect := fs.String("dialect", "sqlite", "the database to write for: "+strings.Join(names, ", "))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected a single .mime file")
+	}
+	d, ok := ddl.Dialects[*dialect]
+	if !ok {
+		return fmt.Errorf("unknown dialect %s; expected one of %s", *dialect, strings.Join(names, ", "))
+	}
+
+	s, err := loadSchema(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	out, warnings, err := ddl.Generate(s, d)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "mime sql: warning: %s\n", w)
+	}
+
+	fmt.Print(out)
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"willofdaedalus/mime/internal/engine/runtime"
+)
+
+// mime validate [-json] schema.mime entity [payload.json]
+//
+// the payload is read from stdin when no file is given
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the errors as json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 && fs.NArg() != 3 {
+		return errors.New("expected a .mime file, an entity and optionally a payload file")
+	}
+
+	s, err := loadSchema(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	in := io.Reader(os.Stdin)
+	if fs.NArg() == 3 {
+		f, err := os.Open(fs.Arg(2))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	var payload map[string]any
+	if err := json.NewDecoder(in).Decode(&payload); err != nil {
+		return fmt.Errorf("reading payload: %w", err)
+	}
+
+	errs := runtime.NewValidator(s).Validate(fs.Arg(1), payload)
+	if *asJSON {
+		if errs == nil {
+			errs = []runtime.FieldError{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(errs); err != nil {
+			return err
+		}
+	} else {
+		for _, e := range errs {
+			fmt.Printf("%s\t%s\t%s\n", e.Pointer, e.Code, e.Message)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("payload has %d problem(s)", len(errs))
+	}
+	return nil
+}
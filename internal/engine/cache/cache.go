@@ -0,0 +1,240 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/parser"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// compiling a big schema means lexing, parsing, expanding and checking every
+// file again each time the engine starts. the cache keeps the finished model
+// in a .mimec file so a restart with nothing changed skips all of that.
+//
+// a cache file is laid out as
+//
+//	magic     "MIMEC" and a format byte
+//	checksum  sha256 of everything after it
+//	key       the key it was written under
+//	schema    see cache_codec.go
+//
+// the key covers the engine
+// version and every source file's name and content so editing, adding,
+// removing or renaming a file or upgrading the engine all miss the cache.
+// a file that doesn't match its checksum or doesn't decode is treated the
+// same as a miss and gets rewritten
+
+const Ext = ".mimec"
+
+// bumped whenever the layout above or the codec changes
+const format byte = 3
+
+var magic = []byte("MIMEC")
+
+// Version identifies the engine. release builds set it with
+// -ldflags "-X willofdaedalus/mime/internal/engine/cache.Version=v1.2.0";
+// otherwise the vcs revision the binary was built from is used
+var Version = "dev"
+
+var (
+	ErrStale   = errors.New("cache is out of date")
+	ErrCorrupt = errors.New("cache is corrupt")
+)
+
+func engineVersion() string {
+	v := Version
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" || s.Key == "vcs.modified" {
+				v += "+" + s.Value
+			}
+		}
+	}
+	return v
+}
+
+// Key works out the cache key for a set of sources. the order of the sources
+// matters since it can change which declaration an error is reported against
+func Key(srcs []parser.Source) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00", engineVersion(), format)
+	for _, src := range srcs {
+		sum := sha256.Sum256([]byte(src.Input))
+		fmt.Fprintf(h, "%s\x00%x\x00", src.Name, sum)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Read loads the schema cached at path. it fails with ErrStale when the
+// cache was written for a different key and ErrCorrupt when it can't be
+// trusted at all
+func Read(path, key string) (*types.Schema, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	header := len(magic) + 1
+	if len(b) < header+sha256.Size || !bytes.Equal(b[:len(magic)], magic) {
+		return nil, fmt.Errorf("%w: %s isn't a cache file", ErrCorrupt, path)
+	}
+	if b[len(magic)] != format {
+		return nil, fmt.Errorf("%w: %s was written in format %d", ErrStale, path, b[len(magic)])
+	}
+
+	sum, body := b[header:header+sha256.Size], b[header+sha256.Size:]
+	if got := sha256.Sum256(body); !bytes.Equal(sum, got[:]) {
+		return nil, fmt.Errorf("%w: %s doesn't match its checksum", ErrCorrupt, path)
+	}
+
+	d := &decoder{buf: body}
+	if d.string() != key {
+		return nil, ErrStale
+	}
+	s := d.schema()
+	if d.err == nil && len(d.buf) > 0 {
+		d.fail(errors.New("trailing data"))
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrCorrupt, path, d.err)
+	}
+
+	// fields only carry the name of the named enums they use; point them
+	// back at the schema's
+	if errs := s.Resolve(); len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %s: %v", ErrCorrupt, path, errors.Join(errs...))
+	}
+
+	return s, nil
+}
+
+// Write stores the schema under key. the file is replaced in one go so a
+// reader never sees half of it
+func Write(path, key string, s *types.Schema) error {
+	e := &encoder{}
+	e.string(key)
+	e.schema(unlinked(s))
+	sum := sha256.Sum256(e.buf)
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	for _, b := range [][]byte{magic, {format}, sum[:], e.buf} {
+		if _, err := tmp.Write(b); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// unlinked copies the schema with fields referring to named enums by name
+// alone. Read links them back up so there's no need to store the enum again
+// for every field that uses it
+func unlinked(s *types.Schema) *types.Schema {
+	c := *s
+	c.Entities = make([]*types.EntityNode, 0, len(s.Entities))
+	for _, e := range s.Entities {
+		ce := *e
+		ce.Fields = make([]*types.Field, 0, len(e.Fields))
+		for _, f := range e.Fields {
+			if f.Enum != nil && !f.Enum.Inline() {
+				cf := *f
+				cf.Enum = &types.EnumNode{Name: f.Enum.Name}
+				f = &cf
+			}
+			ce.Fields = append(ce.Fields, f)
+		}
+		c.Entities = append(c.Entities, &ce)
+	}
+	return &c
+}
+
+// Compile returns the schema for the files, from the cache at path when it's
+// still good and by compiling them (and refreshing the cache) when it isn't.
+// hit reports which of the two happened
+func Compile(path string, files []string) (s *types.Schema, hit bool, err error) {
+	srcs, err := sources(files)
+	if err != nil {
+		return nil, false, err
+	}
+	key := Key(srcs)
+
+	if s, err := lookup(path, key); s != nil || err != nil {
+		return s, s != nil, err
+	}
+
+	s, errs := parser.ParseSources(srcs...)
+	if len(errs) > 0 {
+		return nil, false, errors.Join(errs...)
+	}
+	if err := Write(path, key, s); err != nil {
+		return nil, false, fmt.Errorf("writing cache: %w", err)
+	}
+
+	return s, false, nil
+}
+
+// Path is where Load looks for the cache of a single file, the file with its
+// extension swapped for Ext; `mime compile -o user.mimec user.mime` writes it
+func Path(file string) string {
+	return strings.TrimSuffix(file, filepath.Ext(file)) + Ext
+}
+
+// Load is Compile for a single file that never writes the cache. a missing,
+// stale or corrupt cache at Path(file) just means the file is parsed
+func Load(file string) (s *types.Schema, hit bool, err error) {
+	srcs, err := sources([]string{file})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if s, err := lookup(Path(file), Key(srcs)); s != nil || err != nil {
+		return s, s != nil, err
+	}
+
+	s, errs := parser.ParseSources(srcs...)
+	if len(errs) > 0 {
+		return nil, false, errors.Join(errs...)
+	}
+	return s, false, nil
+}
+
+func sources(files []string) ([]parser.Source, error) {
+	srcs := make([]parser.Source, 0, len(files))
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		srcs = append(srcs, parser.Source{Name: f, Input: string(b)})
+	}
+	return srcs, nil
+}
+
+// lookup returns nil and no error when the cache has to be rebuilt
+func lookup(path, key string) (*types.Schema, error) {
+	s, err := Read(path, key)
+	if err == nil {
+		return s, nil
+	}
+	if errors.Is(err, ErrStale) || errors.Is(err, ErrCorrupt) || errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return nil, err
+}
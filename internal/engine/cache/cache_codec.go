@@ -0,0 +1,481 @@
+package cache
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// the body of a cache file is the schema written out field by field. gob
+// would do the same job without any of this code but decoding with it took
+// about as long as parsing the sources again which defeats the point of a
+// cache. every struct is written in declaration order; strings and slices
+// are length prefixed, numbers are varints and optional members start with a
+// presence byte.
+//
+// anything added to the types the schema is made of has to be added here too
+// and format bumped. TestCodecCoversTypes fails until that's done
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) uint(v uint64) { e.buf = binary.AppendUvarint(e.buf, v) }
+func (e *encoder) int(v int)     { e.buf = binary.AppendVarint(e.buf, int64(v)) }
+
+func (e *encoder) bool(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+		return
+	}
+	e.buf = append(e.buf, 0)
+}
+
+func (e *encoder) string(s string) {
+	e.uint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) strings(ss []string) {
+	e.bool(ss != nil)
+	e.uint(uint64(len(ss)))
+	for _, s := range ss {
+		e.string(s)
+	}
+}
+
+// present writes whether an optional member is there and reports it
+func (e *encoder) present(ok bool) bool {
+	e.bool(ok)
+	return ok
+}
+
+func (e *encoder) schema(s *types.Schema) {
+	e.uint(uint64(len(s.Entities)))
+	for _, en := range s.Entities {
+		e.entity(en)
+	}
+	e.uint(uint64(len(s.Enums)))
+	for _, en := range s.Enums {
+		e.enum(en)
+	}
+	e.uint(uint64(len(s.Routes)))
+	for _, r := range s.Routes {
+		e.route(r)
+	}
+	e.uint(uint64(len(s.Mixins)))
+	for _, m := range s.Mixins {
+		e.mixin(m)
+	}
+}
+
+func (e *encoder) entity(en *types.EntityNode) {
+	e.string(en.Name)
+	e.string(en.Doc)
+	e.fields(en.Fields)
+	e.bool(en.SoftDelete)
+	e.uses(en.Uses)
+}
+
+func (e *encoder) fields(fields []*types.Field) {
+	e.bool(fields != nil)
+	e.uint(uint64(len(fields)))
+	for _, f := range fields {
+		e.field(f)
+	}
+}
+
+func (e *encoder) field(f *types.Field) {
+	e.string(f.Name)
+	e.string(f.Doc)
+	e.int(int(f.Kind))
+	e.int(int(f.DataType))
+	if e.present(f.Target != nil) {
+		e.target(f.Target)
+	}
+	e.fields(f.Embedded)
+	e.int(int(f.Attributes))
+	if e.present(f.Computed != nil) {
+		e.expr(f.Computed)
+	}
+	if e.present(f.Default != nil) {
+		e.defaultValue(f.Default)
+	}
+	if e.present(f.OnUpdate != nil) {
+		e.defaultValue(f.OnUpdate)
+	}
+	if e.present(f.Enum != nil) {
+		e.enum(f.Enum)
+	}
+	if e.present(f.Length != nil) {
+		e.int(f.Length.Min)
+		e.int(f.Length.Max)
+	}
+	e.string(f.Pattern)
+	if e.present(f.Check != nil) {
+		e.expr(f.Check)
+	}
+	if e.present(f.Origin != nil) {
+		e.string(f.Origin.Mixin)
+		e.pos(f.Origin.Defined)
+		e.pos(f.Origin.Used)
+	}
+}
+
+func (e *encoder) target(t *types.ReferenceTarget) {
+	e.string(t.Entity)
+	e.string(t.Field)
+}
+
+func (e *encoder) expr(x *types.Expr) {
+	e.int(int(x.Kind))
+	e.string(x.Value)
+	e.bool(x.Args != nil)
+	e.uint(uint64(len(x.Args)))
+	for _, a := range x.Args {
+		e.expr(a)
+	}
+	if e.present(x.Target != nil) {
+		e.target(x.Target)
+	}
+}
+
+func (e *encoder) defaultValue(d *types.DefaultValue) {
+	e.int(int(d.Kind))
+	e.string(d.Value)
+	e.strings(d.Args)
+}
+
+func (e *encoder) enum(en *types.EnumNode) {
+	e.string(en.Name)
+	e.string(en.Doc)
+	e.bool(en.Members != nil)
+	e.uint(uint64(len(en.Members)))
+	for _, m := range en.Members {
+		e.string(m.Name)
+		e.string(m.Value)
+		e.string(m.Label)
+		e.string(m.Description)
+		e.bool(m.Deprecated)
+	}
+	e.int(int(en.Backing))
+}
+
+func (e *encoder) route(r *types.Route) {
+	e.string(r.Doc)
+	e.string(r.Method)
+	e.string(r.Path)
+	e.int(int(r.Action))
+	e.string(r.Entity)
+	if e.present(r.Match != nil) {
+		e.string(r.Match.Field)
+		e.string(r.Match.Source)
+	}
+	if e.present(r.Fallback != nil) {
+		e.int(r.Fallback.Status)
+		e.string(r.Fallback.Message)
+	}
+	e.int(int(r.Options))
+}
+
+func (e *encoder) mixin(m *types.MixinNode) {
+	e.string(m.Name)
+	e.strings(m.Params)
+	e.fields(m.Fields)
+	e.uses(m.Uses)
+	e.strings(m.Options)
+	e.pos(m.Pos)
+}
+
+func (e *encoder) uses(uses []*types.MixinUse) {
+	e.bool(uses != nil)
+	e.uint(uint64(len(uses)))
+	for _, u := range uses {
+		e.string(u.Name)
+		e.strings(u.Args)
+		e.int(u.Index)
+		e.pos(u.Pos)
+	}
+}
+
+func (e *encoder) pos(p types.Pos) {
+	e.string(p.File)
+	e.int(p.Line)
+}
+
+var errShort = errors.New("unexpected end of data")
+
+// decoder mirrors encoder. the first error sticks and everything after it
+// reads as zero so callers only check once at the end
+type decoder struct {
+	buf []byte
+	err error
+	// field names, types and file names repeat a lot. handing out the same
+	// string each time saves most of the allocations
+	strs map[string]string
+}
+
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+	d.buf = nil
+}
+
+func (d *decoder) uint() uint64 {
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.fail(errShort)
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) int() int {
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.fail(errShort)
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return int(v)
+}
+
+func (d *decoder) bool() bool {
+	if len(d.buf) == 0 {
+		d.fail(errShort)
+		return false
+	}
+	v := d.buf[0]
+	d.buf = d.buf[1:]
+	return v == 1
+}
+
+// len reads a length and makes sure there's at least that many bytes left
+// so a corrupt length can't make us allocate something huge
+func (d *decoder) len() int {
+	n := d.uint()
+	if n > uint64(len(d.buf)) {
+		d.fail(errShort)
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) string() string {
+	n := d.len()
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+
+	if s, ok := d.strs[string(b)]; ok {
+		return s
+	}
+	s := string(b)
+	if d.strs == nil {
+		d.strs = make(map[string]string)
+	}
+	d.strs[s] = s
+	return s
+}
+
+func (d *decoder) strings() []string {
+	if !d.bool() {
+		d.uint()
+		return nil
+	}
+	ss := make([]string, d.len())
+	for i := range ss {
+		ss[i] = d.string()
+	}
+	return ss
+}
+
+func (d *decoder) schema() *types.Schema {
+	s := &types.Schema{}
+	if n := d.len(); n > 0 {
+		s.Entities = make([]*types.EntityNode, n)
+		for i := range s.Entities {
+			s.Entities[i] = d.entity()
+		}
+	}
+	if n := d.len(); n > 0 {
+		s.Enums = make([]*types.EnumNode, n)
+		for i := range s.Enums {
+			s.Enums[i] = d.enum()
+		}
+	}
+	if n := d.len(); n > 0 {
+		s.Routes = make([]*types.Route, n)
+		for i := range s.Routes {
+			s.Routes[i] = d.route()
+		}
+	}
+	if n := d.len(); n > 0 {
+		s.Mixins = make([]*types.MixinNode, n)
+		for i := range s.Mixins {
+			s.Mixins[i] = d.mixin()
+		}
+	}
+	return s
+}
+
+func (d *decoder) entity() *types.EntityNode {
+	return &types.EntityNode{
+		Name:       d.string(),
+		Doc:        d.string(),
+		Fields:     d.fields(),
+		SoftDelete: d.bool(),
+		Uses:       d.uses(),
+	}
+}
+
+func (d *decoder) fields() []*types.Field {
+	if !d.bool() {
+		d.uint()
+		return nil
+	}
+	fields := make([]*types.Field, d.len())
+	block := make([]types.Field, len(fields))
+	for i := range fields {
+		d.field(&block[i])
+		fields[i] = &block[i]
+	}
+	return fields
+}
+
+func (d *decoder) field(f *types.Field) {
+	f.Name = d.string()
+	f.Doc = d.string()
+	f.Kind = types.FieldKind(d.int())
+	f.DataType = types.DataType(d.int())
+	if d.bool() {
+		f.Target = d.target()
+	}
+	f.Embedded = d.fields()
+	f.Attributes = types.Attribute(d.int())
+	if d.bool() {
+		f.Computed = d.expr()
+	}
+	if d.bool() {
+		f.Default = d.defaultValue()
+	}
+	if d.bool() {
+		f.OnUpdate = d.defaultValue()
+	}
+	if d.bool() {
+		f.Enum = d.enum()
+	}
+	if d.bool() {
+		f.Length = &types.Length{Min: d.int(), Max: d.int()}
+	}
+	f.Pattern = d.string()
+	if d.bool() {
+		f.Check = d.expr()
+	}
+	if d.bool() {
+		f.Origin = &types.Origin{Mixin: d.string(), Defined: d.pos(), Used: d.pos()}
+	}
+}
+
+func (d *decoder) target() *types.ReferenceTarget {
+	return &types.ReferenceTarget{Entity: d.string(), Field: d.string()}
+}
+
+func (d *decoder) expr() *types.Expr {
+	x := &types.Expr{
+		Kind:  types.ExprKind(d.int()),
+		Value: d.string(),
+	}
+	if d.bool() {
+		x.Args = make([]*types.Expr, d.len())
+		for i := range x.Args {
+			x.Args[i] = d.expr()
+		}
+	} else {
+		d.uint()
+	}
+	if d.bool() {
+		x.Target = d.target()
+	}
+	return x
+}
+
+func (d *decoder) defaultValue() *types.DefaultValue {
+	return &types.DefaultValue{
+		Kind:  types.DefaultKind(d.int()),
+		Value: d.string(),
+		Args:  d.strings(),
+	}
+}
+
+func (d *decoder) enum() *types.EnumNode {
+	en := &types.EnumNode{Name: d.string(), Doc: d.string()}
+	if d.bool() {
+		en.Members = make([]types.EnumMember, d.len())
+		for i := range en.Members {
+			en.Members[i] = types.EnumMember{
+				Name:        d.string(),
+				Value:       d.string(),
+				Label:       d.string(),
+				Description: d.string(),
+				Deprecated:  d.bool(),
+			}
+		}
+	} else {
+		d.uint()
+	}
+	en.Backing = types.DataType(d.int())
+	return en
+}
+
+func (d *decoder) route() *types.Route {
+	r := &types.Route{
+		Doc:    d.string(),
+		Method: d.string(),
+		Path:   d.string(),
+		Action: types.RouteAction(d.int()),
+		Entity: d.string(),
+	}
+	if d.bool() {
+		r.Match = &types.RouteMatch{Field: d.string(), Source: d.string()}
+	}
+	if d.bool() {
+		r.Fallback = &types.Response{Status: d.int(), Message: d.string()}
+	}
+	r.Options = types.RouteOption(d.int())
+	return r
+}
+
+func (d *decoder) mixin() *types.MixinNode {
+	return &types.MixinNode{
+		Name:    d.string(),
+		Params:  d.strings(),
+		Fields:  d.fields(),
+		Uses:    d.uses(),
+		Options: d.strings(),
+		Pos:     d.pos(),
+	}
+}
+
+func (d *decoder) uses() []*types.MixinUse {
+	if !d.bool() {
+		d.uint()
+		return nil
+	}
+	uses := make([]*types.MixinUse, d.len())
+	for i := range uses {
+		uses[i] = &types.MixinUse{
+			Name:  d.string(),
+			Args:  d.strings(),
+			Index: d.int(),
+			Pos:   d.pos(),
+		}
+	}
+	return uses
+}
+
+func (d *decoder) pos() types.Pos {
+	return types.Pos{File: d.string(), Line: d.int()}
+}
@@ -0,0 +1,384 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/engine/ir"
+	"willofdaedalus/mime/internal/engine/parser"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// writeSchema spreads n entities over files of ten each. every entity points
+// at the one before it and uses a shared enum and mixin so the cache has
+// references, enums, routes and expanded fields to carry
+func writeSchema(t testing.TB, dir string, n int) []string {
+	t.Helper()
+
+	files := []string{filepath.Join(dir, "base.mime")}
+	base := `enum status ->
+	draft
+	published
+	archived [deprecated]
+end
+
+mixin timestamps ->
+	created_at timestamp [default:now()]
+	updated_at timestamp [default:now() on_update:now()]
+end
+
+routes ->
+	GET /e0/:id -> @e0.id == :id || respond 404 "not found"
+	GET /admin/e0 [admin] -> @e0 == params
+end
+`
+	if err := os.WriteFile(files[0], []byte(base), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	for i := range n {
+		fmt.Fprintf(&b, "entity e%d [soft_delete] ->\n", i)
+		b.WriteString("\tid uuid [primary unique required default:uuid_v7()]\n")
+		b.WriteString("\ttitle text [required length:1,200 pattern:\"^\\S\"]\n")
+		b.WriteString("\tstatus &status [default:\"draft\"]\n")
+		b.WriteString("\tscore int [check:score >= 0]\n")
+		b.WriteString("\tsize text (\"small\" \"large\")\n")
+		b.WriteString("\tdouble int = score * 2\n")
+		if i > 0 {
+			fmt.Fprintf(&b, "\tparent @e%d.id\n", i-1)
+		}
+		b.WriteString("\tuse timestamps\nend\n\n")
+
+		if i%10 == 9 || i == n-1 {
+			f := filepath.Join(dir, fmt.Sprintf("entities_%d.mime", i/10))
+			if err := os.WriteFile(f, []byte(b.String()), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			files = append(files, f)
+			b.Reset()
+		}
+	}
+
+	return files
+}
+
+func TestCompile(t *testing.T) {
+	dir := t.TempDir()
+	files := writeSchema(t, dir, 25)
+	path := filepath.Join(dir, "schema"+Ext)
+
+	cold, hit, err := Compile(path, files)
+	if err != nil {
+		t.Fatalf("cold compile: %v", err)
+	}
+	if hit {
+		t.Fatalf("expected a miss with no cache on disk")
+	}
+
+	warm, hit, err := Compile(path, files)
+	if err != nil {
+		t.Fatalf("warm compile: %v", err)
+	}
+	if !hit {
+		t.Fatalf("expected a hit with nothing changed")
+	}
+	if !reflect.DeepEqual(cold, warm) {
+		t.Fatalf("the cached schema doesn't match the compiled one")
+	}
+
+	status := warm.Entity("e3").Field("status").Enum
+	if status != warm.Enum("status") {
+		t.Fatalf("expected fields to share the schema's enum after loading")
+	}
+
+	// editing any file invalidates the cache
+	src, _ := os.ReadFile(files[1])
+	edited := strings.Replace(string(src), "score int [", "score int [unique ", 1)
+	if err := os.WriteFile(files[1], []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, hit, err := Compile(path, files)
+	if err != nil {
+		t.Fatalf("compile after edit: %v", err)
+	}
+	if hit {
+		t.Fatalf("expected a miss after editing a file")
+	}
+	if ir.Hash(s).Schema == ir.Hash(cold).Schema {
+		t.Fatalf("expected the edit to be picked up")
+	}
+}
+
+// oneFile joins what writeSchema wrote into a single schema.mime
+func oneFile(t testing.TB, dir string, n int) string {
+	t.Helper()
+	var b strings.Builder
+	for _, f := range writeSchema(t, dir, n) {
+		src, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Write(src)
+	}
+	file := filepath.Join(dir, "schema.mime")
+	if err := os.WriteFile(file, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoad(t *testing.T) {
+	file := oneFile(t, t.TempDir(), 25)
+
+	parsed, hit, err := Load(file)
+	if err != nil {
+		t.Fatalf("load without a cache: %v", err)
+	}
+	if hit {
+		t.Fatalf("expected a miss with no cache on disk")
+	}
+	if _, err := os.Stat(Path(file)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected load to leave writing the cache to compile, got %v", err)
+	}
+
+	if _, _, err := Compile(Path(file), []string{file}); err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	cached, hit, err := Load(file)
+	if err != nil {
+		t.Fatalf("load with a cache: %v", err)
+	}
+	if !hit {
+		t.Fatalf("expected a hit after compiling the same file")
+	}
+	if !reflect.DeepEqual(parsed, cached) {
+		t.Fatalf("the cached schema doesn't match the parsed one")
+	}
+
+	// a stale cache is ignored, not rewritten
+	before, _ := os.ReadFile(Path(file))
+	src, _ := os.ReadFile(file)
+	if err := os.WriteFile(file, []byte(strings.Replace(string(src), "score int [", "score int [unique ", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, hit, err := Load(file)
+	if err != nil {
+		t.Fatalf("load after edit: %v", err)
+	}
+	if hit {
+		t.Fatalf("expected a miss after editing the file")
+	}
+	if ir.Hash(s).Schema == ir.Hash(cached).Schema {
+		t.Fatalf("expected the edit to be picked up")
+	}
+	if after, _ := os.ReadFile(Path(file)); !bytes.Equal(before, after) {
+		t.Fatalf("expected load to leave the stale cache alone")
+	}
+}
+
+func TestReadRejects(t *testing.T) {
+	dir := t.TempDir()
+	files := writeSchema(t, dir, 3)
+
+	srcs := make([]parser.Source, 0, len(files))
+	for _, f := range files {
+		b, _ := os.ReadFile(f)
+		srcs = append(srcs, parser.Source{Name: f, Input: string(b)})
+	}
+	s, errs := parser.ParseSources(srcs...)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected parse errors: %v", errs)
+	}
+	key := Key(srcs)
+
+	path := filepath.Join(dir, "schema"+Ext)
+	if err := Write(path, key, s); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	good, _ := os.ReadFile(path)
+
+	tests := []struct {
+		name    string
+		mangle  func([]byte) []byte
+		key     string
+		wantErr error
+	}{
+		{
+			name:    "different key",
+			mangle:  func(b []byte) []byte { return b },
+			key:     Key(srcs[:1]),
+			wantErr: ErrStale,
+		},
+		{
+			name: "flipped byte",
+			mangle: func(b []byte) []byte {
+				b[len(b)-10] ^= 0xff
+				return b
+			},
+			wantErr: ErrCorrupt,
+		},
+		{
+			name:    "truncated",
+			mangle:  func(b []byte) []byte { return b[:len(b)/2] },
+			wantErr: ErrCorrupt,
+		},
+		{
+			name:    "not a cache file",
+			mangle:  func([]byte) []byte { return []byte("entity note ->\nend\n") },
+			wantErr: ErrCorrupt,
+		},
+		{
+			name: "older format",
+			mangle: func(b []byte) []byte {
+				b[len(magic)] = format - 1
+				return b
+			},
+			wantErr: ErrStale,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(path, tt.mangle(append([]byte(nil), good...)), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			k := key
+			if tt.key != "" {
+				k = tt.key
+			}
+			if _, err := Read(path, k); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("for test %s: expected %v, got %v", tt.name, tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	a := []parser.Source{{Name: "a.mime", Input: "x"}, {Name: "b.mime", Input: "y"}}
+	renamed := []parser.Source{{Name: "a.mime", Input: "x"}, {Name: "c.mime", Input: "y"}}
+	edited := []parser.Source{{Name: "a.mime", Input: "x"}, {Name: "b.mime", Input: "z"}}
+
+	if Key(a) != Key(a) {
+		t.Fatalf("expected the same sources to give the same key")
+	}
+	if Key(a) == Key(renamed) || Key(a) == Key(edited) || Key(a) == Key(a[:1]) {
+		t.Fatalf("expected renaming, editing or dropping a file to change the key")
+	}
+
+	before := Key(a)
+	old := Version
+	Version = "v0.0.1-test"
+	defer func() { Version = old }()
+	if Key(a) == before {
+		t.Fatalf("expected a different engine version to change the key")
+	}
+}
+
+// go test ./internal/engine/cache -run x -bench Startup
+//
+// uncached is what starting up cost before there was a cache, cold is a
+// miss that also has to write the cache out and warm is a hit. the load runs
+// go through Load the way every mime command loads its schema, without and
+// with a compiled cache next to the file
+func BenchmarkStartup(b *testing.B) {
+	dir := b.TempDir()
+	files := writeSchema(b, dir, 200)
+	path := filepath.Join(dir, "schema"+Ext)
+
+	b.Run("uncached", func(b *testing.B) {
+		for b.Loop() {
+			srcs := make([]parser.Source, 0, len(files))
+			for _, f := range files {
+				src, err := os.ReadFile(f)
+				if err != nil {
+					b.Fatal(err)
+				}
+				srcs = append(srcs, parser.Source{Name: f, Input: string(src)})
+			}
+			if _, errs := parser.ParseSources(srcs...); len(errs) > 0 {
+				b.Fatal(errs)
+			}
+		}
+	})
+
+	b.Run("cold", func(b *testing.B) {
+		for b.Loop() {
+			os.Remove(path)
+			if _, _, err := Compile(path, files); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("warm", func(b *testing.B) {
+		if _, _, err := Compile(path, files); err != nil {
+			b.Fatal(err)
+		}
+		for b.Loop() {
+			if _, hit, err := Compile(path, files); err != nil || !hit {
+				b.Fatalf("expected a cache hit, got %v %v", hit, err)
+			}
+		}
+	})
+
+	file := oneFile(b, b.TempDir(), 200)
+	b.Run("load/uncached", func(b *testing.B) {
+		for b.Loop() {
+			if _, hit, err := Load(file); err != nil || hit {
+				b.Fatalf("expected a miss, got %v %v", hit, err)
+			}
+		}
+	})
+
+	b.Run("load/warm", func(b *testing.B) {
+		if _, _, err := Compile(Path(file), []string{file}); err != nil {
+			b.Fatal(err)
+		}
+		for b.Loop() {
+			if _, hit, err := Load(file); err != nil || !hit {
+				b.Fatalf("expected a cache hit, got %v %v", hit, err)
+			}
+		}
+	})
+}
+
+// the codec writes every member of these by hand. when one of them grows a
+// member this fails so it doesn't silently go missing from the cache; add it
+// to cache_codec.go, bump format and update the count here
+func TestCodecCoversTypes(t *testing.T) {
+	tests := []struct {
+		value   any
+		members int
+	}{
+		{types.Schema{}, 4},
+		{types.EntityNode{}, 5},
+		{types.Field{}, 15},
+		{types.Length{}, 2},
+		{types.ReferenceTarget{}, 2},
+		{types.Expr{}, 4},
+		{types.DefaultValue{}, 3},
+		{types.EnumNode{}, 4},
+		{types.EnumMember{}, 5},
+		{types.Origin{}, 3},
+		{types.Pos{}, 2},
+		{types.Route{}, 8},
+		{types.RouteMatch{}, 2},
+		{types.Response{}, 2},
+		{types.MixinNode{}, 6},
+		{types.MixinUse{}, 4},
+	}
+
+	for _, tt := range tests {
+		typ := reflect.TypeOf(tt.value)
+		if typ.NumField() != tt.members {
+			t.Errorf("%s has %d members but the codec knows about %d", typ, typ.NumField(), tt.members)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// the code generators turn a resolved schema into source for the languages
+// its clients and services are written in so their types never drift from
+// the .mime file. like the ddl generators they only ever see a schema that's
+// already been expanded, resolved and validated.
+//
+// every generator agrees on what a shape holds since they all ask the
+// schema:
+//
+//   - the row is every field with a column; aggregates are computed when
+//     the row is read and have none
+//   - the payload is EntityNode.PayloadFields and a field can be left out of
+//     it when it's nullable or has a default
+//   - the response is EntityNode.ResponseFields
+//
+// and output is deterministic so regenerating an unchanged schema gives an
+// identical file
+
+// words splits a mime name into the words it's made of
+func words(name string) []string {
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// pascal joins name's words with each one capitalised e.g. order_line
+// becomes OrderLine
+func pascal(name string) string {
+	var b strings.Builder
+	for _, w := range words(name) {
+		r := []rune(w)
+		b.WriteString(string(unicode.ToUpper(r[0])) + string(r[1:]))
+	}
+	return b.String()
+}
+
+// camel is pascal with the first letter left lowercase
+func camel(name string) string {
+	p := []rune(pascal(name))
+	if len(p) == 0 {
+		return ""
+	}
+	return string(unicode.ToLower(p[0])) + string(p[1:])
+}
+
+// constName is a member's name the way enum values are written in the
+// languages that spell them in capitals. they can't start with a digit so
+// those get an underscore
+func constName(name string) string {
+	v := strings.ToUpper(protoName(name))
+	if v == "" || v[0] >= '0' && v[0] <= '9' {
+		return "_" + v
+	}
+	return v
+}
+
+// an enum a generator writes as a type of its own. inline enums are named
+// after the entity and field they're declared on
+type enumType struct {
+	enum *types.EnumNode
+	name string
+	// where an inline enum is declared; both nil for a named enum
+	entity *types.EntityNode
+	field  *types.Field
+}
+
+// values describes an inline enum the same way in every language
+func (t enumType) values() string {
+	return fmt.Sprintf("one of the values %s.%s takes", t.entity.Name, t.field.Name)
+}
+
+// enumTypes names every enum the schema's fields can take, named enums first
+// and then inline ones in field order. name turns a mime name into the type
+// name the language uses. the map is what fields look their type up in
+func enumTypes(s *types.Schema, name func(string) string) (map[*types.EnumNode]string, []enumType) {
+	names := make(map[*types.EnumNode]string)
+	var out []enumType
+	for _, enum := range s.Enums {
+		names[enum] = name(enum.Name)
+		out = append(out, enumType{enum: enum, name: names[enum]})
+	}
+	for _, e := range s.Entities {
+		for _, f := range e.Fields {
+			if f.Kind == types.FieldPrimitive && f.Enum != nil && f.Enum.Inline() {
+				names[f.Enum] = name(e.Name) + name(f.Name)
+				out = append(out, enumType{enum: f.Enum, name: names[f.Enum], entity: e, field: f})
+			}
+		}
+	}
+	return names, out
+}
+
+// optional reports whether a payload can leave the field out
+func optional(f *types.Field) bool {
+	return f.Nullable() || f.Default != nil
+}
+
+// valueType is the type of the values a field holds with enums and
+// references looked through. enum is set for both named and inline enums
+func valueType(s *types.Schema, f *types.Field) (dt types.DataType, enum *types.EnumNode) {
+	dt, enum = s.FieldType(f)
+	if dt == types.DataEnum && enum != nil {
+		dt = enum.Backing
+	}
+	return dt, enum
+}
+
+// a route as a client calls it. every target with an api names routes the
+// same way so a ts function, an openapi operation and an rpc line up
+type apiRoute struct {
+	route  *types.Route
+	entity *types.EntityNode
+	// e.g. getNote or listNotes
+	name string
+	// the field the route's capture is matched against, if it has one
+	match *types.Field
+	// the route's answer is a list of rows
+	list bool
+}
+
+// params reports whether the route filters on the query string
+func (r apiRoute) params() bool {
+	return r.route.Match != nil && r.route.Match.Source == types.ParamsSource
+}
+
+// apiRoutes names every route after what it does. a find is a list unless
+// it's matched on a unique field. routes that would share a name are
+// numbered
+func apiRoutes(s *types.Schema) ([]apiRoute, error) {
+	taken := make(map[string]int)
+	var out []apiRoute
+	for _, r := range s.Routes {
+		e := s.Entity(r.Entity)
+		if e == nil {
+			return nil, fmt.Errorf("route %s %s: entity '%s' doesn't exist", r.Method, r.Path, r.Entity)
+		}
+		ar := apiRoute{route: r, entity: e, match: matchField(r, e)}
+
+		var name string
+		switch r.Action {
+		case types.ActionFind:
+			ar.list = ar.match == nil || ar.match.Attributes&(types.AttrPrimary|types.AttrUnique) == 0
+			if ar.list {
+				name = "list" + pascal(plural(e.Name))
+			} else {
+				name = "get" + pascal(e.Name)
+			}
+			if ar.match != nil && ar.match.Attributes&types.AttrPrimary == 0 {
+				name += "By" + pascal(ar.match.Name)
+			}
+		default:
+			name = r.Action.String() + pascal(e.Name)
+		}
+		if r.Options&types.RouteAdmin != 0 {
+			name = "admin" + pascal(name)
+		}
+
+		taken[name]++
+		if n := taken[name]; n > 1 {
+			name += strconv.Itoa(n)
+		}
+		ar.name = name
+		out = append(out, ar)
+	}
+	return out, nil
+}
+
+// matchField is the field a route's capture is matched against. a capture
+// with no field matches the primary key
+func matchField(r *types.Route, e *types.EntityNode) *types.Field {
+	if r.Match == nil || r.Match.Source == types.ParamsSource {
+		return nil
+	}
+	if r.Match.Field != "" {
+		return e.Field(r.Match.Field)
+	}
+	if pk := e.PrimaryKey(); len(pk) == 1 {
+		return pk[0]
+	}
+	return nil
+}
+
+// plural is good enough for naming list functions
+func plural(name string) string {
+	switch {
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
+
+// queryFields are the fields `@entity == params` can match on
+func queryFields(e *types.EntityNode) []*types.Field {
+	var out []*types.Field
+	for _, f := range e.Fields {
+		if f.Kind != types.FieldComputed && f.Kind != types.FieldEmbedded {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// object is a json object that keeps its members in the order they were
+// set so generated documents read in schema order
+type object struct {
+	keys   []string
+	values map[string]any
+}
+
+func newObject() *object {
+	return &object{values: make(map[string]any)}
+}
+
+// set adds or replaces a member. nil values are left out
+func (o *object) set(key string, v any) *object {
+	if v == nil {
+		return o
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+	return o
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := marshalJSON(k)
+		if err != nil {
+			return nil, err
+		}
+		v, err := marshalJSON(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// marshalJSON is json.Marshal without the html escaping that'd turn the <
+// in a pattern into \u003c
+func marshalJSON(v any) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// indentJSON writes a generated document the way people write them by hand
+func indentJSON(v any) (string, error) {
+	data, err := marshalJSON(v)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, data, "", "  "); err != nil {
+		return "", err
+	}
+	b.WriteByte('\n')
+	return b.String(), nil
+}
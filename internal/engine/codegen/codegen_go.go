@@ -0,0 +1,982 @@
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/ddl"
+	"willofdaedalus/mime/internal/engine/ir"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// Go writes the schema as a go package. every entity gets a struct for its
+// row, its payload and its response, every enum a named type with a constant
+// per member, and every entity a repository over database/sql that speaks
+// dialect's sql. pkg is the package the file goes in
+func Go(s *types.Schema, pkg, dialect string) (string, error) {
+	d, ok := ddl.Dialects[dialect]
+	if !ok {
+		return "", fmt.Errorf("unknown dialect %s", dialect)
+	}
+	if !token.IsIdentifier(pkg) {
+		return "", fmt.Errorf("%q isn't a valid package name", pkg)
+	}
+
+	g := &goWriter{
+		schema:  s,
+		dialect: d,
+		sql:     goDialects[dialect],
+		imports: make(map[string]bool),
+		helpers: make(map[string]bool),
+	}
+	g.options()
+
+	var body strings.Builder
+	g.b = &body
+	var enums []enumType
+	g.enums, enums = enumTypes(s, goName)
+	for _, t := range enums {
+		if t.field == nil {
+			g.enum(t.enum, fmt.Sprintf("%s is a member of the %s enum", t.name, t.enum.Name))
+			continue
+		}
+		g.enum(t.enum, t.name+" is "+t.values())
+	}
+	for _, e := range s.Entities {
+		g.structs(e)
+	}
+	if g.opts.hash || g.opts.next {
+		g.writeOptions()
+	}
+	for _, e := range s.Entities {
+		if err := g.repository(e); err != nil {
+			return "", fmt.Errorf("entity '%s': %w", e.Name, err)
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString(ir.Header(s, "//") + "\n")
+	out.WriteString("package " + pkg + "\n")
+	if len(g.imports) > 0 {
+		out.WriteString("\nimport (\n")
+		for _, imp := range slices.Sorted(maps.Keys(g.imports)) {
+			out.WriteString("\t" + strconv.Quote(imp) + "\n")
+		}
+		out.WriteString(")\n")
+	}
+	out.WriteString(body.String())
+	for _, name := range goHelperOrder {
+		if g.helpers[name] {
+			out.WriteString("\n" + g.helperSource(name))
+		}
+	}
+
+	src, err := format.Source([]byte(out.String()))
+	if err != nil {
+		return "", fmt.Errorf("generated go doesn't parse: %w", err)
+	}
+	return string(src), nil
+}
+
+// what the repositories need to know about each database on top of its
+// ddl.Dialect
+type goSQL struct {
+	// param is the placeholder for the nth argument of a statement
+	param func(n int) string
+	// paramSource and insertSource are the same two things for the
+	// generated code
+	paramSource  string
+	insertSource string
+	// returning reports whether an insert can hand back the row it wrote
+	returning bool
+	// sqlite keeps timestamps as text
+	textTime bool
+}
+
+var goDialects = map[string]goSQL{
+	"sqlite": {
+		param:        func(int) string { return "?" },
+		paramSource:  "func param(int) string {\n\treturn \"?\"\n}\n",
+		insertSource: goInsertDefaultValues,
+		returning:    true,
+		textTime:     true,
+	},
+	"postgres": {
+		param:        func(n int) string { return "$" + strconv.Itoa(n) },
+		paramSource:  "func param(n int) string {\n\treturn \"$\" + strconv.Itoa(n)\n}\n",
+		insertSource: goInsertDefaultValues,
+		returning:    true,
+	},
+	"mysql": {
+		param:       func(int) string { return "?" },
+		paramSource: "func param(int) string {\n\treturn \"?\"\n}\n",
+		insertSource: `func insertSQL(table string, cols []string) string {
+	if len(cols) == 0 {
+		return "INSERT INTO " + table + " () VALUES ()"
+	}
+	return "INSERT INTO " + table + " (" + strings.Join(cols, ", ") + ") VALUES (" + params(len(cols)) + ")"
+}
+`,
+	},
+}
+
+const goInsertDefaultValues = `func insertSQL(table string, cols []string) string {
+	if len(cols) == 0 {
+		return "INSERT INTO " + table + " DEFAULT VALUES"
+	}
+	return "INSERT INTO " + table + " (" + strings.Join(cols, ", ") + ") VALUES (" + params(len(cols)) + ")"
+}
+`
+
+// go's standard library has no decimal type so decimals are kept as the
+// string of digits every driver scans a numeric column into exactly
+var goTypes = map[types.DataType]string{
+	types.DataText:      "string",
+	types.DataInt:       "int64",
+	types.DataReal:      "float64",
+	types.DataBool:      "bool",
+	types.DataUUID:      "string",
+	types.DataTimestamp: "time.Time",
+	types.DataDecimal:   "string",
+}
+
+// words go spells in capitals
+var goInitialisms = map[string]bool{
+	"api": true, "dns": true, "html": true, "http": true, "https": true, "id": true, "ip": true,
+	"json": true, "sql": true, "ttl": true, "uri": true, "url": true, "utc": true, "uuid": true, "xml": true,
+}
+
+// goName is pascal with go's initialisms e.g. owner_id becomes OwnerID
+func goName(name string) string {
+	var b strings.Builder
+	for _, w := range words(name) {
+		if goInitialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		b.WriteString(pascal(w))
+	}
+	return b.String()
+}
+
+// names the generated methods already use for their own variables
+var goReserved = map[string]bool{
+	"args": true, "cols": true, "ctx": true, "err": true, "out": true, "p": true, "r": true,
+	"res": true, "row": true, "rows": true, "set": true, "sets": true, "v": true, "where": true,
+}
+
+// goVar names a local variable or parameter after a field
+func goVar(name string) string {
+	v := camel(goName(name))
+	if len(v) > 0 && strings.ToUpper(v) == goName(name) {
+		// ID becomes id rather than iD
+		v = strings.ToLower(v)
+	}
+	if token.IsKeyword(v) || goReserved[v] || v == "" {
+		v += "Value"
+	}
+	return v
+}
+
+type goWriter struct {
+	schema  *types.Schema
+	dialect ddl.Dialect
+	sql     goSQL
+	b       *strings.Builder
+	imports map[string]bool
+	helpers map[string]bool
+	// what enumTypes named each enum
+	enums map[*types.EnumNode]string
+	// what the schema needs from Options
+	opts struct{ hash, next bool }
+}
+
+func (g *goWriter) use(imports ...string) {
+	for _, imp := range imports {
+		g.imports[imp] = true
+	}
+}
+
+func (g *goWriter) helper(name string) {
+	g.helpers[name] = true
+	for _, dep := range goHelperDeps[name] {
+		g.helper(dep)
+	}
+	g.use(goHelperImports[name]...)
+	if name == "param" && strings.Contains(g.sql.paramSource, "strconv") {
+		g.use("strconv")
+	}
+}
+
+func (g *goWriter) printf(format string, args ...any) {
+	fmt.Fprintf(g.b, format, args...)
+}
+
+func (g *goWriter) options() {
+	for _, e := range g.schema.Entities {
+		for _, f := range e.Fields {
+			if f.Attributes&types.AttrHash != 0 {
+				g.opts.hash = true
+			}
+			if f.Default != nil && f.Default.Kind == types.DefaultFunc && f.Default.Value == types.FuncSequence {
+				g.opts.next = true
+			}
+		}
+	}
+}
+
+func (g *goWriter) enum(enum *types.EnumNode, doc string) {
+	name := g.enums[enum]
+	base := "string"
+	if enum.Backing == types.DataInt {
+		base = "int64"
+	}
+
+	g.printf("\n// %s\ntype %s %s\n\nconst (\n", doc, name, base)
+	consts := make([]string, 0, len(enum.Members))
+	byName := false
+	for _, m := range enum.Members {
+		c := name + goName(m.Name)
+		consts = append(consts, c)
+		byName = byName || m.Name != m.Value
+
+		var comments []string
+		if m.Label != "" {
+			comments = append(comments, m.Label)
+		}
+		if m.Description != "" {
+			comments = append(comments, m.Description)
+		}
+		if m.Deprecated {
+			comments = append(comments, fmt.Sprintf("Deprecated: %s is deprecated.", m.Name))
+		}
+		for i, c := range comments {
+			if i > 0 {
+				g.printf("\t//\n")
+			}
+			g.printf("\t// %s\n", c)
+		}
+
+		value := strconv.Quote(m.Value)
+		if enum.Backing == types.DataInt {
+			value = m.Value
+		}
+		g.printf("\t%s %s = %s\n", c, name, value)
+	}
+	g.printf(")\n")
+
+	g.printf("\n// Valid reports whether v is one of %s's members\nfunc (v %s) Valid() bool {\n", name, name)
+	g.printf("\tswitch v {\n\tcase %s:\n\t\treturn true\n\t}\n\treturn false\n}\n", strings.Join(consts, ", "))
+
+	// the runtime takes a member's name as well as its value
+	if !byName {
+		return
+	}
+	g.use("encoding/json", "fmt")
+	g.printf("\n// UnmarshalJSON takes a member's name as well as its value, the same as the\n// runtime does\n")
+	g.printf("func (v *%s) UnmarshalJSON(data []byte) error {\n", name)
+	g.printf("\tvar name string\n\tif err := json.Unmarshal(data, &name); err == nil {\n\t\tswitch name {\n")
+	for i, m := range enum.Members {
+		if m.Name != m.Value {
+			g.printf("\t\tcase %s:\n\t\t\t*v = %s\n\t\t\treturn nil\n", strconv.Quote(m.Name), consts[i])
+		}
+	}
+	g.printf("\t\t}\n")
+	if enum.Backing == types.DataInt {
+		g.printf("\t\treturn fmt.Errorf(\"%%q isn't a member of %s\", name)\n\t}\n\n", enum.Name)
+		g.printf("\tvar n int64\n\tif err := json.Unmarshal(data, &n); err != nil {\n\t\treturn err\n\t}\n\t*v = %s(n)\n\treturn nil\n}\n", name)
+		return
+	}
+	g.printf("\t\t*v = %s(name)\n\t\treturn nil\n\t}\n", name)
+	g.printf("\treturn fmt.Errorf(\"%s is text, got %%s\", data)\n}\n", enum.Name)
+}
+
+// fieldType is the go type of f in one of an entity's shapes. shape is the
+// suffix of the struct embedded entities are written as
+func (g *goWriter) fieldType(f *types.Field, shape string, pointer bool) string {
+	var t string
+	if f.Kind == types.FieldEmbedded {
+		t = goName(f.Name) + shape
+	} else {
+		dt, enum := valueType(g.schema, f)
+		if name, ok := g.enums[enum]; ok {
+			t = name
+		} else {
+			t = goTypes[dt]
+		}
+		if dt == types.DataTimestamp {
+			g.use("time")
+		}
+	}
+
+	if pointer {
+		return "*" + t
+	}
+	return t
+}
+
+func (g *goWriter) structs(e *types.EntityNode) {
+	name := goName(e.Name)
+
+	g.printf("\n// %s is a row of the %s table\ntype %s struct {\n", name, e.Name, name)
+	for _, f := range e.Fields {
+		if !f.Stored() {
+			g.printf("\t// computed from other rows when it's read\n")
+		}
+		g.printf("\t%s %s `json:%q db:%q`\n", goName(f.Name), g.fieldType(f, "", f.Nullable() || !f.Stored()), f.Name, f.Name)
+	}
+	g.printf("}\n")
+
+	g.printf("\n// %sPayload is what a client sends to create or update a %s. fields it\n", name, e.Name)
+	g.printf("// can leave out are pointers\ntype %sPayload struct {\n", name)
+	for _, f := range e.PayloadFields() {
+		tag := f.Name
+		if optional(f) {
+			tag += ",omitempty"
+		}
+		g.printf("\t%s %s `json:%q`\n", goName(f.Name), g.fieldType(f, "Payload", optional(f)), tag)
+	}
+	g.printf("}\n")
+
+	response := e.ResponseFields()
+	g.printf("\n// %sResponse is what a client gets back for a %s\ntype %sResponse struct {\n", name, e.Name, name)
+	for _, f := range response {
+		if !f.Stored() {
+			g.printf("\t// computed from other rows when it's read\n")
+		}
+		g.printf("\t%s %s `json:%q`\n", goName(f.Name), g.fieldType(f, "Response", f.Nullable() || !f.Stored()), f.Name)
+	}
+	g.printf("}\n")
+
+	recv := strings.ToLower(name[:1])
+	g.printf("\n// Response is the row as a client sees it\nfunc (%s %s) Response() %sResponse {\n", recv, name, name)
+	g.printf("\tout := %sResponse{\n", name)
+	var nested []*types.Field
+	for _, f := range response {
+		switch {
+		case f.Kind == types.FieldEmbedded && f.Nullable():
+			nested = append(nested, f)
+		case f.Kind == types.FieldEmbedded:
+			g.printf("\t\t%s: %s.%s.Response(),\n", goName(f.Name), recv, goName(f.Name))
+		default:
+			g.printf("\t\t%s: %s.%s,\n", goName(f.Name), recv, goName(f.Name))
+		}
+	}
+	g.printf("\t}\n")
+	for _, f := range nested {
+		field := goName(f.Name)
+		g.printf("\tif %s.%s != nil {\n\t\tnested := %s.%s.Response()\n\t\tout.%s = &nested\n\t}\n", recv, field, recv, field, field)
+	}
+	g.printf("\treturn out\n}\n")
+}
+
+func (g *goWriter) writeOptions() {
+	g.use("context", "errors")
+	g.printf("\n// Options fills in what the database can't for the repositories\ntype Options struct {\n")
+	if g.opts.hash {
+		g.printf("\t// Hash is applied to hash fields before they're stored e.g. bcrypt\n")
+		g.printf("\tHash func(plain string) (string, error)\n")
+	}
+	if g.opts.next {
+		g.printf("\t// Next hands out the next value of a sequence() default's counter\n")
+		g.printf("\tNext func(ctx context.Context, name string) (int64, error)\n")
+	}
+	g.printf("}\n")
+
+	if g.opts.hash {
+		g.printf("\nfunc (o Options) hash(plain string) (string, error) {\n\tif o.Hash == nil {\n")
+		g.printf("\t\treturn \"\", errors.New(\"Options.Hash has to be set to store hash fields\")\n\t}\n\treturn o.Hash(plain)\n}\n")
+	}
+	if g.opts.next {
+		g.printf("\nfunc (o Options) next(ctx context.Context, name string) (int64, error) {\n\tif o.Next == nil {\n")
+		g.printf("\t\treturn 0, errors.New(\"Options.Next has to be set to fill sequence defaults\")\n\t}\n\treturn o.Next(ctx, name)\n}\n")
+	}
+}
+
+// goString quotes s as a go string, raw when it can be so sql stays readable
+func goString(s string) string {
+	if !strings.Contains(s, "`") {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+// repo holds what the methods of one entity's repository share
+type repo struct {
+	e       *types.EntityNode
+	name    string
+	table   string
+	columns string
+	keys    []*types.Field
+	// the where clause that finds a live row by its keys, numbered from the
+	// first argument
+	where string
+	// the key parameters of Get, Update and Delete
+	params []string
+	args   []string
+}
+
+// repository writes the entity's repository. aggregates are selected with
+// the subquery that computes them
+func (g *goWriter) repository(e *types.EntityNode) error {
+	d := g.dialect
+	r := repo{e: e, name: goName(e.Name), table: d.Quote(e.Name), keys: e.PrimaryKey()}
+
+	var cols []string
+	for _, f := range e.Fields {
+		if f.Stored() {
+			cols = append(cols, d.Quote(f.Name))
+			continue
+		}
+		expr, err := ddl.Computed(g.schema, d, e, f)
+		if err != nil {
+			return err
+		}
+		cols = append(cols, expr+" AS "+d.Quote(f.Name))
+	}
+	r.columns = strings.Join(cols, ", ")
+
+	var conds []string
+	for i, f := range r.keys {
+		conds = append(conds, fmt.Sprintf("%s = %s", d.Quote(f.Name), g.sql.param(i+1)))
+		r.params = append(r.params, goVar(f.Name)+" "+g.fieldType(f, "", false))
+		r.args = append(r.args, goVar(f.Name))
+	}
+	r.where = strings.Join(conds, " AND ")
+	live := ""
+	if e.SoftDelete {
+		live = d.Quote(types.SoftDeleteField) + " IS NULL"
+	}
+
+	g.use("context", "database/sql")
+	recv := "*" + camel(r.name) + "Repository"
+	ctor := "db *sql.DB"
+	fields := "\tdb *sql.DB\n"
+	assign := "db: db"
+	if g.opts.hash || g.opts.next {
+		ctor += ", opts Options"
+		fields += "\topts Options\n"
+		assign += ", opts: opts"
+	}
+
+	// the interface
+	keyParams := strings.Join(r.params, ", ")
+	g.printf("\n// %sRepository reads and writes %s rows\ntype %sRepository interface {\n", r.name, e.Name, r.name)
+	if len(r.keys) == 0 {
+		g.printf("\tCreate(ctx context.Context, p %sPayload) error\n", r.name)
+	} else {
+		g.printf("\tCreate(ctx context.Context, p %sPayload) (*%s, error)\n", r.name, r.name)
+		g.printf("\tGet(ctx context.Context, %s) (*%s, error)\n", keyParams, r.name)
+	}
+	g.printf("\tList(ctx context.Context, limit, offset int) ([]%s, error)\n", r.name)
+	if len(r.keys) > 0 {
+		g.printf("\t// Update writes the fields p sets; ones it leaves nil keep their value\n")
+		g.printf("\tUpdate(ctx context.Context, %s, p %sPayload) (*%s, error)\n", keyParams, r.name, r.name)
+		if e.SoftDelete {
+			g.printf("\t// Delete marks the row deleted and Restore brings it back\n")
+		}
+		g.printf("\tDelete(ctx context.Context, %s) error\n", keyParams)
+		if e.SoftDelete {
+			g.printf("\tRestore(ctx context.Context, %s) error\n", keyParams)
+		}
+	}
+	g.printf("}\n")
+
+	g.printf("\ntype %sRepository struct {\n%s}\n", camel(r.name), fields)
+	g.printf("\nfunc New%sRepository(%s) %sRepository {\n\treturn &%sRepository{%s}\n}\n",
+		r.name, ctor, r.name, camel(r.name), assign)
+	g.printf("\nconst %sColumns = %s\n", camel(r.name), goString(r.columns))
+
+	g.create(r, recv)
+	if len(r.keys) > 0 {
+		where := r.where
+		if live != "" {
+			where += " AND " + live
+		}
+		g.printf("\nfunc (r %s) Get(ctx context.Context, %s) (*%s, error) {\n", recv, keyParams, r.name)
+		g.printf("\treturn scan%s(r.db.QueryRowContext(ctx, \"SELECT \"+%sColumns+%s, %s))\n}\n",
+			r.name, camel(r.name), goString(" FROM "+r.table+" WHERE "+where), strings.Join(r.args, ", "))
+	}
+	g.list(r, recv, live)
+	if len(r.keys) > 0 {
+		g.update(r, recv, live)
+		g.delete(r, recv)
+	}
+	g.scan(r)
+	return nil
+}
+
+func (g *goWriter) create(r repo, recv string) {
+	fail := "return nil, err"
+	if len(r.keys) == 0 {
+		fail = "return err"
+		g.printf("\nfunc (r %s) Create(ctx context.Context, p %sPayload) error {\n", recv, r.name)
+	} else {
+		g.printf("\nfunc (r %s) Create(ctx context.Context, p %sPayload) (*%s, error) {\n", recv, r.name, r.name)
+	}
+	g.printf("\tvar cols []string\n\tvar args []any\n")
+	g.printf("\tset := func(col string, v any) {\n\t\tcols = append(cols, col)\n\t\targs = append(args, v)\n\t}\n")
+
+	// without RETURNING the row is read back by its keys
+	keyVars := make(map[*types.Field]string)
+	if !g.sql.returning {
+		for i, f := range r.keys {
+			keyVars[f] = fmt.Sprintf("key%d", i)
+			if f.Attributes&types.AttrIncrement == 0 {
+				g.printf("\tvar %s %s\n", keyVars[f], g.fieldType(f, "", false))
+			}
+		}
+	}
+
+	payload := r.e.PayloadFields()
+	for _, f := range r.e.Fields {
+		if f.Kind == types.FieldComputed || f.Attributes&types.AttrIncrement != 0 {
+			continue
+		}
+		// keys are always filled in here so they're known without RETURNING
+		fill := f.Default != nil && (f.Attributes&types.AttrPrimary != 0 || g.runtimeOnly(r.e, f, f.Default))
+		switch {
+		case !slices.Contains(payload, f):
+			if fill {
+				g.fill(r.e, f, f.Default, keyVars[f], fail, "\t")
+			}
+		case !optional(f):
+			g.write(f, "p."+goName(f.Name), keyVars[f], fail, "\t")
+		default:
+			g.printf("\tif p.%s != nil {\n", goName(f.Name))
+			g.write(f, "*p."+goName(f.Name), keyVars[f], fail, "\t\t")
+			if fill {
+				g.printf("\t} else {\n")
+				g.fill(r.e, f, f.Default, keyVars[f], fail, "\t\t")
+			}
+			g.printf("\t}\n")
+		}
+	}
+
+	g.helper("insertSQL")
+	insert := fmt.Sprintf("insertSQL(%s, cols)", goString(r.table))
+	switch {
+	case len(r.keys) == 0:
+		g.printf("\tif _, err := r.db.ExecContext(ctx, %s, args...); err != nil {\n\t\treturn err\n\t}\n\treturn nil\n}\n", insert)
+	case g.sql.returning:
+		g.printf("\treturn scan%s(r.db.QueryRowContext(ctx, %s+\" RETURNING \"+%sColumns, args...))\n}\n",
+			r.name, insert, camel(r.name))
+	case slices.ContainsFunc(r.keys, func(f *types.Field) bool { return f.Attributes&types.AttrIncrement != 0 }):
+		g.printf("\tres, err := r.db.ExecContext(ctx, %s, args...)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n", insert)
+		var keys []string
+		for _, f := range r.keys {
+			if f.Attributes&types.AttrIncrement != 0 {
+				g.printf("\t%s, err := res.LastInsertId()\n\tif err != nil {\n\t\treturn nil, err\n\t}\n", keyVars[f])
+			}
+			keys = append(keys, keyVars[f])
+		}
+		g.printf("\treturn r.Get(ctx, %s)\n}\n", strings.Join(keys, ", "))
+	default:
+		g.printf("\tif _, err := r.db.ExecContext(ctx, %s, args...); err != nil {\n\t\treturn nil, err\n\t}\n", insert)
+		var keys []string
+		for _, f := range r.keys {
+			keys = append(keys, keyVars[f])
+		}
+		g.printf("\treturn r.Get(ctx, %s)\n}\n", strings.Join(keys, ", "))
+	}
+}
+
+// runtimeOnly reports whether a default is one the database can't fill
+func (g *goWriter) runtimeOnly(e *types.EntityNode, f *types.Field, def *types.DefaultValue) bool {
+	dt, enum := valueType(g.schema, f)
+	return g.dialect.Default(def, ddl.Column{Entity: e, Field: f, Type: dt, Enum: enum}) == ""
+}
+
+// write sets f's column to the go expression src
+func (g *goWriter) write(f *types.Field, src, keyVar, fail, indent string) {
+	col := strconv.Quote(g.dialect.Quote(f.Name))
+	switch {
+	case f.Attributes&types.AttrHash != 0:
+		v := goVar(f.Name) + "Hash"
+		g.printf("%s%s, err := r.opts.hash(%s)\n%sif err != nil {\n%s\t%s\n%s}\n", indent, v, src, indent, indent, fail, indent)
+		g.printf("%sset(%s, %s)\n", indent, col, v)
+	case f.Kind == types.FieldEmbedded:
+		g.helper("jsonArg")
+		g.printf("%sset(%s, jsonArg(%s))\n", indent, col, src)
+	case f.DataType == types.DataTimestamp && g.sql.textTime:
+		g.helper("timeArg")
+		g.printf("%sset(%s, timeArg(%s))\n", indent, col, src)
+	default:
+		g.printf("%sset(%s, %s)\n", indent, col, src)
+	}
+	if keyVar != "" {
+		g.printf("%s%s = %s\n", indent, keyVar, src)
+	}
+}
+
+// fill works out a default the database can't and writes it
+func (g *goWriter) fill(e *types.EntityNode, f *types.Field, def *types.DefaultValue, keyVar, fail, indent string) {
+	v := goVar(f.Name)
+	expr, fallible := g.goDefault(e, f, def)
+	if fallible {
+		g.printf("%s%s, err := %s\n%sif err != nil {\n%s\t%s\n%s}\n", indent, v, expr, indent, indent, fail, indent)
+	} else {
+		g.printf("%s%s := %s\n", indent, v, expr)
+	}
+	g.write(f, v, keyVar, fail, indent)
+}
+
+// goDefault is the go expression for a default. fallible ones return an
+// error as well
+func (g *goWriter) goDefault(e *types.EntityNode, f *types.Field, def *types.DefaultValue) (string, bool) {
+	dt, enum := valueType(g.schema, f)
+	if def.Kind == types.DefaultLiteral {
+		if m := enumMember(enum, def.Value); m != nil {
+			return g.enums[enum] + goName(m.Name), false
+		}
+		switch dt {
+		case types.DataInt:
+			return "int64(" + def.Value + ")", false
+		case types.DataReal:
+			return "float64(" + def.Value + ")", false
+		case types.DataBool:
+			return def.Value, false
+		case types.DataTimestamp:
+			g.helper("mustTime")
+			return "mustTime(" + strconv.Quote(def.Value) + ")", false
+		}
+		return strconv.Quote(def.Value), false
+	}
+
+	switch def.Value {
+	case types.FuncNow:
+		g.use("time")
+		return "time.Now().UTC()", false
+	case types.FuncToday:
+		g.use("time")
+		if dt == types.DataText {
+			return "time.Now().UTC().Format(time.DateOnly)", false
+		}
+		return "time.Now().UTC().Truncate(24 * time.Hour)", false
+	case types.FuncUUIDv4:
+		g.helper("newUUIDv4")
+		return "newUUIDv4()", true
+	case types.FuncUUIDv7:
+		g.helper("newUUIDv7")
+		return "newUUIDv7()", true
+	case types.FuncSequence:
+		// unnamed sequences are scoped to the field they fill
+		name := e.Name + "." + f.Name
+		if len(def.Args) > 0 {
+			name = def.Args[0]
+		}
+		return fmt.Sprintf("r.opts.next(ctx, %s)", strconv.Quote(name)), true
+	}
+	return "nil", false
+}
+
+func enumMember(enum *types.EnumNode, v string) *types.EnumMember {
+	if enum == nil {
+		return nil
+	}
+	return enum.Member(v)
+}
+
+func (g *goWriter) list(r repo, recv, live string) {
+	query := " FROM " + r.table
+	if live != "" {
+		query += " WHERE " + live
+	}
+	if len(r.keys) > 0 {
+		var order []string
+		for _, f := range r.keys {
+			order = append(order, g.dialect.Quote(f.Name))
+		}
+		query += " ORDER BY " + strings.Join(order, ", ")
+	}
+	query += fmt.Sprintf(" LIMIT %s OFFSET %s", g.sql.param(1), g.sql.param(2))
+
+	g.printf("\nfunc (r %s) List(ctx context.Context, limit, offset int) ([]%s, error) {\n", recv, r.name)
+	g.printf("\trows, err := r.db.QueryContext(ctx, \"SELECT \"+%sColumns+%s, limit, offset)\n", camel(r.name), goString(query))
+	g.printf("\tif err != nil {\n\t\treturn nil, err\n\t}\n\tdefer rows.Close()\n\n")
+	g.printf("\tvar out []%s\n\tfor rows.Next() {\n\t\tv, err := scan%s(rows)\n", r.name, r.name)
+	g.printf("\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n\t\tout = append(out, *v)\n\t}\n\treturn out, rows.Err()\n}\n")
+}
+
+func (g *goWriter) update(r repo, recv, live string) {
+	d := g.dialect
+	fail := "return nil, err"
+	keyParams := strings.Join(r.params, ", ")
+	g.printf("\nfunc (r %s) Update(ctx context.Context, %s, p %sPayload) (*%s, error) {\n", recv, keyParams, r.name, r.name)
+	g.printf("\tvar sets []string\n\tvar args []any\n")
+	g.printf("\tset := func(col string, v any) {\n\t\targs = append(args, v)\n\t\tsets = append(sets, col+\" = \"+param(len(args)))\n\t}\n")
+	g.helper("param")
+
+	// always is set once a column is written on every update, which makes
+	// the check for an empty update dead code
+	always := false
+	for _, f := range r.e.PayloadFields() {
+		// on_update fields take the value on_update gives them; writing the
+		// payload's too would set the column twice
+		if f.Attributes&types.AttrPrimary != 0 || f.OnUpdate != nil {
+			continue
+		}
+		if !optional(f) {
+			g.write(f, "p."+goName(f.Name), "", fail, "\t")
+			always = true
+			continue
+		}
+		g.printf("\tif p.%s != nil {\n", goName(f.Name))
+		g.write(f, "*p."+goName(f.Name), "", fail, "\t\t")
+		g.printf("\t}\n")
+	}
+	for _, f := range r.e.Fields {
+		if f.OnUpdate == nil {
+			continue
+		}
+		always = true
+		if g.runtimeOnly(r.e, f, f.OnUpdate) {
+			g.fill(r.e, f, f.OnUpdate, "", fail, "\t")
+			continue
+		}
+		dt, enum := valueType(g.schema, f)
+		expr := d.Quote(f.Name) + " = " + d.Default(f.OnUpdate, ddl.Column{Entity: r.e, Field: f, Type: dt, Enum: enum})
+		g.printf("\tsets = append(sets, %s)\n", goString(expr))
+	}
+
+	if !always {
+		g.printf("\tif len(sets) == 0 {\n\t\treturn r.Get(ctx, %s)\n\t}\n", strings.Join(r.args, ", "))
+	}
+	g.printf("\n")
+	for i, f := range r.keys {
+		g.printf("\targs = append(args, %s)\n", r.args[i])
+		op := "where +="
+		prefix := " AND "
+		if i == 0 {
+			op, prefix = "where :=", " WHERE "
+		}
+		g.printf("\t%s %s + param(len(args))\n", op, goString(prefix+d.Quote(f.Name)+" = "))
+	}
+	if live != "" {
+		g.printf("\twhere += %s\n", goString(" AND "+live))
+	}
+	g.use("strings")
+	g.printf("\tif _, err := r.db.ExecContext(ctx, %s+strings.Join(sets, \", \")+where, args...); err != nil {\n\t\treturn nil, err\n\t}\n",
+		goString("UPDATE "+r.table+" SET "))
+	g.printf("\treturn r.Get(ctx, %s)\n}\n", strings.Join(r.args, ", "))
+}
+
+func (g *goWriter) delete(r repo, recv string) {
+	d := g.dialect
+	keyParams := strings.Join(r.params, ", ")
+	args := strings.Join(r.args, ", ")
+	g.helper("affected")
+
+	if !r.e.SoftDelete {
+		g.printf("\nfunc (r %s) Delete(ctx context.Context, %s) error {\n", recv, keyParams)
+		g.printf("\treturn affected(r.db.ExecContext(ctx, %s, %s))\n}\n", goString("DELETE FROM "+r.table+" WHERE "+r.where), args)
+		return
+	}
+
+	col := d.Quote(types.SoftDeleteField)
+	now := d.Default(&types.DefaultValue{Kind: types.DefaultFunc, Value: types.FuncNow},
+		ddl.Column{Entity: r.e, Field: r.e.Field(types.SoftDeleteField), Type: types.DataTimestamp})
+	g.printf("\nfunc (r %s) Delete(ctx context.Context, %s) error {\n", recv, keyParams)
+	g.printf("\treturn affected(r.db.ExecContext(ctx, %s, %s))\n}\n",
+		goString("UPDATE "+r.table+" SET "+col+" = "+now+" WHERE "+r.where+" AND "+col+" IS NULL"), args)
+	g.printf("\nfunc (r %s) Restore(ctx context.Context, %s) error {\n", recv, keyParams)
+	g.printf("\treturn affected(r.db.ExecContext(ctx, %s, %s))\n}\n",
+		goString("UPDATE "+r.table+" SET "+col+" = NULL WHERE "+r.where+" AND "+col+" IS NOT NULL"), args)
+}
+
+func (g *goWriter) scan(r repo) {
+	g.helper("scanner")
+	var dests []string
+	for _, f := range r.e.Fields {
+		dest := "&v." + goName(f.Name)
+		switch {
+		case f.Kind == types.FieldEmbedded:
+			g.helper("jsonColumn")
+			dest = "jsonColumn{" + dest + "}"
+		case f.DataType == types.DataTimestamp:
+			g.helper("timeColumn")
+			dest = "timeColumn{" + dest + "}"
+		}
+		dests = append(dests, dest)
+	}
+
+	g.printf("\nfunc scan%s(row scanner) (*%s, error) {\n\tvar v %s\n", r.name, r.name, r.name)
+	g.printf("\tif err := row.Scan(%s); err != nil {\n\t\treturn nil, err\n\t}\n\treturn &v, nil\n}\n", strings.Join(dests, ", "))
+}
+
+// the helpers generated code can call, written once at the end of the file
+// in this order when anything uses them
+var goHelperOrder = []string{
+	"scanner", "param", "params", "insertSQL", "affected", "timeArg", "timeColumn", "mustTime",
+	"jsonArg", "jsonColumn", "newUUIDv4", "newUUIDv7", "formatUUID",
+}
+
+var goHelperDeps = map[string][]string{
+	"insertSQL": {"params"},
+	"params":    {"param"},
+	"newUUIDv4": {"formatUUID"},
+	"newUUIDv7": {"formatUUID"},
+}
+
+var goHelperImports = map[string][]string{
+	"insertSQL":  {"strings"},
+	"params":     {"strings"},
+	"affected":   {"database/sql"},
+	"timeArg":    {"time"},
+	"timeColumn": {"fmt", "time"},
+	"mustTime":   {"time"},
+	"jsonArg":    {"encoding/json"},
+	"jsonColumn": {"encoding/json", "fmt"},
+	"newUUIDv4":  {"crypto/rand", "fmt"},
+	"newUUIDv7":  {"crypto/rand", "encoding/binary", "fmt", "time"},
+	"formatUUID": {"fmt"},
+}
+
+func (g *goWriter) helperSource(name string) string {
+	switch name {
+	case "param":
+		return "// param is the placeholder for a statement's nth argument\n" + g.sql.paramSource
+	case "insertSQL":
+		return "// insertSQL inserts a row with the given columns, leaving the rest to\n// their defaults\n" + g.sql.insertSource
+	case "timeArg":
+		if g.sql.textTime {
+			return goTimeArgText
+		}
+	}
+	return goHelpers[name]
+}
+
+const goTimeArgText = `// timeArg writes a timestamp in the same rfc 3339 form sqlite's own defaults
+// use so they sort and compare correctly
+func timeArg(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+`
+
+var goHelpers = map[string]string{
+	"scanner": `type scanner interface {
+	Scan(dest ...any) error
+}
+`,
+	"params": `func params(n int) string {
+	ps := make([]string, n)
+	for i := range ps {
+		ps[i] = param(i + 1)
+	}
+	return strings.Join(ps, ", ")
+}
+`,
+	"affected": `// affected turns a statement that matched no rows into sql.ErrNoRows
+func affected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+`,
+	"timeColumn": `// timeColumn reads a timestamp whether the driver hands back a time.Time or
+// the rfc 3339 text it was stored as
+type timeColumn struct {
+	dst any
+}
+
+func (c timeColumn) Scan(src any) error {
+	var t time.Time
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		t = v
+	case []byte:
+		return c.Scan(string(v))
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return fmt.Errorf("reading a timestamp: %w", err)
+		}
+		t = parsed
+	default:
+		return fmt.Errorf("can't read a timestamp from %T", src)
+	}
+
+	switch dst := c.dst.(type) {
+	case *time.Time:
+		*dst = t
+	case **time.Time:
+		*dst = &t
+	}
+	return nil
+}
+`,
+	"mustTime": `func mustTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+`,
+	"jsonArg": `// jsonArg stores an embedded entity as json
+func jsonArg(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+`,
+	"jsonColumn": `// jsonColumn reads an embedded entity back out of its json
+type jsonColumn struct {
+	dst any
+}
+
+func (c jsonColumn) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), c.dst)
+	case []byte:
+		return json.Unmarshal(v, c.dst)
+	}
+	return fmt.Errorf("can't read json from %T", src)
+}
+`,
+	"newUUIDv4": `func newUUIDv4() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", fmt.Errorf("couldn't generate uuid: %w", err)
+	}
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return formatUUID(u), nil
+}
+`,
+	"newUUIDv7": `// newUUIDv7 puts the time first so keys sort by when they were made
+func newUUIDv7() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[6:]); err != nil {
+		return "", fmt.Errorf("couldn't generate uuid: %w", err)
+	}
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(time.Now().UnixMilli()))
+	copy(u[:6], ms[2:])
+	u[6] = (u[6] & 0x0f) | 0x70
+	u[8] = (u[8] & 0x3f) | 0x80
+	return formatUUID(u), nil
+}
+`,
+	"formatUUID": `func formatUUID(u [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+`,
+}
@@ -0,0 +1,59 @@
+package codegen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/engine/ddl"
+	"willofdaedalus/mime/internal/testutil"
+)
+
+// every shared schema is written for every dialect. the output has to
+// type check and the sqlite version is compared with testdata/<schema>.go.golden
+func TestGo(t *testing.T) {
+	fset := token.NewFileSet()
+	imp := importer.ForCompiler(fset, "source", nil)
+
+	for _, file := range testutil.Schemas(t, testutil.Shared) {
+		s := testutil.Load(t, file)
+		name := strings.TrimSuffix(filepath.Base(file), ".mime")
+		for _, dialect := range slices.Sorted(maps.Keys(ddl.Dialects)) {
+			t.Run(name+"/"+dialect, func(t *testing.T) {
+				out, err := Go(s, "models", dialect)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				f, err := parser.ParseFile(fset, name+".go", out, 0)
+				if err != nil {
+					t.Fatalf("generated go doesn't parse: %v", err)
+				}
+				conf := types.Config{Importer: imp}
+				if _, err := conf.Check("models", fset, []*ast.File{f}, nil); err != nil {
+					t.Fatalf("generated go doesn't type check: %v\n%s", err, out)
+				}
+				if dialect == "sqlite" {
+					testutil.Golden(t, name+".go.golden", out)
+				}
+			})
+		}
+	}
+}
+
+func TestGoErrors(t *testing.T) {
+	s := testutil.Load(t, filepath.Join(testutil.Shared, "shop.mime"))
+	if _, err := Go(s, "models", "oracle"); err == nil {
+		t.Fatal("expected an error for an unknown dialect")
+	}
+	if _, err := Go(s, "my-models", "sqlite"); err == nil {
+		t.Fatal("expected an error for an invalid package name")
+	}
+}
@@ -0,0 +1,326 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/ir"
+	"willofdaedalus/mime/internal/engine/query"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// GraphQL writes the schema as graphql sdl. every entity's response is an
+// object type whose references are the rows they point at and which lists
+// the rows that point back at it, every payload is an input type, every enum
+// a graphql enum and the routes are the fields of Query and Mutation
+func GraphQL(s *types.Schema) (string, error) {
+	g := &gqlWriter{schema: s}
+	var body strings.Builder
+	g.b = &body
+
+	var enums []enumType
+	g.enums, enums = enumTypes(s, pascal)
+	for _, t := range enums {
+		if t.field == nil {
+			g.enum(t.enum, t.enum.Doc)
+			continue
+		}
+		g.enum(t.enum, t.values())
+	}
+
+	routes, err := apiRoutes(s)
+	if err != nil {
+		return "", err
+	}
+	patched := make(map[*types.EntityNode]bool)
+	for _, r := range routes {
+		if r.route.Action == types.ActionUpdate && r.route.Method == "PATCH" {
+			patched[r.entity] = true
+		}
+	}
+	for _, e := range s.Entities {
+		g.object(e)
+	}
+	for _, e := range s.Entities {
+		g.input(e, "Input", "what a client sends to create or update a "+e.Name, optional)
+		if patched[e] {
+			g.input(e, "Patch", "the fields of a "+e.Name+" a PATCH changes; the rest are left alone",
+				func(*types.Field) bool { return true })
+		}
+	}
+
+	var queries, mutations []apiRoute
+	for _, r := range routes {
+		if r.route.Action == types.ActionFind {
+			queries = append(queries, r)
+		} else {
+			mutations = append(mutations, r)
+		}
+	}
+	g.root("Query", queries)
+	g.root("Mutation", mutations)
+
+	var out strings.Builder
+	out.WriteString(ir.Header(s, "#"))
+	if g.dateTime {
+		out.WriteString("\n\"an rfc 3339 timestamp\"\nscalar DateTime @specifiedBy(url: \"https://www.rfc-editor.org/rfc/rfc3339\")\n")
+	}
+	if g.decimal {
+		out.WriteString("\n\"an exact decimal written as a string\"\nscalar Decimal\n")
+	}
+	out.WriteString(body.String())
+	return out.String(), nil
+}
+
+type gqlWriter struct {
+	schema *types.Schema
+	b      *strings.Builder
+	// what enumTypes named each enum
+	enums map[*types.EnumNode]string
+	// a timestamp was written so the DateTime scalar has to be declared
+	dateTime bool
+	// the same for a decimal and the Decimal scalar
+	decimal bool
+}
+
+func (g *gqlWriter) printf(format string, args ...any) {
+	fmt.Fprintf(g.b, format, args...)
+}
+
+// description writes doc as a graphql description
+func (g *gqlWriter) description(indent, doc string) {
+	if doc == "" {
+		return
+	}
+	if !strings.Contains(doc, "\n") {
+		g.printf("%s%s\n", indent, gqlString(doc))
+		return
+	}
+	g.printf("%s\"\"\"\n", indent)
+	for _, line := range strings.Split(doc, "\n") {
+		g.printf("%s%s\n", indent, strings.ReplaceAll(line, `"""`, `\"""`))
+	}
+	g.printf("%s\"\"\"\n", indent)
+}
+
+func gqlString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (g *gqlWriter) enum(enum *types.EnumNode, doc string) {
+	g.printf("\n")
+	g.description("", doc)
+	g.printf("enum %s {\n", g.enums[enum])
+	for _, m := range enum.Members {
+		var docs []string
+		if m.Label != "" {
+			docs = append(docs, m.Label)
+		}
+		if m.Description != "" {
+			docs = append(docs, m.Description)
+		}
+		g.description("  ", strings.Join(docs, ". "))
+		deprecated := ""
+		if m.Deprecated {
+			deprecated = " @deprecated"
+		}
+		g.printf("  %s%s\n", constName(m.Name), deprecated)
+	}
+	g.printf("}\n")
+}
+
+var gqlTypes = map[types.DataType]string{
+	types.DataText:      "String",
+	types.DataUUID:      "ID",
+	types.DataInt:       "Int",
+	types.DataReal:      "Float",
+	types.DataBool:      "Boolean",
+	types.DataTimestamp: "DateTime",
+	types.DataDecimal:   "Decimal",
+}
+
+// valueType is the graphql type of the values f holds. references are the
+// value of the field they point at
+func (g *gqlWriter) valueType(f *types.Field) string {
+	dt, enum := valueType(g.schema, f)
+	if name, ok := g.enums[enum]; ok {
+		return name
+	}
+	if dt == types.DataTimestamp {
+		g.dateTime = true
+	}
+	if dt == types.DataDecimal {
+		g.decimal = true
+	}
+	if t, ok := gqlTypes[dt]; ok {
+		return t
+	}
+	return "String"
+}
+
+// an inverse is a reference from another entity seen from the entity it
+// points at e.g. note.owner makes every user have notes
+type gqlInverse struct {
+	name   string
+	entity *types.EntityNode
+	field  *types.Field
+}
+
+// inverses are the references to e from every entity. a unique reference
+// points back at one row and anything else at a list of them. when the name
+// would be ambiguous it says which field it goes through
+func (g *gqlWriter) inverses(e *types.EntityNode) []gqlInverse {
+	var out []gqlInverse
+	for _, from := range g.schema.Entities {
+		var refs []*types.Field
+		for _, f := range from.Fields {
+			if f.Kind == types.FieldReference && f.Target != nil && f.Target.Entity == e.Name {
+				refs = append(refs, f)
+			}
+		}
+		for _, f := range refs {
+			name := plural(from.Name)
+			if f.Attributes&types.AttrUnique != 0 {
+				name = from.Name
+			}
+			if len(refs) > 1 || e.Field(name) != nil {
+				name += "_by_" + f.Name
+			}
+			out = append(out, gqlInverse{name: name, entity: from, field: f})
+		}
+	}
+	return out
+}
+
+func (g *gqlWriter) object(e *types.EntityNode) {
+	g.printf("\n")
+	g.description("", e.Doc)
+	g.printf("type %s {\n", pascal(e.Name))
+	for _, f := range e.ResponseFields() {
+		doc := f.Doc
+		if !f.Stored() {
+			doc = strings.TrimPrefix(doc+"\ncomputed from other rows when it's read", "\n")
+		}
+		g.description("  ", doc)
+
+		var t string
+		switch f.Kind {
+		case types.FieldEmbedded:
+			t = pascal(f.Name)
+		case types.FieldReference:
+			t = pascal(f.Target.Entity)
+		default:
+			t = g.valueType(f)
+		}
+		if !f.Nullable() && f.Stored() {
+			t += "!"
+		}
+		g.printf("  %s: %s\n", f.Name, t)
+	}
+	for _, inv := range g.inverses(e) {
+		rows, t := plural(inv.entity.Name), "["+pascal(inv.entity.Name)+"!]!"
+		if inv.field.Attributes&types.AttrUnique != 0 {
+			rows, t = inv.entity.Name, pascal(inv.entity.Name)
+		}
+		g.description("  ", fmt.Sprintf("the %s whose %s is this %s", rows, inv.field.Name, e.Name))
+		g.printf("  %s: %s\n", inv.name, t)
+	}
+	g.printf("}\n")
+}
+
+// input writes the entity's payload as <Entity><suffix>. nullable decides
+// which of its fields can be left out
+func (g *gqlWriter) input(e *types.EntityNode, suffix, doc string, nullable func(*types.Field) bool) {
+	g.printf("\n")
+	g.description("", doc)
+	g.printf("input %s%s {\n", pascal(e.Name), suffix)
+	for _, f := range e.PayloadFields() {
+		g.description("  ", f.Doc)
+		var t string
+		if f.Kind == types.FieldEmbedded {
+			t = pascal(f.Name) + "Input"
+		} else {
+			t = g.valueType(f)
+		}
+		if !nullable(f) {
+			t += "!"
+		}
+		g.printf("  %s: %s\n", f.Name, t)
+	}
+	g.printf("}\n")
+}
+
+// root writes Query or Mutation with a field per route. a root with no
+// routes is left out since graphql doesn't allow empty types
+func (g *gqlWriter) root(name string, routes []apiRoute) {
+	if len(routes) == 0 {
+		return
+	}
+	g.printf("\ntype %s {\n", name)
+	for i, r := range routes {
+		if i > 0 {
+			g.printf("\n")
+		}
+		g.field(r)
+	}
+	g.printf("}\n")
+}
+
+func (g *gqlWriter) field(r apiRoute) {
+	route, e := r.route, r.entity
+
+	var args []string
+	for _, p := range route.PathParams() {
+		t := "String"
+		if r.match != nil && route.Match.Source == ":"+p {
+			t = g.valueType(r.match)
+		}
+		args = append(args, p+": "+t+"!")
+	}
+	if r.params() {
+		for _, f := range queryFields(e) {
+			args = append(args, f.Name+": "+g.valueType(f))
+		}
+		if route.Options&types.RouteAdmin != 0 && e.SoftDelete {
+			args = append(args, query.IncludeDeletedParam+": Boolean")
+		}
+	}
+	switch route.Action {
+	case types.ActionCreate:
+		args = append(args, "input: "+pascal(e.Name)+"Input!")
+	case types.ActionUpdate:
+		suffix := "Input"
+		if route.Method == "PATCH" {
+			suffix = "Patch"
+		}
+		args = append(args, "input: "+pascal(e.Name)+suffix+"!")
+	}
+
+	var result string
+	switch {
+	case r.list:
+		result = "[" + pascal(e.Name) + "!]!"
+	case route.Action == types.ActionFind:
+		// nothing matching is null rather than an error
+		result = pascal(e.Name)
+	case route.Action == types.ActionCreate, route.Action == types.ActionUpdate:
+		result = pascal(e.Name) + "!"
+	default:
+		result = "Boolean!"
+	}
+
+	doc := route.Method + " " + route.Path
+	if fb := route.Fallback; fb != nil {
+		doc += fmt.Sprintf("; fails with %d %q", fb.Status, fb.Message)
+	}
+	if route.Doc != "" {
+		doc = route.Doc + "\n" + doc
+	}
+	g.description("  ", doc)
+
+	list := ""
+	if len(args) > 0 {
+		list = "(" + strings.Join(args, ", ") + ")"
+	}
+	g.printf("  %s%s: %s\n", r.name, list, result)
+}
@@ -0,0 +1,37 @@
+package codegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/testutil"
+)
+
+// every shared schema is compared with testdata/<schema>.graphql
+func TestGraphQL(t *testing.T) {
+	for _, file := range testutil.Schemas(t, testutil.Shared) {
+		name := strings.TrimSuffix(filepath.Base(file), ".mime")
+		t.Run(name, func(t *testing.T) {
+			out, err := GraphQL(testutil.Load(t, file))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testutil.Golden(t, name+".graphql", out)
+		})
+	}
+}
+
+func TestConstName(t *testing.T) {
+	tests := map[string]string{
+		"admin":   "ADMIN",
+		"on-hold": "ON_HOLD",
+		"1":       "_1",
+		"true":    "TRUE",
+	}
+	for name, want := range tests {
+		if got := constName(name); got != want {
+			t.Errorf("constName(%q): expected %s, got %s", name, want, got)
+		}
+	}
+}
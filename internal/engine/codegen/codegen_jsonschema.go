@@ -0,0 +1,58 @@
+package codegen
+
+import (
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/ir"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// JSONSchema writes a draft 2020-12 schema for every entity's payload and
+// response, keyed by file name e.g. student.payload.json. each file stands
+// on its own: enums are written out where they're used and embedded
+// entities are a $ref to the file for the same shape of the embedded entity
+func JSONSchema(s *types.Schema) (map[string]string, error) {
+	o := &schemaWriter{
+		schema: s,
+		embed: func(entity, shape string) *object {
+			return newObject().set("$ref", jsonSchemaFile(entity, shape))
+		},
+	}
+
+	comment := ir.Generated + " fingerprint: " + ir.Hash(s).Schema
+	files := make(map[string]string, 2*len(s.Entities))
+	for _, e := range s.Entities {
+		for _, shape := range []string{"Payload", "Response"} {
+			var schema *object
+			if shape == "Payload" {
+				schema = o.payload(e)
+			} else {
+				schema = o.response(e)
+			}
+
+			name := jsonSchemaFile(e.Name, shape)
+			out := newObject().
+				set("$schema", "https://json-schema.org/draft/2020-12/schema").
+				set("$id", name).
+				set("$comment", comment).
+				set("title", pascal(e.Name)+shape)
+			for _, k := range schema.keys {
+				out.set(k, schema.values[k])
+			}
+			// payloads already reject unknown members; responses are closed
+			// too so consumers notice when a field they don't know shows up
+			out.set("additionalProperties", false)
+
+			data, err := indentJSON(out)
+			if err != nil {
+				return nil, err
+			}
+			files[name] = data
+		}
+	}
+	return files, nil
+}
+
+func jsonSchemaFile(entity, shape string) string {
+	return entity + "." + strings.ToLower(shape) + ".json"
+}
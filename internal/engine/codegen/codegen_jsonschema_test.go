@@ -0,0 +1,60 @@
+package codegen
+
+import (
+	"encoding/json"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/testutil"
+)
+
+// every shared schema is compared with testdata/<schema>.jsonschema.golden,
+// which holds every file under a -- name -- line
+func TestJSONSchema(t *testing.T) {
+	for _, file := range testutil.Schemas(t, testutil.Shared) {
+		name := strings.TrimSuffix(filepath.Base(file), ".mime")
+		t.Run(name, func(t *testing.T) {
+			files, err := JSONSchema(testutil.Load(t, file))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var all strings.Builder
+			for _, fname := range slices.Sorted(maps.Keys(files)) {
+				var doc any
+				if err := json.Unmarshal([]byte(files[fname]), &doc); err != nil {
+					t.Fatalf("%s isn't json: %v", fname, err)
+				}
+				for _, ref := range refs(doc) {
+					if _, ok := files[ref]; !ok {
+						t.Errorf("%s refers to %s which wasn't written", fname, ref)
+					}
+				}
+				all.WriteString("-- " + fname + " --\n" + files[fname])
+			}
+			testutil.Golden(t, name+".jsonschema.golden", all.String())
+		})
+	}
+}
+
+// refs finds every $ref in a decoded document
+func refs(v any) []string {
+	var out []string
+	switch v := v.(type) {
+	case map[string]any:
+		if r, ok := v["$ref"].(string); ok {
+			out = append(out, r)
+		}
+		for _, m := range v {
+			out = append(out, refs(m)...)
+		}
+	case []any:
+		for _, m := range v {
+			out = append(out, refs(m)...)
+		}
+	}
+	return out
+}
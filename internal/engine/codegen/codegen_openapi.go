@@ -0,0 +1,187 @@
+package codegen
+
+import (
+	"strconv"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/ir"
+	"willofdaedalus/mime/internal/engine/query"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// OpenAPI writes the schema as an openapi 3.1 document. every entity's
+// payload and response are component schemas, named enums are schemas of
+// their own and every route is an operation named the way the ts client
+// names its functions. version defaults to the start of the schema's
+// fingerprint so the document changes version whenever the api does
+func OpenAPI(s *types.Schema, title, version string) (string, error) {
+	fp := ir.Hash(s).Schema
+	if version == "" {
+		version = fp[:12]
+	}
+	o := &schemaWriter{
+		schema: s,
+		enums:  make(map[*types.EnumNode]string),
+		embed: func(entity, shape string) *object {
+			return ref(pascal(entity) + shape)
+		},
+	}
+
+	schemas := newObject()
+	for _, enum := range s.Enums {
+		o.enums[enum] = pascal(enum.Name)
+		schemas.set(pascal(enum.Name), o.enum(enum).set("description", doc(enum.Doc)))
+	}
+	for _, e := range s.Entities {
+		name := pascal(e.Name)
+		schemas.set(name+"Payload", o.payload(e))
+		schemas.set(name+"Response", o.response(e))
+	}
+	schemas.set("FieldError", openAPIFieldError)
+	schemas.set("ErrorEnvelope", openAPIErrorEnvelope)
+
+	routes, err := apiRoutes(s)
+	if err != nil {
+		return "", err
+	}
+	paths := newObject()
+	for _, r := range routes {
+		path := openAPIPath(r.route.Path)
+		item, _ := paths.values[path].(*object)
+		if item == nil {
+			item = newObject()
+			paths.set(path, item)
+		}
+		item.set(strings.ToLower(r.route.Method), o.operation(r))
+	}
+
+	out := newObject().
+		set("openapi", "3.1.0").
+		set("info", newObject().
+			set("title", title).
+			set("version", version).
+			set("x-mime-fingerprint", fp)).
+		set("paths", paths).
+		set("components", newObject().set("schemas", schemas))
+	return indentJSON(out)
+}
+
+// the envelope mirrors the runtime's errors; see tsErrors
+var openAPIFieldError = newObject().
+	set("type", "object").
+	set("description", "why a field in a payload was rejected").
+	set("properties", newObject().
+		set("pointer", newObject().
+			set("type", "string").
+			set("description", "a json pointer to the field e.g. /person/name")).
+		set("code", newObject().set("type", "string").set("enum", tsErrorCodes)).
+		set("message", newObject().set("type", "string"))).
+	set("required", []string{"pointer", "code", "message"})
+
+var openAPIErrorEnvelope = newObject().
+	set("type", "object").
+	set("description", "what the server sends back when a request fails").
+	set("properties", newObject().
+		set("status", newObject().set("type", "integer")).
+		set("message", newObject().set("type", "string")).
+		set("errors", newObject().set("type", "array").set("items", ref("FieldError")))).
+	set("required", []string{"status", "message"})
+
+func ref(name string) *object {
+	return newObject().set("$ref", "#/components/schemas/"+name)
+}
+
+// openAPIPath turns :captures into {captures}
+func openAPIPath(path string) string {
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		if strings.HasPrefix(seg, ":") {
+			segs[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+func (o *schemaWriter) operation(r apiRoute) *object {
+	route, e := r.route, r.entity
+	op := newObject().
+		set("operationId", r.name).
+		set("tags", []string{e.Name}).
+		set("description", doc(route.Doc))
+
+	var params []any
+	for _, p := range route.PathParams() {
+		schema := newObject().set("type", "string")
+		if r.match != nil && route.Match.Source == ":"+p {
+			schema = o.value(r.match, false)
+		}
+		params = append(params, newObject().
+			set("name", p).
+			set("in", "path").
+			set("required", true).
+			set("schema", schema))
+	}
+	if r.params() {
+		for _, f := range queryFields(e) {
+			params = append(params, newObject().
+				set("name", f.Name).
+				set("in", "query").
+				set("description", doc(f.Doc)).
+				set("schema", o.value(f, false)))
+		}
+		if route.Options&types.RouteAdmin != 0 && e.SoftDelete {
+			params = append(params, newObject().
+				set("name", query.IncludeDeletedParam).
+				set("in", "query").
+				set("description", "include rows that were soft deleted").
+				set("schema", newObject().set("type", "boolean")))
+		}
+	}
+	if len(params) > 0 {
+		op.set("parameters", params)
+	}
+
+	if route.Action == types.ActionCreate || route.Action == types.ActionUpdate {
+		op.set("requestBody", newObject().
+			set("required", true).
+			set("content", jsonContent(ref(pascal(e.Name)+"Payload"))))
+	}
+
+	responses := newObject()
+	switch route.Action {
+	case types.ActionFind, types.ActionUpdate:
+		var schema any = ref(pascal(e.Name) + "Response")
+		description := "the " + e.Name
+		if r.list {
+			schema = newObject().set("type", "array").set("items", schema)
+			description = "the matching " + plural(e.Name)
+		}
+		responses.set("200", newObject().set("description", description).set("content", jsonContent(schema)))
+	case types.ActionCreate:
+		responses.set("201", newObject().
+			set("description", "the "+e.Name+" that was created").
+			set("content", jsonContent(ref(pascal(e.Name)+"Response"))))
+	default:
+		responses.set("204", newObject().set("description", "the "+e.Name+" was "+route.Action.String()+"d"))
+	}
+	if fb := route.Fallback; fb != nil {
+		status := strconv.Itoa(fb.Status)
+		if _, ok := responses.values[status]; !ok {
+			description := fb.Message
+			if description == "" {
+				description = "the route's fallback"
+			}
+			responses.set(status, newObject().
+				set("description", description).
+				set("content", jsonContent(ref("ErrorEnvelope"))))
+		}
+	}
+	responses.set("default", newObject().
+		set("description", "anything else that went wrong").
+		set("content", jsonContent(ref("ErrorEnvelope"))))
+	return op.set("responses", responses)
+}
+
+func jsonContent(schema any) *object {
+	return newObject().set("application/json", newObject().set("schema", schema))
+}
@@ -0,0 +1,47 @@
+package codegen
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/testutil"
+)
+
+// every shared schema is compared with testdata/<schema>.openapi.json
+func TestOpenAPI(t *testing.T) {
+	for _, file := range testutil.Schemas(t, testutil.Shared) {
+		name := strings.TrimSuffix(filepath.Base(file), ".mime")
+		t.Run(name, func(t *testing.T) {
+			out, err := OpenAPI(testutil.Load(t, file), name, "1.0.0")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !json.Valid([]byte(out)) {
+				t.Fatalf("generated document isn't json:\n%s", out)
+			}
+			testutil.Golden(t, name+".openapi.json", out)
+		})
+	}
+}
+
+func TestOpenAPIVersion(t *testing.T) {
+	out, err := OpenAPI(testutil.Load(t, filepath.Join(testutil.Shared, "shop.mime")), "shop", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var doc struct {
+		Info struct {
+			Version     string `json:"version"`
+			Fingerprint string `json:"x-mime-fingerprint"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Info.Version == "" || !strings.HasPrefix(doc.Info.Fingerprint, doc.Info.Version) {
+		t.Errorf("version %q isn't the start of the fingerprint %q", doc.Info.Version, doc.Info.Fingerprint)
+	}
+}
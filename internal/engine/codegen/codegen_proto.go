@@ -0,0 +1,436 @@
+package codegen
+
+import (
+	"fmt"
+	"go/token"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"willofdaedalus/mime/internal/engine/ir"
+	"willofdaedalus/mime/internal/engine/query"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// ProtoLock keeps the number Proto gave every message field and enum member
+// so regenerating never renumbers what's already on the wire. it's keyed by
+// message or enum and then by the mime name of the field or member. names
+// that leave the schema stay in the lock and their numbers are reserved
+// instead of handed out again
+type ProtoLock struct {
+	Messages map[string]map[string]int `json:"messages"`
+	Enums    map[string]map[string]int `json:"enums"`
+}
+
+// Proto writes the schema as a proto3 file in package pkg. every entity is a
+// message of its response and a message of its payload, every enum a proto
+// enum whose zero value is UNSPECIFIED and the routes on each entity a
+// service. numbers come from lock and the ones handed out to new fields are
+// added to it, so the caller has to save lock for the numbers to stick
+func Proto(s *types.Schema, pkg string, lock *ProtoLock) (string, error) {
+	for _, part := range strings.Split(pkg, ".") {
+		if !token.IsIdentifier(part) {
+			return "", fmt.Errorf("%q isn't a valid package name", pkg)
+		}
+	}
+	if lock == nil {
+		lock = &ProtoLock{}
+	}
+	if lock.Messages == nil {
+		lock.Messages = make(map[string]map[string]int)
+	}
+	if lock.Enums == nil {
+		lock.Enums = make(map[string]map[string]int)
+	}
+
+	p := &protoWriter{
+		schema:  s,
+		lock:    lock,
+		imports: make(map[string]bool),
+	}
+	var body strings.Builder
+	p.b = &body
+
+	var enums []enumType
+	p.enums, enums = enumTypes(s, pascal)
+	for _, t := range enums {
+		if t.field == nil {
+			p.enum(t.enum, t.enum.Doc)
+			continue
+		}
+		p.enum(t.enum, t.values())
+	}
+	for _, e := range s.Entities {
+		p.messages(e)
+	}
+
+	routes, err := apiRoutes(s)
+	if err != nil {
+		return "", err
+	}
+	for _, r := range routes {
+		p.request(r)
+	}
+	for _, e := range s.Entities {
+		var own []apiRoute
+		for _, r := range routes {
+			if r.entity == e {
+				own = append(own, r)
+			}
+		}
+		if len(own) > 0 {
+			p.service(e, own)
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString(ir.Header(s, "//"))
+	out.WriteString("\nsyntax = \"proto3\";\n\npackage " + pkg + ";\n")
+	if len(p.imports) > 0 {
+		out.WriteString("\n")
+		for _, imp := range slices.Sorted(maps.Keys(p.imports)) {
+			out.WriteString("import " + strconv.Quote(imp) + ";\n")
+		}
+	}
+	out.WriteString(body.String())
+	return out.String(), nil
+}
+
+type protoWriter struct {
+	schema  *types.Schema
+	lock    *ProtoLock
+	b       *strings.Builder
+	imports map[string]bool
+	// what enumTypes named each enum
+	enums map[*types.EnumNode]string
+}
+
+func (p *protoWriter) printf(format string, args ...any) {
+	fmt.Fprintf(p.b, format, args...)
+}
+
+// doc writes each line of a doc comment as a // comment
+func (p *protoWriter) doc(indent, doc string) {
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		p.printf("%s// %s\n", indent, line)
+	}
+}
+
+// numbers gives each of names the number the lock has for it or the next
+// one after the highest it's ever handed out. it returns what the lock has
+// for names that aren't there anymore so they can be reserved
+func numbers(lock map[string]map[string]int, key string, names []string) (map[string]int, map[string]int) {
+	nums := lock[key]
+	if nums == nil {
+		nums = make(map[string]int)
+		lock[key] = nums
+	}
+	next := 1
+	for _, n := range nums {
+		next = max(next, n+1)
+	}
+	for _, name := range names {
+		if _, ok := nums[name]; !ok {
+			nums[name] = next
+			next++
+		}
+	}
+
+	gone := maps.Clone(nums)
+	for _, name := range names {
+		delete(gone, name)
+	}
+	return nums, gone
+}
+
+// reserved writes the numbers and names of removed fields or members so
+// nobody reuses them by hand either
+func (p *protoWriter) reserved(gone map[string]int, name func(string) string) {
+	if len(gone) == 0 {
+		return
+	}
+	names := slices.SortedFunc(maps.Keys(gone), func(a, b string) int { return gone[a] - gone[b] })
+	nums := make([]string, len(names))
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		nums[i] = strconv.Itoa(gone[n])
+		quoted[i] = strconv.Quote(name(n))
+	}
+	p.printf("  reserved %s;\n  reserved %s;\n", strings.Join(nums, ", "), strings.Join(quoted, ", "))
+}
+
+func (p *protoWriter) enum(enum *types.EnumNode, doc string) {
+	name := p.enums[enum]
+	prefix := upperSnake(name) + "_"
+	names := make([]string, len(enum.Members))
+	for i, m := range enum.Members {
+		names[i] = m.Name
+	}
+	nums, gone := numbers(p.lock.Enums, name, names)
+	value := func(member string) string {
+		return prefix + strings.ToUpper(protoName(member))
+	}
+
+	p.printf("\n")
+	p.doc("", doc)
+	p.printf("enum %s {\n  %sUNSPECIFIED = 0;\n", name, prefix)
+	for _, m := range enum.Members {
+		var docs []string
+		if m.Label != "" {
+			docs = append(docs, m.Label)
+		}
+		if m.Description != "" {
+			docs = append(docs, m.Description)
+		}
+		p.doc("  ", strings.Join(docs, ". "))
+		opts := ""
+		if m.Deprecated {
+			opts = " [deprecated = true]"
+		}
+		p.printf("  %s = %d%s;\n", value(m.Name), nums[m.Name], opts)
+	}
+	p.reserved(gone, value)
+	p.printf("}\n")
+}
+
+// decimals are strings since proto has no exact number that isn't whole
+var protoTypes = map[types.DataType]string{
+	types.DataText:      "string",
+	types.DataUUID:      "string",
+	types.DataInt:       "int64",
+	types.DataReal:      "double",
+	types.DataBool:      "bool",
+	types.DataTimestamp: "google.protobuf.Timestamp",
+	types.DataDecimal:   "string",
+}
+
+// fieldType is the proto type of f's values. shape is the suffix of the
+// message embedded entities are written as
+func (p *protoWriter) fieldType(f *types.Field, shape string) string {
+	if f.Kind == types.FieldEmbedded {
+		return pascal(f.Name) + shape
+	}
+	dt, enum := valueType(p.schema, f)
+	if name, ok := p.enums[enum]; ok {
+		return name
+	}
+	if dt == types.DataTimestamp {
+		p.imports["google/protobuf/timestamp.proto"] = true
+	}
+	if t, ok := protoTypes[dt]; ok {
+		return t
+	}
+	return "bytes"
+}
+
+// field writes a scalar with explicit presence when it can be left out.
+// messages always have presence so they never need it
+func (p *protoWriter) field(doc, typ, name string, num int, optional bool) {
+	p.doc("  ", doc)
+	label := ""
+	if optional && p.scalar(typ) {
+		label = "optional "
+	}
+	p.printf("  %s%s %s = %d;\n", label, typ, protoName(name), num)
+}
+
+// scalar reports whether typ is a scalar or an enum rather than a message
+func (p *protoWriter) scalar(typ string) bool {
+	for _, t := range protoTypes {
+		if t == typ && !strings.HasPrefix(t, "google.protobuf.") {
+			return true
+		}
+	}
+	return typ == "bytes" || slices.Contains(slices.Collect(maps.Values(p.enums)), typ)
+}
+
+// messages writes the entity's response as <Entity> and its payload as
+// <Entity>Payload. both are numbered from the same lock entry so a field
+// has one number whichever shape it's in
+func (p *protoWriter) messages(e *types.EntityNode) {
+	name := pascal(e.Name)
+	names := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		names[i] = f.Name
+	}
+	nums, gone := numbers(p.lock.Messages, name, names)
+
+	p.printf("\n")
+	if e.Doc != "" {
+		p.doc("", e.Doc)
+	} else {
+		p.printf("// a %s as a client gets it back\n", e.Name)
+	}
+	p.printf("message %s {\n", name)
+	for _, f := range e.ResponseFields() {
+		doc := f.Doc
+		if !f.Stored() {
+			doc = strings.TrimPrefix(doc+"\ncomputed from other rows when it's read", "\n")
+		}
+		p.field(doc, p.fieldType(f, ""), f.Name, nums[f.Name], f.Nullable() || !f.Stored())
+	}
+	p.reserved(gone, protoName)
+	p.printf("}\n")
+
+	p.printf("\n// what a client sends to create or update a %s\nmessage %sPayload {\n", e.Name, name)
+	for _, f := range e.PayloadFields() {
+		p.field(f.Doc, p.fieldType(f, "Payload"), f.Name, nums[f.Name], optional(f))
+	}
+	p.reserved(gone, protoName)
+	p.printf("}\n")
+}
+
+// a field of a request message
+type protoField struct {
+	doc, typ, name string
+	optional       bool
+}
+
+// request writes the message a route's rpc takes: its captures, what it
+// filters on and the payload it writes. lists get a response message too
+func (p *protoWriter) request(r apiRoute) {
+	route, e := r.route, r.entity
+	var fields []protoField
+	for _, param := range route.PathParams() {
+		typ := "string"
+		if r.match != nil && route.Match.Source == ":"+param {
+			typ = p.fieldType(r.match, "")
+		}
+		fields = append(fields, protoField{typ: typ, name: param})
+	}
+	if r.params() {
+		for _, f := range queryFields(e) {
+			fields = append(fields, protoField{doc: f.Doc, typ: p.fieldType(f, ""), name: f.Name, optional: true})
+		}
+		if route.Options&types.RouteAdmin != 0 && e.SoftDelete {
+			fields = append(fields, protoField{
+				doc:      "include rows that were soft deleted",
+				typ:      "bool",
+				name:     query.IncludeDeletedParam,
+				optional: true,
+			})
+		}
+	}
+	switch route.Action {
+	case types.ActionCreate, types.ActionUpdate:
+		fields = append(fields, protoField{typ: pascal(e.Name) + "Payload", name: e.Name})
+		if route.Method == "PATCH" {
+			p.imports["google/protobuf/field_mask.proto"] = true
+			fields = append(fields, protoField{
+				doc:  "the payload's fields to change; PATCH leaves the rest alone",
+				typ:  "google.protobuf.FieldMask",
+				name: "update_mask",
+			})
+		}
+	}
+
+	// a query field can share its name with a capture; the capture wins
+	seen := make(map[string]bool)
+	fields = slices.DeleteFunc(fields, func(f protoField) bool {
+		dup := seen[protoName(f.name)]
+		seen[protoName(f.name)] = true
+		return dup
+	})
+
+	name := pascal(r.name) + "Request"
+	p.printf("\n// what %s %s takes\nmessage %s {\n", route.Method, route.Path, name)
+	p.fields(name, fields)
+	p.printf("}\n")
+
+	if r.list {
+		name := pascal(r.name) + "Response"
+		p.printf("\n// what %s %s gives back\nmessage %s {\n", route.Method, route.Path, name)
+		p.fields(name, []protoField{{typ: "repeated " + pascal(e.Name), name: plural(e.Name)}})
+		p.printf("}\n")
+	}
+}
+
+func (p *protoWriter) fields(message string, fields []protoField) {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	nums, gone := numbers(p.lock.Messages, message, names)
+	for _, f := range fields {
+		p.field(f.doc, f.typ, f.name, nums[f.name], f.optional)
+	}
+	p.reserved(gone, protoName)
+}
+
+func (p *protoWriter) service(e *types.EntityNode, routes []apiRoute) {
+	p.printf("\n// the routes on %s\nservice %sService {\n", e.Name, pascal(e.Name))
+	for i, r := range routes {
+		route := r.route
+		if i > 0 {
+			p.printf("\n")
+		}
+		p.doc("  ", route.Doc)
+		line := route.Method + " " + route.Path
+		if fb := route.Fallback; fb != nil {
+			line += "; fails with " + grpcCode(fb.Status)
+			if fb.Message != "" {
+				line += " " + strconv.Quote(fb.Message)
+			}
+		}
+		p.printf("  // %s\n", line)
+
+		result := "google.protobuf.Empty"
+		switch {
+		case r.list:
+			result = pascal(r.name) + "Response"
+		case route.Action == types.ActionFind, route.Action == types.ActionCreate, route.Action == types.ActionUpdate:
+			result = pascal(e.Name)
+		default:
+			p.imports["google/protobuf/empty.proto"] = true
+		}
+		p.printf("  rpc %s(%sRequest) returns (%s);\n", pascal(r.name), pascal(r.name), result)
+	}
+	p.printf("}\n")
+}
+
+// the grpc status codes http statuses map to, going by the table in
+// grpc's http mapping
+var grpcCodes = map[int]string{
+	400: "INVALID_ARGUMENT",
+	401: "UNAUTHENTICATED",
+	403: "PERMISSION_DENIED",
+	404: "NOT_FOUND",
+	409: "ALREADY_EXISTS",
+	412: "FAILED_PRECONDITION",
+	429: "RESOURCE_EXHAUSTED",
+	499: "CANCELLED",
+	500: "INTERNAL",
+	501: "UNIMPLEMENTED",
+	503: "UNAVAILABLE",
+	504: "DEADLINE_EXCEEDED",
+}
+
+func grpcCode(status int) string {
+	if code, ok := grpcCodes[status]; ok {
+		return code
+	}
+	return "UNKNOWN"
+}
+
+// protoName is a mime name in the snake case proto fields are written in
+func protoName(name string) string {
+	return strings.ToLower(strings.Join(words(name), "_"))
+}
+
+// upperSnake turns a pascal case name into the upper snake case enum values
+// are prefixed with e.g. UserRole becomes USER_ROLE
+func upperSnake(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package codegen
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/engine/types"
+	"willofdaedalus/mime/internal/testutil"
+)
+
+// every shared schema is compared with testdata/<schema>.proto.golden
+func TestProto(t *testing.T) {
+	for _, file := range testutil.Schemas(t, testutil.Shared) {
+		name := strings.TrimSuffix(filepath.Base(file), ".mime")
+		t.Run(name, func(t *testing.T) {
+			out, err := Proto(testutil.Load(t, file), name+".v1", &ProtoLock{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testutil.Golden(t, name+".proto.golden", out)
+		})
+	}
+}
+
+func TestProtoLock(t *testing.T) {
+	s := testutil.Load(t, filepath.Join(testutil.Shared, "notes.mime"))
+	lock := &ProtoLock{}
+	if _, err := Proto(s, "notes", lock); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	before := lock.Messages["Note"]
+	pinned, words, used := before["pinned"], before["words"], len(before)
+
+	// drop a field from the middle and add one at the front; nothing that's
+	// left may move and the new field can't take the dropped one's number
+	note := s.Entity("note")
+	note.Fields = slices.DeleteFunc(note.Fields, func(f *types.Field) bool { return f.Name == "pinned" })
+	note.Fields = append([]*types.Field{{Name: "color", Kind: types.FieldPrimitive, DataType: types.DataText}}, note.Fields...)
+
+	out, err := Proto(s, "notes", lock)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	after := lock.Messages["Note"]
+	if after["words"] != words {
+		t.Errorf("words moved from %d to %d", words, after["words"])
+	}
+	if after["pinned"] != pinned {
+		t.Errorf("the lock forgot pinned's number %d", pinned)
+	}
+	if after["color"] <= used {
+		t.Errorf("color took the used number %d", after["color"])
+	}
+	if !strings.Contains(out, `reserved "pinned";`) {
+		t.Errorf("pinned isn't reserved:\n%s", out)
+	}
+}
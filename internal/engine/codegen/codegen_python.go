@@ -0,0 +1,518 @@
+package codegen
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/ir"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// Python writes the schema as a python module of pydantic v2 models. every
+// entity gets a model for its payload and its response, named enums become
+// enum classes and inline ones Literal aliases. payloads carry the field
+// rules: length and pattern as Field constraints and checks as a model
+// validator that follows the runtime's null semantics, so a payload python
+// accepts is one the server accepts too
+func Python(s *types.Schema) (string, error) {
+	p := &pyWriter{schema: s, imports: make(map[string][]string)}
+	var body strings.Builder
+	p.b = &body
+
+	var enums []enumType
+	p.enums, enums = enumTypes(s, pascal)
+	for _, t := range enums {
+		if t.field == nil {
+			p.enum(t.enum)
+			continue
+		}
+		p.literal(t.enum, t.name, t.values())
+	}
+	for _, e := range s.Entities {
+		p.payload(e)
+		p.response(e)
+	}
+
+	var out strings.Builder
+	out.WriteString(ir.Header(s, "#"))
+	out.WriteString("\nfrom __future__ import annotations\n\n")
+	out.WriteString(p.importLines())
+	if p.checks {
+		out.WriteString(pyChecks)
+	}
+	out.WriteString(body.String())
+	return out.String(), nil
+}
+
+type pyWriter struct {
+	schema *types.Schema
+	b      *strings.Builder
+	// what enumTypes named each enum
+	enums map[*types.EnumNode]string
+	// the names imported from each module; a module imported whole maps to
+	// nothing
+	imports map[string][]string
+	// a payload has checks so the helpers they call have to be written
+	checks bool
+}
+
+func (p *pyWriter) printf(format string, args ...any) {
+	fmt.Fprintf(p.b, format, args...)
+}
+
+// use records an import. with no names the module is imported whole
+func (p *pyWriter) use(module string, names ...string) {
+	have := p.imports[module]
+	for _, n := range names {
+		if !slices.Contains(have, n) {
+			have = append(have, n)
+		}
+	}
+	p.imports[module] = have
+}
+
+// importLines groups the imports the way isort does: the standard library
+// with whole modules first, then pydantic
+func (p *pyWriter) importLines() string {
+	var whole, from []string
+	for module, names := range p.imports {
+		if module == "pydantic" {
+			continue
+		}
+		if len(names) == 0 {
+			whole = append(whole, "import "+module+"\n")
+		} else {
+			names = slices.Sorted(slices.Values(names))
+			from = append(from, "from "+module+" import "+strings.Join(names, ", ")+"\n")
+		}
+	}
+	slices.Sort(whole)
+	slices.Sort(from)
+
+	var b strings.Builder
+	for _, line := range append(whole, from...) {
+		b.WriteString(line)
+	}
+	if len(whole)+len(from) > 0 {
+		b.WriteString("\n")
+	}
+	names := slices.Sorted(slices.Values(append(p.imports["pydantic"], "BaseModel")))
+	b.WriteString("from pydantic import " + strings.Join(slices.Compact(names), ", ") + "\n")
+	return b.String()
+}
+
+// docstring writes doc as the docstring of whatever was just opened
+func (p *pyWriter) docstring(indent, doc string) {
+	doc = strings.NewReplacer(`\`, `\\`, `"""`, `\"""`).Replace(doc)
+	if strings.HasSuffix(doc, `"`) {
+		doc += " "
+	}
+	if !strings.Contains(doc, "\n") {
+		p.printf("%s\"\"\"%s\"\"\"\n", indent, doc)
+		return
+	}
+	p.printf("%s\"\"\"%s\n%s\"\"\"\n", indent, strings.ReplaceAll(doc, "\n", "\n"+indent), indent)
+}
+
+// pyValue is an enum member's stored value as a python literal
+func pyValue(enum *types.EnumNode, v string) string {
+	if enum.Backing == types.DataInt {
+		return v
+	}
+	return strconv.Quote(v)
+}
+
+// named enums are enum classes that are also their stored values so a
+// member compares equal to what's in the database and serialises to it
+func (p *pyWriter) enum(enum *types.EnumNode) {
+	p.use("enum")
+	base := "str, enum.Enum"
+	if enum.Backing == types.DataInt {
+		base = "enum.IntEnum"
+	}
+	p.printf("\n\nclass %s(%s):\n", p.enums[enum], base)
+	doc := enum.Doc
+	if doc == "" {
+		doc = "a member of the " + enum.Name + " enum"
+	}
+	p.docstring("    ", doc)
+	p.printf("\n")
+	for _, m := range enum.Members {
+		var docs []string
+		if m.Label != "" {
+			docs = append(docs, m.Label)
+		}
+		if m.Description != "" {
+			docs = append(docs, m.Description)
+		}
+		if m.Deprecated {
+			docs = append(docs, "deprecated")
+		}
+		if len(docs) > 0 {
+			p.printf("    # %s\n", strings.Join(docs, ". "))
+		}
+		p.printf("    %s = %s\n", constName(m.Name), pyValue(enum, m.Value))
+	}
+}
+
+// inline enums only list values so they're Literal aliases
+func (p *pyWriter) literal(enum *types.EnumNode, name, doc string) {
+	p.use("typing", "Literal")
+	values := make([]string, len(enum.Members))
+	for i, m := range enum.Members {
+		values[i] = pyValue(enum, m.Value)
+	}
+	p.printf("\n\n# %s\n%s = Literal[%s]\n", doc, name, strings.Join(values, ", "))
+}
+
+// fieldType is the python type of f's values. shape is the suffix of the
+// model embedded entities are written as
+func (p *pyWriter) fieldType(f *types.Field, shape string) string {
+	if f.Kind == types.FieldEmbedded {
+		return pascal(f.Name) + shape
+	}
+	dt, enum := valueType(p.schema, f)
+	if name, ok := p.enums[enum]; ok {
+		return name
+	}
+	switch dt {
+	case types.DataText:
+		return "str"
+	case types.DataInt:
+		return "int"
+	case types.DataReal:
+		return "float"
+	case types.DataDecimal:
+		p.use("decimal", "Decimal")
+		return "Decimal"
+	case types.DataBool:
+		return "bool"
+	case types.DataUUID:
+		p.use("uuid", "UUID")
+		return "UUID"
+	case types.DataTimestamp:
+		p.use("datetime", "datetime")
+		return "datetime"
+	}
+	p.use("typing", "Any")
+	return "Any"
+}
+
+var pyKeywords = []string{
+	"False", "None", "True", "and", "as", "assert", "async", "await", "break",
+	"class", "continue", "def", "del", "elif", "else", "except", "finally",
+	"for", "from", "global", "if", "import", "in", "is", "lambda", "nonlocal",
+	"not", "or", "pass", "raise", "return", "try", "while", "with", "yield",
+}
+
+// pyName is the attribute a field is written as. names python can't use
+// get an alias so the json keys stay the same
+func pyName(name string) string {
+	n := strings.Join(words(name), "_")
+	if n == "" || n[0] >= '0' && n[0] <= '9' {
+		n = "f_" + n
+	}
+	if slices.Contains(pyKeywords, n) {
+		n += "_"
+	}
+	return n
+}
+
+// a line of a model
+type pyField struct {
+	name, typ string
+	// the arguments of its Field(); the default comes first when there is one
+	args []string
+	def  string
+}
+
+func (p *pyWriter) model(name, doc string, config []string, fields []pyField) {
+	p.printf("\n\nclass %s(BaseModel):\n", name)
+	p.docstring("    ", doc)
+	aliased := slices.ContainsFunc(fields, func(f pyField) bool {
+		return slices.ContainsFunc(f.args, func(a string) bool { return strings.HasPrefix(a, "alias=") })
+	})
+	if aliased {
+		config = append(config, "populate_by_name=True")
+	}
+	if len(config) > 0 {
+		p.use("pydantic", "ConfigDict")
+		p.printf("\n    model_config = ConfigDict(%s)\n", strings.Join(config, ", "))
+	}
+	if len(fields) > 0 {
+		p.printf("\n")
+	}
+	for _, f := range fields {
+		switch {
+		case len(f.args) > 0:
+			p.use("pydantic", "Field")
+			args := f.args
+			if f.def != "" {
+				args = append([]string{"default=" + f.def}, args...)
+			}
+			p.printf("    %s: %s = Field(%s)\n", f.name, f.typ, strings.Join(args, ", "))
+		case f.def != "":
+			p.printf("    %s: %s = %s\n", f.name, f.typ, f.def)
+		default:
+			p.printf("    %s: %s\n", f.name, f.typ)
+		}
+	}
+}
+
+// common are the Field arguments every shape has
+func common(f *types.Field, doc string) []string {
+	var args []string
+	if n := pyName(f.Name); n != f.Name {
+		args = append(args, "alias="+strconv.Quote(f.Name))
+	}
+	if doc != "" {
+		args = append(args, "description="+strconv.Quote(doc))
+	}
+	return args
+}
+
+func (p *pyWriter) nullable(t string) string {
+	p.use("typing", "Optional")
+	return "Optional[" + t + "]"
+}
+
+// the payload can leave out what's nullable or has a default and holds the
+// same rules the runtime checks
+func (p *pyWriter) payload(e *types.EntityNode) {
+	var fields []pyField
+	var checked []*types.Field
+	for _, f := range e.PayloadFields() {
+		pf := pyField{name: pyName(f.Name), typ: p.fieldType(f, "Payload"), args: common(f, f.Doc)}
+		if optional(f) {
+			pf.typ, pf.def = p.nullable(pf.typ), "None"
+		}
+		if l := f.Length; l != nil {
+			if l.Min > 0 {
+				pf.args = append(pf.args, fmt.Sprintf("min_length=%d", l.Min))
+			}
+			if l.Max > 0 {
+				pf.args = append(pf.args, fmt.Sprintf("max_length=%d", l.Max))
+			}
+		}
+		if f.Pattern != "" {
+			pf.args = append(pf.args, "pattern="+pyRegex(f.Pattern))
+		}
+		if f.Check != nil {
+			checked = append(checked, f)
+		}
+		fields = append(fields, pf)
+	}
+
+	name := pascal(e.Name) + "Payload"
+	p.model(name, "what a client sends to create or update a "+e.Name, []string{`extra="forbid"`}, fields)
+	if len(checked) == 0 {
+		return
+	}
+
+	p.checks = true
+	p.use("enum")
+	p.use("math")
+	p.use("operator")
+	p.use("datetime", "datetime")
+	p.use("decimal", "ROUND_HALF_UP", "Decimal")
+	p.use("pydantic", "model_validator")
+	inPayload := make(map[string]bool)
+	for _, f := range e.PayloadFields() {
+		inPayload[f.Name] = true
+	}
+	px := pyExpr{entity: e, fields: inPayload}
+	p.printf("\n    @model_validator(mode=\"after\")\n    def check_constraints(self) -> %s:\n", name)
+	p.printf("        # a check that comes out None passes, the way it does in sql\n")
+	p.printf("        failed = []\n")
+	for _, f := range checked {
+		p.printf("        if %s is False:\n", px.expr(f.Check))
+		p.printf("            failed.append(%s)\n", strconv.Quote(fmt.Sprintf("%s: has to satisfy %s", f.Name, f.Check)))
+	}
+	p.printf("        if failed:\n            raise ValueError(\"; \".join(failed))\n        return self\n")
+}
+
+// the response always has every key; nullable fields and aggregates can
+// hold None
+func (p *pyWriter) response(e *types.EntityNode) {
+	var fields []pyField
+	for _, f := range e.ResponseFields() {
+		doc := f.Doc
+		if !f.Stored() {
+			doc = strings.TrimPrefix(doc+"\ncomputed from other rows when it's read", "\n")
+		}
+		pf := pyField{name: pyName(f.Name), typ: p.fieldType(f, "Response"), args: common(f, doc)}
+		if f.Nullable() || !f.Stored() {
+			pf.typ = p.nullable(pf.typ)
+		}
+		fields = append(fields, pf)
+	}
+	p.model(pascal(e.Name)+"Response", "what a client gets back for a "+e.Name, nil, fields)
+}
+
+// pyRegex writes a pattern as a raw string when python allows it
+func pyRegex(pattern string) string {
+	trailing := len(pattern) - len(strings.TrimRight(pattern, `\`))
+	if !strings.ContainsAny(pattern, "\"\n\r") && trailing%2 == 0 {
+		return `r"` + pattern + `"`
+	}
+	return strconv.Quote(pattern)
+}
+
+// pyExpr translates a check into python calling the helpers in pyChecks.
+// fields the payload doesn't have read as None the way the runtime leaves
+// them out of the row
+type pyExpr struct {
+	entity *types.EntityNode
+	fields map[string]bool
+}
+
+func (px pyExpr) expr(e *types.Expr) string {
+	switch e.Kind {
+	case types.ExprField:
+		if !px.fields[e.Value] {
+			return "None"
+		}
+		return "self." + pyName(e.Value)
+	case types.ExprString:
+		return strconv.Quote(e.Value)
+	case types.ExprNumber:
+		return e.Value
+	case types.ExprBinary:
+		left, right := px.expr(e.Args[0]), px.expr(e.Args[1])
+		switch e.Value {
+		case "and":
+			return "_and(" + left + ", " + right + ")"
+		case "or":
+			return "_or(" + left + ", " + right + ")"
+		}
+		if lit, ok := px.enumLiteral(e.Args[0], e.Args[1]); ok {
+			right = lit
+		}
+		if lit, ok := px.enumLiteral(e.Args[1], e.Args[0]); ok {
+			left = lit
+		}
+		return "_op(" + strconv.Quote(e.Value) + ", " + left + ", " + right + ")"
+	case types.ExprCall:
+		args := []string{strconv.Quote(e.Value)}
+		for _, a := range e.Args {
+			args = append(args, px.expr(a))
+		}
+		return "_call(" + strings.Join(args, ", ") + ")"
+	}
+	// validation only lets checks hold the kinds above
+	return "None"
+}
+
+// enumLiteral swaps a literal compared with an enum field for the member's
+// stored value since checks can name members either way
+func (px pyExpr) enumLiteral(side, other *types.Expr) (string, bool) {
+	if side.Kind != types.ExprField || other.Kind != types.ExprString && other.Kind != types.ExprNumber {
+		return "", false
+	}
+	f := px.entity.Field(side.Value)
+	if f == nil || f.DataType != types.DataEnum || f.Enum == nil {
+		return "", false
+	}
+	m := f.Enum.Member(other.Value)
+	if m == nil {
+		return "", false
+	}
+	return pyValue(f.Enum, m.Value), true
+}
+
+// pyChecks are what translated checks call. they mirror the runtime's
+// evaluator: anything None touches is None except and/or, which use three
+// valued logic
+const pyChecks = `
+
+def _value(v):
+    # enum members compare as the value they're stored as
+    return v.value if isinstance(v, enum.Enum) else v
+
+
+def _and(a, b):
+    if a is False or b is False:
+        return False
+    if a is None or b is None:
+        return None
+    return True
+
+
+def _or(a, b):
+    if a is True or b is True:
+        return True
+    if a is None or b is None:
+        return None
+    return False
+
+
+def _text(v):
+    if isinstance(v, bool):
+        return "true" if v else "false"
+    if isinstance(v, datetime):
+        return v.isoformat()
+    return str(v)
+
+
+def _op(op, a, b):
+    a, b = _value(a), _value(b)
+    if a is None or b is None:
+        return None
+    if op == "||":
+        return _text(a) + _text(b)
+    # floats win over decimals the way they do in sql
+    if isinstance(a, float) and isinstance(b, Decimal):
+        b = float(b)
+    elif isinstance(a, Decimal) and isinstance(b, float):
+        a = float(a)
+    if op == "/":
+        if b == 0:
+            return None
+        if isinstance(a, int) and isinstance(b, int):
+            # integer division truncates toward zero like the database does
+            q = abs(a) // abs(b)
+            return q if (a < 0) == (b < 0) else -q
+        return a / b
+    return _OPS[op](a, b)
+
+
+_OPS = {
+    "+": operator.add,
+    "-": operator.sub,
+    "*": operator.mul,
+    "==": operator.eq,
+    "!=": operator.ne,
+    "<": operator.lt,
+    "<=": operator.le,
+    ">": operator.gt,
+    ">=": operator.ge,
+}
+
+
+def _round(x):
+    # halves round away from zero rather than to even
+    if isinstance(x, Decimal):
+        return int(x.to_integral_value(rounding=ROUND_HALF_UP))
+    return int(math.copysign(math.floor(abs(x) + 0.5), x))
+
+
+def _call(fn, *args):
+    args = [_value(a) for a in args]
+    if fn == "coalesce":
+        return next((a for a in args if a is not None), None)
+    if args[0] is None:
+        return None
+    return _FUNCS[fn](args[0])
+
+
+_FUNCS = {
+    "lower": lambda v: _text(v).lower(),
+    "upper": lambda v: _text(v).upper(),
+    "trim": lambda v: _text(v).strip(),
+    "length": lambda v: len(_text(v)),
+    "abs": abs,
+    "round": _round,
+}
+`
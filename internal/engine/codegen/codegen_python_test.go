@@ -0,0 +1,37 @@
+package codegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/testutil"
+)
+
+// every shared schema is compared with testdata/<schema>.py.golden
+func TestPython(t *testing.T) {
+	for _, file := range testutil.Schemas(t, testutil.Shared) {
+		name := strings.TrimSuffix(filepath.Base(file), ".mime")
+		t.Run(name, func(t *testing.T) {
+			out, err := Python(testutil.Load(t, file))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testutil.Golden(t, name+".py.golden", out)
+		})
+	}
+}
+
+func TestPyName(t *testing.T) {
+	tests := map[string]string{
+		"first_name": "first_name",
+		"from":       "from_",
+		"on-hold":    "on_hold",
+		"2fa":        "f_2fa",
+	}
+	for name, want := range tests {
+		if got := pyName(name); got != want {
+			t.Errorf("pyName(%q): expected %s, got %s", name, want, got)
+		}
+	}
+}
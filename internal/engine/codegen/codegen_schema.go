@@ -0,0 +1,240 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// the json schema of an entity's shapes is the same whether it's a
+// component of an openapi document or a file of its own. only how a schema
+// points at another one differs
+
+type schemaWriter struct {
+	schema *types.Schema
+	// named enums with a schema of their own to $ref; the rest are written
+	// out where they're used
+	enums map[*types.EnumNode]string
+	// the schema of an embedded entity in one of its shapes
+	embed func(entity, shape string) *object
+}
+
+// doc turns a doc comment into a description. comment lines are joined
+// back into the paragraph they were wrapped from
+func doc(s string) any {
+	if s == "" {
+		return nil
+	}
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// the values are what's stored and sent; member names go in x-enum-varnames
+// which is what most client generators name their constants after. the
+// labels and deprecations are folded into x-enum-descriptions for those
+// generators and kept on their own in x-enum-labels and x-enum-deprecated so
+// mime import can take them back
+func (o *schemaWriter) enum(enum *types.EnumNode) *object {
+	out := newObject()
+	values := make([]any, len(enum.Members))
+	names := make([]string, len(enum.Members))
+	labels := make([]string, len(enum.Members))
+	descriptions := make([]string, len(enum.Members))
+	var deprecated []string
+	described, renamed, labelled := false, false, false
+	for i, m := range enum.Members {
+		values[i] = m.Value
+		if enum.Backing == types.DataInt {
+			values[i] = json.Number(m.Value)
+		}
+		names[i] = m.Name
+		renamed = renamed || m.Name != m.Value
+		labels[i] = m.Label
+		labelled = labelled || m.Label != ""
+		if m.Deprecated {
+			deprecated = append(deprecated, m.Name)
+		}
+
+		d := m.Label
+		if m.Description != "" {
+			d = strings.TrimPrefix(d+". "+m.Description, ". ")
+		}
+		if m.Deprecated {
+			d = strings.TrimPrefix(d+". deprecated", ". ")
+		}
+		descriptions[i] = d
+		described = described || d != ""
+	}
+
+	if enum.Backing == types.DataInt {
+		out.set("type", "integer")
+	} else {
+		out.set("type", "string")
+	}
+	out.set("enum", values)
+	if renamed {
+		out.set("x-enum-varnames", names)
+	}
+	if described {
+		out.set("x-enum-descriptions", descriptions)
+	}
+	if labelled {
+		out.set("x-enum-labels", labels)
+	}
+	if len(deprecated) > 0 {
+		out.set("x-enum-deprecated", deprecated)
+	}
+	return out
+}
+
+var schemaTypes = map[types.DataType]string{
+	types.DataText:      "string",
+	types.DataUUID:      "string",
+	types.DataInt:       "integer",
+	types.DataReal:      "number",
+	types.DataBool:      "boolean",
+	types.DataTimestamp: "string",
+	types.DataDecimal:   "string",
+}
+
+var schemaFormats = map[types.DataType]string{
+	types.DataUUID:      "uuid",
+	types.DataInt:       "int64",
+	types.DataTimestamp: "date-time",
+	types.DataDecimal:   "decimal",
+}
+
+// property is f's schema in one of its entity's shapes, Payload or Response
+func (o *schemaWriter) property(f *types.Field, shape string, nullable bool) *object {
+	var out *object
+	if f.Kind == types.FieldEmbedded {
+		out = o.nullable(o.embed(f.Name, shape), nullable)
+	} else {
+		out = o.value(f, nullable)
+	}
+
+	out.set("description", doc(f.Doc))
+	if f.Attributes&types.AttrReadonly != 0 || f.Kind == types.FieldComputed || f.Attributes&types.AttrIncrement != 0 {
+		out.set("readOnly", true)
+	}
+	// hidden fields are only ever in payloads
+	if f.Attributes&types.AttrHidden != 0 {
+		out.set("writeOnly", true)
+	}
+	if v, ok := o.literal(f); ok {
+		out.set("default", v)
+	}
+	return out
+}
+
+// value is the schema of the values a field holds with its rules applied
+func (o *schemaWriter) value(f *types.Field, nullable bool) *object {
+	dt, enum := valueType(o.schema, f)
+	if name, ok := o.enums[enum]; ok {
+		return o.nullable(ref(name), nullable)
+	}
+
+	var out *object
+	if enum != nil {
+		out = o.enum(enum)
+	} else {
+		out = newObject().set("type", schemaTypes[dt])
+		if format, ok := schemaFormats[dt]; ok {
+			out.set("format", format)
+		}
+	}
+	if nullable {
+		out.set("type", []any{out.values["type"], "null"})
+		if enum != nil {
+			out.set("enum", append(out.values["enum"].([]any), nil))
+		}
+	}
+
+	if f.Length != nil {
+		out.set("minLength", f.Length.Min)
+		if f.Length.Max > 0 {
+			out.set("maxLength", f.Length.Max)
+		}
+	}
+	if f.Pattern != "" {
+		out.set("pattern", f.Pattern)
+	}
+	return out
+}
+
+// nullable lets a $ref be null too
+func (o *schemaWriter) nullable(schema *object, nullable bool) *object {
+	if !nullable {
+		return schema
+	}
+	return newObject().set("oneOf", []any{schema, newObject().set("type", "null")})
+}
+
+// literal is a field's literal default as the json value a client would
+// send. defaults a function fills in have nothing to show
+func (o *schemaWriter) literal(f *types.Field) (any, bool) {
+	if f.Default == nil || f.Default.Kind != types.DefaultLiteral {
+		return nil, false
+	}
+	v := f.Default.Value
+	dt, enum := valueType(o.schema, f)
+	if enum != nil {
+		if m := enum.Member(v); m != nil {
+			v = m.Value
+		}
+	}
+
+	switch dt {
+	case types.DataInt, types.DataReal:
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return nil, false
+		}
+		return json.Number(v), true
+	case types.DataBool:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	}
+	return v, true
+}
+
+// payloads reject members they don't know so additionalProperties is off
+func (o *schemaWriter) payload(e *types.EntityNode) *object {
+	props := newObject()
+	required := []string{}
+	for _, f := range e.PayloadFields() {
+		props.set(f.Name, o.property(f, "Payload", f.Nullable()))
+		if !optional(f) {
+			required = append(required, f.Name)
+		}
+	}
+	return o.shape(e, fmt.Sprintf("what a client sends to create or update a %s", e.Name), props, required).
+		set("additionalProperties", false)
+}
+
+// every member of a response is always there even when it's null
+func (o *schemaWriter) response(e *types.EntityNode) *object {
+	props := newObject()
+	required := []string{}
+	for _, f := range e.ResponseFields() {
+		props.set(f.Name, o.property(f, "Response", f.Nullable() || !f.Stored()))
+		required = append(required, f.Name)
+	}
+	return o.shape(e, fmt.Sprintf("what a client gets back for a %s", e.Name), props, required)
+}
+
+func (o *schemaWriter) shape(e *types.EntityNode, fallback string, props *object, required []string) *object {
+	description := doc(e.Doc)
+	if description == nil {
+		description = fallback
+	}
+	out := newObject().
+		set("type", "object").
+		set("description", description).
+		set("properties", props)
+	if len(required) > 0 {
+		out.set("required", required)
+	}
+	return out
+}
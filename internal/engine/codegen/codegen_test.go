@@ -0,0 +1,33 @@
+package codegen
+
+import "testing"
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		name, pascal, camel, goName, goVar string
+	}{
+		{name: "order_line", pascal: "OrderLine", camel: "orderLine", goName: "OrderLine", goVar: "orderLine"},
+		{name: "owner_id", pascal: "OwnerId", camel: "ownerId", goName: "OwnerID", goVar: "ownerID"},
+		{name: "id", pascal: "Id", camel: "id", goName: "ID", goVar: "id"},
+		{name: "on-hold", pascal: "OnHold", camel: "onHold", goName: "OnHold", goVar: "onHold"},
+		{name: "type", pascal: "Type", camel: "type", goName: "Type", goVar: "typeValue"},
+		{name: "1", pascal: "1", camel: "1", goName: "1", goVar: "1"},
+	}
+
+	for _, tt := range tests {
+		if got := pascal(tt.name); got != tt.pascal {
+			t.Fatalf("pascal(%q): expected %s, got %s", tt.name, tt.pascal, got)
+		}
+		if got := camel(tt.name); got != tt.camel {
+			t.Fatalf("camel(%q): expected %s, got %s", tt.name, tt.camel, got)
+		}
+		if got := goName(tt.name); got != tt.goName {
+			t.Fatalf("goName(%q): expected %s, got %s", tt.name, tt.goName, got)
+		}
+		if tt.name != "1" {
+			if got := goVar(tt.name); got != tt.goVar {
+				t.Fatalf("goVar(%q): expected %s, got %s", tt.name, tt.goVar, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,409 @@
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/ir"
+	"willofdaedalus/mime/internal/engine/query"
+	"willofdaedalus/mime/internal/engine/runtime"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// TypeScript writes the schema as a typescript module. every entity gets an
+// interface for its payload and its response, every enum a union of its
+// values and every route a function on the client createClient returns
+func TypeScript(s *types.Schema) (string, error) {
+	t := &tsWriter{schema: s}
+	var b strings.Builder
+	t.b = &b
+
+	b.WriteString(ir.Header(s, "//"))
+	var enums []enumType
+	t.enums, enums = enumTypes(s, pascal)
+	for _, et := range enums {
+		if et.field == nil {
+			t.enum(et.enum, fmt.Sprintf("a member of the %s enum", et.enum.Name))
+			continue
+		}
+		t.enum(et.enum, et.values())
+	}
+	for _, e := range s.Entities {
+		t.interfaces(e)
+	}
+
+	routes, err := apiRoutes(s)
+	if err != nil {
+		return "", err
+	}
+	queried := make(map[string]bool)
+	for _, r := range routes {
+		queried[r.entity.Name] = queried[r.entity.Name] || r.params()
+	}
+	for _, e := range s.Entities {
+		if queried[e.Name] {
+			t.query(e)
+		}
+	}
+	t.printf("%s", tsErrors)
+	for _, r := range routes {
+		if r.route.Fallback != nil {
+			t.printf("\n/** how %s %s fails */\nexport type %sError = ApiError<%d>;\n",
+				r.route.Method, r.route.Path, pascal(r.name), r.route.Fallback.Status)
+		}
+	}
+	t.client(routes)
+
+	return b.String(), nil
+}
+
+type tsWriter struct {
+	schema *types.Schema
+	b      *strings.Builder
+	// what enumTypes named each enum
+	enums map[*types.EnumNode]string
+}
+
+func (t *tsWriter) printf(format string, args ...any) {
+	fmt.Fprintf(t.b, format, args...)
+}
+
+// the enum is written twice under one name: a union of its values for
+// fields and a const object of its members for code that wants to name them
+func (t *tsWriter) enum(enum *types.EnumNode, doc string) {
+	name := t.enums[enum]
+	values := make([]string, len(enum.Members))
+	for i, m := range enum.Members {
+		values[i] = tsEnumValue(enum, m)
+	}
+	t.printf("\n/** %s */\nexport type %s = %s;\n", doc, name, strings.Join(values, " | "))
+
+	t.printf("\nexport const %s = {\n", name)
+	for i, m := range enum.Members {
+		var docs []string
+		if m.Label != "" {
+			docs = append(docs, m.Label)
+		}
+		if m.Description != "" {
+			docs = append(docs, m.Description)
+		}
+		if m.Deprecated {
+			docs = append(docs, "@deprecated")
+		}
+		if len(docs) > 0 {
+			t.printf("  /** %s */\n", strings.Join(docs, ". "))
+		}
+		t.printf("  %s: %s,\n", tsKey(m.Name), values[i])
+	}
+	t.printf("} as const;\n")
+}
+
+func tsEnumValue(enum *types.EnumNode, m types.EnumMember) string {
+	if enum.Backing == types.DataInt {
+		return m.Value
+	}
+	return strconv.Quote(m.Value)
+}
+
+var tsTypes = map[types.DataType]string{
+	types.DataText:      "string",
+	types.DataUUID:      "string",
+	types.DataInt:       "number",
+	types.DataReal:      "number",
+	types.DataBool:      "boolean",
+	types.DataTimestamp: "string",
+	types.DataDecimal:   "string",
+}
+
+// fieldType is the typescript type of f's values. shape is the suffix of the
+// interface embedded entities are written as
+func (t *tsWriter) fieldType(f *types.Field, shape string) string {
+	if f.Kind == types.FieldEmbedded {
+		return pascal(f.Name) + shape
+	}
+	dt, enum := valueType(t.schema, f)
+	if name, ok := t.enums[enum]; ok {
+		return name
+	}
+	if ts, ok := tsTypes[dt]; ok {
+		return ts
+	}
+	return "unknown"
+}
+
+func (t *tsWriter) interfaces(e *types.EntityNode) {
+	name := pascal(e.Name)
+
+	t.printf("\n/** what a client sends to create or update a %s */\nexport interface %sPayload {\n", e.Name, name)
+	for _, f := range e.PayloadFields() {
+		t.property(f, t.fieldType(f, "Payload"), optional(f), f.Nullable())
+	}
+	t.printf("}\n")
+
+	t.printf("\n/** what a client gets back for a %s */\nexport interface %sResponse {\n", e.Name, name)
+	for _, f := range e.ResponseFields() {
+		if !f.Stored() {
+			t.printf("  /** computed from other rows when it's read */\n")
+		}
+		t.property(f, t.fieldType(f, "Response"), false, f.Nullable() || !f.Stored())
+	}
+	t.printf("}\n")
+}
+
+func (t *tsWriter) property(f *types.Field, ts string, optional, nullable bool) {
+	if f.Kind == types.FieldPrimitive {
+		switch dt, _ := valueType(t.schema, f); dt {
+		case types.DataTimestamp:
+			t.printf("  /** rfc 3339 */\n")
+		case types.DataDecimal:
+			t.printf("  /** exact decimal; a number would round it */\n")
+		}
+	}
+	mark := ""
+	if optional {
+		mark = "?"
+	}
+	if nullable {
+		ts += " | null"
+	}
+	t.printf("  %s%s: %s;\n", tsKey(f.Name), mark, ts)
+}
+
+// query is what a route matched on params can filter by. it's every field
+// with a column of its own
+func (t *tsWriter) query(e *types.EntityNode) {
+	t.printf("\n/** the fields a %s can be looked up by */\nexport interface %sQuery {\n", e.Name, pascal(e.Name))
+	for _, f := range queryFields(e) {
+		t.printf("  %s?: %s;\n", tsKey(f.Name), t.fieldType(f, ""))
+	}
+	t.printf("}\n")
+}
+
+// the envelope mirrors the runtime's errors; field problems carry the same
+// codes runtime.Validate reports
+var tsErrorCodes = []string{
+	runtime.CodeEntity, runtime.CodeRequired, runtime.CodeUnknown, runtime.CodeReadonly,
+	runtime.CodeType, runtime.CodeEnum, runtime.CodeLength, runtime.CodePattern, runtime.CodeCheck,
+}
+
+var tsErrors = func() string {
+	codes := make([]string, len(tsErrorCodes))
+	for i, c := range tsErrorCodes {
+		codes[i] = strconv.Quote(c)
+	}
+	return `
+/** why a field in a payload was rejected */
+export type FieldErrorCode = ` + strings.Join(codes, " | ") + `;
+
+export interface FieldError {
+  /** a json pointer to the field e.g. /person/name */
+  pointer: string;
+  code: FieldErrorCode;
+  message: string;
+}
+
+/** what the server sends back when a request fails */
+export interface ErrorEnvelope<S extends number = number> {
+  status: S;
+  message: string;
+  errors?: FieldError[];
+}
+
+/** thrown by the client for any response that isn't a 2xx */
+export class ApiError<S extends number = number> extends Error {
+  readonly envelope: ErrorEnvelope<S>;
+
+  constructor(envelope: ErrorEnvelope<S>) {
+    super(envelope.message);
+    this.name = "ApiError";
+    this.envelope = envelope;
+  }
+
+  get status(): S {
+    return this.envelope.status;
+  }
+}
+`
+}()
+
+const tsClient = `
+export interface ClientOptions {
+  /** where the api is served from e.g. https://api.example.com */
+  baseUrl: string;
+  /** used instead of the global fetch */
+  fetch?: typeof fetch;
+  /** sent with every request e.g. an authorization header */
+  headers?: Record<string, string>;
+}
+
+export function createClient(options: ClientOptions) {
+  const request = async <T>(method: string, path: string, query?: object, body?: unknown): Promise<T> => {
+    let url = options.baseUrl.replace(/\/+$/, "") + path;
+    if (query) {
+      const search = new URLSearchParams();
+      for (const [key, value] of Object.entries(query)) {
+        if (value !== undefined && value !== null) {
+          search.append(key, String(value));
+        }
+      }
+      const qs = search.toString();
+      if (qs !== "") {
+        url += "?" + qs;
+      }
+    }
+
+    const headers: Record<string, string> = { Accept: "application/json", ...options.headers };
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+    const res = await (options.fetch ?? fetch)(url, {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+
+    if (!res.ok) {
+      let envelope: ErrorEnvelope = { status: res.status, message: res.statusText };
+      try {
+        envelope = { ...(await res.json()), status: res.status };
+      } catch {
+        // the body wasn't json; the status line is all there is
+      }
+      throw new ApiError(envelope);
+    }
+    if (res.status === 204) {
+      return undefined as T;
+    }
+    return (await res.json()) as T;
+  };
+
+  return {
+`
+
+func (t *tsWriter) client(routes []apiRoute) {
+	t.printf("%s", tsClient)
+	for i, r := range routes {
+		if i > 0 {
+			t.printf("\n")
+		}
+		t.function(r)
+	}
+	t.printf("  };\n}\n\nexport type Client = ReturnType<typeof createClient>;\n")
+}
+
+func (t *tsWriter) function(r apiRoute) {
+	route, e, match := r.route, r.entity, r.match
+	vars := make(map[string]string)
+
+	var args []string
+	for _, p := range route.PathParams() {
+		ts := "string"
+		if match != nil && route.Match.Source == ":"+p {
+			ts = t.fieldType(match, "")
+		}
+		vars[p] = tsVar(p)
+		args = append(args, vars[p]+": "+ts)
+	}
+
+	search, body := "undefined", ""
+	if r.params() {
+		ts := pascal(e.Name) + "Query"
+		if route.Options&types.RouteAdmin != 0 && e.SoftDelete {
+			ts += " & { " + query.IncludeDeletedParam + "?: boolean }"
+		}
+		args = append(args, "params?: "+ts)
+		search = "params"
+	}
+	switch route.Action {
+	case types.ActionCreate:
+		args = append(args, "payload: "+pascal(e.Name)+"Payload")
+		body = "payload"
+	case types.ActionUpdate:
+		// PATCH only sends what changed
+		ts := pascal(e.Name) + "Payload"
+		if route.Method == "PATCH" {
+			ts = "Partial<" + ts + ">"
+		}
+		args = append(args, "payload: "+ts)
+		body = "payload"
+	}
+
+	result := "void"
+	switch route.Action {
+	case types.ActionFind, types.ActionCreate, types.ActionUpdate:
+		result = pascal(e.Name) + "Response"
+		if r.list {
+			result += "[]"
+		}
+	}
+
+	doc := route.Method + " " + route.Path
+	if route.Fallback != nil {
+		doc += fmt.Sprintf("; fails with %d %q", route.Fallback.Status, route.Fallback.Message)
+	}
+	t.printf("    /** %s */\n", doc)
+	t.printf("    %s(%s): Promise<%s> {\n", r.name, strings.Join(args, ", "), result)
+
+	call := []string{strconv.Quote(route.Method), tsPath(route.Path, vars)}
+	if body != "" {
+		call = append(call, search, body)
+	} else if search != "undefined" {
+		call = append(call, search)
+	}
+	t.printf("      return request(%s);\n    },\n", strings.Join(call, ", "))
+}
+
+// tsPath writes path as a template literal with its captures substituted
+func tsPath(path string, vars map[string]string) string {
+	segs := strings.Split(path, "/")
+	subst := false
+	for i, seg := range segs {
+		if strings.HasPrefix(seg, ":") {
+			segs[i] = "${encodeURIComponent(String(" + vars[seg[1:]] + "))}"
+			subst = true
+			continue
+		}
+		segs[i] = strings.NewReplacer("\\", "\\\\", "`", "\\`", "$", "\\$").Replace(seg)
+	}
+	if !subst {
+		return strconv.Quote(path)
+	}
+	return "`" + strings.Join(segs, "/") + "`"
+}
+
+var tsReserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true,
+	"debugger": true, "default": true, "delete": true, "do": true, "else": true, "enum": true,
+	"export": true, "extends": true, "false": true, "finally": true, "for": true, "function": true,
+	"if": true, "import": true, "in": true, "instanceof": true, "new": true, "null": true,
+	"return": true, "super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true, "with": true,
+	"let": true, "static": true, "yield": true, "await": true, "interface": true, "package": true,
+	"private": true, "protected": true, "public": true, "implements": true,
+	// the names the client's own arguments use
+	"params": true, "payload": true, "request": true, "options": true,
+}
+
+// tsVar is the camel case name of an argument
+func tsVar(name string) string {
+	v := camel(name)
+	if tsReserved[v] || v == "" {
+		return v + "Value"
+	}
+	return v
+}
+
+// tsKey quotes a property name when it isn't an identifier
+func tsKey(name string) string {
+	for i, r := range name {
+		if r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9' {
+			continue
+		}
+		return strconv.Quote(name)
+	}
+	if name == "" {
+		return `""`
+	}
+	return name
+}
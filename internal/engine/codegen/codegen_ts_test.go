@@ -0,0 +1,39 @@
+package codegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/testutil"
+)
+
+// every shared schema is compared with testdata/<schema>.ts.golden
+func TestTypeScript(t *testing.T) {
+	for _, file := range testutil.Schemas(t, testutil.Shared) {
+		name := strings.TrimSuffix(filepath.Base(file), ".mime")
+		t.Run(name, func(t *testing.T) {
+			out, err := TypeScript(testutil.Load(t, file))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testutil.Golden(t, name+".ts.golden", out)
+		})
+	}
+}
+
+func TestPlural(t *testing.T) {
+	tests := map[string]string{
+		"note":     "notes",
+		"class":    "classes",
+		"box":      "boxes",
+		"category": "categories",
+		"day":      "days",
+		"branch":   "branches",
+	}
+	for in, want := range tests {
+		if got := plural(in); got != want {
+			t.Errorf("plural(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
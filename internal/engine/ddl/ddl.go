@@ -0,0 +1,548 @@
+package ddl
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/ir"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// the ddl generators turn a resolved schema into the statements that create its
+// tables. the generators only ever see a schema that's already been expanded,
+// resolved and validated so they don't repeat any of those checks; anything
+// they can't express in sql is an error rather than being dropped.
+//
+// what goes into the database
+//
+//   - one table per entity, created after the tables it references
+//   - computed fields that only read their own row become generated columns.
+//     aggregates are computed when the row is read and have no column
+//   - embedded entities are stored as json in a single column
+//   - required, unique, primary, increment, literal defaults, now() and
+//     today(), checks and enum lists
+//   - uuid, sequence and on_update defaults in the form each database has
+//     for them. where it has none they're a warning; the app fills them in
+//     with runtime.Defaults or its generated repository
+//   - length, pattern, hash, hidden and readonly are runtime only
+//
+// everything above is shared; a Dialect only decides how each piece is
+// spelled in its database
+
+// Dialect is what differs between the databases the generator writes for
+type Dialect interface {
+	Quote(ident string) string
+	// Types declares anything the tables depend on e.g. postgres enum types
+	Types(s *types.Schema) []string
+	// Type is the column's type. native reports whether the type already
+	// keeps the column to its enum's values
+	Type(c Column) (typ string, native bool, err error)
+	// JSON is the column type embedded entities are stored in, plus a check
+	// for databases that don't validate json themselves
+	JSON(col string) (typ, check string)
+	// Decimal reads a decimal column as a number computed fields and checks
+	// can do arithmetic with and compare
+	Decimal(col string) string
+	// Increment makes the column count up
+	Increment(c Column) (string, error)
+	// Default renders the column's default or returns "" when the runtime
+	// has to fill it in
+	Default(def *types.DefaultValue, c Column) string
+	// OnUpdate renders what refreshes the column whenever its row is
+	// updated or returns "" when the runtime has to
+	OnUpdate(def *types.DefaultValue, c Column) string
+	// Generated turns a computed field's expression into a column constraint
+	Generated(expr string) string
+	// Call lowers a function call made by a computed field or check
+	Call(fn string, args []string) string
+	// Concat joins the operands of a chain of ||
+	Concat(parts []string) string
+	// PartialIndexes reports whether a unique index can leave out soft
+	// deleted rows
+	PartialIndexes() bool
+	// TableOptions follows the closing parenthesis of each CREATE TABLE
+	TableOptions() string
+	// TimeLayout is how a timestamp is written as a literal the column
+	// compares with
+	TimeLayout() string
+}
+
+// Column is what a dialect picks a column's type and default from
+type Column struct {
+	Entity *types.EntityNode
+	Field  *types.Field
+	// Type and Enum are resolved through references and enums so Type is
+	// never DataEnum
+	Type types.DataType
+	Enum *types.EnumNode
+	// Key is set on the entity's only primary key
+	Key bool
+	// Indexed is set on keys, unique fields and both ends of a foreign key
+	Indexed bool
+}
+
+// Dialects lists every dialect by the name `mime sql -dialect` takes
+var Dialects = map[string]Dialect{
+	"sqlite":   SQLite,
+	"postgres": Postgres,
+	"mysql":    MySQL,
+}
+
+// Generate renders the schema as d's statements: the types the tables need,
+// then one CREATE TABLE per entity followed by any indexes it needs. anything
+// d can't express that can be left to the runtime comes back as a warning
+// and is noted in the output; everything else is an error
+func Generate(s *types.Schema, d Dialect) (string, []string, error) {
+	var b strings.Builder
+	b.WriteString(ir.Header(s, "--"))
+
+	if decls := d.Types(s); len(decls) > 0 {
+		b.WriteString("\n")
+		for _, decl := range decls {
+			b.WriteString(decl + ";\n")
+		}
+	}
+
+	g := generator{schema: s, dialect: d, referenced: referenced(s)}
+	for _, e := range Ordered(s) {
+		table, err := g.table(e)
+		if err != nil {
+			return "", nil, fmt.Errorf("entity '%s': %w", e.Name, err)
+		}
+		idx := g.liveUniqueIndexes(e)
+
+		b.WriteString("\n")
+		for _, w := range g.warnings[g.reported:] {
+			b.WriteString("-- warning: " + w + "\n")
+		}
+		g.reported = len(g.warnings)
+		b.WriteString(table)
+		for _, stmt := range idx {
+			b.WriteString(stmt + ";\n")
+		}
+	}
+
+	return b.String(), g.warnings, nil
+}
+
+type generator struct {
+	schema  *types.Schema
+	dialect Dialect
+	// every entity.field a foreign key points at
+	referenced map[string]bool
+
+	warnings []string
+	// how many warnings are already in the output
+	reported int
+}
+
+func (g *generator) warn(format string, args ...any) {
+	g.warnings = append(g.warnings, fmt.Sprintf(format, args...))
+}
+
+func referenced(s *types.Schema) map[string]bool {
+	refs := make(map[string]bool)
+	for _, e := range s.Entities {
+		for _, f := range e.Fields {
+			if f.Kind == types.FieldReference && f.Target != nil {
+				refs[f.Target.Entity+"."+f.Target.Field] = true
+			}
+		}
+	}
+	return refs
+}
+
+// Ordered returns the entities with every entity after the ones it
+// references so each table's foreign keys point at a table that already
+// exists. entities that don't depend on each other keep their declaration
+// order; a cycle can't be ordered so it's left in declaration order too
+func Ordered(s *types.Schema) []*types.EntityNode {
+	deps := make(map[string][]string, len(s.Entities))
+	for _, e := range s.Entities {
+		for _, f := range e.Fields {
+			if f.Kind == types.FieldReference && f.Target != nil && f.Target.Entity != e.Name &&
+				!slices.Contains(deps[e.Name], f.Target.Entity) {
+				deps[e.Name] = append(deps[e.Name], f.Target.Entity)
+			}
+		}
+	}
+
+	out := make([]*types.EntityNode, 0, len(s.Entities))
+	done := make(map[string]bool, len(s.Entities))
+	for len(out) < len(s.Entities) {
+		progress := false
+		for _, e := range s.Entities {
+			if done[e.Name] || slices.ContainsFunc(deps[e.Name], func(d string) bool { return !done[d] }) {
+				continue
+			}
+			out, done[e.Name], progress = append(out, e), true, true
+		}
+		if progress {
+			continue
+		}
+		// only cycles are left
+		for _, e := range s.Entities {
+			if !done[e.Name] {
+				out, done[e.Name] = append(out, e), true
+			}
+		}
+	}
+
+	return out
+}
+
+// ownCheck reports whether f's check only reads f. checks that read other
+// fields go on the table since not every database lets a column's check see
+// the rest of the row
+func ownCheck(f *types.Field) bool {
+	for _, name := range f.Check.Fields() {
+		if name != f.Name {
+			return false
+		}
+	}
+	return true
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// literal renders a value for a column of type dt. enum members are written
+// as their stored value whichever way they were given
+func literal(dt types.DataType, enum *types.EnumNode, v string) string {
+	if enum != nil {
+		if m := enum.Member(v); m != nil {
+			v = m.Value
+		}
+		if dt == types.DataEnum {
+			dt = enum.Backing
+		}
+	}
+
+	switch dt {
+	case types.DataInt, types.DataReal:
+		return v
+	case types.DataBool:
+		if b, err := strconv.ParseBool(v); err == nil && b {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return quoteString(v)
+}
+
+// enumValues renders every value an enum column may hold
+func enumValues(enum *types.EnumNode) []string {
+	values := make([]string, 0, len(enum.Members))
+	for _, v := range enum.Values() {
+		values = append(values, literal(enum.Backing, nil, v))
+	}
+	return values
+}
+
+func (g *generator) table(e *types.EntityNode) (string, error) {
+	d := g.dialect
+	pk := e.PrimaryKey()
+
+	var lines []string
+	for _, f := range e.Fields {
+		if !f.Stored() {
+			continue
+		}
+		col, err := g.column(e, f, len(pk) == 1)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, col)
+	}
+
+	if len(pk) > 1 {
+		cols := make([]string, 0, len(pk))
+		for _, f := range pk {
+			cols = append(cols, d.Quote(f.Name))
+		}
+		lines = append(lines, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(cols, ", ")))
+	}
+	for _, f := range e.Fields {
+		if f.Check == nil || ownCheck(f) {
+			continue
+		}
+		expr, err := exprWriter{dialect: d, schema: g.schema, entity: e}.write(f.Check)
+		if err != nil {
+			return "", fmt.Errorf("check on field '%s': %w", f.Name, err)
+		}
+		lines = append(lines, fmt.Sprintf("CHECK (%s)", expr))
+	}
+	for _, f := range e.Fields {
+		if f.Kind == types.FieldReference && f.Target != nil {
+			lines = append(lines, fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)",
+				d.Quote(f.Name), d.Quote(f.Target.Entity), d.Quote(f.Target.Field)))
+		}
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (\n  %s\n)%s;\n",
+		d.Quote(e.Name), strings.Join(lines, ",\n  "), d.TableOptions()), nil
+}
+
+func (g *generator) column(e *types.EntityNode, f *types.Field, inlinePK bool) (string, error) {
+	d := g.dialect
+	w := exprWriter{dialect: d, schema: g.schema, entity: e}
+	name := d.Quote(f.Name)
+	attrs := f.Attributes
+
+	// embedded entities are kept as a json document
+	if f.Kind == types.FieldEmbedded {
+		typ, check := d.JSON(name)
+		col := name + " " + typ
+		switch {
+		case attrs&types.AttrRequired != 0:
+			col += " NOT NULL"
+		case check != "":
+			// older sqlite reports json_valid(NULL) as false so a missing
+			// embed has to be let through on its own
+			check = fmt.Sprintf("%s IS NULL OR %s", name, check)
+		}
+		if check != "" {
+			col += fmt.Sprintf(" CHECK (%s)", check)
+		}
+		return col, nil
+	}
+
+	c := Column{Entity: e, Field: f, Key: attrs&types.AttrPrimary != 0 && inlinePK}
+	c.Type, c.Enum = g.schema.FieldType(f)
+	if c.Type == types.DataEnum {
+		c.Type = c.Enum.Backing
+	}
+	c.Indexed = attrs&types.AttrPrimary != 0 || f.Kind == types.FieldReference ||
+		(attrs&types.AttrUnique != 0 && !e.SoftDelete) || g.referenced[e.Name+"."+f.Name]
+
+	typ, native, err := d.Type(c)
+	if err != nil {
+		return "", fmt.Errorf("field '%s': %w", f.Name, err)
+	}
+	col := name + " " + typ
+
+	if f.Kind == types.FieldComputed {
+		expr, err := w.write(f.Computed)
+		if err != nil {
+			return "", fmt.Errorf("computed field '%s': %w", f.Name, err)
+		}
+		return col + " " + d.Generated(expr), nil
+	}
+
+	if c.Key {
+		col += " PRIMARY KEY"
+	}
+	if attrs&types.AttrIncrement != 0 {
+		inc, err := d.Increment(c)
+		if err != nil {
+			return "", err
+		}
+		col += " " + inc
+	}
+	if attrs&(types.AttrRequired|types.AttrPrimary) != 0 {
+		col += " NOT NULL"
+	}
+	// soft deleted entities get partial unique indexes instead
+	if attrs&types.AttrUnique != 0 && attrs&types.AttrPrimary == 0 && !e.SoftDelete {
+		col += " UNIQUE"
+	}
+
+	if f.Default != nil {
+		if def := d.Default(f.Default, c); def != "" {
+			col += " DEFAULT " + def
+		} else {
+			g.warn("%s.%s defaults to %s() which the database can't generate; the runtime fills it in",
+				e.Name, f.Name, f.Default.Value)
+		}
+	}
+	if f.OnUpdate != nil {
+		if on := d.OnUpdate(f.OnUpdate, c); on != "" {
+			col += " " + on
+		} else {
+			g.warn("%s.%s is set to %s() on every update which the database can't do; the runtime does it",
+				e.Name, f.Name, f.OnUpdate.Value)
+		}
+	}
+
+	// a reference is already kept to its target's values by the foreign key
+	if c.Enum != nil && !native && f.Kind != types.FieldReference {
+		col += fmt.Sprintf(" CHECK (%s IN (%s))", name, strings.Join(enumValues(c.Enum), ", "))
+	}
+	if f.Check != nil && ownCheck(f) {
+		expr, err := w.write(f.Check)
+		if err != nil {
+			return "", fmt.Errorf("check on field '%s': %w", f.Name, err)
+		}
+		col += fmt.Sprintf(" CHECK (%s)", expr)
+	}
+
+	return col, nil
+}
+
+// liveUniqueIndexes replaces column level UNIQUE on soft deleted entities.
+// without partial indexes the runtime is left to keep those fields unique
+func (g *generator) liveUniqueIndexes(e *types.EntityNode) []string {
+	if !e.SoftDelete {
+		return nil
+	}
+
+	d := g.dialect
+	var idx []string
+	for _, f := range e.Fields {
+		if f.Attributes&types.AttrUnique == 0 || f.Attributes&types.AttrPrimary != 0 {
+			continue
+		}
+		if !d.PartialIndexes() {
+			g.warn("%s.%s is unique among rows that aren't deleted; without partial indexes only the runtime checks it",
+				e.Name, f.Name)
+			continue
+		}
+		idx = append(idx, fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (%s) WHERE %s IS NULL",
+			d.Quote(e.Name+"_"+f.Name+"_live_key"), d.Quote(e.Name), d.Quote(f.Name), d.Quote(types.SoftDeleteField)))
+	}
+	return idx
+}
+
+// exprWriter lowers expressions into sql. the operators computed fields and
+// checks use are spelled the same everywhere; functions go through the
+// dialect
+type exprWriter struct {
+	dialect Dialect
+	schema  *types.Schema
+	entity  *types.EntityNode
+}
+
+var sqlOperators = map[string]string{
+	"==":  "=",
+	"!=":  "<>",
+	"and": "AND",
+	"or":  "OR",
+}
+
+func (w exprWriter) write(e *types.Expr) (string, error) {
+	switch e.Kind {
+	case types.ExprField:
+		if f := w.entity.Field(e.Value); f != nil {
+			if dt, _ := w.schema.FieldType(f); dt == types.DataDecimal {
+				return w.dialect.Decimal(w.dialect.Quote(e.Value)), nil
+			}
+		}
+		return w.dialect.Quote(e.Value), nil
+	case types.ExprString:
+		return quoteString(e.Value), nil
+	case types.ExprNumber:
+		return e.Value, nil
+	case types.ExprBinary:
+		if e.Value == "||" {
+			return w.concat(e)
+		}
+		left, err := w.operand(e.Args[0], e.Args[1])
+		if err != nil {
+			return "", err
+		}
+		right, err := w.operand(e.Args[1], e.Args[0])
+		if err != nil {
+			return "", err
+		}
+		op := e.Value
+		if sqlOp, ok := sqlOperators[op]; ok {
+			op = sqlOp
+		}
+		return fmt.Sprintf("%s %s %s", left, op, right), nil
+	case types.ExprCall:
+		if len(e.Args) == 1 && e.Args[0].Kind == types.ExprReference {
+			return w.aggregate(e)
+		}
+		args := make([]string, 0, len(e.Args))
+		for _, a := range e.Args {
+			s, err := w.write(a)
+			if err != nil {
+				return "", err
+			}
+			args = append(args, s)
+		}
+		return w.dialect.Call(e.Value, args), nil
+	}
+
+	return "", fmt.Errorf("%s can't be written as sql", e)
+}
+
+// aggregate reads the rows pointing back at the entity's row with a
+// subquery, e.g. count(@note.owner) on user is
+//
+//	(SELECT count("r"."owner") FROM "note" AS "r" WHERE "r"."owner" = "user"."id")
+//
+// soft deleted rows don't count
+func (w exprWriter) aggregate(e *types.Expr) (string, error) {
+	d := w.dialect
+	ref := e.Args[0]
+	child, join, err := ref.AggregateJoin(w.entity.Name, w.schema)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", e.Value, err)
+	}
+
+	r := d.Quote("r")
+	conds := []string{fmt.Sprintf("%s.%s = %s.%s",
+		r, d.Quote(join.Name), d.Quote(w.entity.Name), d.Quote(join.Target.Field))}
+	if child.SoftDelete {
+		conds = append(conds, r+"."+d.Quote(types.SoftDeleteField)+" IS NULL")
+	}
+	return fmt.Sprintf("(SELECT %s FROM %s AS %s WHERE %s)",
+		d.Call(e.Value, []string{r + "." + d.Quote(ref.Target.Field)}), d.Quote(child.Name), r,
+		strings.Join(conds, " AND ")), nil
+}
+
+// Computed writes a computed field's expression as d's sql for selecting
+// it from its entity's table. aggregates become subqueries
+func Computed(s *types.Schema, d Dialect, e *types.EntityNode, f *types.Field) (string, error) {
+	if f.Kind != types.FieldComputed {
+		return "", fmt.Errorf("field '%s' isn't computed", f.Name)
+	}
+	return exprWriter{dialect: d, schema: s, entity: e}.write(f.Computed)
+}
+
+// concat writes a || b || c as a single chain since every database either
+// has the operator or a CONCAT that takes any number of arguments
+func (w exprWriter) concat(e *types.Expr) (string, error) {
+	var parts []string
+	var walk func(e *types.Expr) error
+	walk = func(e *types.Expr) error {
+		if e.Kind == types.ExprBinary && e.Value == "||" {
+			if err := walk(e.Args[0]); err != nil {
+				return err
+			}
+			return walk(e.Args[1])
+		}
+		s, err := w.operand(e, nil)
+		parts = append(parts, s)
+		return err
+	}
+
+	if err := walk(e); err != nil {
+		return "", err
+	}
+	return w.dialect.Concat(parts), nil
+}
+
+// operand writes one side of a binary expression. binary operands are
+// parenthesised so the sql never depends on each database's precedence
+// rules, and a string compared with an enum field becomes the member's
+// stored value the way the runtime compares them
+func (w exprWriter) operand(e, other *types.Expr) (string, error) {
+	if e.Kind == types.ExprString && other != nil && other.Kind == types.ExprField {
+		if f := w.entity.Field(other.Value); f != nil {
+			if dt, enum := w.schema.FieldType(f); enum != nil {
+				return literal(dt, enum, e.Value), nil
+			}
+		}
+	}
+
+	s, err := w.write(e)
+	if err != nil || e.Kind != types.ExprBinary {
+		return s, err
+	}
+	return "(" + s + ")", nil
+}
@@ -0,0 +1,142 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// MySQL writes for mysql 8.0.16 or later and mariadb 10.4 or later, the
+// first versions of each that enforce checks and take expression defaults
+var MySQL Dialect = mysql{}
+
+type mysql struct{}
+
+// uuids are CHAR(36) rather than BINARY(16) so they're the same strings the
+// runtime hands around; BINARY(16) would need converting on every read and
+// write. timestamps are always utc so DATETIME doesn't need a zone. a decimal
+// doesn't say how many digits it needs so it gets the most DECIMAL can hold
+var mysqlTypes = map[types.DataType]string{
+	types.DataText:      "TEXT",
+	types.DataInt:       "BIGINT",
+	types.DataReal:      "DOUBLE",
+	types.DataBool:      "BOOLEAN",
+	types.DataUUID:      "CHAR(36)",
+	types.DataTimestamp: "DATETIME(6)",
+	types.DataDecimal:   "DECIMAL(65,30)",
+}
+
+// innodb keys are at most 3072 bytes and a utf8mb4 character can take four
+const mysqlMaxIndexedText = 768
+
+func (mysql) Quote(ident string) string {
+	return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+}
+
+func (mysql) Types(*types.Schema) []string {
+	return nil
+}
+
+// text can only be indexed as a VARCHAR, and enums backed by text are
+// native ENUM columns whether they're named or a list of values
+func (m mysql) Type(c Column) (string, bool, error) {
+	if c.Enum != nil && c.Enum.Backing == types.DataText {
+		return fmt.Sprintf("ENUM(%s)", strings.Join(enumValues(c.Enum), ", ")), true, nil
+	}
+	if !m.varchar(c) {
+		return mysqlTypes[c.Type], false, nil
+	}
+
+	n := mysqlMaxIndexedText
+	if l := c.Field.Length; l != nil && l.Max > 0 {
+		if l.Max > mysqlMaxIndexedText {
+			return "", false, fmt.Errorf("mysql can only index text up to %d characters but the length allows %d",
+				mysqlMaxIndexedText, l.Max)
+		}
+		n = l.Max
+	}
+	return fmt.Sprintf("VARCHAR(%d)", n), false, nil
+}
+
+func (mysql) varchar(c Column) bool {
+	return c.Type == types.DataText && c.Enum == nil && c.Indexed
+}
+
+func (mysql) JSON(string) (string, string) {
+	return "JSON", ""
+}
+
+func (mysql) Decimal(col string) string {
+	return col
+}
+
+// AUTO_INCREMENT has to be on a key
+func (mysql) Increment(c Column) (string, error) {
+	if !c.Key && !c.Indexed {
+		return "", fmt.Errorf("mysql can only increment a primary key or unique int, not '%s'", c.Field.Name)
+	}
+	return "AUTO_INCREMENT", nil
+}
+
+// TEXT columns only take expression defaults so their literals are wrapped
+func (m mysql) Default(def *types.DefaultValue, c Column) string {
+	switch {
+	case def.Kind == types.DefaultLiteral && c.Type == types.DataText && c.Enum == nil && !m.varchar(c):
+		return "(" + literal(c.Type, c.Enum, def.Value) + ")"
+	case def.Kind == types.DefaultLiteral:
+		return literal(c.Type, c.Enum, def.Value)
+	case def.Value == types.FuncNow:
+		return "(UTC_TIMESTAMP(6))"
+	case def.Value == types.FuncToday && c.Type == types.DataText:
+		return "(CAST(UTC_DATE() AS CHAR))"
+	case def.Value == types.FuncToday:
+		return "(CAST(UTC_DATE() AS DATETIME(6)))"
+	}
+	// UUID() is a version 1 uuid which gives away the host and the time so
+	// neither uuid is generated here, and mysql has no sequences
+	return ""
+}
+
+// ON UPDATE only takes the current time and only on a DATETIME. it's in the
+// session's time zone, which has to be utc for DATETIME to read back as utc
+// anyway
+func (mysql) OnUpdate(def *types.DefaultValue, c Column) string {
+	if def.Kind == types.DefaultFunc && def.Value == types.FuncNow && c.Type == types.DataTimestamp {
+		return "ON UPDATE CURRENT_TIMESTAMP(6)"
+	}
+	return ""
+}
+
+func (mysql) Generated(expr string) string {
+	return fmt.Sprintf("GENERATED ALWAYS AS (%s) VIRTUAL", expr)
+}
+
+func (mysql) Call(fn string, args []string) string {
+	switch fn {
+	case "round":
+		return fmt.Sprintf("CAST(round(%s) AS SIGNED)", strings.Join(args, ", "))
+	case "length":
+		// LENGTH counts bytes
+		fn = "char_length"
+	}
+	return fmt.Sprintf("%s(%s)", fn, strings.Join(args, ", "))
+}
+
+// || is a logical or in mysql unless PIPES_AS_CONCAT is set
+func (mysql) Concat(parts []string) string {
+	return fmt.Sprintf("CONCAT(%s)", strings.Join(parts, ", "))
+}
+
+func (mysql) PartialIndexes() bool {
+	return false
+}
+
+func (mysql) TableOptions() string {
+	return " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+}
+
+// DATETIME doesn't take a zone; every timestamp is utc
+func (mysql) TimeLayout() string {
+	return "2006-01-02 15:04:05.000000"
+}
@@ -0,0 +1,138 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// Postgres writes for postgres 13 or later, the first with generated columns
+// and gen_random_uuid built in
+var Postgres Dialect = postgres{}
+
+type postgres struct{}
+
+// ints are bigint and floats double precision because the runtime hands them
+// around as int64 and float64. decimals are numeric so money and the like add
+// up exactly
+var postgresTypes = map[types.DataType]string{
+	types.DataText:      "text",
+	types.DataInt:       "bigint",
+	types.DataReal:      "double precision",
+	types.DataBool:      "boolean",
+	types.DataUUID:      "uuid",
+	types.DataTimestamp: "timestamptz",
+	types.DataDecimal:   "numeric",
+}
+
+func (postgres) Quote(ident string) string {
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+
+// named enums get a type of their own. postgres enums are labels so int
+// backed enums stay integers and are checked like inline lists instead.
+// every sequence() default gets a sequence, shared by the fields that name
+// the same one
+func (p postgres) Types(s *types.Schema) []string {
+	var decls []string
+	for _, enum := range s.Enums {
+		if enum.Backing != types.DataText {
+			continue
+		}
+		decls = append(decls, fmt.Sprintf("CREATE TYPE %s AS ENUM (%s)",
+			p.Quote(enum.Name), strings.Join(enumValues(enum), ", ")))
+	}
+
+	declared := make(map[string]bool)
+	for _, e := range s.Entities {
+		for _, f := range e.Fields {
+			if f.Default == nil || f.Default.Kind != types.DefaultFunc || f.Default.Value != types.FuncSequence {
+				continue
+			}
+			name := types.SequenceName(e, f, f.Default)
+			if !declared[name] {
+				declared[name] = true
+				decls = append(decls, "CREATE SEQUENCE IF NOT EXISTS "+p.Quote(name))
+			}
+		}
+	}
+	return decls
+}
+
+func (p postgres) Type(c Column) (string, bool, error) {
+	if c.Enum != nil && !c.Enum.Inline() && c.Enum.Backing == types.DataText {
+		return p.Quote(c.Enum.Name), true, nil
+	}
+	return postgresTypes[c.Type], false, nil
+}
+
+func (postgres) JSON(string) (string, string) {
+	return "jsonb", ""
+}
+
+func (postgres) Decimal(col string) string {
+	return col
+}
+
+func (postgres) Increment(Column) (string, error) {
+	return "GENERATED BY DEFAULT AS IDENTITY", nil
+}
+
+// today() is midnight utc to match what the runtime fills in. there's no
+// uuid_v7() before postgres 18 so the runtime generates those
+func (p postgres) Default(def *types.DefaultValue, c Column) string {
+	switch {
+	case def.Kind == types.DefaultLiteral:
+		return literal(c.Type, c.Enum, def.Value)
+	case def.Value == types.FuncNow:
+		return "now()"
+	case def.Value == types.FuncToday && c.Type == types.DataText:
+		return "(to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD'))"
+	case def.Value == types.FuncToday:
+		return "(date_trunc('day', now() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC')"
+	case def.Value == types.FuncUUIDv4 && c.Type == types.DataText:
+		return "(gen_random_uuid()::text)"
+	case def.Value == types.FuncUUIDv4:
+		return "gen_random_uuid()"
+	case def.Value == types.FuncSequence:
+		return fmt.Sprintf("nextval(%s)", quoteString(p.Quote(types.SequenceName(c.Entity, c.Field, def))))
+	}
+	return ""
+}
+
+// refreshing a column on update takes a trigger in postgres so it's left to
+// the runtime
+func (postgres) OnUpdate(*types.DefaultValue, Column) string {
+	return ""
+}
+
+// postgres can't compute a column on read so generated columns are stored
+func (postgres) Generated(expr string) string {
+	return fmt.Sprintf("GENERATED ALWAYS AS (%s) STORED", expr)
+}
+
+func (postgres) Call(fn string, args []string) string {
+	call := fmt.Sprintf("%s(%s)", fn, strings.Join(args, ", "))
+	if fn == "round" {
+		return fmt.Sprintf("CAST(%s AS bigint)", call)
+	}
+	return call
+}
+
+func (postgres) Concat(parts []string) string {
+	return strings.Join(parts, " || ")
+}
+
+func (postgres) PartialIndexes() bool {
+	return true
+}
+
+func (postgres) TableOptions() string {
+	return ""
+}
+
+func (postgres) TimeLayout() string {
+	return time.RFC3339Nano
+}
@@ -0,0 +1,110 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// SQLite writes for sqlite 3.31 or later, the first with generated columns
+var SQLite Dialect = sqlite{}
+
+type sqlite struct{}
+
+// sqlite has five storage classes and a loose idea of column types. uuids
+// and timestamps are stored as text; timestamps in the same rfc 3339 form the
+// runtime writes so they sort and compare correctly. decimals are text too
+// since a REAL or NUMERIC column would round them to a double; DECIMAL TEXT
+// has text affinity and still says what the column holds
+var sqliteTypes = map[types.DataType]string{
+	types.DataText:      "TEXT",
+	types.DataInt:       "INTEGER",
+	types.DataReal:      "REAL",
+	types.DataBool:      "INTEGER",
+	types.DataUUID:      "TEXT",
+	types.DataTimestamp: "TEXT",
+	types.DataDecimal:   "DECIMAL TEXT",
+}
+
+func (sqlite) Quote(ident string) string {
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+
+func (sqlite) Types(*types.Schema) []string {
+	return nil
+}
+
+func (sqlite) Type(c Column) (string, bool, error) {
+	return sqliteTypes[c.Type], false, nil
+}
+
+func (sqlite) JSON(col string) (string, string) {
+	return "TEXT", fmt.Sprintf("json_valid(%s)", col)
+}
+
+// text compares as text so "10" would sort before "9". sqlite has nothing
+// exact to read it as; NUMERIC is only as close as a double
+func (sqlite) Decimal(col string) string {
+	return "CAST(" + col + " AS NUMERIC)"
+}
+
+// sqlite only counts up the rowid which is an INTEGER PRIMARY KEY
+func (sqlite) Increment(c Column) (string, error) {
+	if !c.Key {
+		return "", fmt.Errorf("sqlite can only increment a single int primary key, not '%s'", c.Field.Name)
+	}
+	return "AUTOINCREMENT", nil
+}
+
+func (sqlite) Default(def *types.DefaultValue, c Column) string {
+	switch {
+	case def.Kind == types.DefaultLiteral:
+		return literal(c.Type, c.Enum, def.Value)
+	case def.Value == types.FuncNow:
+		return "(strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))"
+	case def.Value == types.FuncToday && c.Type == types.DataText:
+		return "(date('now'))"
+	case def.Value == types.FuncToday:
+		return "(strftime('%Y-%m-%dT00:00:00Z', 'now'))"
+	}
+	// sqlite can't generate uuids or count with a sequence
+	return ""
+}
+
+// refreshing a column on update takes a trigger in sqlite so it's left to
+// the runtime
+func (sqlite) OnUpdate(*types.DefaultValue, Column) string {
+	return ""
+}
+
+func (sqlite) Generated(expr string) string {
+	return fmt.Sprintf("GENERATED ALWAYS AS (%s) VIRTUAL", expr)
+}
+
+func (sqlite) Call(fn string, args []string) string {
+	call := fmt.Sprintf("%s(%s)", fn, strings.Join(args, ", "))
+	// round gives back a real in sql but computed fields treat it as an int
+	if fn == "round" {
+		return fmt.Sprintf("CAST(%s AS INTEGER)", call)
+	}
+	return call
+}
+
+func (sqlite) Concat(parts []string) string {
+	return strings.Join(parts, " || ")
+}
+
+func (sqlite) PartialIndexes() bool {
+	return true
+}
+
+func (sqlite) TableOptions() string {
+	return ""
+}
+
+// timestamps are kept as rfc 3339 text
+func (sqlite) TimeLayout() string {
+	return time.RFC3339Nano
+}
@@ -0,0 +1,166 @@
+package ddl
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/parser"
+	"willofdaedalus/mime/internal/engine/types"
+	"willofdaedalus/mime/internal/testutil"
+)
+
+// every schema in testdata is rendered in every dialect and compared with
+// testdata/<schema>.<dialect>.sql
+func TestGenerate(t *testing.T) {
+	for _, file := range testutil.Schemas(t, "testdata") {
+		s := testutil.Load(t, file)
+		name := strings.TrimSuffix(filepath.Base(file), ".mime")
+		for dialect, d := range Dialects {
+			t.Run(name+"/"+dialect, func(t *testing.T) {
+				out, _, err := Generate(s, d)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				testutil.Golden(t, name+"."+dialect+".sql", out)
+			})
+		}
+	}
+}
+
+func TestOrdered(t *testing.T) {
+	entity := func(name string, refs ...string) *types.EntityNode {
+		e := &types.EntityNode{Name: name}
+		for _, r := range refs {
+			e.Fields = append(e.Fields, &types.Field{Name: r, Kind: types.FieldReference,
+				Target: &types.ReferenceTarget{Entity: r, Field: "id"}})
+		}
+		return e
+	}
+
+	tests := []struct {
+		name     string
+		entities []*types.EntityNode
+		want     string
+	}{
+		{
+			name:     "dependencies first",
+			entities: []*types.EntityNode{entity("c", "b"), entity("b", "a"), entity("a")},
+			want:     "a b c",
+		},
+		{
+			name:     "independent entities keep their order",
+			entities: []*types.EntityNode{entity("z"), entity("y", "x"), entity("x"), entity("w")},
+			want:     "z x w y",
+		},
+		{
+			name:     "self references don't count",
+			entities: []*types.EntityNode{entity("node", "node")},
+			want:     "node",
+		},
+		{
+			name:     "cycles fall back to declaration order",
+			entities: []*types.EntityNode{entity("a", "b"), entity("b", "a"), entity("c")},
+			want:     "c a b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, e := range Ordered(&types.Schema{Entities: tt.entities}) {
+				names = append(names, e.Name)
+			}
+			if got := strings.Join(names, " "); got != tt.want {
+				t.Fatalf("for test %s: expected %s, got %s", tt.name, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIncrementOffThePrimaryKey(t *testing.T) {
+	s, errs := parser.NewParser(lexer.New("entity ticket ->\n\tid uuid [primary unique required]\n\tnumber int [required increment]\nend\n")).Schema()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected parse errors: %v", errs)
+	}
+
+	if _, _, err := Generate(s, SQLite); err == nil {
+		t.Fatalf("expected sqlite to reject increment on a column that isn't the primary key")
+	}
+	if _, _, err := Generate(s, MySQL); err == nil {
+		t.Fatalf("expected mysql to reject increment on a column that isn't a key")
+	}
+	out, _, err := Generate(s, Postgres)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, `"number" bigint GENERATED BY DEFAULT AS IDENTITY NOT NULL`) {
+		t.Fatalf("expected an identity column, got\n%s", out)
+	}
+}
+
+func TestMySQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantErr  string
+		warnings []string
+	}{
+		{
+			name:     "partial indexes are warned about",
+			input:    "entity tag [soft_delete] ->\n\tid int [primary unique required]\n\tname text [unique required]\nend\n",
+			warnings: []string{"tag.name is unique among rows that aren't deleted; without partial indexes only the runtime checks it"},
+		},
+		{
+			name:  "defaults mysql can't generate are warned about",
+			input: "entity tag ->\n\tid uuid [primary unique required default:uuid_v7()]\n\tnumber int [default:sequence()]\n\tseen timestamp [on_update:today()]\nend\n",
+			warnings: []string{
+				"tag.id defaults to uuid_v7() which the database can't generate; the runtime fills it in",
+				"tag.number defaults to sequence() which the database can't generate; the runtime fills it in",
+				"tag.seen is set to today() on every update which the database can't do; the runtime does it",
+			},
+		},
+		{
+			name:     "uuid_v4 isn't left to mysql's version 1 UUID()",
+			input:    "entity tag ->\n\tid uuid [primary unique required default:uuid_v4()]\nend\n",
+			warnings: []string{"tag.id defaults to uuid_v4() which the database can't generate; the runtime fills it in"},
+		},
+		{
+			name:  "defaults mysql can generate aren't",
+			input: "entity tag ->\n\tid int [primary unique required]\n\tseen timestamp [default:now() on_update:now()]\nend\n",
+		},
+		{
+			name:    "indexed text longer than a key",
+			input:   "entity tag ->\n\tid int [primary unique required]\n\tname text [unique required length:1,1000]\nend\n",
+			wantErr: "entity 'tag': field 'name': mysql can only index text up to 768 characters but the length allows 1000",
+		},
+		{
+			name:  "indexed text within a key",
+			input: "entity tag ->\n\tid int [primary unique required]\n\tname text [unique required length:1,200]\nend\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, errs := parser.NewParser(lexer.New(tt.input)).Schema()
+			if len(errs) > 0 {
+				t.Fatalf("unexpected parse errors: %v", errs)
+			}
+			_, warnings, err := Generate(s, MySQL)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("for test %s: expected error %q, got %v", tt.name, tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("for test %s: unexpected error: %v", tt.name, err)
+			}
+			if !slices.Equal(warnings, tt.warnings) {
+				t.Fatalf("for test %s: expected warnings %q, got %q", tt.name, tt.warnings, warnings)
+			}
+		})
+	}
+}
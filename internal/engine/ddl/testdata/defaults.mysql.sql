@@ -0,0 +1,26 @@
+-- Code generated by mime. DO NOT EDIT.
+-- fingerprint: 8b0762ae32569a4f05e4a3c109c73f59712e7e1eccc92da052a3f3618dea1f37
+
+-- warning: ticket.id defaults to uuid_v4() which the database can't generate; the runtime fills it in
+-- warning: ticket.public_id defaults to uuid_v4() which the database can't generate; the runtime fills it in
+-- warning: ticket.trace defaults to uuid_v7() which the database can't generate; the runtime fills it in
+-- warning: ticket.number defaults to sequence() which the database can't generate; the runtime fills it in
+-- warning: ticket.invoice defaults to sequence() which the database can't generate; the runtime fills it in
+-- warning: ticket.checked_on is set to today() on every update which the database can't do; the runtime does it
+CREATE TABLE `ticket` (
+  `id` CHAR(36) PRIMARY KEY NOT NULL,
+  `public_id` VARCHAR(768) UNIQUE,
+  `trace` CHAR(36),
+  `number` BIGINT UNIQUE,
+  `invoice` BIGINT,
+  `opened` TEXT DEFAULT (CAST(UTC_DATE() AS CHAR)),
+  `checked_at` DATETIME(6) DEFAULT (UTC_TIMESTAMP(6)) ON UPDATE CURRENT_TIMESTAMP(6),
+  `checked_on` DATETIME(6)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- warning: refund.id defaults to sequence() which the database can't generate; the runtime fills it in
+CREATE TABLE `refund` (
+  `id` BIGINT PRIMARY KEY NOT NULL,
+  `ticket` CHAR(36) NOT NULL,
+  FOREIGN KEY (`ticket`) REFERENCES `ticket` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,25 @@
+-- Code generated by mime. DO NOT EDIT.
+-- fingerprint: 8b0762ae32569a4f05e4a3c109c73f59712e7e1eccc92da052a3f3618dea1f37
+
+CREATE SEQUENCE IF NOT EXISTS "ticket_number_seq";
+CREATE SEQUENCE IF NOT EXISTS "invoices";
+
+-- warning: ticket.trace defaults to uuid_v7() which the database can't generate; the runtime fills it in
+-- warning: ticket.checked_at is set to now() on every update which the database can't do; the runtime does it
+-- warning: ticket.checked_on is set to today() on every update which the database can't do; the runtime does it
+CREATE TABLE "ticket" (
+  "id" uuid PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+  "public_id" text UNIQUE DEFAULT (gen_random_uuid()::text),
+  "trace" uuid,
+  "number" bigint UNIQUE DEFAULT nextval('"ticket_number_seq"'),
+  "invoice" bigint DEFAULT nextval('"invoices"'),
+  "opened" text DEFAULT (to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD')),
+  "checked_at" timestamptz DEFAULT now(),
+  "checked_on" timestamptz
+);
+
+CREATE TABLE "refund" (
+  "id" bigint PRIMARY KEY NOT NULL DEFAULT nextval('"invoices"'),
+  "ticket" uuid NOT NULL,
+  FOREIGN KEY ("ticket") REFERENCES "ticket" ("id")
+);
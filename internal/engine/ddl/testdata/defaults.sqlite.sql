@@ -0,0 +1,27 @@
+-- Code generated by mime. DO NOT EDIT.
+-- fingerprint: 8b0762ae32569a4f05e4a3c109c73f59712e7e1eccc92da052a3f3618dea1f37
+
+-- warning: ticket.id defaults to uuid_v4() which the database can't generate; the runtime fills it in
+-- warning: ticket.public_id defaults to uuid_v4() which the database can't generate; the runtime fills it in
+-- warning: ticket.trace defaults to uuid_v7() which the database can't generate; the runtime fills it in
+-- warning: ticket.number defaults to sequence() which the database can't generate; the runtime fills it in
+-- warning: ticket.invoice defaults to sequence() which the database can't generate; the runtime fills it in
+-- warning: ticket.checked_at is set to now() on every update which the database can't do; the runtime does it
+-- warning: ticket.checked_on is set to today() on every update which the database can't do; the runtime does it
+CREATE TABLE "ticket" (
+  "id" TEXT PRIMARY KEY NOT NULL,
+  "public_id" TEXT UNIQUE,
+  "trace" TEXT,
+  "number" INTEGER UNIQUE,
+  "invoice" INTEGER,
+  "opened" TEXT DEFAULT (date('now')),
+  "checked_at" TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+  "checked_on" TEXT
+);
+
+-- warning: refund.id defaults to sequence() which the database can't generate; the runtime fills it in
+CREATE TABLE "refund" (
+  "id" INTEGER PRIMARY KEY NOT NULL,
+  "ticket" TEXT NOT NULL,
+  FOREIGN KEY ("ticket") REFERENCES "ticket" ("id")
+);
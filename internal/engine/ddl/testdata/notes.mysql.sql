@@ -0,0 +1,43 @@
+-- Code generated by mime. DO NOT EDIT.
+-- fingerprint: 3a0c5622a26e89ee9959d8b6b5b470bb6c0a28b678cde9866a9c4d0226cb151b
+
+CREATE TABLE `person` (
+  `name` TEXT NOT NULL,
+  `email` TEXT
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- warning: user.id defaults to uuid_v7() which the database can't generate; the runtime fills it in
+CREATE TABLE `user` (
+  `id` CHAR(36) PRIMARY KEY NOT NULL,
+  `email` VARCHAR(768) NOT NULL UNIQUE,
+  `first_name` TEXT NOT NULL,
+  `last_name` TEXT,
+  `age` BIGINT CHECK ((`age` >= 13) AND (`age` < 150)),
+  `seats` BIGINT,
+  `balance` DOUBLE DEFAULT 0.0,
+  `role` BIGINT DEFAULT 2 CHECK (`role` IN (1, 2, 3)),
+  `password` TEXT,
+  `birthday` TEXT DEFAULT (CAST(UTC_DATE() AS CHAR)),
+  `person` JSON,
+  `full_name` TEXT GENERATED ALWAYS AS (CONCAT(`first_name`, ' ', `last_name`)) VIRTUAL,
+  `initials` TEXT GENERATED ALWAYS AS (upper(`first_name`)) VIRTUAL,
+  `created_at` DATETIME(6) DEFAULT (UTC_TIMESTAMP(6)),
+  `updated_at` DATETIME(6) DEFAULT (UTC_TIMESTAMP(6)) ON UPDATE CURRENT_TIMESTAMP(6),
+  CHECK ((`seats` > 0) OR (`role` = 3))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- warning: note.id defaults to uuid_v7() which the database can't generate; the runtime fills it in
+-- warning: note.slug is unique among rows that aren't deleted; without partial indexes only the runtime checks it
+CREATE TABLE `note` (
+  `id` CHAR(36) PRIMARY KEY NOT NULL,
+  `owner` CHAR(36) NOT NULL,
+  `title` TEXT NOT NULL,
+  `slug` TEXT NOT NULL,
+  `category` ENUM('work', 'home') DEFAULT 'home',
+  `pinned` BOOLEAN DEFAULT FALSE,
+  `words` BIGINT CHECK (`words` >= 0),
+  `created_at` DATETIME(6) DEFAULT (UTC_TIMESTAMP(6)),
+  `updated_at` DATETIME(6) DEFAULT (UTC_TIMESTAMP(6)) ON UPDATE CURRENT_TIMESTAMP(6),
+  `deleted_at` DATETIME(6),
+  FOREIGN KEY (`owner`) REFERENCES `user` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
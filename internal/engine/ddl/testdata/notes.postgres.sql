@@ -0,0 +1,45 @@
+-- Code generated by mime. DO NOT EDIT.
+-- fingerprint: 3a0c5622a26e89ee9959d8b6b5b470bb6c0a28b678cde9866a9c4d0226cb151b
+
+CREATE TABLE "person" (
+  "name" text NOT NULL,
+  "email" text
+);
+
+-- warning: user.id defaults to uuid_v7() which the database can't generate; the runtime fills it in
+-- warning: user.updated_at is set to now() on every update which the database can't do; the runtime does it
+CREATE TABLE "user" (
+  "id" uuid PRIMARY KEY NOT NULL,
+  "email" text NOT NULL UNIQUE,
+  "first_name" text NOT NULL,
+  "last_name" text,
+  "age" bigint CHECK (("age" >= 13) AND ("age" < 150)),
+  "seats" bigint,
+  "balance" double precision DEFAULT 0.0,
+  "role" bigint DEFAULT 2 CHECK ("role" IN (1, 2, 3)),
+  "password" text,
+  "birthday" text DEFAULT (to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD')),
+  "person" jsonb,
+  "full_name" text GENERATED ALWAYS AS ("first_name" || ' ' || "last_name") STORED,
+  "initials" text GENERATED ALWAYS AS (upper("first_name")) STORED,
+  "created_at" timestamptz DEFAULT now(),
+  "updated_at" timestamptz DEFAULT now(),
+  CHECK (("seats" > 0) OR ("role" = 3))
+);
+
+-- warning: note.id defaults to uuid_v7() which the database can't generate; the runtime fills it in
+-- warning: note.updated_at is set to now() on every update which the database can't do; the runtime does it
+CREATE TABLE "note" (
+  "id" uuid PRIMARY KEY NOT NULL,
+  "owner" uuid NOT NULL,
+  "title" text NOT NULL,
+  "slug" text NOT NULL,
+  "category" text DEFAULT 'home' CHECK ("category" IN ('work', 'home')),
+  "pinned" boolean DEFAULT FALSE,
+  "words" bigint CHECK ("words" >= 0),
+  "created_at" timestamptz DEFAULT now(),
+  "updated_at" timestamptz DEFAULT now(),
+  "deleted_at" timestamptz,
+  FOREIGN KEY ("owner") REFERENCES "user" ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "note_slug_live_key" ON "note" ("slug") WHERE "deleted_at" IS NULL;
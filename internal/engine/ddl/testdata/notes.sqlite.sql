@@ -0,0 +1,45 @@
+-- Code generated by mime. DO NOT EDIT.
+-- fingerprint: 3a0c5622a26e89ee9959d8b6b5b470bb6c0a28b678cde9866a9c4d0226cb151b
+
+CREATE TABLE "person" (
+  "name" TEXT NOT NULL,
+  "email" TEXT
+);
+
+-- warning: user.id defaults to uuid_v7() which the database can't generate; the runtime fills it in
+-- warning: user.updated_at is set to now() on every update which the database can't do; the runtime does it
+CREATE TABLE "user" (
+  "id" TEXT PRIMARY KEY NOT NULL,
+  "email" TEXT NOT NULL UNIQUE,
+  "first_name" TEXT NOT NULL,
+  "last_name" TEXT,
+  "age" INTEGER CHECK (("age" >= 13) AND ("age" < 150)),
+  "seats" INTEGER,
+  "balance" REAL DEFAULT 0.0,
+  "role" INTEGER DEFAULT 2 CHECK ("role" IN (1, 2, 3)),
+  "password" TEXT,
+  "birthday" TEXT DEFAULT (date('now')),
+  "person" TEXT CHECK ("person" IS NULL OR json_valid("person")),
+  "full_name" TEXT GENERATED ALWAYS AS ("first_name" || ' ' || "last_name") VIRTUAL,
+  "initials" TEXT GENERATED ALWAYS AS (upper("first_name")) VIRTUAL,
+  "created_at" TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+  "updated_at" TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+  CHECK (("seats" > 0) OR ("role" = 3))
+);
+
+-- warning: note.id defaults to uuid_v7() which the database can't generate; the runtime fills it in
+-- warning: note.updated_at is set to now() on every update which the database can't do; the runtime does it
+CREATE TABLE "note" (
+  "id" TEXT PRIMARY KEY NOT NULL,
+  "owner" TEXT NOT NULL,
+  "title" TEXT NOT NULL,
+  "slug" TEXT NOT NULL,
+  "category" TEXT DEFAULT 'home' CHECK ("category" IN ('work', 'home')),
+  "pinned" INTEGER DEFAULT FALSE,
+  "words" INTEGER CHECK ("words" >= 0),
+  "created_at" TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+  "updated_at" TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+  "deleted_at" TEXT,
+  FOREIGN KEY ("owner") REFERENCES "user" ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "note_slug_live_key" ON "note" ("slug") WHERE "deleted_at" IS NULL;
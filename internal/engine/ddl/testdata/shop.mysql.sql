@@ -0,0 +1,31 @@
+-- Code generated by mime. DO NOT EDIT.
+-- fingerprint: a6b28e91532de5dd3ff8776692952982ae2ef8cedc5afb2aed215c209c2b3271
+
+CREATE TABLE `product` (
+  `sku` BIGINT PRIMARY KEY NOT NULL,
+  `name` TEXT NOT NULL,
+  `priority` BIGINT DEFAULT 2 CHECK (`priority` IN (1, 2, 3)),
+  `price` DECIMAL(65,30) NOT NULL DEFAULT '9.90' CHECK (`price` >= 0)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `orders` (
+  `id` BIGINT PRIMARY KEY AUTO_INCREMENT NOT NULL,
+  `status` ENUM('open', 'paid', 'shipped') NOT NULL DEFAULT 'open',
+  `placed_at` DATETIME(6) DEFAULT (CAST(UTC_DATE() AS DATETIME(6))),
+  `note` TEXT DEFAULT ('it''s fragile'),
+  `gift` BOOLEAN DEFAULT FALSE,
+  `due` TEXT DEFAULT (CAST(UTC_DATE() AS CHAR))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `order_line` (
+  `order_id` BIGINT NOT NULL,
+  `product` BIGINT NOT NULL,
+  `line` BIGINT NOT NULL,
+  `position` BIGINT NOT NULL,
+  `quantity` BIGINT NOT NULL DEFAULT 1 CHECK (`quantity` > 0),
+  `unit_price` DOUBLE NOT NULL,
+  `total` DOUBLE GENERATED ALWAYS AS (CAST(round(`quantity` * `unit_price`) AS SIGNED)) VIRTUAL,
+  PRIMARY KEY (`line`, `position`),
+  FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`),
+  FOREIGN KEY (`product`) REFERENCES `product` (`sku`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,33 @@
+-- Code generated by mime. DO NOT EDIT.
+-- fingerprint: a6b28e91532de5dd3ff8776692952982ae2ef8cedc5afb2aed215c209c2b3271
+
+CREATE TYPE "status" AS ENUM ('open', 'paid', 'shipped');
+
+CREATE TABLE "product" (
+  "sku" bigint PRIMARY KEY NOT NULL,
+  "name" text NOT NULL,
+  "priority" bigint DEFAULT 2 CHECK ("priority" IN (1, 2, 3)),
+  "price" numeric NOT NULL DEFAULT '9.90' CHECK ("price" >= 0)
+);
+
+CREATE TABLE "orders" (
+  "id" bigint PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY NOT NULL,
+  "status" "status" NOT NULL DEFAULT 'open',
+  "placed_at" timestamptz DEFAULT (date_trunc('day', now() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'),
+  "note" text DEFAULT 'it''s fragile',
+  "gift" boolean DEFAULT FALSE,
+  "due" text DEFAULT (to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD'))
+);
+
+CREATE TABLE "order_line" (
+  "order_id" bigint NOT NULL,
+  "product" bigint NOT NULL,
+  "line" bigint NOT NULL,
+  "position" bigint NOT NULL,
+  "quantity" bigint NOT NULL DEFAULT 1 CHECK ("quantity" > 0),
+  "unit_price" double precision NOT NULL,
+  "total" double precision GENERATED ALWAYS AS (CAST(round("quantity" * "unit_price") AS bigint)) STORED,
+  PRIMARY KEY ("line", "position"),
+  FOREIGN KEY ("order_id") REFERENCES "orders" ("id"),
+  FOREIGN KEY ("product") REFERENCES "product" ("sku")
+);
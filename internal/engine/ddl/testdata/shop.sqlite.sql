@@ -0,0 +1,31 @@
+-- Code generated by mime. DO NOT EDIT.
+-- fingerprint: a6b28e91532de5dd3ff8776692952982ae2ef8cedc5afb2aed215c209c2b3271
+
+CREATE TABLE "product" (
+  "sku" INTEGER PRIMARY KEY NOT NULL,
+  "name" TEXT NOT NULL,
+  "priority" INTEGER DEFAULT 2 CHECK ("priority" IN (1, 2, 3)),
+  "price" DECIMAL TEXT NOT NULL DEFAULT '9.90' CHECK (CAST("price" AS NUMERIC) >= 0)
+);
+
+CREATE TABLE "orders" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+  "status" TEXT NOT NULL DEFAULT 'open' CHECK ("status" IN ('open', 'paid', 'shipped')),
+  "placed_at" TEXT DEFAULT (strftime('%Y-%m-%dT00:00:00Z', 'now')),
+  "note" TEXT DEFAULT 'it''s fragile',
+  "gift" INTEGER DEFAULT FALSE,
+  "due" TEXT DEFAULT (date('now'))
+);
+
+CREATE TABLE "order_line" (
+  "order_id" INTEGER NOT NULL,
+  "product" INTEGER NOT NULL,
+  "line" INTEGER NOT NULL,
+  "position" INTEGER NOT NULL,
+  "quantity" INTEGER NOT NULL DEFAULT 1 CHECK ("quantity" > 0),
+  "unit_price" REAL NOT NULL,
+  "total" REAL GENERATED ALWAYS AS (CAST(round("quantity" * "unit_price") AS INTEGER)) VIRTUAL,
+  PRIMARY KEY ("line", "position"),
+  FOREIGN KEY ("order_id") REFERENCES "orders" ("id"),
+  FOREIGN KEY ("product") REFERENCES "product" ("sku")
+);
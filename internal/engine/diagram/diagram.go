@@ -0,0 +1,321 @@
+package diagram
+
+import (
+	"fmt"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// diagrams draw a resolved schema for design docs. every format draws the
+// same graph:
+//
+//   - every entity is a node listing the fields it declares itself with
+//     their types and PK, FK and UK markers
+//   - named enums are nodes of their own listing their members
+//   - mixins and templates are nodes listing the fields they bring in and an
+//     entity that uses one points at it the way a subclass points at its base
+//   - a reference is an edge from the entity holding it to the one it points
+//     at with the cardinality of both ends; embeds are drawn differently
+//     since the embedded entity's fields live in the same row
+//
+// a focus limits the drawing to the entities within depth references or
+// embeds of one entity in either direction, with whatever enums and mixins
+// they use
+
+// Options decide how much of the schema is drawn
+type Options struct {
+	// the entity the drawing is centred on; everything is drawn when empty
+	Focus string
+	// how many references or embeds away from Focus an entity can be
+	Depth int
+}
+
+// Formats lists every format by the name `mime diagram -format` takes
+var Formats = map[string]func(s *types.Schema, opts Options) (string, error){
+	"dot":     Dot,
+	"mermaid": Mermaid,
+}
+
+// graph is what's drawn, in schema order
+type graph struct {
+	schema   *types.Schema
+	entities []*types.EntityNode
+	enums    []*types.EnumNode
+	mixins   []*mixin
+	drawn    map[string]bool
+}
+
+// a mixin or template as one of the entities using it expanded it, so its
+// fields have the types they resolved to
+type mixin struct {
+	node *types.MixinNode
+	// the first drawn entity to use it; its fields are taken from there
+	first  *types.EntityNode
+	fields []*types.Field
+}
+
+// a reference or embed between two drawn entities
+type edge struct {
+	from, to *types.EntityNode
+	field    *types.Field
+}
+
+func newGraph(s *types.Schema, opts Options) (*graph, error) {
+	g := &graph{schema: s, drawn: make(map[string]bool)}
+	if opts.Focus == "" {
+		for _, e := range s.Entities {
+			g.drawn[e.Name] = true
+		}
+	} else {
+		if s.Entity(opts.Focus) == nil {
+			return nil, fmt.Errorf("entity '%s' doesn't exist", opts.Focus)
+		}
+		if opts.Depth < 0 {
+			return nil, fmt.Errorf("depth has to be 0 or more, got %d", opts.Depth)
+		}
+		g.neighbourhood(opts.Focus, opts.Depth)
+	}
+
+	enums := make(map[*types.EnumNode]bool)
+	mixins := make(map[string]*mixin)
+	for _, e := range s.Entities {
+		if !g.drawn[e.Name] {
+			continue
+		}
+		g.entities = append(g.entities, e)
+		for _, f := range e.Fields {
+			if _, enum := s.FieldType(f); enum != nil && !enum.Inline() {
+				enums[enum] = true
+			}
+			if f.Origin == nil {
+				continue
+			}
+			m := mixins[f.Origin.Mixin]
+			if m == nil {
+				m = &mixin{node: s.Mixin(f.Origin.Mixin), first: e}
+				if m.node == nil {
+					m.node = &types.MixinNode{Name: f.Origin.Mixin}
+				}
+				mixins[f.Origin.Mixin] = m
+				g.mixins = append(g.mixins, m)
+			}
+			if m.first == e {
+				m.fields = append(m.fields, f)
+			}
+		}
+	}
+	for _, enum := range s.Enums {
+		if enums[enum] {
+			g.enums = append(g.enums, enum)
+		}
+	}
+	return g, nil
+}
+
+// neighbourhood marks every entity within depth hops of focus as drawn
+func (g *graph) neighbourhood(focus string, depth int) {
+	g.drawn[focus] = true
+	frontier := []string{focus}
+	for range depth {
+		var next []string
+		visit := func(name string) {
+			if !g.drawn[name] {
+				g.drawn[name] = true
+				next = append(next, name)
+			}
+		}
+		for _, name := range frontier {
+			for _, e := range g.schema.Entities {
+				for _, f := range e.Fields {
+					to := target(f)
+					switch {
+					case e.Name == name && to != "":
+						visit(to)
+					case to == name:
+						visit(e.Name)
+					}
+				}
+			}
+		}
+		frontier = next
+	}
+}
+
+// target is the entity a reference or embed points at
+func target(f *types.Field) string {
+	switch f.Kind {
+	case types.FieldReference:
+		if f.Target != nil {
+			return f.Target.Entity
+		}
+	case types.FieldEmbedded:
+		return f.Name
+	}
+	return ""
+}
+
+// edges are the references and embeds between drawn entities, mixin fields
+// included since the reference is the entity's either way
+func (g *graph) edges() []edge {
+	var out []edge
+	for _, e := range g.entities {
+		for _, f := range e.Fields {
+			if to := target(f); to != "" && g.drawn[to] {
+				out = append(out, edge{from: e, to: g.schema.Entity(to), field: f})
+			}
+		}
+	}
+	return out
+}
+
+// an enum field of a drawn entity or mixin
+type enumEdge struct {
+	from string
+	// from names a mixin rather than an entity
+	mixin bool
+	field *types.Field
+	enum  *types.EnumNode
+}
+
+// enumEdges link the entities and mixins that declare enum fields to the
+// enums. references to enum fields are left to the reference edge
+func (g *graph) enumEdges() []enumEdge {
+	var out []enumEdge
+	add := func(from string, mixin bool, fields []*types.Field) {
+		for _, f := range fields {
+			if f.Kind == types.FieldReference {
+				continue
+			}
+			if _, enum := g.schema.FieldType(f); enum != nil && !enum.Inline() {
+				out = append(out, enumEdge{from: from, mixin: mixin, field: f, enum: enum})
+			}
+		}
+	}
+	for _, e := range g.entities {
+		add(e.Name, false, own(e))
+	}
+	for _, m := range g.mixins {
+		add(m.node.Name, true, m.fields)
+	}
+	return out
+}
+
+// uses are the mixins e's fields came from in the order they first show up
+func (g *graph) uses(e *types.EntityNode) []*mixin {
+	var out []*mixin
+	seen := make(map[string]bool)
+	for _, f := range e.Fields {
+		if f.Origin != nil && !seen[f.Origin.Mixin] {
+			seen[f.Origin.Mixin] = true
+			for _, m := range g.mixins {
+				if m.node.Name == f.Origin.Mixin {
+					out = append(out, m)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// own are the fields e declares itself. embeds are drawn as edges instead
+func own(e *types.EntityNode) []*types.Field {
+	var out []*types.Field
+	for _, f := range e.Fields {
+		if f.Origin == nil && f.Kind != types.FieldEmbedded {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// a line in a node
+type row struct {
+	typ, name, note string
+	keys            []string
+}
+
+func (g *graph) rows(fields []*types.Field) []row {
+	out := make([]row, len(fields))
+	for i, f := range fields {
+		out[i] = row{typ: g.typeName(f), name: f.Name, keys: keys(f), note: note(f)}
+	}
+	return out
+}
+
+// member is what an enum member's row notes: its value when that isn't its
+// name, its label and whether it's deprecated
+func member(m types.EnumMember) string {
+	var notes []string
+	if m.Value != m.Name {
+		notes = append(notes, "= "+m.Value)
+	}
+	if m.Label != "" {
+		notes = append(notes, m.Label)
+	}
+	if m.Deprecated {
+		notes = append(notes, "deprecated")
+	}
+	return strings.Join(notes, ", ")
+}
+
+var typeNames = map[types.DataType]string{
+	types.DataText:      "text",
+	types.DataInt:       "int",
+	types.DataReal:      "float",
+	types.DataBool:      "bool",
+	types.DataUUID:      "uuid",
+	types.DataTimestamp: "timestamp",
+	types.DataDecimal:   "decimal",
+}
+
+// typeName is how a field's type is written. references have the type of
+// the field they point at and enums their name
+func (g *graph) typeName(f *types.Field) string {
+	if f.Kind == types.FieldEmbedded {
+		return f.Name
+	}
+	dt, enum := g.schema.FieldType(f)
+	switch {
+	case enum != nil && enum.Inline():
+		return "enum"
+	case enum != nil:
+		return enum.Name
+	}
+	if name, ok := typeNames[dt]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// keys are the PK, FK and UK markers of a field
+func keys(f *types.Field) []string {
+	var out []string
+	if f.Attributes&types.AttrPrimary != 0 {
+		out = append(out, "PK")
+	}
+	if f.Kind == types.FieldReference {
+		out = append(out, "FK")
+	}
+	if f.Attributes&(types.AttrUnique|types.AttrPrimary) == types.AttrUnique {
+		out = append(out, "UK")
+	}
+	return out
+}
+
+// note is anything about a field its type doesn't say
+func note(f *types.Field) string {
+	switch {
+	case f.Kind == types.FieldComputed:
+		return "computed"
+	case f.Enum != nil && f.Enum.Inline():
+		return "one of " + strings.Join(f.Enum.Values(), ", ")
+	}
+	return ""
+}
+
+// many reports whether several rows of the referencing entity can point at
+// the same row
+func many(f *types.Field) bool {
+	return f.Attributes&(types.AttrUnique|types.AttrPrimary) == 0
+}
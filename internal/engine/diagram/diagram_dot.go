@@ -0,0 +1,105 @@
+package diagram
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/ir"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// Dot draws the schema as a graphviz digraph. nodes are html tables;
+// references are solid edges labelled with their cardinality, embeds end in
+// a diamond, mixins in a hollow arrow like a base class and enum fields are
+// dotted
+func Dot(s *types.Schema, opts Options) (string, error) {
+	g, err := newGraph(s, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(ir.Header(s, "//"))
+	b.WriteString("digraph mime {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=plain, fontname=\"Helvetica\", fontsize=11];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=9];\n")
+
+	for _, e := range g.entities {
+		dotNode(&b, dotID("", e.Name), "<b>"+html.EscapeString(e.Name)+"</b>", "#dddddd", g.rows(own(e)))
+	}
+	for _, enum := range g.enums {
+		var rows []row
+		for _, m := range enum.Members {
+			rows = append(rows, row{name: m.Name, note: member(m)})
+		}
+		dotNode(&b, dotID("enum", enum.Name), "<i>enum</i> "+html.EscapeString(enum.Name), "#f3e6c4", rows)
+	}
+	for _, m := range g.mixins {
+		kind := "mixin"
+		title := m.node.Name
+		if len(m.node.Params) > 0 {
+			kind = "template"
+			title += "<" + strings.Join(m.node.Params, ", ") + ">"
+		}
+		dotNode(&b, dotID("mixin", m.node.Name), "<i>"+kind+"</i> "+html.EscapeString(title), "#dde8f3", g.rows(m.fields))
+	}
+
+	for _, ed := range g.edges() {
+		from, to, f := dotID("", ed.from.Name), dotID("", ed.to.Name), ed.field
+		if f.Kind == types.FieldEmbedded {
+			fmt.Fprintf(&b, "  %s -> %s [label=\"embeds\", style=dashed, arrowhead=diamond];\n", from, to)
+			continue
+		}
+		tail, head := "*", "1"
+		if !many(f) {
+			tail = "0..1"
+		}
+		if f.Nullable() {
+			head = "0..1"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [label=%s, taillabel=%q, headlabel=%q];\n",
+			from, to, strconv.Quote(f.Name), tail, head)
+	}
+	for _, ed := range g.enumEdges() {
+		from := dotID("", ed.from)
+		if ed.mixin {
+			from = dotID("mixin", ed.from)
+		}
+		fmt.Fprintf(&b, "  %s -> %s [label=%s, style=dotted, arrowhead=open];\n",
+			from, dotID("enum", ed.enum.Name), strconv.Quote(ed.field.Name))
+	}
+	for _, e := range g.entities {
+		for _, m := range g.uses(e) {
+			fmt.Fprintf(&b, "  %s -> %s [label=\"uses\", style=dashed, arrowhead=empty];\n",
+				dotID("", e.Name), dotID("mixin", m.node.Name))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// dotID quotes a node's id. enums and mixins are prefixed with what they are
+// so they can share a name with an entity
+func dotID(kind, name string) string {
+	if kind != "" {
+		name = kind + ":" + name
+	}
+	return strconv.Quote(name)
+}
+
+func dotNode(b *strings.Builder, id, title, colour string, rows []row) {
+	fmt.Fprintf(b, "  %s [label=<<table border=\"1\" cellborder=\"0\" cellspacing=\"0\" cellpadding=\"4\">\n", id)
+	fmt.Fprintf(b, "    <tr><td colspan=\"3\" bgcolor=%q>%s</td></tr>\n", colour, title)
+	for _, r := range rows {
+		markers := strings.Join(r.keys, ", ")
+		if r.note != "" {
+			markers = strings.TrimPrefix(markers+" <i>"+html.EscapeString(r.note)+"</i>", " ")
+		}
+		fmt.Fprintf(b, "    <tr><td align=\"left\">%s</td><td align=\"left\">%s</td><td align=\"left\">%s</td></tr>\n",
+			html.EscapeString(r.name), html.EscapeString(r.typ), markers)
+	}
+	b.WriteString("  </table>>];\n")
+}
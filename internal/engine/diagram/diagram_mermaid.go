@@ -0,0 +1,101 @@
+package diagram
+
+import (
+	"fmt"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/ir"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// Mermaid draws the schema as a mermaid erDiagram. references are solid
+// lines with crow's feet at both ends; embeds, enums and mixins are dashed
+// and say what they are in their label since mermaid has no other way to
+// tell relationships apart
+func Mermaid(s *types.Schema, opts Options) (string, error) {
+	g, err := newGraph(s, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(ir.Header(s, "%%"))
+	b.WriteString("erDiagram\n")
+	for _, e := range g.entities {
+		mermaidNode(&b, e.Name, g.rows(own(e)))
+	}
+	for _, enum := range g.enums {
+		var rows []row
+		for _, m := range enum.Members {
+			rows = append(rows, row{typ: typeNames[enum.Backing], name: m.Name, note: member(m)})
+		}
+		mermaidNode(&b, enum.Name, rows)
+	}
+	for _, m := range g.mixins {
+		mermaidNode(&b, m.node.Name, g.rows(m.fields))
+	}
+
+	for _, ed := range g.edges() {
+		from, to, f := mermaidName(ed.from.Name), mermaidName(ed.to.Name), ed.field
+		if f.Kind == types.FieldEmbedded {
+			fmt.Fprintf(&b, "  %s ||..|| %s : \"embeds\"\n", from, to)
+			continue
+		}
+		left, right := "}o", "||"
+		if !many(f) {
+			left = "|o"
+		}
+		if f.Nullable() {
+			right = "o|"
+		}
+		fmt.Fprintf(&b, "  %s %s--%s %s : %s\n", from, left, right, to, mermaidLabel(f.Name))
+	}
+	for _, ed := range g.enumEdges() {
+		fmt.Fprintf(&b, "  %s }o..|| %s : %s\n", mermaidName(ed.from), mermaidName(ed.enum.Name), mermaidLabel(ed.field.Name))
+	}
+	for _, e := range g.entities {
+		for _, m := range g.uses(e) {
+			fmt.Fprintf(&b, "  %s }|..|| %s : \"uses\"\n", mermaidName(e.Name), mermaidName(m.node.Name))
+		}
+	}
+	return b.String(), nil
+}
+
+// mermaidNode writes a node with its rows. mermaid won't take an empty
+// block so a node without any is just its name
+func mermaidNode(b *strings.Builder, name string, rows []row) {
+	if len(rows) == 0 {
+		fmt.Fprintf(b, "  %s\n", mermaidName(name))
+		return
+	}
+	fmt.Fprintf(b, "  %s {\n", mermaidName(name))
+	for _, r := range rows {
+		line := r.typ + " " + mermaidName(r.name)
+		if len(r.keys) > 0 {
+			line += " " + strings.Join(r.keys, ",")
+		}
+		if r.note != "" {
+			line += " " + `"` + strings.ReplaceAll(r.note, `"`, "'") + `"`
+		}
+		fmt.Fprintf(b, "    %s\n", line)
+	}
+	b.WriteString("  }\n")
+}
+
+// mermaidName replaces whatever mermaid doesn't allow in a name
+func mermaidName(name string) string {
+	out := []rune(name)
+	for i, r := range out {
+		if r != '_' && r != '-' && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') {
+			out[i] = '_'
+		}
+	}
+	if len(out) == 0 || out[0] >= '0' && out[0] <= '9' || out[0] == '-' {
+		return "_" + string(out)
+	}
+	return string(out)
+}
+
+func mermaidLabel(label string) string {
+	return `"` + strings.ReplaceAll(label, `"`, "'") + `"`
+}
@@ -0,0 +1,66 @@
+package diagram
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/testutil"
+)
+
+// every format is compared with testdata/blog.<format>
+func TestFormats(t *testing.T) {
+	s := testutil.Load(t, filepath.Join("testdata", "blog.mime"))
+	for name, draw := range Formats {
+		t.Run(name, func(t *testing.T) {
+			out, err := draw(s, Options{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testutil.Golden(t, "blog."+name, out)
+		})
+	}
+}
+
+func TestFocus(t *testing.T) {
+	s := testutil.Load(t, filepath.Join("testdata", "blog.mime"))
+	tests := []struct {
+		focus    string
+		depth    int
+		entities []string
+	}{
+		{focus: "comment", depth: 0, entities: []string{"comment"}},
+		{focus: "comment", depth: 1, entities: []string{"post", "comment"}},
+		{focus: "comment", depth: 2, entities: []string{"user", "post", "comment", "post_audit"}},
+		{focus: "user", depth: 1, entities: []string{"address", "user", "profile", "post"}},
+		{focus: "tag", depth: 3, entities: []string{"tag"}},
+	}
+
+	for _, tt := range tests {
+		g, err := newGraph(s, Options{Focus: tt.focus, Depth: tt.depth})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got []string
+		for _, e := range g.entities {
+			got = append(got, e.Name)
+		}
+		if !slices.Equal(got, tt.entities) {
+			t.Errorf("focus %s depth %d: expected %v, got %v", tt.focus, tt.depth, tt.entities, got)
+		}
+	}
+
+	// only what the drawn entities use comes along
+	out, err := Mermaid(s, Options{Focus: "comment", Depth: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(out, "role {") || !strings.Contains(out, "timestamps {") {
+		t.Errorf("expected timestamps and not role to be drawn:\n%s", out)
+	}
+
+	if _, err := Dot(s, Options{Focus: "nope"}); err == nil {
+		t.Error("expected an error focusing on an entity that doesn't exist")
+	}
+}
@@ -0,0 +1,311 @@
+package docs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/ir"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// docs turn a resolved schema into pages people can browse: an index with
+// every entity, enum and route, a page per entity and a page per named
+// enum. every format writes the same pages from the same site so the html
+// and the markdown never disagree:
+//
+//	index.<ext>
+//	entities/<entity>.<ext>
+//	enums/<enum>.<ext>
+//
+// links between pages are relative so the output works wherever it's put
+
+// Formats lists every format by the name `mime docs -format` takes. each
+// returns its pages keyed by their path inside the output directory
+var Formats = map[string]func(s *types.Schema) (map[string]string, error){
+	"html":     HTML,
+	"markdown": Markdown,
+}
+
+// site is everything the pages show
+type site struct {
+	Fingerprint string
+	Entities    []*entityPage
+	Enums       []*enumPage
+	Routes      []*routeRow
+}
+
+type entityPage struct {
+	Name       string
+	Doc        string
+	SoftDelete bool
+	Fields     []*fieldRow
+	// references this entity's fields make and the ones other entities
+	// make to it; embeds are listed as references too
+	Outgoing []*reference
+	Incoming []*reference
+	Routes   []*routeRow
+}
+
+type fieldRow struct {
+	Name string
+	Doc  string
+	// the type the way it's written in a .mime file e.g. @user.id or
+	// text ("work" "home")
+	Type string
+	// the entity or named enum the type links to, if any
+	Entity string
+	Enum   string
+	// the attributes the way they're written in a .mime file
+	Attributes string
+	// the mixin the field came from
+	Mixin string
+	// whether the field is in the payload and if it can be left out
+	Payload string
+	// whether the field is in the response and if it can be null
+	Response string
+}
+
+// a reference from From.Field to To.Target. an embed has no target field
+type reference struct {
+	From, Field, To, Target string
+	Embed                   bool
+}
+
+type enumPage struct {
+	Name    string
+	Doc     string
+	Backing string
+	Members []types.EnumMember
+	// the fields that hold one of its members
+	UsedBy []*reference
+}
+
+type routeRow struct {
+	Doc      string
+	Method   string
+	Path     string
+	Action   string
+	Entity   string
+	Match    string
+	Fallback string
+	Admin    bool
+}
+
+var typeNames = map[types.DataType]string{
+	types.DataText:      "text",
+	types.DataInt:       "int",
+	types.DataReal:      "float",
+	types.DataBool:      "bool",
+	types.DataUUID:      "uuid",
+	types.DataTimestamp: "timestamp",
+	types.DataDecimal:   "decimal",
+}
+
+// the order attributes are written in
+var attributes = []types.Attribute{
+	types.AttrPrimary, types.AttrUnique, types.AttrRequired, types.AttrIncrement,
+	types.AttrDefault, types.AttrOnUpdate, types.AttrHash, types.AttrHidden,
+	types.AttrReadonly, types.AttrOverride, types.AttrLength, types.AttrPattern,
+	types.AttrCheck,
+}
+
+func newSite(s *types.Schema) *site {
+	out := &site{Fingerprint: ir.Hash(s).Schema}
+	pages := make(map[string]*entityPage)
+	enums := make(map[*types.EnumNode]*enumPage)
+
+	for _, enum := range s.Enums {
+		page := &enumPage{Name: enum.Name, Doc: enum.Doc, Backing: typeNames[enum.Backing], Members: enum.Members}
+		enums[enum] = page
+		out.Enums = append(out.Enums, page)
+	}
+	for _, e := range s.Entities {
+		page := &entityPage{Name: e.Name, Doc: e.Doc, SoftDelete: e.SoftDelete}
+		pages[e.Name] = page
+		out.Entities = append(out.Entities, page)
+	}
+
+	for _, e := range s.Entities {
+		page := pages[e.Name]
+		payload := make(map[*types.Field]bool)
+		for _, f := range e.PayloadFields() {
+			payload[f] = true
+		}
+		response := make(map[*types.Field]bool)
+		for _, f := range e.ResponseFields() {
+			response[f] = true
+		}
+
+		for _, f := range e.Fields {
+			row := &fieldRow{Name: f.Name, Doc: f.Doc, Type: typeOf(f), Attributes: attributesOf(f)}
+			if f.Origin != nil {
+				row.Mixin = f.Origin.Mixin
+			}
+			switch {
+			case !payload[f]:
+			case f.Nullable() || f.Default != nil:
+				row.Payload = "optional"
+			default:
+				row.Payload = "required"
+			}
+			switch {
+			case !response[f]:
+			case f.Nullable() || f.Kind == types.FieldComputed && f.Computed.IsAggregate():
+				row.Response = "nullable"
+			default:
+				row.Response = "always"
+			}
+
+			var ref *reference
+			switch f.Kind {
+			case types.FieldReference:
+				row.Entity = f.Target.Entity
+				ref = &reference{From: e.Name, Field: f.Name, To: f.Target.Entity, Target: f.Target.Field}
+			case types.FieldEmbedded:
+				row.Entity = f.Name
+				ref = &reference{From: e.Name, Field: f.Name, To: f.Name, Embed: true}
+			default:
+				if f.Enum != nil && !f.Enum.Inline() {
+					row.Enum = f.Enum.Name
+					if page := enums[f.Enum]; page != nil {
+						page.UsedBy = append(page.UsedBy, &reference{From: e.Name, Field: f.Name, To: f.Enum.Name})
+					}
+				}
+			}
+			if ref != nil {
+				page.Outgoing = append(page.Outgoing, ref)
+				if to := pages[ref.To]; to != nil {
+					to.Incoming = append(to.Incoming, ref)
+				}
+			}
+			page.Fields = append(page.Fields, row)
+		}
+	}
+
+	for _, r := range s.Routes {
+		row := &routeRow{
+			Doc:    r.Doc,
+			Method: r.Method,
+			Path:   r.Path,
+			Action: r.Action.String(),
+			Entity: r.Entity,
+			Admin:  r.Options&types.RouteAdmin != 0,
+		}
+		if m := r.Match; m != nil {
+			row.Match = "@" + r.Entity
+			if m.Field != "" {
+				row.Match += "." + m.Field
+			}
+			row.Match += " == " + m.Source
+		}
+		if fb := r.Fallback; fb != nil {
+			row.Fallback = strings.TrimSpace(fmt.Sprintf("%d %s", fb.Status, fb.Message))
+		}
+		out.Routes = append(out.Routes, row)
+		if page := pages[r.Entity]; page != nil {
+			page.Routes = append(page.Routes, row)
+		}
+	}
+	return out
+}
+
+// typeOf writes f's type the way it's declared
+func typeOf(f *types.Field) string {
+	switch f.Kind {
+	case types.FieldReference:
+		return "@" + f.Target.Entity + "." + f.Target.Field
+	case types.FieldEmbedded:
+		return "@" + f.Name
+	}
+
+	var t string
+	switch {
+	case f.Enum != nil && f.Enum.Inline():
+		values := make([]string, len(f.Enum.Members))
+		for i, m := range f.Enum.Members {
+			values[i] = strconv.Quote(m.Value)
+		}
+		t = typeNames[f.Enum.Backing] + " (" + strings.Join(values, " ") + ")"
+	case f.Enum != nil:
+		t = "&" + f.Enum.Name
+	default:
+		t = typeNames[f.DataType]
+	}
+	if f.Kind == types.FieldComputed {
+		t += " = " + f.Computed.String()
+	}
+	return t
+}
+
+// attributesOf writes f's attributes the way they're declared, without the
+// brackets
+func attributesOf(f *types.Field) string {
+	var out []string
+	for _, a := range attributes {
+		if f.Attributes&a == 0 {
+			continue
+		}
+		name := types.AttributeName(a)
+		switch a {
+		case types.AttrDefault:
+			name += ":" + f.Default.String()
+		case types.AttrOnUpdate:
+			name += ":" + f.OnUpdate.String()
+		case types.AttrLength:
+			if f.Length != nil {
+				name += ":" + f.Length.String()
+			}
+		case types.AttrPattern:
+			name += ":" + strconv.Quote(f.Pattern)
+		case types.AttrCheck:
+			name += ":" + f.Check.String()
+		}
+		out = append(out, name)
+	}
+	return strings.Join(out, " ")
+}
+
+// page is what every template is executed with. Root leads back to the
+// output directory from wherever the page is
+type page struct {
+	Root   string
+	Title  string
+	Site   *site
+	Entity *entityPage
+	Enum   *enumPage
+}
+
+// routeTable is what the routes template is executed with since it's used
+// on pages at different depths
+func routeTable(root string, routes []*routeRow) map[string]any {
+	return map[string]any{"Root": root, "Routes": routes}
+}
+
+// render writes every page of the site with execute, which runs the named
+// template
+func render(s *types.Schema, ext string, execute func(name string, p page) (string, error)) (map[string]string, error) {
+	st := newSite(s)
+	files := make(map[string]string)
+
+	out, err := execute("index", page{Title: "schema", Site: st})
+	if err != nil {
+		return nil, err
+	}
+	files["index"+ext] = out
+	for _, e := range st.Entities {
+		out, err := execute("entity", page{Root: "../", Title: e.Name, Site: st, Entity: e})
+		if err != nil {
+			return nil, fmt.Errorf("entity '%s': %w", e.Name, err)
+		}
+		files["entities/"+e.Name+ext] = out
+	}
+	for _, enum := range st.Enums {
+		out, err := execute("enum", page{Root: "../", Title: enum.Name, Site: st, Enum: enum})
+		if err != nil {
+			return nil, fmt.Errorf("enum '%s': %w", enum.Name, err)
+		}
+		files["enums/"+enum.Name+ext] = out
+	}
+	return files, nil
+}
@@ -0,0 +1,189 @@
+package docs
+
+import (
+	"html/template"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// HTML writes the site as standalone html pages. the styles are inlined so
+// the pages need nothing but each other
+func HTML(s *types.Schema) (map[string]string, error) {
+	return render(s, ".html", func(name string, p page) (string, error) {
+		var b strings.Builder
+		if err := htmlTemplates.ExecuteTemplate(&b, name, p); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	})
+}
+
+var htmlTemplates = template.Must(template.New("docs").Funcs(template.FuncMap{"routes": routeTable}).Parse(`
+{{- define "head" -}}
+<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; display: flex; font: 15px/1.5 system-ui, sans-serif; color: #222; }
+nav { width: 14em; flex-shrink: 0; padding: 1em; background: #f5f5f5; min-height: 100vh; }
+nav ul { list-style: none; padding: 0; margin: 0 0 1em; }
+nav h2 { font-size: 0.8em; text-transform: uppercase; color: #777; margin: 1em 0 0.3em; }
+main { padding: 1em 2em; max-width: 70em; overflow-x: auto; }
+a { color: #0b5cad; text-decoration: none; }
+a:hover { text-decoration: underline; }
+table { border-collapse: collapse; margin: 0.5em 0 1.5em; }
+th, td { text-align: left; vertical-align: top; padding: 0.3em 0.8em; border-bottom: 1px solid #e3e3e3; }
+th { font-weight: 600; background: #fafafa; }
+code { font: 0.9em ui-monospace, monospace; background: #f2f2f2; padding: 0.05em 0.3em; border-radius: 3px; }
+.doc { white-space: pre-line; color: #444; }
+.muted { color: #888; }
+.tag { font-size: 0.8em; padding: 0.1em 0.5em; border-radius: 1em; background: #e6eef8; }
+footer { margin-top: 3em; font-size: 0.8em; color: #888; }
+</style>
+</head>
+<body>
+<nav>
+<a href="{{.Root}}index.html"><strong>schema</strong></a>
+<h2>entities</h2>
+<ul>
+{{- range .Site.Entities}}
+<li><a href="{{$.Root}}entities/{{.Name}}.html">{{.Name}}</a></li>
+{{- end}}
+</ul>
+{{- if .Site.Enums}}
+<h2>enums</h2>
+<ul>
+{{- range .Site.Enums}}
+<li><a href="{{$.Root}}enums/{{.Name}}.html">{{.Name}}</a></li>
+{{- end}}
+</ul>
+{{- end}}
+</nav>
+<main>
+{{end}}
+
+{{- define "foot" -}}
+<footer>generated by mime; fingerprint <code>{{.Site.Fingerprint}}</code></footer>
+</main>
+</body>
+</html>
+{{end}}
+
+{{- define "routes" -}}
+<table>
+<tr><th>method</th><th>path</th><th>action</th><th>entity</th><th>matches</th><th>otherwise</th></tr>
+{{- range .Routes}}
+<tr>
+<td><code>{{.Method}}</code></td>
+<td><code>{{.Path}}</code>{{if .Admin}} <span class="tag">admin</span>{{end}}{{if .Doc}}<div class="doc">{{.Doc}}</div>{{end}}</td>
+<td>{{.Action}}</td>
+<td><a href="{{$.Root}}entities/{{.Entity}}.html">{{.Entity}}</a></td>
+<td>{{if .Match}}<code>{{.Match}}</code>{{end}}</td>
+<td>{{.Fallback}}</td>
+</tr>
+{{- end}}
+</table>
+{{end}}
+
+{{- define "index" -}}
+{{template "head" .}}
+<h1>schema</h1>
+<h2>entities</h2>
+<table>
+<tr><th>entity</th><th>fields</th><th></th></tr>
+{{- range .Site.Entities}}
+<tr><td><a href="entities/{{.Name}}.html">{{.Name}}</a></td><td>{{len .Fields}}</td><td class="doc">{{.Doc}}</td></tr>
+{{- end}}
+</table>
+{{- if .Site.Enums}}
+<h2>enums</h2>
+<table>
+<tr><th>enum</th><th>members</th><th></th></tr>
+{{- range .Site.Enums}}
+<tr><td><a href="enums/{{.Name}}.html">{{.Name}}</a></td><td>{{len .Members}}</td><td class="doc">{{.Doc}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Site.Routes}}
+<h2>routes</h2>
+{{template "routes" (routes .Root .Site.Routes)}}
+{{- end}}
+{{template "foot" .}}
+{{- end}}
+
+{{- define "entity" -}}
+{{template "head" .}}
+{{- with .Entity}}
+<h1>{{.Name}}{{if .SoftDelete}} <span class="tag">soft delete</span>{{end}}</h1>
+{{- if .Doc}}
+<p class="doc">{{.Doc}}</p>
+{{- end}}
+<h2>fields</h2>
+<table>
+<tr><th>field</th><th>type</th><th>attributes</th><th>payload</th><th>response</th><th></th></tr>
+{{- range .Fields}}
+<tr>
+<td><code>{{.Name}}</code></td>
+<td>{{if .Entity}}<a href="{{$.Root}}entities/{{.Entity}}.html"><code>{{.Type}}</code></a>{{else if .Enum}}<a href="{{$.Root}}enums/{{.Enum}}.html"><code>{{.Type}}</code></a>{{else}}<code>{{.Type}}</code>{{end}}</td>
+<td>{{if .Attributes}}<code>{{.Attributes}}</code>{{end}}</td>
+<td>{{or .Payload "—"}}</td>
+<td>{{or .Response "—"}}</td>
+<td>{{if .Doc}}<div class="doc">{{.Doc}}</div>{{end}}{{if .Mixin}}<div class="muted">from {{.Mixin}}</div>{{end}}</td>
+</tr>
+{{- end}}
+</table>
+{{- if .Outgoing}}
+<h2>references</h2>
+<table>
+<tr><th>field</th><th>points at</th></tr>
+{{- range .Outgoing}}
+<tr><td><code>{{.Field}}</code></td><td><a href="{{$.Root}}entities/{{.To}}.html">{{.To}}</a>{{if .Embed}} <span class="muted">embedded</span>{{else}}<code>.{{.Target}}</code>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Incoming}}
+<h2>referenced by</h2>
+<table>
+<tr><th>entity</th><th>field</th></tr>
+{{- range .Incoming}}
+<tr><td><a href="{{$.Root}}entities/{{.From}}.html">{{.From}}</a></td><td><code>{{.Field}}</code>{{if .Embed}} <span class="muted">embedded</span>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Routes}}
+<h2>routes</h2>
+{{template "routes" (routes $.Root .Routes)}}
+{{- end}}
+{{- end}}
+{{template "foot" .}}
+{{- end}}
+
+{{- define "enum" -}}
+{{template "head" .}}
+{{- with .Enum}}
+<h1>{{.Name}} <span class="tag">enum of {{.Backing}}</span></h1>
+{{- if .Doc}}
+<p class="doc">{{.Doc}}</p>
+{{- end}}
+<table>
+<tr><th>member</th><th>value</th><th>label</th><th></th></tr>
+{{- range .Members}}
+<tr><td><code>{{.Name}}</code></td><td><code>{{.Value}}</code></td><td>{{.Label}}</td><td>{{.Description}}{{if .Deprecated}} <span class="tag">deprecated</span>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- if .UsedBy}}
+<h2>used by</h2>
+<ul>
+{{- range .UsedBy}}
+<li><a href="{{$.Root}}entities/{{.From}}.html">{{.From}}</a><code>.{{.Field}}</code></li>
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}
+{{template "foot" .}}
+{{- end}}
+`))
@@ -0,0 +1,160 @@
+package docs
+
+import (
+	"strings"
+	"text/template"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// Markdown writes the site as github flavoured markdown pages for wikis
+func Markdown(s *types.Schema) (map[string]string, error) {
+	return render(s, ".md", func(name string, p page) (string, error) {
+		var b strings.Builder
+		if err := markdownTemplates.ExecuteTemplate(&b, name, p); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	})
+}
+
+// cell keeps text inside a table cell: pipes would end it and newlines would
+// end the row
+func cell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", "<br>").Replace(s)
+}
+
+// code wraps s in a code span that survives whatever backticks it holds
+func code(s string) string {
+	if s == "" {
+		return ""
+	}
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		return fence + " " + cell(s) + " " + fence
+	}
+	return fence + cell(s) + fence
+}
+
+var markdownTemplates = template.Must(template.New("docs").Funcs(template.FuncMap{
+	"routes": routeTable,
+	"cell":   cell,
+	"code":   code,
+}).Parse(`
+{{- define "foot" -}}
+<sub>generated by mime; fingerprint ` + "`{{.Site.Fingerprint}}`" + `</sub>
+{{end}}
+
+{{- define "routes" -}}
+| method | path | action | entity | matches | otherwise |
+| --- | --- | --- | --- | --- | --- |
+{{- range .Routes}}
+| {{code .Method}} | {{code .Path}}{{if .Admin}} (admin){{end}}{{if .Doc}}<br>{{cell .Doc}}{{end}} | {{.Action}} | [{{cell .Entity}}]({{$.Root}}entities/{{.Entity}}.md) | {{code .Match}} | {{cell .Fallback}} |
+{{- end}}{{end}}
+
+{{- define "index" -}}
+# schema
+
+## entities
+
+| entity | fields | |
+| --- | --- | --- |
+{{- range .Site.Entities}}
+| [{{cell .Name}}](entities/{{.Name}}.md) | {{len .Fields}} | {{cell .Doc}} |
+{{- end}}
+{{- if .Site.Enums}}
+
+## enums
+
+| enum | members | |
+| --- | --- | --- |
+{{- range .Site.Enums}}
+| [{{cell .Name}}](enums/{{.Name}}.md) | {{len .Members}} | {{cell .Doc}} |
+{{- end}}
+{{- end}}
+{{- if .Site.Routes}}
+
+## routes
+
+{{template "routes" (routes .Root .Site.Routes)}}
+{{- end}}
+
+{{template "foot" .}}
+{{- end}}
+
+{{- define "entity" -}}
+[schema]({{.Root}}index.md)
+{{with .Entity}}
+# {{.Name}}{{if .SoftDelete}} (soft delete){{end}}
+{{- if .Doc}}
+
+{{.Doc}}
+{{- end}}
+
+## fields
+
+| field | type | attributes | payload | response | |
+| --- | --- | --- | --- | --- | --- |
+{{- range .Fields}}
+| {{code .Name}} | {{if .Entity}}[{{code .Type}}]({{$.Root}}entities/{{.Entity}}.md){{else if .Enum}}[{{code .Type}}]({{$.Root}}enums/{{.Enum}}.md){{else}}{{code .Type}}{{end}} | {{code .Attributes}} | {{or .Payload "—"}} | {{or .Response "—"}} | {{cell .Doc}}{{if .Mixin}}{{if .Doc}}<br>{{end}}from {{cell .Mixin}}{{end}} |
+{{- end}}
+{{- if .Outgoing}}
+
+## references
+
+| field | points at |
+| --- | --- |
+{{- range .Outgoing}}
+| {{code .Field}} | [{{cell .To}}]({{$.Root}}entities/{{.To}}.md){{if .Embed}} (embedded){{else}}{{code (print "." .Target)}}{{end}} |
+{{- end}}
+{{- end}}
+{{- if .Incoming}}
+
+## referenced by
+
+| entity | field |
+| --- | --- |
+{{- range .Incoming}}
+| [{{cell .From}}]({{$.Root}}entities/{{.From}}.md) | {{code .Field}}{{if .Embed}} (embedded){{end}} |
+{{- end}}
+{{- end}}
+{{- if .Routes}}
+
+## routes
+
+{{template "routes" (routes $.Root .Routes)}}
+{{- end}}
+{{- end}}
+
+{{template "foot" .}}
+{{- end}}
+
+{{- define "enum" -}}
+[schema]({{.Root}}index.md)
+{{with .Enum}}
+# {{.Name}} (enum of {{.Backing}})
+{{- if .Doc}}
+
+{{.Doc}}
+{{- end}}
+
+| member | value | label | |
+| --- | --- | --- | --- |
+{{- range .Members}}
+| {{code .Name}} | {{code .Value}} | {{cell .Label}} | {{cell .Description}}{{if .Deprecated}} (deprecated){{end}} |
+{{- end}}
+{{- if .UsedBy}}
+
+## used by
+{{range .UsedBy}}
+- [{{.From}}]({{$.Root}}entities/{{.From}}.md){{code (print "." .Field)}}
+{{- end}}
+{{- end}}
+{{- end}}
+
+{{template "foot" .}}
+{{- end}}
+`))
@@ -0,0 +1,73 @@
+package docs
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/testutil"
+)
+
+// every format's pages are compared with testdata/notes.<format>.golden,
+// one after the other under a -- path -- line
+func TestFormats(t *testing.T) {
+	s := testutil.Load(t, filepath.Join(testutil.Shared, "notes.mime"))
+	for name, render := range Formats {
+		t.Run(name, func(t *testing.T) {
+			files, err := render(s)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var b strings.Builder
+			for _, path := range slices.Sorted(maps.Keys(files)) {
+				b.WriteString("-- " + path + " --\n" + files[path])
+			}
+			testutil.Golden(t, "notes."+name+".golden", b.String())
+		})
+	}
+}
+
+// every relative link has to lead to a page that was written
+func TestLinks(t *testing.T) {
+	s := testutil.Load(t, filepath.Join(testutil.Shared, "notes.mime"))
+	for name, render := range Formats {
+		files, err := render(s)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		for path, page := range files {
+			for _, link := range links(page) {
+				if strings.Contains(link, "://") {
+					t.Errorf("%s: %s links outside the site: %s", name, path, link)
+					continue
+				}
+				target := filepath.ToSlash(filepath.Join(filepath.Dir(path), link))
+				if _, ok := files[target]; !ok {
+					t.Errorf("%s: %s links to %s which wasn't written", name, path, link)
+				}
+			}
+		}
+	}
+}
+
+// links finds the targets of html hrefs and markdown links
+func links(page string) []string {
+	var out []string
+	for _, open := range []string{`href="`, `](`} {
+		rest := page
+		for {
+			i := strings.Index(rest, open)
+			if i < 0 {
+				break
+			}
+			rest = rest[i+len(open):]
+			end := strings.IndexAny(rest, `")`)
+			out = append(out, rest[:end])
+			rest = rest[end:]
+		}
+	}
+	return out
+}
@@ -0,0 +1,232 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// the importers turn schemas written for other tools into .mime source so
+// an existing database or api can be brought under mime without retyping
+// it. each one builds a schema and hands it to write along with notes for
+// whatever didn't carry over; those end up as comments next to where they
+// would have gone rather than being dropped
+
+// notes are the comments an importer leaves behind
+type notes struct {
+	file     []string
+	entities map[string][]string
+	// keyed on entity.field
+	fields map[string][]string
+}
+
+func newNotes() *notes {
+	return &notes{entities: make(map[string][]string), fields: make(map[string][]string)}
+}
+
+func (n *notes) top(format string, args ...any) {
+	n.file = append(n.file, fmt.Sprintf(format, args...))
+}
+
+func (n *notes) entity(entity, format string, args ...any) {
+	n.entities[entity] = append(n.entities[entity], fmt.Sprintf(format, args...))
+}
+
+func (n *notes) field(entity, field, format string, args ...any) {
+	key := entity + "." + field
+	n.fields[key] = append(n.fields[key], fmt.Sprintf(format, args...))
+}
+
+// ident turns a name from elsewhere into one the lexer reads as a single
+// identifier. renamed reports whether it had to change
+func ident(name string) (id string, renamed bool) {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+			b.WriteRune(r)
+		case unicode.IsDigit(r):
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+
+	id = b.String()
+	if id == "" {
+		id = "_"
+	}
+	// field and entity names can't be keywords, and and/or read as operators
+	// inside checks and computed fields
+	if _, ok := lexer.Keywords[id]; ok || id == "and" || id == "or" {
+		id += "_"
+	}
+	return id, id != name
+}
+
+// softDelete turns on soft_delete for an entity with a nullable deleted_at
+// timestamp, which is how soft deletes are stored
+func softDelete(e *types.EntityNode) {
+	if f := e.Field(types.SoftDeleteField); f != nil && f.Kind == types.FieldPrimitive &&
+		f.DataType == types.DataTimestamp && f.Attributes&(types.AttrRequired|types.AttrDefault) == 0 {
+		_ = e.EnableSoftDelete()
+	}
+}
+
+var dataTypeNames = map[types.DataType]string{
+	types.DataText:      "text",
+	types.DataInt:       "int",
+	types.DataReal:      "float",
+	types.DataBool:      "bool",
+	types.DataUUID:      "uuid",
+	types.DataTimestamp: "timestamp",
+	types.DataDecimal:   "decimal",
+}
+
+// attributes are written in this order whatever order they were found in
+var attributeOrder = []types.Attribute{
+	types.AttrPrimary,
+	types.AttrUnique,
+	types.AttrRequired,
+	types.AttrIncrement,
+	types.AttrReadonly,
+	types.AttrHidden,
+	types.AttrHash,
+	types.AttrDefault,
+	types.AttrOnUpdate,
+	types.AttrLength,
+	types.AttrPattern,
+	types.AttrCheck,
+}
+
+// write renders the schema as .mime source. header goes at the very top as
+// a comment and every note is written above what it's about
+func write(header string, s *types.Schema, n *notes) string {
+	var b strings.Builder
+	comment(&b, "", header)
+	for _, note := range n.file {
+		comment(&b, "", note)
+	}
+
+	for _, enum := range s.Enums {
+		b.WriteString("\n")
+		writeEnum(&b, enum)
+	}
+
+	for _, e := range s.Entities {
+		b.WriteString("\n")
+		for _, note := range n.entities[e.Name] {
+			comment(&b, "", note)
+		}
+
+		b.WriteString("entity " + e.Name)
+		if e.SoftDelete {
+			b.WriteString(" [soft_delete]")
+		}
+		b.WriteString(" ->\n")
+		for _, f := range e.Fields {
+			// soft_delete brings its own
+			if e.SoftDelete && f.Name == types.SoftDeleteField {
+				continue
+			}
+			for _, note := range n.fields[e.Name+"."+f.Name] {
+				comment(&b, "\t", note)
+			}
+			b.WriteString("\t" + field(f) + "\n")
+		}
+		b.WriteString("end\n")
+	}
+
+	return b.String()
+}
+
+func comment(b *strings.Builder, indent, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(strings.TrimRight(indent+"# "+line, " ") + "\n")
+	}
+}
+
+func writeEnum(b *strings.Builder, enum *types.EnumNode) {
+	b.WriteString("enum " + enum.Name + " ->\n")
+	for _, m := range enum.Members {
+		b.WriteString("\t" + m.Name)
+		if m.Value != "" && m.Value != m.Name {
+			if enum.Backing == types.DataInt {
+				b.WriteString(" = " + m.Value)
+			} else {
+				b.WriteString(` = "` + m.Value + `"`)
+			}
+		}
+		if m.Label != "" || m.Description != "" {
+			b.WriteString(` "` + m.Label + `"`)
+		}
+		if m.Description != "" {
+			b.WriteString(` "` + m.Description + `"`)
+		}
+		if m.Deprecated {
+			b.WriteString(" [deprecated]")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("end\n")
+}
+
+func field(f *types.Field) string {
+	var line string
+	switch {
+	case f.Kind == types.FieldEmbedded:
+		return "@" + f.Name
+	case f.Kind == types.FieldReference:
+		line = fmt.Sprintf("%s @%s.%s", f.Name, f.Target.Entity, f.Target.Field)
+	case f.DataType == types.DataEnum:
+		line = fmt.Sprintf("%s &%s", f.Name, f.Enum.Name)
+	default:
+		line = f.Name + " " + dataTypeNames[f.DataType]
+		if f.Enum != nil {
+			values := make([]string, 0, len(f.Enum.Members))
+			for _, m := range f.Enum.Members {
+				if f.DataType == types.DataText {
+					values = append(values, `"`+m.Value+`"`)
+				} else {
+					values = append(values, m.Value)
+				}
+			}
+			line += " (" + strings.Join(values, " ") + ")"
+		}
+		if f.Kind == types.FieldComputed {
+			line += " = " + f.Computed.String()
+		}
+	}
+
+	var attrs []string
+	for _, attr := range attributeOrder {
+		if f.Attributes&attr == 0 {
+			continue
+		}
+		name := types.AttributeName(attr)
+		switch attr {
+		case types.AttrDefault:
+			name += ":" + f.Default.String()
+		case types.AttrOnUpdate:
+			name += ":" + f.OnUpdate.String()
+		case types.AttrLength:
+			name += ":" + f.Length.String()
+		case types.AttrPattern:
+			name += `:"` + f.Pattern + `"`
+		case types.AttrCheck:
+			name += ":" + f.Check.String()
+		}
+		attrs = append(attrs, name)
+	}
+	if len(attrs) > 0 {
+		line += " [" + strings.Join(attrs, " ") + "]"
+	}
+
+	return line
+}
@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// json documents are read into these instead of map[string]any so that
+// properties come out in the order they were written, which is the order
+// fields get declared in
+
+type jsonObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func (o *jsonObject) get(key string) any {
+	if o == nil {
+		return nil
+	}
+	return o.values[key]
+}
+
+func (o *jsonObject) object(key string) *jsonObject {
+	v, _ := o.get(key).(*jsonObject)
+	return v
+}
+
+func (o *jsonObject) str(key string) string {
+	v, _ := o.get(key).(string)
+	return v
+}
+
+func (o *jsonObject) list(key string) []any {
+	v, _ := o.get(key).([]any)
+	return v
+}
+
+func (o *jsonObject) number(key string) (json.Number, bool) {
+	v, ok := o.get(key).(json.Number)
+	return v, ok
+}
+
+func (o *jsonObject) flag(key string) bool {
+	v, _ := o.get(key).(bool)
+	return v
+}
+
+// strs returns the strings in a list like required, skipping anything else
+func (o *jsonObject) strs(key string) []string {
+	var out []string
+	for _, v := range o.list(key) {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// decodeJSON reads a whole document. numbers are kept as json.Number so
+// integers don't pass through a float
+func decodeJSON(src []byte) (*jsonObject, error) {
+	if trimmed := bytes.TrimSpace(src); len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, errors.New("expected a json object; convert yaml documents to json first")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	v, err := jsonValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the document")
+	}
+	return v.(*jsonObject), nil
+}
+
+func jsonValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		o := &jsonObject{values: make(map[string]any)}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := jsonValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			k := key.(string)
+			if _, ok := o.values[k]; !ok {
+				o.keys = append(o.keys, k)
+			}
+			o.values[k] = v
+		}
+		_, err := dec.Token()
+		return o, err
+	case json.Delim('['):
+		list := []any{}
+		for dec.More() {
+			v, err := jsonValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		_, err := dec.Token()
+		return list, err
+	case json.Delim('}'), json.Delim(']'):
+		return nil, fmt.Errorf("unexpected %v", tok)
+	}
+	return tok, nil
+}
@@ -0,0 +1,627 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// JSONSchema reads a json schema document. every object schema under $defs
+// or definitions becomes an entity, as does the root when it describes an
+// object, and enums become enum declarations. name is used in the header
+// and to name an untitled root
+func JSONSchema(name string, src []byte) (string, error) {
+	doc, err := decodeJSON(src)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+
+	im := newSchemaImport()
+	for _, key := range []string{"$defs", "definitions"} {
+		im.define("#/"+key+"/", doc.object(key))
+	}
+	if doc.str("type") == "object" || doc.object("properties") != nil {
+		root := doc.str("title")
+		if root == "" {
+			root, _, _ = strings.Cut(name, ".")
+		}
+		im.add("#", root, doc)
+	}
+	return im.write(fmt.Sprintf("imported from %s by mime import jsonschema", name)), nil
+}
+
+// OpenAPI reads the component schemas of an openapi 3 document, or the
+// definitions of a swagger 2.0 one, the same way JSONSchema reads $defs
+func OpenAPI(name string, src []byte) (string, error) {
+	doc, err := decodeJSON(src)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+
+	im := newSchemaImport()
+	switch {
+	case strings.HasPrefix(doc.str("openapi"), "3."):
+		im.define("#/components/schemas/", doc.object("components").object("schemas"))
+	case doc.str("swagger") == "2.0":
+		im.define("#/definitions/", doc.object("definitions"))
+	default:
+		return "", fmt.Errorf("%s: expected an openapi 3 or swagger 2.0 document", name)
+	}
+	if paths := doc.object("paths"); paths != nil && len(paths.keys) > 0 {
+		im.notes.top("%d paths aren't imported; only the schemas are", len(paths.keys))
+	}
+	return im.write(fmt.Sprintf("imported from %s by mime import openapi", name)), nil
+}
+
+type schemaImport struct {
+	schema *types.Schema
+	notes  *notes
+	defs   []*schemaDef
+	// keyed on the $ref that points at them
+	refs map[string]*schemaDef
+}
+
+// a named schema from the document and whatever it became. aliases like
+// `Email: {type: string, format: email}` become neither and are read in
+// place of the $refs that point at them
+type schemaDef struct {
+	source string
+	schema *jsonObject
+	enum   *types.EnumNode
+	entity *types.EntityNode
+}
+
+func newSchemaImport() *schemaImport {
+	return &schemaImport{schema: &types.Schema{}, notes: newNotes(), refs: make(map[string]*schemaDef)}
+}
+
+func (im *schemaImport) define(prefix string, defs *jsonObject) {
+	if defs == nil {
+		return
+	}
+	for _, key := range defs.keys {
+		if s, ok := defs.get(key).(*jsonObject); ok {
+			im.add(prefix+key, key, s)
+		}
+	}
+}
+
+func (im *schemaImport) add(ref, source string, s *jsonObject) {
+	d := &schemaDef{source: source, schema: s}
+	im.defs = append(im.defs, d)
+	im.refs[ref] = d
+
+	name, renamed := ident(source)
+	taken := im.schema.Entity(name) != nil || slices.ContainsFunc(im.schema.Enums, func(e *types.EnumNode) bool {
+		return e.Name == name
+	})
+
+	switch {
+	case s.get("enum") != nil && schemaType(s) != "object":
+		if taken {
+			im.notes.top("skipped a second schema called %s", name)
+			return
+		}
+		enum, err := schemaEnum(name, s)
+		if err != nil {
+			im.notes.top("schema %s isn't imported: %v", source, err)
+			return
+		}
+		d.enum = enum
+		im.schema.Enums = append(im.schema.Enums, enum)
+	case isObject(s):
+		if taken {
+			im.notes.top("skipped a second schema called %s", name)
+			return
+		}
+		d.entity = &types.EntityNode{Name: name}
+		im.schema.Entities = append(im.schema.Entities, d.entity)
+		if renamed {
+			im.notes.entity(name, "was schema %q", source)
+		}
+	case s.list("oneOf") != nil || s.list("anyOf") != nil:
+		im.notes.top("%s: oneOf/anyOf schemas aren't imported", source)
+	}
+}
+
+// isObject reports whether s describes an object with fields of its own or
+// made up out of others with allOf
+func isObject(s *jsonObject) bool {
+	if schemaType(s) == "object" || s.object("properties") != nil {
+		return true
+	}
+	for _, part := range s.list("allOf") {
+		if p, ok := part.(*jsonObject); ok && (p.object("properties") != nil || p.str("$ref") != "") {
+			return true
+		}
+	}
+	return false
+}
+
+func (im *schemaImport) write(header string) string {
+	for _, d := range im.defs {
+		if d.entity != nil {
+			im.entity(d)
+		}
+	}
+	return write(header, im.schema, im.notes)
+}
+
+type schemaProperty struct {
+	name   string
+	schema *jsonObject
+}
+
+// properties collects an object's properties in order, including the ones
+// it takes from the schemas in its allOf
+func (im *schemaImport) properties(s *jsonObject, required map[string]bool, seen map[*jsonObject]bool) []schemaProperty {
+	if seen[s] {
+		return nil
+	}
+	seen[s] = true
+
+	var props []schemaProperty
+	for _, part := range s.list("allOf") {
+		p, ok := part.(*jsonObject)
+		if !ok {
+			continue
+		}
+		if d := im.refs[p.str("$ref")]; d != nil {
+			p = d.schema
+		}
+		props = append(props, im.properties(p, required, seen)...)
+	}
+	for _, name := range s.strs("required") {
+		required[name] = true
+	}
+	if o := s.object("properties"); o != nil {
+		for _, key := range o.keys {
+			if p, ok := o.get(key).(*jsonObject); ok {
+				props = append(props, schemaProperty{name: key, schema: p})
+			}
+		}
+	}
+	return props
+}
+
+func (im *schemaImport) entity(d *schemaDef) {
+	e := d.entity
+	if desc := d.schema.str("description"); desc != "" {
+		im.notes.entity(e.Name, "%s", desc)
+	}
+
+	required := make(map[string]bool)
+	for _, p := range im.properties(d.schema, required, make(map[*jsonObject]bool)) {
+		name, _ := ident(p.name)
+		if e.Field(name) != nil {
+			im.notes.entity(e.Name, "property %s isn't imported; there's already a field called %s", p.name, name)
+			continue
+		}
+		f, why := im.field(e, p.name, p.schema, required[p.name])
+		if f == nil {
+			im.notes.entity(e.Name, "property %s isn't imported: %s", p.name, why)
+			continue
+		}
+		e.Fields = append(e.Fields, f)
+	}
+
+	softDelete(e)
+}
+
+// field turns a property into a field. a nil field comes with the reason it
+// couldn't be
+func (im *schemaImport) field(e *types.EntityNode, prop string, s *jsonObject, required bool) (*types.Field, string) {
+	name, renamed := ident(prop)
+	var notes []string
+	note := func(format string, args ...any) {
+		notes = append(notes, fmt.Sprintf(format, args...))
+	}
+	if renamed {
+		note("was property %q", prop)
+	}
+	if desc := s.str("description"); desc != "" {
+		note("%s", desc)
+	}
+	if s.flag("deprecated") {
+		note("deprecated")
+	}
+
+	s, nullable := unwrap(s)
+	f := &types.Field{Name: name, Kind: types.FieldPrimitive}
+
+	// aliases are read through, so a $ref ends in an entity, an enum or
+	// a schema with a type
+	for range 8 {
+		ref := s.str("$ref")
+		if ref == "" {
+			break
+		}
+		d := im.refs[ref]
+		switch {
+		case d == nil:
+			return nil, ref + " isn't in the file"
+		case d.enum != nil:
+			f.DataType, f.Enum = types.DataEnum, d.enum
+		case d.entity != nil && primaryKey(d.schema):
+			note("was a whole %s; references hold its id", d.source)
+			f.Kind, f.Target = types.FieldReference, &types.ReferenceTarget{Entity: d.entity.Name, Field: "id"}
+		case d.entity != nil && name == d.entity.Name:
+			f.Kind, f.Name = types.FieldEmbedded, d.entity.Name
+		case d.entity != nil:
+			return nil, fmt.Sprintf("%s has no id to reference and can only be embedded as @%s", d.source, d.entity.Name)
+		default:
+			s = merge(s, d.schema)
+			continue
+		}
+		break
+	}
+
+	if f.Kind == types.FieldPrimitive && f.DataType == 0 {
+		if why := im.primitive(f, s, note); why != "" {
+			return nil, why
+		}
+	}
+
+	if s.flag("readOnly") {
+		f.Attributes |= types.AttrReadonly
+	}
+	if s.flag("writeOnly") {
+		f.Attributes |= types.AttrHidden
+	}
+	if required && !nullable {
+		f.Attributes |= types.AttrRequired
+	}
+	if f.Kind == types.FieldPrimitive && name == "id" && (f.DataType == types.DataInt || f.DataType == types.DataUUID) {
+		f.Attributes |= types.AttrPrimary | types.AttrUnique | types.AttrRequired
+	}
+
+	if v := s.get("default"); v != nil {
+		if err := schemaDefault(f, v); err != nil {
+			note("default %v isn't imported: %v", v, err)
+		}
+	}
+
+	for _, n := range notes {
+		im.notes.field(e.Name, f.Name, "%s", n)
+	}
+	return f, ""
+}
+
+// primitive fills in the type and rules of a property that isn't a $ref
+func (im *schemaImport) primitive(f *types.Field, s *jsonObject, note func(string, ...any)) string {
+	typ := schemaType(s)
+	switch typ {
+	case "string":
+		f.DataType = types.DataText
+		switch format := s.str("format"); format {
+		case "":
+		case "uuid":
+			f.DataType = types.DataUUID
+		case "date-time":
+			f.DataType = types.DataTimestamp
+		case "decimal":
+			f.DataType = types.DataDecimal
+		case "date", "time":
+			note("was format %s; imported as text since it isn't a full timestamp", format)
+		default:
+			note("format %s isn't checked", format)
+		}
+	case "integer":
+		f.DataType = types.DataInt
+	case "number":
+		f.DataType = types.DataReal
+	case "boolean":
+		f.DataType = types.DataBool
+	case "array":
+		return "mime has no list type"
+	case "object":
+		return "inline objects need a schema of their own to be embedded"
+	case "":
+		f.DataType = types.DataText
+		note("had no type; imported as text")
+	default:
+		return "type " + typ + " isn't supported"
+	}
+
+	if values := s.list("enum"); values != nil {
+		if err := schemaValues(f, values); err != nil {
+			note("enum isn't imported: %v", err)
+		}
+	}
+
+	if f.DataType == types.DataText {
+		min, hasMin := schemaInt(s, "minLength")
+		max, hasMax := schemaInt(s, "maxLength")
+		if hasMin || hasMax {
+			f.Attributes |= types.AttrLength
+			f.Length = &types.Length{Min: min, Max: max}
+		}
+	}
+
+	if pattern := s.str("pattern"); pattern != "" {
+		_, err := regexp.Compile(pattern)
+		switch {
+		case f.DataType != types.DataText:
+			note("pattern %s isn't imported; only text fields take one", pattern)
+		case err != nil:
+			note("pattern %s isn't imported: go can't compile it", pattern)
+		default:
+			f.Attributes |= types.AttrPattern
+			f.Pattern = pattern
+		}
+	}
+
+	if f.DataType == types.DataInt || f.DataType == types.DataReal {
+		if check := schemaBounds(f.Name, s); check != nil {
+			f.Attributes |= types.AttrCheck
+			f.Check = check
+		}
+		if n, ok := s.number("multipleOf"); ok {
+			note("multipleOf %s isn't checked", n)
+		}
+	}
+
+	return ""
+}
+
+// unwrap looks through the ways a property is usually wrapped: an allOf with
+// a single schema to put a description next to a $ref, and a oneOf or anyOf
+// with null to make it nullable
+func unwrap(s *jsonObject) (*jsonObject, bool) {
+	nullable := s.flag("nullable") || slices.Contains(s.list("enum"), nil)
+	if list, ok := s.get("type").([]any); ok && slices.Contains(list, any("null")) {
+		nullable = true
+	}
+
+	for _, key := range []string{"allOf", "oneOf", "anyOf"} {
+		var parts []*jsonObject
+		for _, part := range s.list(key) {
+			p, ok := part.(*jsonObject)
+			if !ok {
+				continue
+			}
+			if p.str("type") == "null" {
+				nullable = true
+				continue
+			}
+			parts = append(parts, p)
+		}
+		if len(parts) == 1 {
+			inner, null := unwrap(merge(s, parts[0]))
+			return inner, nullable || null
+		}
+	}
+	return s, nullable
+}
+
+// merge is outer with anything it doesn't say taken from inner. the
+// combining keywords that led to inner are dropped
+func merge(outer, inner *jsonObject) *jsonObject {
+	m := &jsonObject{values: make(map[string]any)}
+	for _, o := range []*jsonObject{outer, inner} {
+		for _, key := range o.keys {
+			switch key {
+			case "allOf", "oneOf", "anyOf":
+				continue
+			case "$ref":
+				if o == outer {
+					continue
+				}
+			}
+			if _, ok := m.values[key]; !ok {
+				m.keys = append(m.keys, key)
+				m.values[key] = o.values[key]
+			}
+		}
+	}
+	return m
+}
+
+// schemaType is the one type s allows other than null, or empty if it
+// doesn't say or allows several
+func schemaType(s *jsonObject) string {
+	switch t := s.get("type").(type) {
+	case string:
+		return t
+	case []any:
+		var found string
+		for _, v := range t {
+			if name, ok := v.(string); ok && name != "null" {
+				if found != "" {
+					return ""
+				}
+				found = name
+			}
+		}
+		return found
+	}
+	return ""
+}
+
+// primaryKey reports whether an object schema has an id that can be its
+// primary key and so be referenced
+func primaryKey(s *jsonObject) bool {
+	id, ok := s.object("properties").get("id").(*jsonObject)
+	if !ok {
+		return false
+	}
+	id, _ = unwrap(id)
+	return schemaType(id) == "integer" || schemaType(id) == "string" && id.str("format") == "uuid"
+}
+
+func schemaEnum(name string, s *jsonObject) (*types.EnumNode, error) {
+	enum := &types.EnumNode{Name: name, Backing: types.DataText}
+	if schemaType(s) == "integer" {
+		enum.Backing = types.DataInt
+	}
+	// the names code generators use for each value
+	names := s.strs("x-enum-varnames")
+	if len(names) == 0 {
+		names = s.strs("x-enumNames")
+	}
+	descriptions := s.strs("x-enum-descriptions")
+	// what mime gen writes besides the names and descriptions
+	labels := s.strs("x-enum-labels")
+	deprecated := s.strs("x-enum-deprecated")
+
+	seen := make(map[string]bool)
+	for i, v := range slices.DeleteFunc(slices.Clone(s.list("enum")), func(v any) bool { return v == nil }) {
+		value, err := enumValue(enum.Backing, v)
+		if err != nil {
+			return nil, err
+		}
+		m := types.EnumMember{Value: value}
+		if i < len(names) {
+			m.Name, _ = ident(names[i])
+		} else {
+			m.Name, _ = ident(value)
+		}
+		if i < len(labels) {
+			m.Label = labels[i]
+		}
+		m.Deprecated = slices.Contains(deprecated, m.Name)
+		if i < len(descriptions) {
+			m.Description = memberDescription(descriptions[i], m.Label, m.Deprecated)
+		}
+		if seen[m.Name] {
+			return nil, fmt.Errorf("more than one value becomes the member %s", m.Name)
+		}
+		seen[m.Name] = true
+		enum.Members = append(enum.Members, m)
+	}
+	if len(enum.Members) == 0 {
+		return nil, fmt.Errorf("it has no values")
+	}
+	return enum, enum.Finalize()
+}
+
+// memberDescription takes back off the label and deprecation mime gen folds
+// into a member's description
+func memberDescription(d, label string, deprecated bool) string {
+	if deprecated {
+		d = strings.TrimSuffix(strings.TrimSuffix(d, "deprecated"), ". ")
+	}
+	if label != "" {
+		d = strings.TrimPrefix(strings.TrimPrefix(d, label), ". ")
+	}
+	return d
+}
+
+func enumValue(dt types.DataType, v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		if dt == types.DataText {
+			return v, nil
+		}
+	case json.Number:
+		if _, err := strconv.ParseInt(v.String(), 10, 64); err == nil && dt == types.DataInt {
+			return v.String(), nil
+		}
+	}
+	return "", fmt.Errorf("%v isn't a %s value", v, dataTypeNames[dt])
+}
+
+// schemaValues gives a text or int field an inline list of values
+func schemaValues(f *types.Field, values []any) error {
+	if f.DataType != types.DataText && f.DataType != types.DataInt {
+		return fmt.Errorf("only text and int fields take a list of values")
+	}
+	var list []string
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		value, err := enumValue(f.DataType, v)
+		if err != nil {
+			return err
+		}
+		list = append(list, value)
+	}
+
+	enum := types.InlineEnum(f.DataType, list)
+	if err := enum.Finalize(); err != nil {
+		return err
+	}
+	f.Enum = enum
+	return nil
+}
+
+func schemaInt(s *jsonObject, key string) (int, bool) {
+	n, ok := s.number(key)
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.Atoi(n.String())
+	return v, err == nil
+}
+
+// schemaBounds turns minimum and maximum into a check. openapi 3.0 marks
+// them exclusive with a bool while json schema gives the bound itself
+func schemaBounds(field string, s *jsonObject) *types.Expr {
+	var check *types.Expr
+	bound := func(op string, n json.Number) {
+		e := &types.Expr{Kind: types.ExprBinary, Value: op, Args: []*types.Expr{
+			{Kind: types.ExprField, Value: field},
+			{Kind: types.ExprNumber, Value: n.String()},
+		}}
+		if check != nil {
+			e = &types.Expr{Kind: types.ExprBinary, Value: "and", Args: []*types.Expr{check, e}}
+		}
+		check = e
+	}
+
+	if n, ok := s.number("minimum"); ok {
+		bound(map[bool]string{false: ">=", true: ">"}[s.flag("exclusiveMinimum")], n)
+	} else if n, ok := s.number("exclusiveMinimum"); ok {
+		bound(">", n)
+	}
+	if n, ok := s.number("maximum"); ok {
+		bound(map[bool]string{false: "<=", true: "<"}[s.flag("exclusiveMaximum")], n)
+	} else if n, ok := s.number("exclusiveMaximum"); ok {
+		bound("<", n)
+	}
+	return check
+}
+
+func schemaDefault(f *types.Field, v any) error {
+	if f.Kind != types.FieldPrimitive {
+		return fmt.Errorf("%s fields can't have one", map[types.FieldKind]string{
+			types.FieldReference: "reference", types.FieldEmbedded: "embedded",
+		}[f.Kind])
+	}
+
+	var value string
+	switch v := v.(type) {
+	case string:
+		value = v
+	case json.Number:
+		value = v.String()
+	case bool:
+		value = strconv.FormatBool(v)
+	default:
+		return fmt.Errorf("it isn't a single value")
+	}
+
+	d := &types.DefaultValue{Kind: types.DefaultLiteral, Value: value}
+	dt := f.DataType
+	if f.Enum != nil {
+		if f.Enum.Member(value) == nil {
+			return fmt.Errorf("it isn't one of the values")
+		}
+		dt = f.Enum.Backing
+	}
+	if err := types.ValidateDefault(dt, d); err != nil {
+		return err
+	}
+	if f.Attributes&types.AttrReadonly != 0 {
+		return fmt.Errorf("readonly fields can only have a generated default")
+	}
+	f.Attributes |= types.AttrDefault
+	f.Default = d
+	return nil
+}
@@ -0,0 +1,124 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/engine/codegen"
+	"willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/parser"
+	"willofdaedalus/mime/internal/testutil"
+)
+
+func TestOpenAPI(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("testdata", "petstore.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := OpenAPI("petstore.json", src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parses(t, "petstore.mime", out)
+	testutil.Golden(t, "petstore.mime", out)
+}
+
+func TestJSONSchema(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("testdata", "catalog.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := JSONSchema("catalog.schema.json", src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parses(t, "catalog.mime", out)
+	testutil.Golden(t, "catalog.mime", out)
+}
+
+func TestOpenAPIErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{name: "yaml", src: "openapi: 3.0.0\n"},
+		{name: "not openapi", src: `{"type": "object"}`},
+		{name: "trailing data", src: `{"openapi": "3.1.0"} {}`},
+		{name: "broken", src: `{"openapi": "3.1.0",`},
+	}
+
+	for _, tt := range tests {
+		if _, err := OpenAPI(tt.name, []byte(tt.src)); err == nil {
+			t.Fatalf("for test %q: expected an error", tt.name)
+		}
+	}
+}
+
+// the labels, descriptions and deprecations mime gen openapi writes for an
+// enum come back on import
+func TestOpenAPIEnumRoundTrip(t *testing.T) {
+	const schema = `enum role ->
+	admin = 1 "Administrator"
+	member = 2 "" "can write notes"
+	guest = 3 "Guest" "read only" [deprecated]
+	owner = 4 [deprecated]
+end
+
+entity user ->
+	id int [primary unique required]
+	role &role [required]
+end
+`
+	s, errs := parser.NewParser(lexer.NewFile("roles.mime", schema)).Schema()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected parse errors: %v", errs)
+	}
+	doc, err := codegen.OpenAPI(s, "roles", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := OpenAPI("roles.json", []byte(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parses(t, "roles.mime", out)
+	for _, want := range []string{
+		`admin = 1 "Administrator"` + "\n",
+		`member = 2 "" "can write notes"` + "\n",
+		`guest = 3 "Guest" "read only" [deprecated]` + "\n",
+		`owner = 4 [deprecated]` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in\n%s", want, out)
+		}
+	}
+}
+
+// a schema that's one of several others has no entity to become
+func TestOpenAPIOneOf(t *testing.T) {
+	const doc = `{"openapi": "3.1.0", "components": {"schemas": {
+		"Animal": {"oneOf": [{"$ref": "#/components/schemas/Dog"}, {"$ref": "#/components/schemas/Cat"}]},
+		"Pet": {"anyOf": [{"$ref": "#/components/schemas/Dog"}]},
+		"Dog": {"type": "object", "properties": {"name": {"type": "string"}}},
+		"Cat": {"type": "object", "properties": {"lives": {"type": "integer"}}}
+	}}}`
+
+	out, err := OpenAPI("zoo.json", []byte(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parses(t, "zoo.mime", out)
+	for _, want := range []string{
+		"# Animal: oneOf/anyOf schemas aren't imported\n",
+		"# Pet: oneOf/anyOf schemas aren't imported\n",
+		"entity Dog ->",
+		"entity Cat ->",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,727 @@
+package importer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// SQL reads sqlite CREATE TABLE and CREATE INDEX statements and writes the
+// schema they describe as .mime source. name is only used in the header
+func SQL(name string, src []byte) (string, error) {
+	toks, err := sqlTokens(string(src))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+
+	im := &sqlImport{src: string(src), schema: &types.Schema{}, notes: newNotes(), fields: make(map[*types.Field]*sqlColumn)}
+	var indexes []*sqlIndex
+	for _, stmt := range statements(toks) {
+		p := &sqlParser{src: string(src), toks: stmt}
+		if !p.accept("CREATE") {
+			// data, pragmas and transactions from a dump
+			continue
+		}
+		_ = p.accept("TEMP") || p.accept("TEMPORARY")
+
+		switch {
+		case p.accept("TABLE"):
+			t, err := p.createTable()
+			if err != nil {
+				im.notes.top("skipped a table: %v", err)
+				continue
+			}
+			if !strings.HasPrefix(strings.ToLower(t.name), "sqlite_") {
+				im.tables = append(im.tables, t)
+			}
+		case p.accept("INDEX"), p.accept("UNIQUE", "INDEX"):
+			idx, err := p.createIndex(slices.ContainsFunc(stmt[:p.pos], func(t sqlToken) bool { return t.keyword("UNIQUE") }))
+			if err != nil {
+				im.notes.top("skipped an index: %v", err)
+				continue
+			}
+			indexes = append(indexes, idx)
+		default:
+			im.notes.top("skipped %s", firstLine(text(im.src, stmt)))
+		}
+	}
+
+	for _, t := range im.tables {
+		im.table(t)
+	}
+	for _, idx := range indexes {
+		im.index(idx)
+	}
+	im.references()
+	im.checks()
+
+	return write(fmt.Sprintf("imported from %s by mime import sql", name), im.schema, im.notes), nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return strings.TrimSpace(line)
+}
+
+type sqlImport struct {
+	src    string
+	schema *types.Schema
+	notes  *notes
+	tables []*sqlTable
+	// the column each field came from
+	fields map[*types.Field]*sqlColumn
+	// checks that still need the rest of the schema
+	pending []pendingCheck
+}
+
+type pendingCheck struct {
+	entity *types.EntityNode
+	// the field the check belongs to; nil for table checks
+	field *types.Field
+	src   string
+	toks  []sqlToken
+}
+
+func (im *sqlImport) entityFor(table string) *types.EntityNode {
+	name, _ := ident(table)
+	return im.schema.Entity(name)
+}
+
+func (im *sqlImport) table(t *sqlTable) {
+	name, renamed := ident(t.name)
+	if im.schema.Entity(name) != nil {
+		im.notes.top("skipped a second table called %s", t.name)
+		return
+	}
+	e := &types.EntityNode{Name: name}
+	im.schema.Entities = append(im.schema.Entities, e)
+	if renamed {
+		im.notes.entity(name, "was table %q", t.name)
+	}
+	for _, note := range t.notes {
+		im.notes.entity(name, "%s", note)
+	}
+
+	for _, pk := range t.primary {
+		if c := t.column(pk); c != nil {
+			c.primary = true
+		}
+	}
+	for _, cols := range t.uniques {
+		if c := t.column(cols[0]); c != nil && len(cols) == 1 {
+			c.unique = true
+			continue
+		}
+		im.notes.entity(name, "UNIQUE (%s) isn't imported; only single fields can be unique", strings.Join(cols, ", "))
+	}
+	for _, fk := range t.foreign {
+		if c := t.column(fk.columns[0]); c != nil && len(fk.columns) == 1 && len(fk.references.columns) <= 1 {
+			c.references = &fk.references
+			continue
+		}
+		im.notes.entity(name, "FOREIGN KEY (%s) REFERENCES %s isn't imported; references are a single field",
+			strings.Join(fk.columns, ", "), fk.references.table)
+	}
+	var keys []*sqlColumn
+	for _, c := range t.columns {
+		if c.primary {
+			keys = append(keys, c)
+		}
+	}
+	if len(keys) > 1 {
+		// every field of a composite key is marked primary and the table gets
+		// PRIMARY KEY (a, b) back; those fields can't be references and have
+		// to be int or uuid like any other primary key
+		names := make([]string, len(keys))
+		var reason string
+		for i, c := range keys {
+			names[i] = c.name
+			dt, _ := sqlType(c.decl)
+			switch {
+			case reason != "":
+			case dt != types.DataInt && dt != types.DataUUID:
+				reason = fmt.Sprintf("%s isn't an int or uuid", c.name)
+			case c.references != nil:
+				reason = fmt.Sprintf("%s is a reference", c.name)
+			}
+		}
+		for _, c := range keys {
+			if reason != "" {
+				c.primary, c.notNull = false, true
+			}
+			c.keyPart = reason == ""
+		}
+		if reason != "" {
+			im.notes.entity(name, "PRIMARY KEY (%s) isn't imported; %s", strings.Join(names, ", "), reason)
+		}
+	}
+
+	for _, c := range t.columns {
+		if f := im.column(e, c); f != nil {
+			e.Fields = append(e.Fields, f)
+			im.fields[f] = c
+		}
+	}
+	for _, expr := range t.checks {
+		im.pending = append(im.pending, pendingCheck{entity: e, src: text(im.src, expr), toks: expr})
+	}
+
+	softDelete(e)
+}
+
+func (t *sqlTable) column(name string) *sqlColumn {
+	for _, c := range t.columns {
+		if strings.EqualFold(c.name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+func (im *sqlImport) column(e *types.EntityNode, c *sqlColumn) *types.Field {
+	name, renamed := ident(c.name)
+	note := func(format string, args ...any) {
+		im.notes.field(e.Name, name, format, args...)
+	}
+	if renamed {
+		note("was column %q", c.name)
+	}
+
+	dt, typeNote := sqlType(c.decl)
+	// sqlite keeps timestamps in text columns, so the way they're filled in
+	// or the name soft deletes use is the only sign of one. a date on its
+	// own is what a text field defaulting to today() holds
+	if dt == types.DataText && typeNote == "" && !c.primary {
+		if def, err := sqlDefault(c.def, types.DataTimestamp); err == nil && def != nil && def.Kind == types.DefaultFunc &&
+			(def.Value == types.FuncNow || sqlMidnight(c.def)) {
+			dt = types.DataTimestamp
+		} else if name == types.SoftDeleteField && !c.notNull && c.def == nil {
+			dt = types.DataTimestamp
+		}
+	}
+	// and bools in INTEGER columns which mime sql defaults to TRUE or FALSE
+	if dt == types.DataInt && len(c.def) == 1 && (c.def[0].keyword("TRUE") || c.def[0].keyword("FALSE")) {
+		dt = types.DataBool
+	}
+	if typeNote != "" {
+		note("%s", typeNote)
+	}
+	for _, n := range c.notes {
+		note("%s", n)
+	}
+
+	f := &types.Field{Name: name, Kind: types.FieldPrimitive, DataType: dt}
+	if c.generated != nil {
+		expr, err := sqlExpr(c.generated)
+		if err != nil {
+			im.notes.entity(e.Name, "column %s AS (%s) isn't imported: %v", c.name, text(im.src, c.generated), err)
+			return nil
+		}
+		f.Kind, f.Computed = types.FieldComputed, expr
+		return f
+	}
+
+	switch {
+	case c.primary && (dt == types.DataInt || dt == types.DataUUID):
+		f.Attributes |= types.AttrPrimary | types.AttrUnique | types.AttrRequired
+		// an INTEGER PRIMARY KEY is the rowid which sqlite fills in, unless
+		// the key has other columns. mime sql marks a key the client picks
+		// NOT NULL and writes AUTOINCREMENT when it means one
+		if dt == types.DataInt && !c.keyPart && (c.increment || strings.EqualFold(c.decl, "INTEGER") && !c.notNull) {
+			f.Attributes |= types.AttrIncrement
+		}
+	case c.primary:
+		f.Attributes |= types.AttrUnique | types.AttrRequired
+		note("was the primary key; only int and uuid fields can be")
+	}
+	if c.notNull {
+		f.Attributes |= types.AttrRequired
+	}
+	if c.unique && f.Attributes&types.AttrUnique == 0 {
+		im.unique(e, f)
+	}
+
+	if c.def != nil {
+		def, err := sqlDefault(c.def, dt)
+		switch {
+		case err != nil:
+			note("DEFAULT %s isn't imported: %v", text(im.src, c.def), err)
+		case def != nil:
+			f.Attributes |= types.AttrDefault
+			f.Default = def
+		}
+	}
+
+	for _, expr := range c.checks {
+		if values, ok := sqlInList(expr, c.name); ok {
+			im.values(e, f, values, text(im.src, expr))
+			continue
+		}
+		im.pending = append(im.pending, pendingCheck{entity: e, field: f, src: text(im.src, expr), toks: expr})
+	}
+
+	return f
+}
+
+func (im *sqlImport) unique(e *types.EntityNode, f *types.Field) {
+	switch f.DataType {
+	case types.DataText, types.DataInt, types.DataReal, types.DataDecimal, types.DataUUID:
+		f.Attributes |= types.AttrUnique
+	default:
+		im.notes.field(e.Name, f.Name, "was UNIQUE; %s fields can't be", dataTypeNames[f.DataType])
+	}
+}
+
+// values turns CHECK (col IN (...)) into a list of values
+func (im *sqlImport) values(e *types.EntityNode, f *types.Field, values []sqlToken, src string) {
+	// a bool column is usually kept to 0 and 1 this way
+	if f.DataType == types.DataBool {
+		return
+	}
+
+	var list []string
+	for _, v := range values {
+		switch {
+		case f.DataType == types.DataText && v.kind == sqlString,
+			f.DataType == types.DataInt && v.kind == sqlNumber && !strings.ContainsAny(v.text, ".eE"):
+			list = append(list, v.text)
+		default:
+			im.notes.field(e.Name, f.Name, "CHECK (%s) isn't imported; only text and int fields take a list of values", src)
+			return
+		}
+	}
+
+	enum := types.InlineEnum(f.DataType, list)
+	if err := enum.Finalize(); err != nil {
+		im.notes.field(e.Name, f.Name, "CHECK (%s) isn't imported: %v", src, err)
+		return
+	}
+	f.Enum = enum
+	if f.Default != nil && enum.Member(f.Default.Value) == nil {
+		im.notes.field(e.Name, f.Name, "DEFAULT %s isn't imported; it isn't one of the values", f.Default.Value)
+		f.Default, f.Attributes = nil, f.Attributes&^types.AttrDefault
+	}
+}
+
+func (im *sqlImport) index(idx *sqlIndex) {
+	e := im.entityFor(idx.table)
+	if e == nil {
+		im.notes.top("skipped index %s on %s, which isn't in the file", idx.name, idx.table)
+		return
+	}
+	if !idx.unique {
+		im.notes.entity(e.Name, "index %s on (%s) isn't imported; mime doesn't declare indexes",
+			idx.name, strings.Join(idx.columns, ", "))
+		return
+	}
+
+	name, _ := ident(idx.columns[0])
+	f := e.Field(name)
+	// soft deleted entities get WHERE deleted_at IS NULL on their unique
+	// indexes already
+	live := e.SoftDelete && len(idx.where) == 3 && sqlFieldName(idx.where[0]) == types.SoftDeleteField &&
+		idx.where[1].keyword("IS") && idx.where[2].keyword("NULL")
+	if len(idx.columns) > 1 || f == nil || (idx.where != nil && !live) {
+		im.notes.entity(e.Name, "unique index %s on (%s) isn't imported; only single fields can be unique",
+			idx.name, strings.Join(idx.columns, ", "))
+		return
+	}
+	if f.Attributes&types.AttrUnique == 0 {
+		im.unique(e, f)
+	}
+}
+
+// references turns columns with a foreign key into reference fields now that
+// every table they could point at is known
+func (im *sqlImport) references() {
+	for _, e := range im.schema.Entities {
+		for i, f := range e.Fields {
+			c := im.fields[f]
+			if c == nil || c.references == nil {
+				continue
+			}
+			ref := c.references
+			note := func(format string, args ...any) {
+				im.notes.field(e.Name, f.Name, format, args...)
+			}
+
+			target := im.entityFor(ref.table)
+			var tf *types.Field
+			if target != nil && len(ref.columns) == 1 {
+				name, _ := ident(ref.columns[0])
+				tf = target.Field(name)
+			} else if target != nil {
+				// REFERENCES t without columns means t's primary key
+				for _, candidate := range target.Fields {
+					if im.fields[candidate] != nil && im.fields[candidate].primary {
+						tf = candidate
+						break
+					}
+				}
+			}
+			if tf == nil {
+				note("references %s(%s), which isn't in the file", ref.table, strings.Join(ref.columns, ", "))
+				continue
+			}
+
+			if ref.actions != "" {
+				note("was %s", ref.actions)
+			}
+			if f.Attributes&types.AttrPrimary != 0 {
+				note("was part of the primary key; references can't be")
+			} else if f.Attributes&types.AttrUnique != 0 {
+				note("was UNIQUE; references can't be")
+			}
+			if f.Default != nil {
+				note("DEFAULT %s isn't imported; references can't have one", f.Default)
+			}
+
+			e.Fields[i] = &types.Field{
+				Name:       f.Name,
+				Kind:       types.FieldReference,
+				Target:     &types.ReferenceTarget{Entity: target.Name, Field: tf.Name},
+				Attributes: f.Attributes & types.AttrRequired,
+			}
+			im.fields[e.Fields[i]] = c
+		}
+	}
+}
+
+// checks reads every check that wasn't a list of values. a check goes on
+// the field it's declared on or, for table checks, the first field it
+// reads. anything mime can't express is left as a note
+func (im *sqlImport) checks() {
+	for _, pc := range im.pending {
+		e := pc.entity
+		expr, err := sqlExpr(pc.toks)
+		var f *types.Field
+		if pc.field != nil {
+			// references() may have swapped the field out
+			f = e.Field(pc.field.Name)
+		}
+		if err == nil && f == nil {
+			if names := expr.Fields(); len(names) > 0 {
+				f = e.Field(names[0])
+			}
+			if f == nil {
+				err = fmt.Errorf("it doesn't read a field")
+			}
+		}
+		if err == nil && (f.Kind == types.FieldReference || f.Kind == types.FieldComputed) {
+			err = fmt.Errorf("%s can't have a check", f.Name)
+		}
+		if err == nil {
+			if f.Check != nil {
+				expr = &types.Expr{Kind: types.ExprBinary, Value: "and", Args: []*types.Expr{f.Check, expr}}
+			}
+			prev := f.Check
+			f.Check = expr
+			if err = types.CheckRule(f, e, im.schema); err != nil {
+				f.Check = prev
+			}
+		}
+
+		if err != nil {
+			if f != nil {
+				im.notes.field(e.Name, f.Name, "CHECK (%s) isn't imported: %v", pc.src, err)
+			} else {
+				im.notes.entity(e.Name, "CHECK (%s) isn't imported: %v", pc.src, err)
+			}
+			continue
+		}
+		f.Attributes |= types.AttrCheck
+	}
+}
+
+// sqlType follows sqlite's own rules for a declared type's affinity, after
+// picking out the names people use for the types sqlite doesn't have
+func sqlType(decl string) (types.DataType, string) {
+	t := strings.ToUpper(decl)
+	switch {
+	case strings.Contains(t, "UUID") || strings.Contains(t, "GUID"):
+		return types.DataUUID, ""
+	case strings.Contains(t, "BOOL"):
+		return types.DataBool, ""
+	case strings.Contains(t, "TIMESTAMP") || strings.Contains(t, "DATETIME"):
+		return types.DataTimestamp, ""
+	case strings.Contains(t, "INT"):
+		return types.DataInt, ""
+	case strings.Contains(t, "DEC") || strings.Contains(t, "NUMERIC"):
+		return types.DataDecimal, ""
+	case strings.Contains(t, "CHAR") || strings.Contains(t, "CLOB") || strings.Contains(t, "TEXT"):
+		return types.DataText, ""
+	case t == "":
+		return types.DataText, "had no type; imported as text"
+	case strings.Contains(t, "BLOB"):
+		return types.DataText, fmt.Sprintf("was %s; mime has no binary type", decl)
+	case strings.Contains(t, "REAL") || strings.Contains(t, "FLOA") || strings.Contains(t, "DOUB"):
+		return types.DataReal, ""
+	case strings.Contains(t, "DATE") || strings.Contains(t, "TIME"):
+		return types.DataText, fmt.Sprintf("was %s; imported as text since it isn't a full timestamp", decl)
+	case strings.Contains(t, "JSON"):
+		return types.DataText, fmt.Sprintf("was %s; imported as text", decl)
+	}
+	return types.DataReal, fmt.Sprintf("was %s; imported as float", decl)
+}
+
+// sqlDefault maps a DEFAULT clause onto a default. nil with no error means
+// there's no default e.g. DEFAULT NULL
+func sqlDefault(toks []sqlToken, dt types.DataType) (*types.DefaultValue, error) {
+	lit := func(v string) (*types.DefaultValue, error) {
+		d := &types.DefaultValue{Kind: types.DefaultLiteral, Value: v}
+		return d, types.ValidateDefault(dt, d)
+	}
+	fn := func(name string) (*types.DefaultValue, error) {
+		d := &types.DefaultValue{Kind: types.DefaultFunc, Value: name}
+		return d, types.ValidateDefault(dt, d)
+	}
+
+	if len(toks) == 2 && toks[0].symbol("-") {
+		return lit("-" + toks[1].text)
+	}
+	if len(toks) == 2 && toks[0].symbol("+") {
+		toks = toks[1:]
+	}
+	if len(toks) == 1 {
+		t := toks[0]
+		switch {
+		case t.keyword("NULL"):
+			return nil, nil
+		case t.keyword("CURRENT_TIMESTAMP"):
+			return fn(types.FuncNow)
+		case t.keyword("CURRENT_DATE"):
+			return fn(types.FuncToday)
+		case t.keyword("TRUE"), t.keyword("FALSE"):
+			return lit(strings.ToLower(t.text))
+		case t.kind == sqlNumber && dt == types.DataBool:
+			return lit(map[string]string{"0": "false", "1": "true"}[t.text])
+		case t.kind == sqlNumber:
+			return lit(t.text)
+		case t.kind == sqlString && dt == types.DataTimestamp:
+			// sqlite's own datetime() leaves out the T and the zone
+			if ts, err := time.Parse(time.DateTime, t.text); err == nil {
+				return lit(ts.UTC().Format(time.RFC3339))
+			}
+			return lit(t.text)
+		case t.kind == sqlString:
+			return lit(t.text)
+		}
+	}
+
+	// (datetime('now')), (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')) and friends
+	if len(toks) > 2 && toks[0].kind == sqlWord && toks[1].symbol("(") &&
+		slices.ContainsFunc(toks, func(t sqlToken) bool { return t.kind == sqlString && strings.EqualFold(t.text, "now") }) {
+		switch strings.ToLower(toks[0].text) {
+		case "date":
+			return fn(types.FuncToday)
+		case "strftime":
+			// mime sql writes today() as midnight in the timestamp format; a
+			// format without the hour is a date whatever else it holds
+			if len(toks) > 2 && toks[2].kind == sqlString && !strings.Contains(toks[2].text, "%H") {
+				return fn(types.FuncToday)
+			}
+			if dt == types.DataTimestamp {
+				return fn(types.FuncNow)
+			}
+		case "datetime", "julianday", "unixepoch":
+			if dt == types.DataTimestamp {
+				return fn(types.FuncNow)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("it isn't a value or a time function mime knows")
+}
+
+// sqlMidnight is the strftime mime sql writes today() on a timestamp as,
+// which has the time in it unlike date('now')
+func sqlMidnight(toks []sqlToken) bool {
+	return len(toks) > 2 && strings.EqualFold(toks[0].text, "strftime") &&
+		toks[2].kind == sqlString && strings.Contains(toks[2].text, "T")
+}
+
+// sqlInList matches `col IN (...)` against the column it's declared on and
+// returns the values
+func sqlInList(toks []sqlToken, column string) ([]sqlToken, bool) {
+	if len(toks) < 3 || !strings.EqualFold(sqlFieldName(toks[0]), column) && !strings.EqualFold(toks[0].text, column) {
+		return nil, false
+	}
+	if !toks[1].keyword("IN") || !toks[2].symbol("(") || !toks[len(toks)-1].symbol(")") {
+		return nil, false
+	}
+
+	var values []sqlToken
+	for _, part := range splitCommas(toks[3 : len(toks)-1]) {
+		switch {
+		case len(part) == 1 && (part[0].kind == sqlString || part[0].kind == sqlNumber):
+			values = append(values, part[0])
+		case len(part) == 2 && part[0].symbol("-") && part[1].kind == sqlNumber:
+			values = append(values, sqlToken{kind: sqlNumber, text: "-" + part[1].text})
+		default:
+			return nil, false
+		}
+	}
+	return values, len(values) > 0
+}
+
+func sqlFieldName(t sqlToken) string {
+	if t.kind != sqlWord && t.kind != sqlQuoted {
+		return ""
+	}
+	name, _ := ident(t.text)
+	return name
+}
+
+// the operators mime expressions have, by their sql spelling, with the same
+// precedence the parser gives them
+var sqlBinary = map[string]struct {
+	op   string
+	prec int
+}{
+	"OR": {"or", 1}, "AND": {"and", 2},
+	"=": {"==", 3}, "==": {"==", 3}, "!=": {"!=", 3}, "<>": {"!=", 3},
+	"<": {"<", 3}, "<=": {"<=", 3}, ">": {">", 3}, ">=": {">=", 3},
+	"||": {"||", 4}, "+": {"+", 5}, "-": {"-", 5}, "*": {"*", 6}, "/": {"/", 6},
+}
+
+// sqlExpr reads a check or generated column as a mime expression
+func sqlExpr(toks []sqlToken) (*types.Expr, error) {
+	r := &exprReader{toks: toks}
+	e, err := r.expr(1)
+	if err != nil {
+		return nil, err
+	}
+	if r.pos < len(toks) {
+		return nil, fmt.Errorf("%s isn't supported", toks[r.pos].text)
+	}
+	return e, nil
+}
+
+type exprReader struct {
+	toks []sqlToken
+	pos  int
+}
+
+func (r *exprReader) peek() sqlToken {
+	if r.pos >= len(r.toks) {
+		return sqlToken{}
+	}
+	return r.toks[r.pos]
+}
+
+func (r *exprReader) binary() (string, int, bool) {
+	t := r.peek()
+	if t.kind != sqlSymbol && t.kind != sqlWord {
+		return "", 0, false
+	}
+	b, ok := sqlBinary[strings.ToUpper(t.text)]
+	return b.op, b.prec, ok
+}
+
+func (r *exprReader) expr(min int) (*types.Expr, error) {
+	left, err := r.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, prec, ok := r.binary()
+		if !ok || prec < min {
+			return left, nil
+		}
+		r.pos++
+		right, err := r.expr(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &types.Expr{Kind: types.ExprBinary, Value: op, Args: []*types.Expr{left, right}}
+	}
+}
+
+func (r *exprReader) operand() (*types.Expr, error) {
+	t := r.peek()
+	r.pos++
+
+	switch {
+	case t.kind == sqlNumber:
+		return &types.Expr{Kind: types.ExprNumber, Value: t.text}, nil
+	case t.symbol("-") && r.peek().kind == sqlNumber:
+		r.pos++
+		return &types.Expr{Kind: types.ExprNumber, Value: "-" + r.toks[r.pos-1].text}, nil
+	case t.kind == sqlString:
+		return &types.Expr{Kind: types.ExprString, Value: t.text}, nil
+	case t.symbol("("):
+		e, err := r.expr(1)
+		if err != nil {
+			return nil, err
+		}
+		if !r.peek().symbol(")") {
+			return nil, fmt.Errorf("expected ), got %s", r.peek().text)
+		}
+		r.pos++
+		return e, nil
+	case t.kind == sqlWord && r.peek().symbol("("):
+		return r.call(t)
+	case t.kind == sqlQuoted, t.kind == sqlWord && sqlBinary[strings.ToUpper(t.text)].op == "" && !sqlReserved[strings.ToUpper(t.text)]:
+		return &types.Expr{Kind: types.ExprField, Value: sqlFieldName(t)}, nil
+	case t.kind == 0:
+		return nil, fmt.Errorf("the expression ends early")
+	}
+	return nil, fmt.Errorf("%s isn't supported", t.text)
+}
+
+// words that can't be a column in an expression
+var sqlReserved = map[string]bool{
+	"NOT": true, "IS": true, "IN": true, "LIKE": true, "GLOB": true, "BETWEEN": true, "CASE": true,
+	"NULL": true, "TRUE": true, "FALSE": true, "EXISTS": true, "CURRENT_TIMESTAMP": true,
+	"CURRENT_DATE": true, "CURRENT_TIME": true,
+}
+
+func (r *exprReader) call(name sqlToken) (*types.Expr, error) {
+	fn := strings.ToLower(name.text)
+	r.pos++ // (
+
+	// CAST(round(x) AS INTEGER) is how round comes out of mime sql, and
+	// CAST(x AS NUMERIC) is how it reads a decimal column
+	if fn == "cast" {
+		inner, err := r.expr(1)
+		if err != nil {
+			return nil, err
+		}
+		if !r.peek().keyword("AS") {
+			return nil, fmt.Errorf("CAST isn't supported")
+		}
+		r.pos++
+		round := inner.Kind == types.ExprCall && inner.Value == "round"
+		if !round && (inner.Kind != types.ExprField || !r.peek().keyword("NUMERIC")) {
+			return nil, fmt.Errorf("CAST isn't supported")
+		}
+		for r.pos < len(r.toks) && !r.peek().symbol(")") {
+			r.pos++
+		}
+		r.pos++
+		return inner, nil
+	}
+	if !types.IsExprFunc(fn) {
+		return nil, fmt.Errorf("%s() isn't supported", name.text)
+	}
+
+	call := &types.Expr{Kind: types.ExprCall, Value: fn}
+	for !r.peek().symbol(")") {
+		arg, err := r.expr(1)
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+		if r.peek().symbol(",") {
+			r.pos++
+		} else if !r.peek().symbol(")") {
+			return nil, fmt.Errorf("expected , or ) in %s(), got %s", name.text, r.peek().text)
+		}
+	}
+	r.pos++ // )
+	return call, nil
+}
@@ -0,0 +1,132 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/engine/ddl"
+	"willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/parser"
+	"willofdaedalus/mime/internal/testutil"
+)
+
+func TestSQL(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("testdata", "legacy.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := SQL("legacy.sql", src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parses(t, "legacy.mime", out)
+	testutil.Golden(t, "legacy.mime", out)
+}
+
+// the sqlite golden for ddl's shop.mime imports back to testdata/shop.mime,
+// which should read like the schema it came from
+func TestSQLShop(t *testing.T) {
+	path := filepath.Join("..", "ddl", "testdata", "shop.sqlite.sql")
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := SQL("shop.sqlite.sql", src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parses(t, "shop.mime", out)
+	testutil.Golden(t, "shop.mime", out)
+}
+
+// what mime sql writes comes back as the same tables
+func TestSQLRoundTrip(t *testing.T) {
+	const schema = `entity author ->
+	id int [primary unique required increment]
+	name text [required unique]
+	rating int (1 2 3)
+end
+
+entity post [soft_delete] ->
+	id uuid [primary unique required]
+	author @author.id [required]
+	slug text [unique required]
+	status text ("draft" "live") [default:"draft"]
+	words int [check:(words >= 0) and (words < 100000)]
+	title text [required]
+	shout text = upper(title)
+	created_at timestamp [default:now()]
+end
+`
+	s, errs := parser.NewParser(lexer.NewFile("blog.mime", schema)).Schema()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected parse errors: %v", errs)
+	}
+	sql, _, err := ddl.Generate(s, ddl.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := SQL("blog.sql", []byte(sql))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parses(t, "blog.mime", out)
+
+	for _, want := range []string{
+		"id int [primary unique required increment]",
+		// sqlite keeps uuids as text so there's no telling them apart
+		"id text [unique required]",
+		"name text [unique required]",
+		"rating int (1 2 3)",
+		"entity post [soft_delete] ->",
+		"author @author.id [required]",
+		"slug text [unique required]",
+		`status text ("draft" "live") [default:"draft"]`,
+		"words int [check:(words >= 0) and (words < 100000)]",
+		"shout text = upper(title)",
+		"created_at timestamp [default:now()]",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "deleted_at") {
+		t.Fatalf("deleted_at should come from soft_delete\n%s", out)
+	}
+}
+
+// ddl's shop.mime through mime sql, the importer and mime sql again makes
+// the same tables
+func TestSQLRoundTripShop(t *testing.T) {
+	path := filepath.Join("..", "ddl", "testdata", "shop.mime")
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generate := func(name, src string) string {
+		t.Helper()
+		s, errs := parser.NewParser(lexer.NewFile(name, src)).Schema()
+		if len(errs) > 0 {
+			t.Fatalf("unexpected parse errors: %v\n%s", errs, src)
+		}
+		sql, _, err := ddl.Generate(s, ddl.SQLite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the fingerprint differs since the enum comes back as a list
+		_, tables, _ := strings.Cut(sql, "\n\n")
+		return tables
+	}
+
+	first := generate("shop.mime", string(src))
+	out, err := SQL("shop.sql", []byte(first))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second := generate("shop.sql.mime", out); second != first {
+		t.Fatalf("the tables changed on the way back\nexpected:\n%s\ngot:\n%s\nfrom:\n%s", first, second, out)
+	}
+}
@@ -0,0 +1,616 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// just enough of sqlite's grammar to read back what a schema dump holds:
+// CREATE TABLE and CREATE INDEX. everything else is either skipped or
+// noted. expressions inside checks, defaults and generated columns are kept
+// as tokens and only read when they're mapped onto the schema
+
+type sqlKind int
+
+const (
+	sqlWord   sqlKind = iota + 1 // bare identifiers and keywords
+	sqlQuoted                    // "name", `name` and [name]
+	sqlString                    // 'text'
+	sqlNumber
+	sqlSymbol // punctuation and operators
+)
+
+type sqlToken struct {
+	kind sqlKind
+	// unquoted for quoted identifiers and strings
+	text string
+	// where the token sits in the source
+	start, end int
+}
+
+// keyword reports whether the token is the bare word w in any case
+func (t sqlToken) keyword(w string) bool {
+	return t.kind == sqlWord && strings.EqualFold(t.text, w)
+}
+
+func (t sqlToken) symbol(s string) bool {
+	return t.kind == sqlSymbol && t.text == s
+}
+
+var sqlOperators = []string{"<=", ">=", "<>", "!=", "==", "||", "<<", ">>"}
+
+func sqlTokens(src string) ([]sqlToken, error) {
+	var toks []sqlToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(src[i:], "--"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				return toks, nil
+			}
+			i += end + 1
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed comment at offset %d", i)
+			}
+			i += end + 4
+			continue
+		}
+
+		tok := sqlToken{start: i}
+		switch {
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			tok.kind = sqlQuoted
+			if c == '\'' {
+				tok.kind = sqlString
+			}
+
+			var b strings.Builder
+			j := i + 1
+			for {
+				if j >= len(src) {
+					return nil, fmt.Errorf("unclosed %c at offset %d", c, i)
+				}
+				if src[j] == closing {
+					// doubling the quote escapes it
+					if closing != ']' && j+1 < len(src) && src[j+1] == closing {
+						b.WriteByte(closing)
+						j += 2
+						continue
+					}
+					break
+				}
+				b.WriteByte(src[j])
+				j++
+			}
+			tok.text, i = b.String(), j+1
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			j := i
+			for j < len(src) && (isDigit(src[j]) || src[j] == '.' || unicode.IsLetter(rune(src[j])) ||
+				((src[j] == '+' || src[j] == '-') && (src[j-1] == 'e' || src[j-1] == 'E'))) {
+				j++
+			}
+			tok.kind, tok.text, i = sqlNumber, src[i:j], j
+		case isWordByte(c):
+			j := i
+			for j < len(src) && (isWordByte(src[j]) || isDigit(src[j])) {
+				j++
+			}
+			tok.kind, tok.text, i = sqlWord, src[i:j], j
+		default:
+			tok.kind, tok.text = sqlSymbol, string(c)
+			for _, op := range sqlOperators {
+				if strings.HasPrefix(src[i:], op) {
+					tok.text = op
+					break
+				}
+			}
+			i += len(tok.text)
+		}
+		tok.end = i
+		toks = append(toks, tok)
+	}
+
+	return toks, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || unicode.IsLetter(rune(c))
+}
+
+// statements splits tokens on the semicolons between statements
+func statements(toks []sqlToken) [][]sqlToken {
+	var out [][]sqlToken
+	depth, start := 0, 0
+	for i, t := range toks {
+		switch {
+		case t.symbol("("):
+			depth++
+		case t.symbol(")"):
+			depth--
+		case t.symbol(";") && depth == 0:
+			if i > start {
+				out = append(out, toks[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(toks) {
+		out = append(out, toks[start:])
+	}
+	return out
+}
+
+type sqlParser struct {
+	src  string
+	toks []sqlToken
+	pos  int
+}
+
+func (p *sqlParser) done() bool {
+	return p.pos >= len(p.toks)
+}
+
+func (p *sqlParser) peek() sqlToken {
+	if p.done() {
+		return sqlToken{}
+	}
+	return p.toks[p.pos]
+}
+
+func (p *sqlParser) next() sqlToken {
+	t := p.peek()
+	if !p.done() {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the keywords if they're next, in order
+func (p *sqlParser) accept(words ...string) bool {
+	if p.pos+len(words) > len(p.toks) {
+		return false
+	}
+	for i, w := range words {
+		if !p.toks[p.pos+i].keyword(w) {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *sqlParser) expect(words ...string) error {
+	if !p.accept(words...) {
+		return fmt.Errorf("expected %s, got %s", strings.Join(words, " "), p.describe())
+	}
+	return nil
+}
+
+func (p *sqlParser) describe() string {
+	if p.done() {
+		return "the end of the statement"
+	}
+	return fmt.Sprintf("%q", p.peek().text)
+}
+
+// name reads an identifier, quoted or not. sqlite also takes a string
+// where it expects a name
+func (p *sqlParser) name() (string, error) {
+	switch t := p.peek(); t.kind {
+	case sqlWord, sqlQuoted, sqlString:
+		p.pos++
+		return t.text, nil
+	}
+	return "", fmt.Errorf("expected a name, got %s", p.describe())
+}
+
+// qualifiedName reads name or schema.name and drops the schema
+func (p *sqlParser) qualifiedName() (string, error) {
+	name, err := p.name()
+	if err != nil {
+		return "", err
+	}
+	if p.peek().symbol(".") {
+		p.pos++
+		return p.name()
+	}
+	return name, nil
+}
+
+// group consumes a parenthesised group and returns what's inside it
+func (p *sqlParser) group() ([]sqlToken, error) {
+	if !p.peek().symbol("(") {
+		return nil, fmt.Errorf("expected (, got %s", p.describe())
+	}
+	depth := 0
+	for i := p.pos; i < len(p.toks); i++ {
+		switch {
+		case p.toks[i].symbol("("):
+			depth++
+		case p.toks[i].symbol(")"):
+			depth--
+			if depth == 0 {
+				inner := p.toks[p.pos+1 : i]
+				p.pos = i + 1
+				return inner, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unclosed (")
+}
+
+// names reads a parenthesised list of column names, skipping any collation
+// or sort order that comes with them
+func (p *sqlParser) names() ([]string, error) {
+	inner, err := p.group()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, part := range splitCommas(inner) {
+		sub := &sqlParser{src: p.src, toks: part}
+		name, err := sub.name()
+		if err != nil {
+			return nil, err
+		}
+		if !sub.done() && !sub.accept("COLLATE") && !sub.accept("ASC") && !sub.accept("DESC") {
+			return nil, fmt.Errorf("expected a column name, got %s", text(p.src, part))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// rest consumes and returns whatever's left
+func (p *sqlParser) rest() []sqlToken {
+	rest := p.toks[p.pos:]
+	p.pos = len(p.toks)
+	return rest
+}
+
+// text gives back the source the tokens were read from
+func text(src string, toks []sqlToken) string {
+	if len(toks) == 0 {
+		return ""
+	}
+	return src[toks[0].start:toks[len(toks)-1].end]
+}
+
+func splitCommas(toks []sqlToken) [][]sqlToken {
+	var out [][]sqlToken
+	depth, start := 0, 0
+	for i, t := range toks {
+		switch {
+		case t.symbol("("):
+			depth++
+		case t.symbol(")"):
+			depth--
+		case t.symbol(",") && depth == 0:
+			out = append(out, toks[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, toks[start:])
+}
+
+type sqlTable struct {
+	name    string
+	columns []*sqlColumn
+	// table constraints
+	primary []string
+	uniques [][]string
+	checks  [][]sqlToken
+	foreign []sqlForeignKey
+	notes   []string
+}
+
+type sqlColumn struct {
+	name string
+	// the declared type e.g. VARCHAR(20); empty if there wasn't one
+	decl                        string
+	primary, increment, notNull bool
+	unique                      bool
+	// one of several columns in the table's PRIMARY KEY
+	keyPart    bool
+	def        []sqlToken
+	checks     [][]sqlToken
+	references *sqlReference
+	generated  []sqlToken
+	notes      []string
+}
+
+type sqlReference struct {
+	table   string
+	columns []string
+	// ON DELETE and the like, which mime doesn't have
+	actions string
+}
+
+type sqlForeignKey struct {
+	columns    []string
+	references sqlReference
+}
+
+type sqlIndex struct {
+	name, table string
+	unique      bool
+	columns     []string
+	where       []sqlToken
+}
+
+// columnConstraints end a column's declared type
+var columnConstraints = map[string]bool{
+	"CONSTRAINT": true, "PRIMARY": true, "NOT": true, "NULL": true, "UNIQUE": true, "CHECK": true,
+	"DEFAULT": true, "COLLATE": true, "REFERENCES": true, "GENERATED": true, "AS": true,
+}
+
+// createTable reads what follows CREATE TABLE
+func (p *sqlParser) createTable() (*sqlTable, error) {
+	p.accept("IF", "NOT", "EXISTS")
+	name, err := p.qualifiedName()
+	if err != nil {
+		return nil, err
+	}
+	t := &sqlTable{name: name}
+
+	if p.accept("AS") {
+		return nil, fmt.Errorf("%s is created from a query", name)
+	}
+	body, err := p.group()
+	if err != nil {
+		return nil, err
+	}
+	// WITHOUT ROWID and STRICT don't change what the table holds
+	p.rest()
+
+	for _, def := range splitCommas(body) {
+		sub := &sqlParser{src: p.src, toks: def}
+		if err := t.definition(sub); err != nil {
+			return nil, fmt.Errorf("table %s: %w", name, err)
+		}
+	}
+	return t, nil
+}
+
+func (t *sqlTable) definition(p *sqlParser) error {
+	if p.accept("CONSTRAINT") {
+		if _, err := p.name(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case p.accept("PRIMARY", "KEY"):
+		cols, err := p.names()
+		if err != nil {
+			return err
+		}
+		t.primary = cols
+		p.conflict()
+	case p.accept("UNIQUE"):
+		cols, err := p.names()
+		if err != nil {
+			return err
+		}
+		t.uniques = append(t.uniques, cols)
+		p.conflict()
+	case p.accept("CHECK"):
+		expr, err := p.group()
+		if err != nil {
+			return err
+		}
+		t.checks = append(t.checks, expr)
+	case p.accept("FOREIGN", "KEY"):
+		cols, err := p.names()
+		if err != nil {
+			return err
+		}
+		if err := p.expect("REFERENCES"); err != nil {
+			return err
+		}
+		ref, err := p.references()
+		if err != nil {
+			return err
+		}
+		t.foreign = append(t.foreign, sqlForeignKey{columns: cols, references: *ref})
+	default:
+		col, err := p.column()
+		if err != nil {
+			return err
+		}
+		t.columns = append(t.columns, col)
+		return nil
+	}
+
+	if !p.done() {
+		t.notes = append(t.notes, fmt.Sprintf("ignored %s", text(p.src, p.rest())))
+	}
+	return nil
+}
+
+func (p *sqlParser) column() (*sqlColumn, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	col := &sqlColumn{name: name}
+
+	start := p.pos
+	for p.peek().kind == sqlWord && !columnConstraints[strings.ToUpper(p.peek().text)] {
+		p.pos++
+	}
+	if p.pos > start && p.peek().symbol("(") {
+		if _, err := p.group(); err != nil {
+			return nil, err
+		}
+	}
+	col.decl = text(p.src, p.toks[start:p.pos])
+
+	for !p.done() {
+		switch {
+		case p.accept("CONSTRAINT"):
+			if _, err := p.name(); err != nil {
+				return nil, err
+			}
+		case p.accept("PRIMARY", "KEY"):
+			col.primary = true
+			_ = p.accept("ASC") || p.accept("DESC")
+			p.conflict()
+			col.increment = p.accept("AUTOINCREMENT")
+		case p.accept("NOT", "NULL"):
+			col.notNull = true
+			p.conflict()
+		case p.accept("NULL"):
+		case p.accept("UNIQUE"):
+			col.unique = true
+			p.conflict()
+		case p.accept("CHECK"):
+			expr, err := p.group()
+			if err != nil {
+				return nil, err
+			}
+			col.checks = append(col.checks, expr)
+		case p.accept("DEFAULT"):
+			def, err := p.defaultValue()
+			if err != nil {
+				return nil, err
+			}
+			col.def = def
+		case p.accept("COLLATE"):
+			collation, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			col.notes = append(col.notes, fmt.Sprintf("was COLLATE %s", collation))
+		case p.accept("REFERENCES"):
+			ref, err := p.references()
+			if err != nil {
+				return nil, err
+			}
+			col.references = ref
+		case p.accept("GENERATED", "ALWAYS", "AS"), p.accept("AS"):
+			expr, err := p.group()
+			if err != nil {
+				return nil, err
+			}
+			col.generated = expr
+			_ = p.accept("VIRTUAL") || p.accept("STORED")
+		default:
+			col.notes = append(col.notes, fmt.Sprintf("ignored %s", text(p.src, p.rest())))
+		}
+	}
+
+	return col, nil
+}
+
+// conflict skips ON CONFLICT clauses; they're about how sqlite reports a
+// violation, not what's allowed
+func (p *sqlParser) conflict() {
+	if p.accept("ON", "CONFLICT") {
+		p.next()
+	}
+}
+
+func (p *sqlParser) defaultValue() ([]sqlToken, error) {
+	start := p.pos
+	switch t := p.peek(); {
+	case t.symbol("("):
+		return p.group()
+	case t.symbol("-") || t.symbol("+"):
+		p.pos++
+		if p.peek().kind != sqlNumber {
+			return nil, fmt.Errorf("expected a number after %s, got %s", t.text, p.describe())
+		}
+		p.pos++
+	case t.kind == sqlSymbol || p.done():
+		return nil, fmt.Errorf("expected a default value, got %s", p.describe())
+	default:
+		p.pos++
+	}
+	return p.toks[start:p.pos], nil
+}
+
+func (p *sqlParser) references() (*sqlReference, error) {
+	table, err := p.qualifiedName()
+	if err != nil {
+		return nil, err
+	}
+	ref := &sqlReference{table: table}
+	if p.peek().symbol("(") {
+		if ref.columns, err = p.names(); err != nil {
+			return nil, err
+		}
+	}
+
+	// ON DELETE, ON UPDATE, MATCH and DEFERRABLE; only the ones that do
+	// something are kept
+	var actions []string
+	for {
+		start := p.pos
+		switch {
+		case p.accept("ON"):
+			p.next() // DELETE or UPDATE
+			if p.accept("NO", "ACTION") {
+				continue
+			}
+			if p.accept("SET") {
+				p.next() // NULL or DEFAULT
+			} else {
+				p.next() // CASCADE or RESTRICT
+			}
+		case p.accept("MATCH"):
+			p.next()
+			continue
+		case p.accept("NOT", "DEFERRABLE"), p.accept("DEFERRABLE"):
+			if p.accept("INITIALLY") {
+				p.next()
+			}
+		default:
+			ref.actions = strings.Join(actions, " ")
+			return ref, nil
+		}
+		actions = append(actions, text(p.src, p.toks[start:p.pos]))
+	}
+}
+
+// createIndex reads what follows CREATE [UNIQUE] INDEX
+func (p *sqlParser) createIndex(unique bool) (*sqlIndex, error) {
+	p.accept("IF", "NOT", "EXISTS")
+	name, err := p.qualifiedName()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("ON"); err != nil {
+		return nil, err
+	}
+	table, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	idx := &sqlIndex{name: name, table: table, unique: unique}
+
+	if idx.columns, err = p.names(); err != nil {
+		return nil, fmt.Errorf("index %s: only indexes on columns can be imported", name)
+	}
+	if p.accept("WHERE") {
+		idx.where = p.rest()
+	}
+	return idx, nil
+}
@@ -0,0 +1,38 @@
+package importer
+
+import (
+	"testing"
+
+	"willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/parser"
+)
+
+// parses makes sure what an importer wrote is a schema mime accepts
+func parses(t *testing.T, name, src string) {
+	t.Helper()
+	if _, errs := parser.NewParser(lexer.NewFile(name, src)).Schema(); len(errs) > 0 {
+		t.Fatalf("the imported schema doesn't parse: %v\n%s", errs, src)
+	}
+}
+
+func TestIdent(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		renamed bool
+	}{
+		{name: "email", want: "email"},
+		{name: "display name", want: "display_name", renamed: true},
+		{name: "2fa", want: "_2fa", renamed: true},
+		{name: "end", want: "end_", renamed: true},
+		{name: "and", want: "and_", renamed: true},
+		{name: "", want: "_", renamed: true},
+	}
+
+	for _, tt := range tests {
+		got, renamed := ident(tt.name)
+		if got != tt.want || renamed != tt.renamed {
+			t.Fatalf("for test %q: expected %s (%t), got %s (%t)", tt.name, tt.want, tt.renamed, got, renamed)
+		}
+	}
+}
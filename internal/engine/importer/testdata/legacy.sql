@@ -0,0 +1,60 @@
+-- a dump of an app that predates mime
+PRAGMA foreign_keys=OFF;
+BEGIN TRANSACTION;
+CREATE TABLE accounts (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  email VARCHAR(255) NOT NULL COLLATE NOCASE,
+  "display name" TEXT,
+  plan TEXT NOT NULL DEFAULT 'free' CHECK (plan IN ('free', 'pro', 'team')),
+  tier INT DEFAULT 1 CHECK (tier IN (1, 2, 3)),
+  balance REAL DEFAULT 0.0 CHECK (balance >= 0),
+  verified BOOLEAN NOT NULL DEFAULT 0 CHECK (verified IN (0, 1)),
+  avatar BLOB,
+  birthday DATE,
+  settings JSON CHECK (json_valid(settings)),
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+  deleted_at DATETIME
+);
+INSERT INTO accounts VALUES(1,'ada@example.com','Ada','pro',1,0.0,1,NULL,NULL,NULL,'2024-01-01 00:00:00',NULL,NULL);
+CREATE UNIQUE INDEX accounts_email ON accounts (email) WHERE deleted_at IS NULL;
+CREATE INDEX accounts_created ON accounts (created_at);
+
+CREATE TABLE IF NOT EXISTS [order] (
+  id TEXT PRIMARY KEY,
+  account_id INTEGER NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+  total NUMERIC(10, 2) NOT NULL,
+  quantity INTEGER NOT NULL DEFAULT 1,
+  unit_price REAL NOT NULL,
+  discount REAL,
+  subtotal REAL GENERATED ALWAYS AS (quantity * unit_price) VIRTUAL,
+  label TEXT AS (CASE WHEN discount > 0 THEN 'sale' ELSE 'full' END) STORED,
+  placed_at TEXT DEFAULT (datetime('now')),
+  CHECK (discount IS NULL OR discount < unit_price),
+  CHECK (quantity > 0 AND quantity <= 100)
+);
+
+CREATE TABLE order_tag (
+  order_id TEXT NOT NULL,
+  tag TEXT NOT NULL,
+  weight INTEGER,
+  PRIMARY KEY (order_id, tag),
+  UNIQUE (tag, weight),
+  FOREIGN KEY (order_id) REFERENCES "order"
+);
+
+CREATE TABLE sessions (
+  token TEXT NOT NULL UNIQUE,
+  account INTEGER REFERENCES accounts,
+  expires TIMESTAMP DEFAULT '2030-01-01 00:00:00',
+  archived BOOLEAN UNIQUE
+);
+CREATE TABLE shelf_slot (
+  shelf INTEGER NOT NULL,
+  slot INTEGER NOT NULL,
+  label TEXT,
+  PRIMARY KEY (shelf, slot)
+);
+CREATE VIEW live_accounts AS SELECT * FROM accounts WHERE deleted_at IS NULL;
+CREATE TABLE sqlite_stat1(tbl,idx,stat);
+COMMIT;
@@ -0,0 +1,191 @@
+package ir
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// the ir is the resolved schema written out as json so tools that aren't
+// written in go can use it without reimplementing the parser. it's produced
+// after mixins are expanded and enums are linked so everything in it is
+// final; mixins themselves don't appear.
+//
+// every enum in the model (data types, attributes, actions...) is written
+// by name rather than number so the document reads on its own and doesn't
+// break when constants are reordered. optional members are left out when
+// they're empty.
+//
+//	{
+//	  "version": 1,
+//	  "fingerprint": {"schema": "9f2c...", "entities": {"note": "41ab..."}},
+//	  "entities": [{
+//	    "name": "note",
+//	    "soft_delete": true,
+//	    "fields": [
+//	      {"name": "id", "kind": "primitive", "data_type": "uuid", "attributes": ["unique", "required", "primary"]},
+//	      {"name": "owner", "kind": "reference", "target": {"entity": "user", "field": "id"}},
+//	      {"name": "role", "kind": "primitive", "data_type": "enum", "enum": {"name": "user_role"}}
+//	    ],
+//	    "payload": ["owner", "role"],
+//	    "response": ["id", "owner", "role", "deleted_at"]
+//	  }],
+//	  "enums": [{"name": "user_role", "backing": "int", "members": [{"name": "admin", "value": "1"}]}],
+//	  "routes": [{"method": "GET", "path": "/notes/:id", "action": "find", "entity": "note", "match": {"field": "id", "source": ":id"}}]
+//	}
+//
+// payload and response list the fields of each shape in order. they're
+// derived from the fields' attributes and are only there for readers; Import
+// works them out again instead of trusting them.
+//
+// the version only goes up when a change would make an older reader
+// misunderstand a document. adding optional members doesn't count
+
+// Version is the ir version this engine writes and the newest it reads
+const Version = 1
+
+type Document struct {
+	Version int `json:"version"`
+	// see Hash. worked out again on import
+	Fingerprint *Fingerprint `json:"fingerprint,omitempty"`
+	Entities    []Entity     `json:"entities"`
+	Enums       []Enum       `json:"enums"`
+	Routes      []Route      `json:"routes"`
+}
+
+type Entity struct {
+	Name string `json:"name"`
+	// the doc comment written above the declaration. fields, enums and
+	// routes have one too
+	Doc        string   `json:"doc,omitempty"`
+	SoftDelete bool     `json:"soft_delete,omitempty"`
+	Fields     []Field  `json:"fields"`
+	Payload    []string `json:"payload"`
+	Response   []string `json:"response"`
+}
+
+type Field struct {
+	Name string `json:"name"`
+	Doc  string `json:"doc,omitempty"`
+	// primitive, reference, embedded or computed
+	Kind string `json:"kind"`
+	// text, int, real, bool, uuid, timestamp or enum. left out of references
+	// and embeds which take their type from what they point at
+	DataType   string    `json:"data_type,omitempty"`
+	Attributes []string  `json:"attributes,omitempty"`
+	Target     *Target   `json:"target,omitempty"`
+	Embedded   []Field   `json:"embedded,omitempty"`
+	Computed   *Computed `json:"computed,omitempty"`
+	Default    *Default  `json:"default,omitempty"`
+	OnUpdate   *Default  `json:"on_update,omitempty"`
+	// named enums only carry their name; inline lists carry their members
+	Enum    *Enum     `json:"enum,omitempty"`
+	Length  *Length   `json:"length,omitempty"`
+	Pattern string    `json:"pattern,omitempty"`
+	Check   *Computed `json:"check,omitempty"`
+	Origin  *Origin   `json:"origin,omitempty"`
+}
+
+// a max of zero is left out and means there's no upper bound
+type Length struct {
+	Min int `json:"min"`
+	Max int `json:"max,omitempty"`
+}
+
+type Target struct {
+	Entity string `json:"entity"`
+	Field  string `json:"field"`
+}
+
+// Computed carries a computed field's expression and also a check's
+type Computed struct {
+	// the expression in mime syntax e.g. first_name || " " || last_name
+	Source string `json:"source"`
+	Expr   *Expr  `json:"expr"`
+}
+
+type Expr struct {
+	// field, string, number, binary, call or reference
+	Kind string `json:"kind"`
+	// field name, literal, operator or function name depending on the kind
+	Value  string  `json:"value,omitempty"`
+	Args   []*Expr `json:"args,omitempty"`
+	Target *Target `json:"target,omitempty"`
+}
+
+type Default struct {
+	// literal or func
+	Kind  string   `json:"kind"`
+	Value string   `json:"value"`
+	Args  []string `json:"args,omitempty"`
+}
+
+type Enum struct {
+	// empty for inline lists
+	Name string `json:"name,omitempty"`
+	Doc  string `json:"doc,omitempty"`
+	// text or int
+	Backing string       `json:"backing,omitempty"`
+	Members []EnumMember `json:"members,omitempty"`
+}
+
+type EnumMember struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	Label       string `json:"label,omitempty"`
+	Description string `json:"description,omitempty"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+}
+
+type Origin struct {
+	Mixin   string `json:"mixin"`
+	Defined Pos    `json:"defined"`
+	Used    Pos    `json:"used"`
+}
+
+type Pos struct {
+	File string `json:"file,omitempty"`
+	Line int    `json:"line"`
+}
+
+type Route struct {
+	Doc    string `json:"doc,omitempty"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// find, create, update, delete or restore
+	Action   string    `json:"action"`
+	Entity   string    `json:"entity"`
+	Match    *Match    `json:"match,omitempty"`
+	Fallback *Response `json:"fallback,omitempty"`
+	Options  []string  `json:"options,omitempty"`
+}
+
+type Match struct {
+	Field  string `json:"field,omitempty"`
+	Source string `json:"source"`
+}
+
+type Response struct {
+	Status  int    `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Encode writes the schema's ir to w
+func Encode(w io.Writer, s *types.Schema) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(Export(s))
+}
+
+// Decode reads an ir document and rebuilds the schema it describes
+func Decode(r io.Reader) (*types.Schema, error) {
+	var doc Document
+	// unknown members are ignored so newer documents that only add to the
+	// format still load
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("ir: %w", err)
+	}
+	return Import(&doc)
+}
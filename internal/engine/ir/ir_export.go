@@ -0,0 +1,184 @@
+package ir
+
+import (
+	"slices"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// Export builds the ir document for a resolved schema
+func Export(s *types.Schema) *Document {
+	doc := export(s)
+	fp := Hash(s)
+	doc.Fingerprint = &fp
+	return doc
+}
+
+func export(s *types.Schema) *Document {
+	doc := &Document{
+		Version:  Version,
+		Entities: make([]Entity, 0, len(s.Entities)),
+		Enums:    make([]Enum, 0, len(s.Enums)),
+		Routes:   make([]Route, 0, len(s.Routes)),
+	}
+
+	for _, e := range s.Entities {
+		doc.Entities = append(doc.Entities, exportEntity(e))
+	}
+	for _, e := range s.Enums {
+		doc.Enums = append(doc.Enums, exportEnum(e))
+	}
+	for _, r := range s.Routes {
+		doc.Routes = append(doc.Routes, exportRoute(r))
+	}
+
+	return doc
+}
+
+func exportEntity(e *types.EntityNode) Entity {
+	return Entity{
+		Name:       e.Name,
+		Doc:        e.Doc,
+		SoftDelete: e.SoftDelete,
+		Fields:     exportFields(e.Fields),
+		Payload:    fieldNames(e.PayloadFields()),
+		Response:   fieldNames(e.ResponseFields()),
+	}
+}
+
+func fieldNames(fields []*types.Field) []string {
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func exportFields(fields []*types.Field) []Field {
+	out := make([]Field, 0, len(fields))
+	for _, f := range fields {
+		out = append(out, exportField(f))
+	}
+	return out
+}
+
+func exportComputed(e *types.Expr) *Computed {
+	return &Computed{Source: e.String(), Expr: exportExpr(e)}
+}
+
+func exportField(f *types.Field) Field {
+	out := Field{
+		Name:     f.Name,
+		Doc:      f.Doc,
+		Kind:     kindNames.name(f.Kind),
+		DataType: dataTypeNames.name(f.DataType),
+		Default:  exportDefault(f.Default),
+		OnUpdate: exportDefault(f.OnUpdate),
+	}
+
+	for _, a := range attributes {
+		if f.Attributes&a != 0 {
+			out.Attributes = append(out.Attributes, types.AttributeName(a))
+		}
+	}
+	if f.Target != nil {
+		out.Target = &Target{Entity: f.Target.Entity, Field: f.Target.Field}
+	}
+	if f.Embedded != nil {
+		out.Embedded = exportFields(f.Embedded)
+	}
+	if f.Computed != nil {
+		out.Computed = exportComputed(f.Computed)
+	}
+	if f.Length != nil {
+		out.Length = &Length{Min: f.Length.Min, Max: f.Length.Max}
+	}
+	out.Pattern = f.Pattern
+	if f.Check != nil {
+		out.Check = exportComputed(f.Check)
+	}
+	if f.Enum != nil {
+		if f.Enum.Inline() {
+			e := exportEnum(f.Enum)
+			out.Enum = &e
+		} else {
+			out.Enum = &Enum{Name: f.Enum.Name}
+		}
+	}
+	if o := f.Origin; o != nil {
+		out.Origin = &Origin{
+			Mixin:   o.Mixin,
+			Defined: Pos{File: o.Defined.File, Line: o.Defined.Line},
+			Used:    Pos{File: o.Used.File, Line: o.Used.Line},
+		}
+	}
+
+	return out
+}
+
+func exportExpr(e *types.Expr) *Expr {
+	out := &Expr{
+		Kind:  exprKindNames.name(e.Kind),
+		Value: e.Value,
+	}
+	for _, a := range e.Args {
+		out.Args = append(out.Args, exportExpr(a))
+	}
+	if e.Target != nil {
+		out.Target = &Target{Entity: e.Target.Entity, Field: e.Target.Field}
+	}
+	return out
+}
+
+func exportDefault(d *types.DefaultValue) *Default {
+	if d == nil {
+		return nil
+	}
+	return &Default{
+		Kind:  defaultKindNames.name(d.Kind),
+		Value: d.Value,
+		Args:  d.Args,
+	}
+}
+
+func exportEnum(e *types.EnumNode) Enum {
+	out := Enum{
+		Name:    e.Name,
+		Doc:     e.Doc,
+		Backing: dataTypeNames.name(e.Backing),
+		Members: make([]EnumMember, 0, len(e.Members)),
+	}
+	for _, m := range e.Members {
+		out.Members = append(out.Members, EnumMember{
+			Name:        m.Name,
+			Value:       m.Value,
+			Label:       m.Label,
+			Description: m.Description,
+			Deprecated:  m.Deprecated,
+		})
+	}
+	return out
+}
+
+func exportRoute(r *types.Route) Route {
+	out := Route{
+		Doc:    r.Doc,
+		Method: r.Method,
+		Path:   r.Path,
+		Action: r.Action.String(),
+		Entity: r.Entity,
+	}
+	if r.Match != nil {
+		out.Match = &Match{Field: r.Match.Field, Source: r.Match.Source}
+	}
+	if r.Fallback != nil {
+		out.Fallback = &Response{Status: r.Fallback.Status, Message: r.Fallback.Message}
+	}
+	for opt, name := range routeOptionNames {
+		if r.Options&opt != 0 {
+			out.Options = append(out.Options, name)
+		}
+	}
+	slices.Sort(out.Options)
+	return out
+}
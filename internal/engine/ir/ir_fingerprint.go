@@ -0,0 +1,172 @@
+package ir
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// a fingerprint is a hash of the parts of the schema that matter to the
+// database and the api. it's taken over a canonical copy of the ir where
+// everything that's only cosmetic is dropped or put in a fixed order:
+//
+//   - entities, fields, enums and routes are sorted by name so moving a
+//     declaration around doesn't change anything. enum members keep their
+//     order since it's the order a postgres enum compares and sorts in
+//   - where a mixin field came from and the rendered expression source are
+//     left out; the expression tree is kept
+//   - payload and response shapes are left out since the attributes decide
+//     them anyway
+//   - doc comments are left out
+//
+// other comments and formatting never make it into the ir in the first place.
+// entity hashes also cover the named enums their fields use so changing an
+// enum's members shows up on every entity storing it
+
+type Fingerprint struct {
+	Schema   string            `json:"schema"`
+	Entities map[string]string `json:"entities"`
+}
+
+// Hash fingerprints a resolved schema
+func Hash(s *types.Schema) Fingerprint {
+	doc := canonical(export(s))
+
+	enums := make(map[string]Enum, len(doc.Enums))
+	for _, e := range doc.Enums {
+		enums[e.Name] = e
+	}
+
+	fp := Fingerprint{Entities: make(map[string]string, len(doc.Entities))}
+	for _, e := range doc.Entities {
+		fp.Entities[e.Name] = hash(struct {
+			Version int    `json:"version"`
+			Entity  Entity `json:"entity"`
+			Enums   []Enum `json:"enums"`
+		}{Version, e, usedEnums(e.Fields, enums)})
+	}
+	fp.Schema = hash(doc)
+
+	return fp
+}
+
+// Generated marks output as generated the way go's tools expect, which
+// editors and code review tools recognise in other languages too
+const Generated = "Code generated by mime. DO NOT EDIT."
+
+// Header is the comment generated output starts with. it records which
+// schema the output came from and any notes go on lines of their own after
+// that. comment is the line comment of the language being written
+func Header(s *types.Schema, comment string, notes ...string) string {
+	var b strings.Builder
+	for _, line := range append([]string{Generated, "fingerprint: " + Hash(s).Schema}, notes...) {
+		b.WriteString(comment + " " + line + "\n")
+	}
+	return b.String()
+}
+
+// Changed lists the entities that were added, removed or changed between
+// two fingerprints in name order
+func (f Fingerprint) Changed(other Fingerprint) []string {
+	var changed []string
+	for name, h := range f.Entities {
+		if other.Entities[name] != h {
+			changed = append(changed, name)
+		}
+	}
+	for name := range other.Entities {
+		if _, ok := f.Entities[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+func hash(v any) string {
+	// json.Marshal sorts map keys and the structs have a fixed field order so
+	// the same value always encodes to the same bytes
+	b, err := json.Marshal(v)
+	if err != nil {
+		// only plain strings, ints and bools ever end up in here
+		panic(err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func canonical(doc *Document) *Document {
+	for i := range doc.Entities {
+		doc.Entities[i].Doc = ""
+		doc.Entities[i].Fields = canonicalFields(doc.Entities[i].Fields)
+		doc.Entities[i].Payload = nil
+		doc.Entities[i].Response = nil
+	}
+	slices.SortFunc(doc.Entities, func(a, b Entity) int { return cmp.Compare(a.Name, b.Name) })
+
+	for i := range doc.Enums {
+		canonicalEnum(&doc.Enums[i])
+	}
+	slices.SortFunc(doc.Enums, func(a, b Enum) int { return cmp.Compare(a.Name, b.Name) })
+
+	for i := range doc.Routes {
+		doc.Routes[i].Doc = ""
+	}
+	slices.SortFunc(doc.Routes, func(a, b Route) int {
+		return cmp.Or(cmp.Compare(a.Path, b.Path), cmp.Compare(a.Method, b.Method))
+	})
+
+	return doc
+}
+
+func canonicalFields(fields []Field) []Field {
+	for i := range fields {
+		f := &fields[i]
+		f.Origin = nil
+		f.Doc = ""
+		if f.Computed != nil {
+			f.Computed.Source = ""
+		}
+		if f.Check != nil {
+			f.Check.Source = ""
+		}
+		if f.Enum != nil {
+			canonicalEnum(f.Enum)
+		}
+		f.Embedded = canonicalFields(f.Embedded)
+	}
+	slices.SortFunc(fields, func(a, b Field) int { return cmp.Compare(a.Name, b.Name) })
+	return fields
+}
+
+func canonicalEnum(e *Enum) {
+	e.Doc = ""
+}
+
+func usedEnums(fields []Field, enums map[string]Enum) []Enum {
+	names := make(map[string]struct{})
+	var walk func([]Field)
+	walk = func(fields []Field) {
+		for _, f := range fields {
+			if f.Enum != nil && f.Enum.Name != "" {
+				names[f.Enum.Name] = struct{}{}
+			}
+			walk(f.Embedded)
+		}
+	}
+	walk(fields)
+
+	used := make([]Enum, 0, len(names))
+	for name := range names {
+		if e, ok := enums[name]; ok {
+			used = append(used, e)
+		}
+	}
+	slices.SortFunc(used, func(a, b Enum) int { return cmp.Compare(a.Name, b.Name) })
+	return used
+}
@@ -0,0 +1,122 @@
+package ir
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHashIgnoresCosmetics(t *testing.T) {
+	reordered := `# routes first this time
+routes @user ->
+	GET /admin/notes [admin] -> @note == params
+	GET /notes/:id -> @note.id == :id || respond 404 "note not found"
+	POST /signup -> create self || respond 400 "signup failed"
+end
+
+entity note [soft_delete] ->
+	title text [required]
+	category text ("work" "home")
+	owner @user.id
+	id uuid [required unique primary]
+end
+
+mixin timestamps ->
+	updated_at timestamp [on_update:now() default:now()]
+	created_at timestamp [default:now()]
+end
+
+entity user ->
+	use timestamps
+	note_count int = count(@note.owner)
+	full_name text = (first_name || " ") || last_name
+	role &user_role [default:"member"]
+	password text [hash hidden]
+	last_name text [length:0,80]
+	first_name text [check:(first_name != last_name) required]
+	id uuid [primary unique required default:uuid_v7()]
+end
+
+enum user_role ->
+	admin = 1 "Administrator"
+	member = 2
+	guest = 3 [deprecated]
+end
+`
+	a, b := Hash(parse(t, notes)), Hash(parse(t, reordered))
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("expected the same fingerprint\n%#v\n%#v", a, b)
+	}
+}
+
+func TestHashChanges(t *testing.T) {
+	base := Hash(parse(t, notes))
+
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		changed []string
+	}{
+		{
+			name:    "attribute",
+			old:     "title text [required]",
+			new:     "title text [required unique]",
+			changed: []string{"note"},
+		},
+		{
+			name:    "enum members",
+			old:     "guest [deprecated]",
+			new:     "guest = 5 [deprecated]",
+			changed: []string{"user"},
+		},
+		{
+			name:    "enum member order",
+			old:     "\tmember\n\tguest [deprecated]",
+			new:     "\tguest = 3 [deprecated]\n\tmember = 2",
+			changed: []string{"user"},
+		},
+		{
+			name:    "inline enum",
+			old:     `("work" "home")`,
+			new:     `("work" "home" "play")`,
+			changed: []string{"note"},
+		},
+		{
+			name:    "computed expression",
+			old:     `first_name || " " || last_name`,
+			new:     `first_name || last_name`,
+			changed: []string{"user"},
+		},
+		{
+			name: "route",
+			old:  `respond 404 "note not found"`,
+			new:  `respond 410 "note not found"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(notes, tt.old) {
+				t.Fatalf("for test %s: %q isn't in the schema", tt.name, tt.old)
+			}
+			fp := Hash(parse(t, strings.Replace(notes, tt.old, tt.new, 1)))
+
+			if fp.Schema == base.Schema {
+				t.Fatalf("for test %s: expected the schema hash to change", tt.name)
+			}
+			if changed := base.Changed(fp); !reflect.DeepEqual(changed, tt.changed) {
+				t.Fatalf("for test %s: expected %v to change, got %v", tt.name, tt.changed, changed)
+			}
+		})
+	}
+}
+
+func TestChangedAddedAndRemoved(t *testing.T) {
+	a := Fingerprint{Entities: map[string]string{"note": "1", "user": "2"}}
+	b := Fingerprint{Entities: map[string]string{"user": "2", "tag": "3"}}
+
+	if changed, want := a.Changed(b), []string{"note", "tag"}; !reflect.DeepEqual(changed, want) {
+		t.Fatalf("expected %v, got %v", want, changed)
+	}
+}
@@ -0,0 +1,231 @@
+package ir
+
+import (
+	"errors"
+	"fmt"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// Import rebuilds the schema an ir document describes. the result goes
+// through the same resolve and validate steps as a parsed schema so a
+// document edited by hand can't sneak anything past the engine
+func Import(doc *Document) (*types.Schema, error) {
+	if doc.Version < 1 || doc.Version > Version {
+		return nil, fmt.Errorf("ir: unsupported version %d; this engine reads up to %d", doc.Version, Version)
+	}
+
+	s := &types.Schema{}
+	for _, e := range doc.Enums {
+		enum, err := importEnum(e)
+		if err != nil {
+			return nil, fmt.Errorf("ir: enum '%s': %w", e.Name, err)
+		}
+		s.Enums = append(s.Enums, enum)
+	}
+	for _, e := range doc.Entities {
+		entity := &types.EntityNode{Name: e.Name, Doc: e.Doc, SoftDelete: e.SoftDelete}
+		fields, err := importFields(e.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("ir: entity '%s': %w", e.Name, err)
+		}
+		entity.Fields = fields
+		s.Entities = append(s.Entities, entity)
+	}
+	for _, r := range doc.Routes {
+		route, err := importRoute(r)
+		if err != nil {
+			return nil, fmt.Errorf("ir: route %s %s: %w", r.Method, r.Path, err)
+		}
+		s.Routes = append(s.Routes, route)
+	}
+
+	if errs := s.Resolve(); len(errs) > 0 {
+		return nil, fmt.Errorf("ir: %w", errors.Join(errs...))
+	}
+	if errs := s.Validate(); len(errs) > 0 {
+		return nil, fmt.Errorf("ir: %w", errors.Join(errs...))
+	}
+
+	return s, nil
+}
+
+func importFields(fields []Field) ([]*types.Field, error) {
+	out := make([]*types.Field, 0, len(fields))
+	for _, f := range fields {
+		field, err := importField(f)
+		if err != nil {
+			return nil, fmt.Errorf("field '%s': %w", f.Name, err)
+		}
+		out = append(out, field)
+	}
+	return out, nil
+}
+
+func importField(f Field) (*types.Field, error) {
+	kind, ok := kindNames.value(f.Kind)
+	if !ok {
+		return nil, fmt.Errorf("unknown kind %q", f.Kind)
+	}
+	field := &types.Field{Name: f.Name, Doc: f.Doc, Kind: kind}
+
+	if f.DataType != "" {
+		dt, ok := dataTypeNames.value(f.DataType)
+		if !ok {
+			return nil, fmt.Errorf("unknown data type %q", f.DataType)
+		}
+		field.DataType = dt
+	}
+	for _, name := range f.Attributes {
+		a, err := types.StringToAttribute(name)
+		if err != nil {
+			return nil, err
+		}
+		field.Attributes |= a
+	}
+	if f.Target != nil {
+		field.Target = &types.ReferenceTarget{Entity: f.Target.Entity, Field: f.Target.Field}
+	}
+	if f.Embedded != nil {
+		embedded, err := importFields(f.Embedded)
+		if err != nil {
+			return nil, err
+		}
+		field.Embedded = embedded
+	}
+	if f.Computed != nil {
+		if f.Computed.Expr == nil {
+			return nil, fmt.Errorf("computed field without an expression")
+		}
+		expr, err := importExpr(f.Computed.Expr)
+		if err != nil {
+			return nil, err
+		}
+		field.Computed = expr
+	}
+	if f.Length != nil {
+		field.Length = &types.Length{Min: f.Length.Min, Max: f.Length.Max}
+	}
+	field.Pattern = f.Pattern
+	if f.Check != nil {
+		if f.Check.Expr == nil {
+			return nil, fmt.Errorf("check without an expression")
+		}
+		expr, err := importExpr(f.Check.Expr)
+		if err != nil {
+			return nil, err
+		}
+		field.Check = expr
+	}
+
+	var err error
+	if field.Default, err = importDefault(f.Default); err != nil {
+		return nil, err
+	}
+	if field.OnUpdate, err = importDefault(f.OnUpdate); err != nil {
+		return nil, err
+	}
+
+	if f.Enum != nil {
+		if f.Enum.Name != "" {
+			// linked to the declared enum by Resolve
+			field.Enum = &types.EnumNode{Name: f.Enum.Name}
+		} else if field.Enum, err = importEnum(*f.Enum); err != nil {
+			return nil, err
+		}
+	}
+	if o := f.Origin; o != nil {
+		field.Origin = &types.Origin{
+			Mixin:   o.Mixin,
+			Defined: types.Pos{File: o.Defined.File, Line: o.Defined.Line},
+			Used:    types.Pos{File: o.Used.File, Line: o.Used.Line},
+		}
+	}
+
+	return field, nil
+}
+
+func importExpr(e *Expr) (*types.Expr, error) {
+	kind, ok := exprKindNames.value(e.Kind)
+	if !ok {
+		return nil, fmt.Errorf("unknown expression kind %q", e.Kind)
+	}
+	out := &types.Expr{Kind: kind, Value: e.Value}
+	for _, a := range e.Args {
+		arg, err := importExpr(a)
+		if err != nil {
+			return nil, err
+		}
+		out.Args = append(out.Args, arg)
+	}
+	if e.Target != nil {
+		out.Target = &types.ReferenceTarget{Entity: e.Target.Entity, Field: e.Target.Field}
+	}
+	return out, nil
+}
+
+func importDefault(d *Default) (*types.DefaultValue, error) {
+	if d == nil {
+		return nil, nil
+	}
+	kind, ok := defaultKindNames.value(d.Kind)
+	if !ok {
+		return nil, fmt.Errorf("unknown default kind %q", d.Kind)
+	}
+	return &types.DefaultValue{Kind: kind, Value: d.Value, Args: d.Args}, nil
+}
+
+func importEnum(e Enum) (*types.EnumNode, error) {
+	backing, ok := dataTypeNames.value(e.Backing)
+	if !ok {
+		return nil, fmt.Errorf("unknown backing type %q", e.Backing)
+	}
+	out := &types.EnumNode{
+		Name:    e.Name,
+		Doc:     e.Doc,
+		Backing: backing,
+		Members: make([]types.EnumMember, 0, len(e.Members)),
+	}
+	for _, m := range e.Members {
+		out.Members = append(out.Members, types.EnumMember{
+			Name:        m.Name,
+			Value:       m.Value,
+			Label:       m.Label,
+			Description: m.Description,
+			Deprecated:  m.Deprecated,
+		})
+	}
+	// values are always written out so this only catches duplicates
+	if err := out.Finalize(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func importRoute(r Route) (*types.Route, error) {
+	action, ok := types.StringToRouteAction(r.Action)
+	if !ok {
+		return nil, fmt.Errorf("unknown action %q", r.Action)
+	}
+	route := &types.Route{
+		Doc:    r.Doc,
+		Method: r.Method,
+		Path:   r.Path,
+		Action: action,
+		Entity: r.Entity,
+	}
+	if r.Match != nil {
+		route.Match = &types.RouteMatch{Field: r.Match.Field, Source: r.Match.Source}
+	}
+	if r.Fallback != nil {
+		route.Fallback = &types.Response{Status: r.Fallback.Status, Message: r.Fallback.Message}
+	}
+	for _, name := range r.Options {
+		opt, err := types.StringToRouteOption(name)
+		if err != nil {
+			return nil, err
+		}
+		route.Options |= opt
+	}
+	return route, nil
+}
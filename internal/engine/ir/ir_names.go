@@ -0,0 +1,75 @@
+package ir
+
+import "willofdaedalus/mime/internal/engine/types"
+
+// names maps the engine's constants to the names the ir uses for them. the
+// names are part of the format so they never change once published
+
+type names[T comparable] map[T]string
+
+func (n names[T]) name(v T) string {
+	return n[v]
+}
+
+func (n names[T]) value(s string) (T, bool) {
+	for v, name := range n {
+		if name == s {
+			return v, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+var kindNames = names[types.FieldKind]{
+	types.FieldPrimitive: "primitive",
+	types.FieldReference: "reference",
+	types.FieldEmbedded:  "embedded",
+	types.FieldComputed:  "computed",
+}
+
+var dataTypeNames = names[types.DataType]{
+	types.DataText:      "text",
+	types.DataInt:       "int",
+	types.DataBool:      "bool",
+	types.DataReal:      "real",
+	types.DataUUID:      "uuid",
+	types.DataEnum:      "enum",
+	types.DataTimestamp: "timestamp",
+	types.DataDecimal:   "decimal",
+}
+
+var exprKindNames = names[types.ExprKind]{
+	types.ExprField:     "field",
+	types.ExprString:    "string",
+	types.ExprNumber:    "number",
+	types.ExprBinary:    "binary",
+	types.ExprCall:      "call",
+	types.ExprReference: "reference",
+}
+
+var defaultKindNames = names[types.DefaultKind]{
+	types.DefaultLiteral: "literal",
+	types.DefaultFunc:    "func",
+}
+
+var routeOptionNames = names[types.RouteOption]{
+	types.RouteAdmin: "admin",
+}
+
+// every attribute in the order they're written out
+var attributes = []types.Attribute{
+	types.AttrDefault,
+	types.AttrHash,
+	types.AttrUnique,
+	types.AttrRequired,
+	types.AttrIncrement,
+	types.AttrOverride,
+	types.AttrPrimary,
+	types.AttrHidden,
+	types.AttrReadonly,
+	types.AttrOnUpdate,
+	types.AttrLength,
+	types.AttrPattern,
+	types.AttrCheck,
+}
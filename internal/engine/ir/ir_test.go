@@ -0,0 +1,165 @@
+package ir
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/parser"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+const notes = `enum user_role ->
+	admin = 1 "Administrator"
+	member
+	guest [deprecated]
+end
+
+mixin timestamps ->
+	created_at timestamp [default:now()]
+	updated_at timestamp [default:now() on_update:now()]
+end
+
+entity user ->
+	id uuid [primary unique required default:uuid_v7()]
+	first_name text [required check:first_name != last_name]
+	last_name text [length:,80]
+	password text [hidden hash]
+	role &user_role [default:"member"]
+	full_name text = first_name || " " || last_name
+	note_count int = count(@note.owner)
+	use timestamps
+end
+
+# a note a user wrote
+entity note [soft_delete] ->
+	id uuid [primary unique required]
+	# the user who wrote it
+	owner @user.id
+	category text ("work" "home")
+	title text [required]
+end
+
+routes @user ->
+	# new users sign themselves up
+	POST /signup -> create self || respond 400 "signup failed"
+	GET /notes/:id -> @note.id == :id || respond 404 "note not found"
+	GET /admin/notes [admin] -> @note == params
+end
+`
+
+func parse(t *testing.T, input string) *types.Schema {
+	t.Helper()
+	s, errs := parser.NewParser(lexer.NewFile("notes.mime", input)).Schema()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected parse errors: %v", errs)
+	}
+	return s
+}
+
+func TestRoundTrip(t *testing.T) {
+	s := parse(t, notes)
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, s); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	got, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	// mixins are expanded away and aren't part of the ir
+	s.Mixins = nil
+	if !reflect.DeepEqual(got, s) {
+		a, _ := json.MarshalIndent(Export(s), "", "  ")
+		b, _ := json.MarshalIndent(Export(got), "", "  ")
+		t.Fatalf("round trip changed the schema\nexpected:\n%s\ngot:\n%s", a, b)
+	}
+}
+
+func TestExport(t *testing.T) {
+	doc := Export(parse(t, notes))
+
+	if doc.Version != Version {
+		t.Fatalf("expected version %d, got %d", Version, doc.Version)
+	}
+
+	user := doc.Entities[0]
+	if want := []string{"id", "first_name", "last_name", "password", "role", "created_at", "updated_at"}; !reflect.DeepEqual(user.Payload, want) {
+		t.Fatalf("expected payload %v, got %v", want, user.Payload)
+	}
+	if want := []string{"id", "first_name", "last_name", "role", "full_name", "note_count", "created_at", "updated_at"}; !reflect.DeepEqual(user.Response, want) {
+		t.Fatalf("expected response %v, got %v", want, user.Response)
+	}
+
+	fullName := user.Fields[5]
+	if fullName.Kind != "computed" || fullName.Computed.Source != `(first_name || " ") || last_name` {
+		t.Fatalf("unexpected computed field %s %q", fullName.Kind, fullName.Computed.Source)
+	}
+
+	role := user.Fields[4]
+	if role.DataType != "enum" || !reflect.DeepEqual(role.Enum, &Enum{Name: "user_role"}) {
+		t.Fatalf("expected role to refer to user_role by name, got %#v", role.Enum)
+	}
+
+	created := user.Fields[7]
+	want := &Origin{Mixin: "timestamps", Defined: Pos{File: "notes.mime", Line: 7}, Used: Pos{File: "notes.mime", Line: 20}}
+	if !reflect.DeepEqual(created.Origin, want) {
+		t.Fatalf("expected origin %#v, got %#v", want, created.Origin)
+	}
+
+	admin := doc.Routes[2]
+	if !reflect.DeepEqual(admin.Options, []string{"admin"}) || admin.Action != "find" {
+		t.Fatalf("unexpected admin route %#v", admin)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "newer version",
+			input:   `{"version": 2}`,
+			wantErr: "unsupported version 2",
+		},
+		{
+			name:    "missing version",
+			input:   `{"entities": []}`,
+			wantErr: "unsupported version 0",
+		},
+		{
+			name:    "unknown data type",
+			input:   `{"version": 1, "entities": [{"name": "note", "fields": [{"name": "at", "kind": "primitive", "data_type": "date"}]}]}`,
+			wantErr: `entity 'note': field 'at': unknown data type "date"`,
+		},
+		{
+			name:    "reference to a missing entity",
+			input:   `{"version": 1, "entities": [{"name": "note", "fields": [{"name": "owner", "kind": "reference", "target": {"entity": "user", "field": "id"}}]}]}`,
+			wantErr: "references unknown entity 'user'",
+		},
+		{
+			name:    "unknown action",
+			input:   `{"version": 1, "routes": [{"method": "GET", "path": "/", "action": "list", "entity": "note"}]}`,
+			wantErr: `route GET /: unknown action "list"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(tt.input))
+			if err == nil {
+				t.Fatalf("for test %s: expected an error", tt.name)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("for test %s: expected error containing %q, got %q", tt.name, tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ package lexer
 
 import (
 	"fmt"
+	"strings"
 	"unicode"
 )
 
@@ -12,9 +13,11 @@ type TokenType int
 // lexer struct
 type Lexer struct {
 	input        string
+	fileName     string
 	position     int  // current position in input
 	readPosition int  // next position to read
 	ch           byte // current character being examined
+	line         int  // line of the current character
 }
 
 // token struct
@@ -23,15 +26,26 @@ type Token struct {
 	Type     TokenType
 	Literal  string
 	LineNum  int
+	// what a comment says after its #; the literal is only ever "#"
+	Text string
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewFile("", input)
+}
+
+// NewFile is New for input read from a file; tokens carry the file name so
+// errors can point back at it
+func NewFile(fileName, input string) *Lexer {
+	l := &Lexer{input: input, fileName: fileName, line: 1}
 	l.readChar()
 	return l
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+	}
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -51,11 +65,20 @@ func (l Lexer) RenderTokens() {
 }
 
 func (l *Lexer) NextToken() Token {
-	var tok Token
-
 	// this might backfire especially if we need structure
 	l.skipWhitespace()
 
+	line := l.line
+	tok := l.scanToken()
+	tok.FileName = l.fileName
+	tok.LineNum = line
+
+	return tok
+}
+
+func (l *Lexer) scanToken() Token {
+	var tok Token
+
 	switch l.ch {
 	case '.':
 		tok = newToken(TokenDot, l.ch)
@@ -65,7 +88,9 @@ func (l *Lexer) NextToken() Token {
 		tok = newToken(TokenConsClose, l.ch)
 	case '#':
 		tok = newToken(TokenComment, l.ch)
+		start := l.readPosition
 		l.skipComment()
+		tok.Text = strings.TrimSpace(l.input[start:l.position])
 	case '[':
 		tok = newToken(TokenEnumOpen, l.ch)
 	case ']':
@@ -84,15 +109,29 @@ func (l *Lexer) NextToken() Token {
 		tok = newToken(TokenAmpersand, l.ch)
 	case '*':
 		tok = newToken(TokenStar, l.ch)
+	case ',':
+		tok = newToken(TokenComma, l.ch)
+	case '=':
+		tok = l.matchOrUnknown('=', TokenEquals, TokenAssign)
+	case '+':
+		tok = newToken(TokenPlus, l.ch)
+	case '!':
+		tok = l.matchOrUnknown('=', TokenNotEq, TokenUnknown)
+	case '<':
+		tok = l.matchOrUnknown('=', TokenLessEq, TokenLess)
+	case '>':
+		tok = l.matchOrUnknown('=', TokenGreaterEq, TokenGreater)
+	case '|':
+		tok = l.matchOrUnknown('|', TokenPipes, TokenUnknown)
 	case '-':
-		tok = l.matchOrUnknown('>', TokenArrow, TokenUnknown)
+		tok = l.matchOrUnknown('>', TokenArrow, TokenMinus)
 	case '/':
 		if unicode.IsLetter(rune(l.peekChar())) {
 			tok.Literal = l.collectEndpointStr()
 			tok.Type = TokenEndpoint
 			return tok
 		}
-		tok = newToken(TokenUnknown, l.ch)
+		tok = newToken(TokenSlash, l.ch)
 	case '"':
 		tok.Type = TokenString
 		tok.Literal = l.readString()
@@ -11,6 +11,7 @@ const (
 	TokenTypeBool      // bool
 	TokenTypeTimestamp // timestamp
 	TokenTypeUuid      // uuid
+	TokenTypeDecimal   // decimal
 	TokenTypeRoutes    // routes
 	// keywords
 	TokenAlter    // alter
@@ -18,6 +19,9 @@ const (
 	TokenRef      // ref
 	TokenSelf     // self
 	TokenEnd      // end
+	TokenMixin    // mixin
+	TokenTemplate // template
+	TokenUse      // use
 	TokenEndpoint // /employees/:id
 	// symbols
 	TokenArrow     // ->
@@ -34,6 +38,18 @@ const (
 	TokenNewline   // \n
 	TokenDot       // .
 	TokenColon     // :
+	TokenComma     // ,
+	TokenAssign    // = for computed fields
+	TokenPlus      // +
+	TokenMinus     // -
+	TokenSlash     // /
+	TokenPipes     // || for text concatenation and route fallbacks
+	TokenEquals    // == for route matches
+	TokenNotEq     // !=
+	TokenLess      // <
+	TokenLessEq    // <=
+	TokenGreater   // >
+	TokenGreaterEq // >=
 	// values
 	TokenIdent       // identifiers like id, student, payload
 	TokenString      // string literals (e.g., `"male"`, `"female"`)
@@ -44,6 +60,7 @@ const (
 	TokenPost   // POST
 	TokenPut    // PUT
 	TokenDelete // DELETE
+	TokenPatch  // PATCH
 	// constraints
 	TokenConstraintAutoIncrement // increment
 	TokenConstraintUnique        // unique
@@ -51,6 +68,7 @@ const (
 	TokenConstraintPrimaryKey    // primary
 	TokenConstraintNotNull       // required
 	TokenConstraintDefault       // default
+	TokenConstraintOnUpdate      // on_update
 	TokenEOF
 	TokenUnknown
 )
@@ -62,22 +80,29 @@ var Keywords = map[string]TokenType{
 	"float":     TokenTypeFloat,
 	"int":       TokenTypeInt,
 	"text":      TokenTypeText,
+	"bool":      TokenTypeBool,
 	"timestamp": TokenTypeTimestamp,
 	"uuid":      TokenTypeUuid,
+	"decimal":   TokenTypeDecimal,
 	"routes":    TokenTypeRoutes,
 	"alter":     TokenAlter,
 	"ref":       TokenRef,
 	"self":      TokenSelf,
 	"end":       TokenEnd,
+	"mixin":     TokenMixin,
+	"template":  TokenTemplate,
+	"use":       TokenUse,
 	// http verbs
 	"GET":    TokenGet,
 	"POST":   TokenPost,
 	"DELETE": TokenDelete,
 	"PUT":    TokenPut,
+	"PATCH":  TokenPatch,
 	// constraints
 	"increment": TokenConstraintAutoIncrement,
 	"unique":    TokenConstraintUnique,
 	"default":   TokenConstraintDefault,
+	"on_update": TokenConstraintOnUpdate,
 	"fk":        TokenConstraintForeignKey,
 	"primary":   TokenConstraintPrimaryKey,
 	"required":  TokenConstraintNotNull,
@@ -89,6 +114,8 @@ var AllDataTypes = map[TokenType]struct{}{
 	TokenTypeText:      {},
 	TokenTypeFloat:     {},
 	TokenTypeUuid:      {},
+	TokenTypeBool:      {},
+	TokenTypeDecimal:   {},
 }
 
 var allConstraints = map[TokenType]struct{}{
@@ -133,6 +160,8 @@ func (t TokenType) String() string {
 		return "TOKEN_timestamp"
 	case TokenTypeUuid:
 		return "TOKEN_uuid"
+	case TokenTypeDecimal:
+		return "TOKEN_decimal"
 	case TokenTypeRoutes:
 		return "TOKEN_routes"
 	case TokenAlter:
@@ -145,6 +174,12 @@ func (t TokenType) String() string {
 		return "TOKEN_bool"
 	case TokenEnd:
 		return "TOKEN_end"
+	case TokenMixin:
+		return "TOKEN_mixin"
+	case TokenTemplate:
+		return "TOKEN_template"
+	case TokenUse:
+		return "TOKEN_use"
 	case TokenEndpoint:
 		return "TOKEN_endpoint"
 	case TokenArrow:
@@ -169,6 +204,30 @@ func (t TokenType) String() string {
 		return "TOKEN_dot"
 	case TokenColon:
 		return "TOKEN_colon"
+	case TokenComma:
+		return "TOKEN_comma"
+	case TokenAssign:
+		return "TOKEN_assign"
+	case TokenPlus:
+		return "TOKEN_plus"
+	case TokenMinus:
+		return "TOKEN_minus"
+	case TokenSlash:
+		return "TOKEN_slash"
+	case TokenPipes:
+		return "TOKEN_pipes"
+	case TokenEquals:
+		return "TOKEN_equals"
+	case TokenNotEq:
+		return "TOKEN_noteq"
+	case TokenLess:
+		return "TOKEN_less"
+	case TokenLessEq:
+		return "TOKEN_lesseq"
+	case TokenGreater:
+		return "TOKEN_greater"
+	case TokenGreaterEq:
+		return "TOKEN_greatereq"
 	case TokenIdent:
 		return "TOKEN_ident"
 	case TokenString:
@@ -185,6 +244,8 @@ func (t TokenType) String() string {
 		return "TOKEN_put"
 	case TokenDelete:
 		return "TOKEN_delete"
+	case TokenPatch:
+		return "TOKEN_patch"
 	case TokenConstraintAutoIncrement:
 		return "TOKEN_autoincrement"
 	case TokenConstraintUnique:
@@ -197,6 +258,8 @@ func (t TokenType) String() string {
 		return "TOKEN_required"
 	case TokenConstraintDefault:
 		return "TOKEN_default"
+	case TokenConstraintOnUpdate:
+		return "TOKEN_onupdate"
 	case TokenEOF:
 		return "TOKEN_eof"
 	case TokenUnknown:
@@ -3,15 +3,20 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/types"
 )
 
 type keywordHandler func(parser *Parser) node
 
 var handlers = map[lexer.TokenType]keywordHandler{
-	lexer.TokenEntity: handleEntity,
-	lexer.TokenEnum:   handleEnum,
+	lexer.TokenEntity:     handleEntity,
+	lexer.TokenEnum:       handleEnum,
+	lexer.TokenTypeRoutes: handleRoutes,
+	lexer.TokenMixin:      handleMixin,
+	lexer.TokenTemplate:   handleMixin,
 }
 
 type node interface {
@@ -26,7 +31,13 @@ type Parser struct {
 	parserErrors   []parserError
 	errors         []error
 	nodes          map[string]node
+	schema         types.Schema
 	invalidParsing bool
+
+	// the run of comment lines the lexer last went through; see takeDoc
+	doc      []string
+	docLine  int
+	lastLine int
 }
 
 func NewParser(l *lexer.Lexer) *Parser {
@@ -44,18 +55,145 @@ func NewParser(l *lexer.Lexer) *Parser {
 func (p *Parser) advanceToken() {
 	p.curToken = p.nextToken
 	p.nextToken = p.lex.NextToken()
+	p.noteDoc(p.nextToken)
+}
+
+// noteDoc keeps track of comments that sit on lines of their own. comments
+// after something else on the line describe that line and aren't docs
+func (p *Parser) noteDoc(tok lexer.Token) {
+	switch tok.Type {
+	case lexer.TokenNewline, lexer.TokenEOF:
+		return
+	case lexer.TokenComment:
+		if tok.LineNum != p.lastLine {
+			if tok.LineNum != p.docLine+1 {
+				p.doc = nil
+			}
+			p.doc = append(p.doc, tok.Text)
+			p.docLine = tok.LineNum
+		}
+	}
+	p.lastLine = tok.LineNum
+}
+
+// takeDoc returns the doc comment of the declaration starting at curToken,
+// which is the comment lines right above it. a blank line in between means
+// the comment isn't about the declaration
+func (p *Parser) takeDoc() string {
+	if len(p.doc) == 0 || p.curToken.LineNum != p.docLine+1 {
+		return ""
+	}
+	return strings.Join(p.doc, "\n")
 }
 
 // { "entity", entityHandler() }
 func (p *Parser) ParseTokens() {
 	for p.curToken.Type != lexer.TokenEOF {
-		if handler, ok := handlers[p.curToken.Type]; ok {
-			v := handler(p)
-			if v != nil {
-				p.nodes[p.curToken.Literal] = v
-			}
+		switch p.curToken.Type {
+		case lexer.TokenNewline, lexer.TokenComment:
+			p.advanceToken() // skip newlines and comments
+			continue
+		}
+
+		handler, ok := handlers[p.curToken.Type]
+		if !ok {
+			p.addError(ParserLogError, fmt.Sprintf("line %d: unexpected %s at top level",
+				p.curToken.LineNum, p.curToken.Literal))
+			p.advanceToken()
+			skipToTok(p, lexer.TokenNewline)
+			// the error is already recorded; don't let it throw away the
+			// next node too
+			p.resetContext()
+			continue
+		}
+		p.collect(handler(p))
+	}
+}
+
+// collect files a parsed node away in the schema. handlers return typed nils
+// when they fail so those are dropped here
+func (p *Parser) collect(n node) {
+	switch v := n.(type) {
+	case *types.EntityNode:
+		if v != nil {
+			p.schema.Entities = append(p.schema.Entities, v)
+		}
+	case *types.EnumNode:
+		if v != nil {
+			p.schema.Enums = append(p.schema.Enums, v)
+		}
+	case *types.RoutesNode:
+		if v != nil {
+			p.schema.Routes = append(p.schema.Routes, v.Routes...)
+		}
+	case *types.MixinNode:
+		if v != nil {
+			p.schema.Mixins = append(p.schema.Mixins, v)
+		}
+	}
+}
+
+// Schema parses everything left in the input and hands back the expanded,
+// resolved and validated schema. the schema is only returned when there were
+// no errors at all
+func (p *Parser) Schema() (*types.Schema, []error) {
+	p.ParseTokens()
+
+	if errs := p.Errors(); len(errs) > 0 {
+		return nil, errs
+	}
+	return finish(&p.schema)
+}
+
+type Source struct {
+	Name  string
+	Input string
+}
+
+// ParseSources is Schema for a schema split over several files. declarations
+// in one file can use anything declared in the others
+func ParseSources(srcs ...Source) (*types.Schema, []error) {
+	s := &types.Schema{}
+	var errs []error
+
+	for _, src := range srcs {
+		p := NewParser(lexer.NewFile(src.Name, src.Input))
+		p.ParseTokens()
+		if e := p.Errors(); len(e) > 0 {
+			errs = append(errs, e...)
+			continue
+		}
+		s.Entities = append(s.Entities, p.schema.Entities...)
+		s.Enums = append(s.Enums, p.schema.Enums...)
+		s.Routes = append(s.Routes, p.schema.Routes...)
+		s.Mixins = append(s.Mixins, p.schema.Mixins...)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return finish(s)
+}
+
+func finish(s *types.Schema) (*types.Schema, []error) {
+	// each step relies on the one before it having succeeded
+	for _, step := range []func() []error{s.Expand, s.Resolve, s.Validate} {
+		if errs := step(); len(errs) > 0 {
+			return nil, errs
+		}
+	}
+	return s, nil
+}
+
+// Errors returns everything that went wrong while parsing; warnings are left out
+func (p *Parser) Errors() []error {
+	errs := make([]error, 0, len(p.parserErrors)+len(p.errors))
+	for _, e := range p.parserErrors {
+		if e.errorLevel == ParserLogError {
+			errs = append(errs, e)
 		}
 	}
+	return append(errs, p.errors...)
 }
 
 func (p *Parser) findEntityNode(name string) (*entityNode, error) {
@@ -71,7 +71,7 @@ func (f FieldAttributes) validateAttributeConflicts() error {
 		// optional warning — not fatal
 		fmt.Printf("warning: field %s has both primary and unique (redundant)\n", f.Name)
 	}
-	if attrs&AttrOverride != 0 && dt != types.DataEntity && dt != TypeArray {
+	if attrs&AttrOverride != 0 && dt != types.DataRef {
 		fmt.Printf("warning: field %s uses override but isn't a nested/array field\n", f.Name)
 	}
 	return nil
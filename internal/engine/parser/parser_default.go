@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"fmt"
+
+	l "willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// example values
+// default:"18"
+// default:now()
+// default:sequence("invoice_no")
+func parseDefaultValue(p *Parser) (*types.DefaultValue, error) {
+	switch p.curToken.Type {
+	case l.TokenString, l.TokenDigits, l.TokenDigitsFloat:
+		v := &types.DefaultValue{
+			Kind:  types.DefaultLiteral,
+			Value: p.curToken.Literal,
+		}
+		p.advanceToken() // consume the literal
+		return v, nil
+	case l.TokenIdent:
+		return parseDefaultFunc(p)
+	}
+
+	return nil, fmt.Errorf("expected a value or function for default, got %s", p.curToken.Literal)
+}
+
+func parseDefaultFunc(p *Parser) (*types.DefaultValue, error) {
+	name := p.curToken.Literal
+	if !types.IsDefaultFunc(name) {
+		return nil, fmt.Errorf("unknown default function %s", name)
+	}
+	p.advanceToken() // consume function name
+
+	if p.curToken.Type != l.TokenListOpen {
+		return nil, fmt.Errorf("expected ( after %s, got %s", name, p.curToken.Literal)
+	}
+	p.advanceToken() // consume '('
+
+	fn := &types.DefaultValue{
+		Kind:  types.DefaultFunc,
+		Value: name,
+	}
+	for p.curToken.Type != l.TokenListClose {
+		if p.curToken.Type != l.TokenString {
+			return nil, fmt.Errorf("unclosed call to %s: expected )", name)
+		}
+		fn.Args = append(fn.Args, p.curToken.Literal)
+		p.advanceToken() // consume argument
+
+		if p.curToken.Type == l.TokenComma {
+			p.advanceToken() // consume ','
+		}
+	}
+	p.advanceToken() // consume ')'
+
+	return fn, nil
+}
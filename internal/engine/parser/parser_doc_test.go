@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"testing"
+
+	"willofdaedalus/mime/internal/engine/lexer"
+)
+
+func TestDocComments(t *testing.T) {
+	input := `# how much access a user has
+enum role ->
+	admin
+	member
+end
+
+# someone who can sign in
+# and write notes
+entity user ->
+	id uuid [primary unique required]
+	# where we send receipts
+	email text [required] # trailing comments aren't docs
+	# this one's detached by the blank line
+
+	name text
+	# the user's role
+	role &role
+end
+
+routes @user ->
+	# look a user up by id
+	GET /users/:id -> @user.id == :id
+	POST /users -> create self
+end
+`
+	s, errs := NewParser(lexer.New(input)).Schema()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	user := s.Entity("user")
+	tests := []struct {
+		what, got, want string
+	}{
+		{"enum", s.Enum("role").Doc, "how much access a user has"},
+		{"entity", user.Doc, "someone who can sign in\nand write notes"},
+		{"field", user.Field("email").Doc, "where we send receipts"},
+		{"field without a comment", user.Field("id").Doc, ""},
+		{"field after a blank line", user.Field("name").Doc, ""},
+		{"field with a comment after a trailing one", user.Field("role").Doc, "the user's role"},
+		{"route", s.Routes[0].Doc, "look a user up by id"},
+		{"route without a comment", s.Routes[1].Doc, ""},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got doc %q, want %q", tt.what, tt.got, tt.want)
+		}
+	}
+}
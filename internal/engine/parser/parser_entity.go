@@ -33,10 +33,13 @@ func (e entityNode) NodeLiteral() string {
 }
 
 func handleEntity(p *Parser) node {
+	defer p.resetContext()
+
 	if !expectTokOf(p.curToken, lexer.TokenEntity) {
 		p.pushError(fmt.Sprintf("expected entity token, got %s", p.curToken.Type))
 		return nil
 	}
+	doc := p.takeDoc()
 	p.advanceToken() // consume 'entity'
 
 	if !expectTokOf(p.curToken, lexer.TokenIdent) {
@@ -45,11 +48,35 @@ func handleEntity(p *Parser) node {
 		return nil
 	}
 
-	entity := types.EntityNode{
+	entity := &types.EntityNode{
 		Name: p.curToken.Literal,
+		Doc:  doc,
 	}
 	p.advanceToken() // consume entity name
 
+	// generated from a template e.g. entity note_audit = audit_log<note>
+	if p.curToken.Type == lexer.TokenAssign {
+		p.advanceToken() // consume '='
+		use, err := parseMixinRef(p)
+		if err != nil {
+			p.addError(ParserLogError, fmt.Sprintf("entity %s: %s", entity.Name, err.Error()))
+			return (*types.EntityNode)(nil)
+		}
+		entity.Uses = append(entity.Uses, use)
+		return entity
+	}
+
+	// entity options e.g. entity note [soft_delete] ->
+	var options []string
+	if p.curToken.Type == lexer.TokenEnumOpen {
+		opts, err := parseEntityOptions(p)
+		if err != nil {
+			p.pushError(fmt.Sprintf("entity %s: %s", entity.Name, err.Error()))
+			return nil
+		}
+		options = opts
+	}
+
 	// check for arrow token
 	if !expectTokOf(p.curToken, lexer.TokenArrow) {
 		p.pushError(fmt.Sprintf("expected -> after entity name, got %s", p.curToken.Type))
@@ -58,9 +85,51 @@ func handleEntity(p *Parser) node {
 	}
 	p.advanceToken() // consume '->'
 
+	fields, uses, ok := parseEntityBody(p, entity.Name)
+	if !ok {
+		return nil
+	}
+	entity.Fields = fields
+	entity.Uses = uses
+
+	// options are applied once the fields are known since some of them
+	// depend on fields the user may or may not have declared
+	for _, opt := range options {
+		if err := types.StringToEntityOption(entity, opt); err != nil {
+			p.addError(ParserLogError, fmt.Sprintf("entity %s: %s", entity.Name, err.Error()))
+		}
+	}
+
+	if p.invalidParsing {
+		return (*types.EntityNode)(nil)
+	}
+
+	return entity
+}
+
+// parseEntityBody collects fields and `use` lines up to and including the
+// closing end. entities and mixins share it
+func parseEntityBody(p *Parser, name string) ([]*types.Field, []*types.MixinUse, bool) {
+	var fields []*types.Field
+	var uses []*types.MixinUse
+
 	for p.curToken.Type != lexer.TokenEnd {
-		if p.curToken.Type == lexer.TokenComment {
-			p.advanceToken()
+		switch p.curToken.Type {
+		case lexer.TokenEOF:
+			p.pushError(fmt.Sprintf("expected end keyword at end of %s", name))
+			return nil, nil, false
+		case lexer.TokenNewline, lexer.TokenComment:
+			p.advanceToken() // skip newlines and comments
+			continue
+		case lexer.TokenUse:
+			u, err := parseUse(p, len(fields))
+			if err != nil {
+				p.addError(ParserLogError, err.Error())
+				skipToTok(p, lexer.TokenNewline, lexer.TokenEnd)
+				continue
+			}
+			uses = append(uses, u...)
+			continue
 		}
 
 		f, err := parseField(p)
@@ -68,13 +137,35 @@ func handleEntity(p *Parser) node {
 			p.addError(ParserLogError, err.Error())
 			// probably not needed considering we'll discard
 			// everything once the parser has an error
+			skipToTok(p, lexer.TokenNewline, lexer.TokenEnd)
 			continue
 		}
 
-		entity.Fields = append(entity.Fields, f)
+		fields = append(fields, f)
 	}
+	p.advanceToken() // consume 'end'
 
-	return entity
+	return fields, uses, true
+}
+
+func parseEntityOptions(p *Parser) ([]string, error) {
+	var options []string
+	p.advanceToken() // consume '['
+
+	for p.curToken.Type != lexer.TokenEnumClose {
+		if p.curToken.Type != lexer.TokenIdent {
+			return nil, fmt.Errorf("expected entity option, got %s", p.curToken.Literal)
+		}
+		options = append(options, p.curToken.Literal)
+		p.advanceToken() // consume option
+
+		if p.curToken.Type == lexer.TokenComma {
+			p.advanceToken() // consume ','
+		}
+	}
+	p.advanceToken() // consume ']'
+
+	return options, nil
 }
 
 func (p *Parser) parseEntity() *entityNode {
@@ -174,7 +265,7 @@ func (p *Parser) parseField() *longField {
 	// continue parsing annotations until newline or unexpected token
 	for p.curToken.Type != lexer.TokenNewline {
 		switch p.curToken.Type {
-		case lexer.TokenEnumOpen:
+		case lexer.TokenListOpen:
 			enums := p.parseEnums(fieldDataType)
 			// enums being nil means we got an error
 			if enums == nil {
@@ -189,12 +280,14 @@ func (p *Parser) parseField() *longField {
 				return nil
 			}
 			f.consInfo = cons
-		case lexer.TokenListOpen:
-			if p.nextToken.Type != lexer.TokenListClose {
+		case lexer.TokenEnumOpen:
+			if p.nextToken.Type != lexer.TokenEnumClose {
 				p.pushError(fmt.Sprintf("%s:%d; expected ], got %s",
 					p.curToken.FileName, p.curToken.LineNum, p.nextToken.Literal))
 				return nil
 			}
+			p.advanceToken() // consume '['
+			p.advanceToken() // consume ']'
 		default:
 			if _, ok := lexer.AnnotationOpens[p.curToken.Type]; !ok {
 				// invalid token found where annotation was expected
@@ -209,7 +302,7 @@ func (p *Parser) parseField() *longField {
 	// rule; it's payload friendly (see list/map) and doesn't have any offending
 	// constraints such as increment
 	if _, ok := payloadFriendly[f.dt]; ok {
-		if f.consInfo.kind&consIncrement == 0 {
+		if f.consInfo == nil || f.consInfo.kind&consIncrement == 0 {
 			f.fieldFlags |= flagPayload
 		}
 	}
@@ -242,7 +335,7 @@ func (p *Parser) parseEnums(fdt lexer.TokenType) []any {
 	}
 
 	// parse enum values until closing parenthesis
-	for p.curToken.Type != lexer.TokenEnumClose && p.curToken.Type != lexer.TokenEOF {
+	for p.curToken.Type != lexer.TokenListClose && p.curToken.Type != lexer.TokenEOF {
 		// make sure the user is not adding unrelated data types
 		if p.curToken.Type == expectedType {
 			// convert token to appropriate value type based on data type
@@ -272,14 +365,17 @@ func (p *Parser) parseEnums(fdt lexer.TokenType) []any {
 		return nil
 	}
 
-	if p.curToken.Type == lexer.TokenEnumClose {
-		p.advanceToken() // consume ')'
-	} else {
+	if p.curToken.Type != lexer.TokenListClose {
 		p.pushError(fmt.Sprintf("%s:%d; unclosed enum definition",
 			p.curToken.FileName, p.curToken.LineNum))
 		return nil
 	}
-
+	p.advanceToken() // consume ')'
+	if len(enums) == 0 {
+		p.pushError(fmt.Sprintf("%s:%d; enum list can't be empty",
+			p.curToken.FileName, p.curToken.LineNum))
+		return nil
+	}
 	return enums
 }
 
@@ -349,8 +445,10 @@ func (p *Parser) parseConstraints(fdt lexer.TokenType) *constraintInfo {
 				return nil
 			}
 
-			// set the value for the constraint
-			result.value = &p.curToken.Literal
+			// set the value for the constraint; the token is overwritten
+			// when the parser advances so its literal is copied
+			value := p.curToken.Literal
+			result.value = &value
 			p.advanceToken() // consume value token
 		}
 	}
@@ -297,7 +297,7 @@ end`,
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := NewParser(lexer.New(tt.input))
-			actual := p.parseEntity()
+			actual := withoutShapes(p.parseEntity())
 
 			if !reflect.DeepEqual(actual, tt.expected) {
 				t.Fatalf("for %s:\nexpected:\n%v\ngot:\n%v", tt.name, tt.expected, actual)
@@ -306,6 +306,43 @@ end`,
 	}
 }
 
+// withoutShapes clears the payload and response the parser derives from the
+// fields so the tests above only compare what was parsed; TestEntityShapes
+// covers the shapes
+func withoutShapes(e *entityNode) *entityNode {
+	if e == nil {
+		return nil
+	}
+	e.payload, e.response = entityObject{}, entityObject{}
+	for i := range e.fields {
+		e.fields[i].fieldFlags = 0
+	}
+	return e
+}
+
+func TestEntityShapes(t *testing.T) {
+	p := NewParser(lexer.New("entity user ->\n\tid int {primary increment}\n\tname text\n\tage int {required}\nend"))
+	e := p.parseEntity()
+	if e == nil {
+		t.Fatalf("unexpected errors: %v", p.Errors())
+	}
+
+	names := func(obj entityObject) []string {
+		var out []string
+		for _, f := range obj.fields {
+			out = append(out, *f.name)
+		}
+		return out
+	}
+	// fields without constraints are writable; incremented ones never are
+	if got, want := names(e.payload), []string{"name", "age"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected payload %v, got %v", want, got)
+	}
+	if got, want := names(e.response), []string{"id", "name", "age"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected response %v, got %v", want, got)
+	}
+}
+
 func TestParseEntityConstraints(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -568,7 +605,7 @@ func TestParseEntityConstraints(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := NewParser(lexer.New(tt.input))
-			actual := p.parseEntity()
+			actual := withoutShapes(p.parseEntity())
 
 			if !reflect.DeepEqual(actual, tt.expected) {
 				t.Fatalf("for %s:\nexpected:\n%v\ngot:\n%v", tt.name, tt.expected, actual)
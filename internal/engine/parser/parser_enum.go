@@ -15,6 +15,7 @@ func handleEnum(p *Parser) node {
 		p.addError(ParserLogError,
 			fmt.Sprintf("expected enum, got %s", p.curToken.Type))
 	}
+	doc := p.takeDoc()
 	p.advanceToken() // consume enum
 
 	if !expectTokOf(p.curToken, lexer.TokenIdent) {
@@ -24,8 +25,9 @@ func handleEnum(p *Parser) node {
 	}
 
 	enumNode := &types.EnumNode{
-		Members: make([]string, 0, 10),
+		Members: make([]types.EnumMember, 0, 10),
 		Name:    p.curToken.Literal,
+		Doc:     doc,
 	}
 	p.advanceToken() // consume "name"
 
@@ -38,21 +40,19 @@ func handleEnum(p *Parser) node {
 	p.advanceToken() // consume '->'
 
 	for p.curToken.Type != lexer.TokenEnd {
-		if p.curToken.Type == lexer.TokenComment {
-			p.advanceToken() // skip comments
-			continue
-		}
-
-		// unexpected end to file with no end keyword
-		if p.curToken.Type == lexer.TokenEOF {
+		switch p.curToken.Type {
+		case lexer.TokenEOF:
+			// unexpected end to file with no end keyword
 			return (*types.EnumNode)(nil)
+		case lexer.TokenNewline, lexer.TokenComment:
+			p.advanceToken() // skip newlines and comments
+			continue
 		}
 
 		if p.curToken.Type != lexer.TokenIdent {
 			p.addError(ParserLogError,
 				fmt.Sprintf("expected enum member got %s", p.curToken.Type))
-			skipToTok(p, lexer.TokenIdent)
-			// p.advanceToken()
+			skipToTok(p, lexer.TokenNewline, lexer.TokenEnd)
 			continue
 		}
 
@@ -64,15 +64,21 @@ func handleEnum(p *Parser) node {
 			p.advanceToken()
 			continue
 		}
-		if slices.Contains(enumNode.Members, v) {
+		if slices.ContainsFunc(enumNode.Members, func(m types.EnumMember) bool { return m.Name == v }) {
 			p.addError(ParserLogError, fmt.Sprintf("duplicate enum member %s", v))
 			p.advanceToken()
 			continue
 		}
 
-		enumNode.Members = append(enumNode.Members, v)
-		p.advanceToken()
+		member, err := parseEnumMember(p)
+		if err != nil {
+			p.addError(ParserLogError, err.Error())
+			skipToTok(p, lexer.TokenNewline, lexer.TokenEnd)
+			continue
+		}
+		enumNode.Members = append(enumNode.Members, *member)
 	}
+	p.advanceToken() // consume 'end'
 
 	if len(enumNode.Members) == 0 {
 		// this won't trigger a p.invalidParsing but will generate a warning
@@ -80,6 +86,10 @@ func handleEnum(p *Parser) node {
 			enumNode.Name))
 	}
 
+	if err := enumNode.Finalize(); err != nil {
+		p.addError(ParserLogError, err.Error())
+	}
+
 	if p.invalidParsing {
 		// this passes the test instead of the usual nil
 		return (*types.EnumNode)(nil)
@@ -88,6 +98,60 @@ func handleEnum(p *Parser) node {
 	return enumNode
 }
 
+// example members
+// admin
+// admin = 1 "Administrator" "can do everything"
+// guest [deprecated]
+func parseEnumMember(p *Parser) (*types.EnumMember, error) {
+	m := &types.EnumMember{Name: p.curToken.Literal}
+	p.advanceToken() // consume member name
+
+	if p.curToken.Type == lexer.TokenAssign {
+		p.advanceToken() // consume '='
+		switch p.curToken.Type {
+		case lexer.TokenDigits, lexer.TokenString:
+			m.Value = p.curToken.Literal
+		default:
+			return nil, fmt.Errorf("expected an integer or string value for %s, got %s",
+				m.Name, p.curToken.Literal)
+		}
+		p.advanceToken() // consume value
+	}
+
+	// an optional display label followed by an optional description
+	if p.curToken.Type == lexer.TokenString {
+		m.Label = p.curToken.Literal
+		p.advanceToken() // consume label
+	}
+	if p.curToken.Type == lexer.TokenString {
+		m.Description = p.curToken.Literal
+		p.advanceToken() // consume description
+	}
+
+	if p.curToken.Type == lexer.TokenEnumOpen {
+		p.advanceToken() // consume '['
+		for p.curToken.Type != lexer.TokenEnumClose {
+			if p.curToken.Type == lexer.TokenNewline || p.curToken.Type == lexer.TokenEOF {
+				return nil, fmt.Errorf("unclosed options for %s: expected ]", m.Name)
+			}
+			if err := types.StringToEnumOption(m, p.curToken.Literal); err != nil {
+				return nil, err
+			}
+			p.advanceToken() // consume option
+		}
+		p.advanceToken() // consume ']'
+	}
+
+	// the lexer folds a newline into any whitespace before it so the next
+	// member can show up without one
+	switch p.curToken.Type {
+	case lexer.TokenNewline, lexer.TokenComment, lexer.TokenEnd, lexer.TokenEOF, lexer.TokenIdent:
+		return m, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %s after enum member %s", p.curToken.Literal, m.Name)
+}
+
 func validateEnumValue(s string) error {
 	// check that it doesn't conflict with any keywords
 	if _, ok := lexer.Keywords[s]; ok {
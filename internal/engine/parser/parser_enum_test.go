@@ -22,8 +22,13 @@ func TestEnumHandler(t *testing.T) {
 			Admin
 			end`,
 			expected: &types.EnumNode{
-				Name:    "role",
-				Members: []string{"admin", "user", "Admin"},
+				Name: "role",
+				Members: []types.EnumMember{
+					{Name: "admin", Value: "admin"},
+					{Name: "user", Value: "user"},
+					{Name: "Admin", Value: "Admin"},
+				},
+				Backing: types.DataText,
 			},
 		},
 		{
@@ -41,7 +46,8 @@ func TestEnumHandler(t *testing.T) {
 			end`,
 			expected: &types.EnumNode{
 				Name:    "role",
-				Members: []string{},
+				Members: []types.EnumMember{},
+				Backing: types.DataText,
 			},
 		},
 		{
@@ -58,8 +64,11 @@ func TestEnumHandler(t *testing.T) {
 			_admin123
 			end`,
 			expected: &types.EnumNode{
-				Name:    "role",
-				Members: []string{"_admin123"},
+				Name: "role",
+				Members: []types.EnumMember{
+					{Name: "_admin123", Value: "_admin123"},
+				},
+				Backing: types.DataText,
 			},
 		},
 		{
@@ -69,7 +78,8 @@ func TestEnumHandler(t *testing.T) {
 			end`,
 			expected: &types.EnumNode{
 				Name:    "role",
-				Members: []string{},
+				Members: []types.EnumMember{},
+				Backing: types.DataText,
 			},
 		},
 		{
@@ -85,8 +95,11 @@ func TestEnumHandler(t *testing.T) {
 			_admin
 			end`,
 			expected: &types.EnumNode{
-				Name:    "role",
-				Members: []string{"_admin"},
+				Name: "role",
+				Members: []types.EnumMember{
+					{Name: "_admin", Value: "_admin"},
+				},
+				Backing: types.DataText,
 			},
 		},
 		{
@@ -122,8 +135,12 @@ func TestEnumHandler(t *testing.T) {
 			user
 			end garbage`,
 			expected: &types.EnumNode{
-				Name:    "role",
-				Members: []string{"admin", "user"},
+				Name: "role",
+				Members: []types.EnumMember{
+					{Name: "admin", Value: "admin"},
+					{Name: "user", Value: "user"},
+				},
+				Backing: types.DataText,
 			},
 		},
 
@@ -137,7 +154,8 @@ func TestEnumHandler(t *testing.T) {
 			end`,
 			expected: &types.EnumNode{
 				Name:    "role",
-				Members: []string{},
+				Members: []types.EnumMember{},
+				Backing: types.DataText,
 			},
 		},
 		{
@@ -147,8 +165,12 @@ func TestEnumHandler(t *testing.T) {
 			user
 			end # no more roles`,
 			expected: &types.EnumNode{
-				Name:    "role",
-				Members: []string{"admin", "user"},
+				Name: "role",
+				Members: []types.EnumMember{
+					{Name: "admin", Value: "admin"},
+					{Name: "user", Value: "user"},
+				},
+				Backing: types.DataText,
 			},
 		},
 		{
@@ -158,16 +180,24 @@ func TestEnumHandler(t *testing.T) {
 			user
 			end`,
 			expected: &types.EnumNode{
-				Name:    "role",
-				Members: []string{"admin", "user"},
+				Name: "role",
+				Members: []types.EnumMember{
+					{Name: "admin", Value: "admin"},
+					{Name: "user", Value: "user"},
+				},
+				Backing: types.DataText,
 			},
 		},
 		{
 			name:  "enum with windows line endings",
 			input: "enum role ->\r\nadmin\r\nuser\r\nend",
 			expected: &types.EnumNode{
-				Name:    "role",
-				Members: []string{"admin", "user"},
+				Name: "role",
+				Members: []types.EnumMember{
+					{Name: "admin", Value: "admin"},
+					{Name: "user", Value: "user"},
+				},
+				Backing: types.DataText,
 			},
 		},
 		{
@@ -184,8 +214,12 @@ func TestEnumHandler(t *testing.T) {
 			Admin
 			end`,
 			expected: &types.EnumNode{
-				Name:    "role",
-				Members: []string{"admin", "Admin"},
+				Name: "role",
+				Members: []types.EnumMember{
+					{Name: "admin", Value: "admin"},
+					{Name: "Admin", Value: "Admin"},
+				},
+				Backing: types.DataText,
 			},
 		},
 		{
@@ -230,3 +264,85 @@ func TestEnumHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestEnumMemberDetails(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *types.EnumNode
+	}{
+		{
+			name: "explicit values carry on counting",
+			input: `enum role ->
+			admin = 10 "Administrator" "can do everything"
+			user
+			guest = 20 [deprecated]
+			end`,
+			expected: &types.EnumNode{
+				Name: "role",
+				Members: []types.EnumMember{
+					{Name: "admin", Value: "10", Label: "Administrator", Description: "can do everything"},
+					{Name: "user", Value: "11"},
+					{Name: "guest", Value: "20", Deprecated: true},
+				},
+				Backing: types.DataInt,
+			},
+		},
+		{
+			name: "text values",
+			input: `enum role ->
+			admin = "ADM" "Administrator"
+			user
+			end`,
+			expected: &types.EnumNode{
+				Name: "role",
+				Members: []types.EnumMember{
+					{Name: "admin", Value: "ADM", Label: "Administrator"},
+					{Name: "user", Value: "user"},
+				},
+				Backing: types.DataText,
+			},
+		},
+		{
+			name: "mixed value types",
+			input: `enum role ->
+			admin = 1
+			user = "USR"
+			end`,
+			expected: nil,
+		},
+		{
+			name: "duplicate values",
+			input: `enum role ->
+			admin = 2
+			owner = 1
+			user
+			end`,
+			expected: nil,
+		},
+		{
+			name: "unknown option",
+			input: `enum role ->
+			admin [secret]
+			end`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(lexer.New(tt.input))
+			actual := handleEnum(p)
+
+			if tt.expected == nil {
+				if n, ok := actual.(*types.EnumNode); ok && n != nil {
+					t.Fatalf("for test %s: expected nil, got %#v", tt.name, actual)
+				}
+				return
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("for test %s:\nexpected:\n%#v\ngot:\n%#v", tt.name, tt.expected, actual)
+			}
+		})
+	}
+}
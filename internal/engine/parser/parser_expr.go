@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"fmt"
+
+	l "willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// operators are listed from the loosest to the tightest binding so
+// `a || b * c` parses as `a || (b * c)` and `a > 1 and b < 2` as
+// `(a > 1) and (b < 2)`
+var exprPrecedence = map[l.TokenType]int{
+	l.TokenEquals:    3,
+	l.TokenNotEq:     3,
+	l.TokenLess:      3,
+	l.TokenLessEq:    3,
+	l.TokenGreater:   3,
+	l.TokenGreaterEq: 3,
+	l.TokenPipes:     4,
+	l.TokenPlus:      5,
+	l.TokenMinus:     5,
+	l.TokenStar:      6,
+	l.TokenSlash:     6,
+}
+
+// and and or aren't keywords so they arrive as identifiers
+var wordPrecedence = map[string]int{
+	"or":  1,
+	"and": 2,
+}
+
+func exprOperator(tok l.Token) (int, bool) {
+	if tok.Type == l.TokenIdent {
+		prec, ok := wordPrecedence[tok.Literal]
+		return prec, ok
+	}
+	prec, ok := exprPrecedence[tok.Type]
+	return prec, ok
+}
+
+// example expressions
+// first_name || " " || last_name
+// count(@note.owner)
+// age >= 18 and age < 150
+func parseExpr(p *Parser, minPrec int) (*types.Expr, error) {
+	left, err := parseExprOperand(p)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		prec, ok := exprOperator(p.curToken)
+		if !ok || prec < minPrec {
+			return left, nil
+		}
+		op := p.curToken.Literal
+		p.advanceToken() // consume the operator
+
+		right, err := parseExpr(p, prec+1)
+		if err != nil {
+			return nil, err
+		}
+
+		left = &types.Expr{
+			Kind:  types.ExprBinary,
+			Value: op,
+			Args:  []*types.Expr{left, right},
+		}
+	}
+}
+
+func parseExprOperand(p *Parser) (*types.Expr, error) {
+	tok := p.curToken
+
+	switch tok.Type {
+	case l.TokenString:
+		p.advanceToken()
+		return &types.Expr{Kind: types.ExprString, Value: tok.Literal}, nil
+	case l.TokenDigits, l.TokenDigitsFloat:
+		p.advanceToken()
+		return &types.Expr{Kind: types.ExprNumber, Value: tok.Literal}, nil
+	case l.TokenAtSymbol:
+		p.advanceToken() // consume @
+		target, err := parseReferenceTarget(p)
+		if err != nil {
+			return nil, err
+		}
+		p.advanceToken() // consume the referenced field name
+		return &types.Expr{Kind: types.ExprReference, Target: target}, nil
+	case l.TokenListOpen:
+		p.advanceToken() // consume '('
+		inner, err := parseExpr(p, 1)
+		if err != nil {
+			return nil, err
+		}
+		if p.curToken.Type != l.TokenListClose {
+			return nil, fmt.Errorf("expected ')' to close expression, got %s", p.curToken.Literal)
+		}
+		p.advanceToken() // consume ')'
+		return inner, nil
+	case l.TokenIdent:
+		p.advanceToken()
+		if p.curToken.Type != l.TokenListOpen {
+			return &types.Expr{Kind: types.ExprField, Value: tok.Literal}, nil
+		}
+		return parseExprCall(p, tok.Literal)
+	}
+
+	return nil, fmt.Errorf("unexpected %s in expression", tok.Type)
+}
+
+func parseExprCall(p *Parser, name string) (*types.Expr, error) {
+	if !types.IsExprFunc(name) {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+	p.advanceToken() // consume '('
+
+	call := &types.Expr{Kind: types.ExprCall, Value: name}
+	for p.curToken.Type != l.TokenListClose {
+		if p.curToken.Type == l.TokenNewline || p.curToken.Type == l.TokenEOF {
+			return nil, fmt.Errorf("unclosed call to %s: expected )", name)
+		}
+
+		arg, err := parseExpr(p, 1)
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+
+		if p.curToken.Type == l.TokenComma {
+			p.advanceToken() // consume ','
+		}
+	}
+	p.advanceToken() // consume ')'
+
+	return call, nil
+}
@@ -2,14 +2,22 @@ package parser
 
 import (
 	"fmt"
+	"strconv"
 
 	l "willofdaedalus/mime/internal/engine/lexer"
 	"willofdaedalus/mime/internal/engine/types"
 )
 
 func parseField(p *Parser) (*types.Field, error) {
-	var field *types.Field
+	doc := p.takeDoc()
+	field, err := parseFieldKind(p)
+	if field != nil {
+		field.Doc = doc
+	}
+	return field, err
+}
 
+func parseFieldKind(p *Parser) (*types.Field, error) {
 	// check for embed (@entity)
 	if p.curToken.Type == l.TokenAtSymbol {
 		p.advanceToken() // consume @
@@ -19,7 +27,10 @@ func parseField(p *Parser) (*types.Field, error) {
 			p.addError(ParserLogError,
 				fmt.Sprintf("expected entity name after '@', got %s", p.curToken.Literal))
 		}
-		field.Name = p.curToken.Literal
+		field := &types.Field{
+			Name: p.curToken.Literal,
+			Kind: types.FieldEmbedded,
+		}
 
 		p.advanceToken() // consume entity name
 
@@ -30,9 +41,7 @@ func parseField(p *Parser) (*types.Field, error) {
 			// return nil, fmt.Errorf("unexpected token after embedded entity: %s", p.curToken.Literal)
 		}
 
-		return &types.Field{
-			Kind: types.FieldEmbedded,
-		}, nil
+		return field, nil
 	}
 
 	// otherwise, treat as primitive field
@@ -40,7 +49,7 @@ func parseField(p *Parser) (*types.Field, error) {
 }
 
 func parseFieldNormal(p *Parser) (*types.Field, error) {
-	var field *types.Field
+	field := &types.Field{}
 
 	// field name
 	if p.curToken.Type != l.TokenIdent {
@@ -71,8 +80,9 @@ func parseFieldNormal(p *Parser) (*types.Field, error) {
 				fmt.Sprintf("expected enum name after '&', got %s", p.curToken.Literal))
 			// return nil, fmt.Errorf("expected enum name after '&', got %s", p.curToken.Literal)
 		}
-		// for enum references, you might want to store this differently
+		// the real enum is linked in once the whole schema is known
 		field.DataType = types.DataEnum
+		field.Enum = &types.EnumNode{Name: p.curToken.Literal}
 		p.advanceToken()
 	} else {
 		// regular data type
@@ -85,21 +95,34 @@ func parseFieldNormal(p *Parser) (*types.Field, error) {
 		field.DataType = dt
 		field.Kind = types.FieldPrimitive
 		p.advanceToken()
+
+		// inline enums e.g. category text ("minor" "adult")
+		if p.curToken.Type == l.TokenListOpen {
+			enum, err := parseInlineEnum(p, field)
+			if err != nil {
+				return nil, err
+			}
+			field.Enum = enum
+		}
+
+		// computed fields derive their value from an expression
+		// full_name text = first_name || " " || last_name
+		if p.curToken.Type == l.TokenAssign {
+			p.advanceToken() // consume '='
+			expr, err := parseExpr(p, 1)
+			if err != nil {
+				return nil, fmt.Errorf("computed field %s: %w", field.Name, err)
+			}
+			field.Kind = types.FieldComputed
+			field.Computed = expr
+		}
 	}
 
 	// parse attributes if present
 	if p.curToken.Type == l.TokenEnumOpen {
-		attrs, err := parseAttributes(p)
-		if err != nil {
+		if err := parseAttributes(p, field); err != nil {
 			return nil, err
 		}
-
-		// convert slice to bitmask
-		var attrMask types.Attribute
-		for _, attr := range attrs {
-			attrMask |= attr
-		}
-		field.Attributes = attrMask
 	}
 
 	// make sure line ends correctly
@@ -111,46 +134,153 @@ func parseFieldNormal(p *Parser) (*types.Field, error) {
 	return field, nil
 }
 
-func parseAttributes(p *Parser) ([]types.Attribute, error) {
-	var attributes []types.Attribute
-
+// example attributes
+// [required unique]
+// [readonly default:now() on_update:now()]
+func parseAttributes(p *Parser, field *types.Field) error {
 	// consume opening bracket
 	if p.curToken.Type != l.TokenEnumOpen {
-		p.addError(ParserLogError, fmt.Sprintf("expected '[' to start attributes"))
-		// return nil, fmt.Errorf("expected '[' to start attributes")
+		return fmt.Errorf("expected '[' to start attributes")
 	}
 	p.advanceToken()
 
 	for p.curToken.Type != l.TokenEnumClose {
-		if p.curToken.Type == l.TokenEOF {
-			p.addError(ParserLogError, fmt.Sprintf("unexpected EOF while parsing attributes"))
-			// return nil, fmt.Errorf("unexpected EOF while parsing attributes")
+		if p.curToken.Type == l.TokenEOF || p.curToken.Type == l.TokenNewline {
+			return fmt.Errorf("unclosed attributes for field %s: expected ]", field.Name)
 		}
 
-		if p.curToken.Type == l.TokenIdent {
-			attr, err := types.StringToAttribute(p.curToken.Literal)
-			if err != nil {
-				return nil, fmt.Errorf("unknown attribute: %s", p.curToken.Literal)
-			}
-			attributes = append(attributes, attr)
-			p.advanceToken()
+		// most attributes share their name with a constraint keyword so go
+		// by the literal rather than the token type
+		attr, err := types.StringToAttribute(p.curToken.Literal)
+		if err != nil {
+			return err
 		}
+		field.Attributes |= attr
+		p.advanceToken()
+
+		if p.curToken.Type != l.TokenColon {
+			continue
+		}
+		p.advanceToken() // consume ':'
 
-		// // Handle comma separation or whitespace
-		// if p.curToken.Type == l.TokenComma {
-		// 	p.advanceToken()
-		// } else if p.curToken.Type != l.TokenEnumClose {
-		// 	// Skip whitespace or other separators if needed
-		// 	p.advanceToken()
-		// }
+		if err := parseAttributeValue(p, field, attr); err != nil {
+			return err
+		}
 	}
 
 	// Consume closing bracket
-	if p.curToken.Type == l.TokenEnumClose {
-		p.advanceToken()
+	p.advanceToken()
+
+	return nil
+}
+
+// example values
+// default:"18" on_update:now()
+// length:3,20 pattern:"^[a-z]+$"
+// check:age >= 18
+func parseAttributeValue(p *Parser, field *types.Field, attr types.Attribute) error {
+	switch attr {
+	case types.AttrDefault, types.AttrOnUpdate:
+		value, err := parseDefaultValue(p)
+		if err != nil {
+			return err
+		}
+		if attr == types.AttrDefault {
+			field.Default = value
+		} else {
+			field.OnUpdate = value
+		}
+	case types.AttrLength:
+		length, err := parseLength(p)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		field.Length = length
+	case types.AttrPattern:
+		if p.curToken.Type != l.TokenString {
+			return fmt.Errorf("field %s: expected a quoted regex for pattern, got %s", field.Name, p.curToken.Literal)
+		}
+		field.Pattern = p.curToken.Literal
+		p.advanceToken() // consume the regex
+	case types.AttrCheck:
+		expr, err := parseExpr(p, 1)
+		if err != nil {
+			return fmt.Errorf("check on field %s: %w", field.Name, err)
+		}
+		field.Check = expr
+	default:
+		return fmt.Errorf("attribute %s doesn't take a value", types.AttributeName(attr))
+	}
+
+	return nil
+}
+
+// either bound can be left out e.g. length:8, or length:,280
+func parseLength(p *Parser) (*types.Length, error) {
+	length := &types.Length{}
+
+	bound := func(v *int) error {
+		if p.curToken.Type != l.TokenDigits {
+			return nil
+		}
+		n, err := strconv.Atoi(p.curToken.Literal)
+		if err != nil {
+			return fmt.Errorf("bad length %s", p.curToken.Literal)
+		}
+		*v = n
+		p.advanceToken() // consume the bound
+		return nil
+	}
+
+	if err := bound(&length.Min); err != nil {
+		return nil, err
+	}
+	if p.curToken.Type != l.TokenComma {
+		return nil, fmt.Errorf("expected length:min,max, got %s", p.curToken.Literal)
+	}
+	p.advanceToken() // consume ','
+	if err := bound(&length.Max); err != nil {
+		return nil, err
+	}
+
+	return length, nil
+}
+
+func parseInlineEnum(p *Parser, field *types.Field) (*types.EnumNode, error) {
+	expected := l.TokenString
+	switch field.DataType {
+	case types.DataText:
+	case types.DataInt:
+		expected = l.TokenDigits
+	default:
+		return nil, fmt.Errorf("field %s: only text and int fields take a list of values", field.Name)
+	}
+	p.advanceToken() // consume '('
+
+	var values []string
+	for p.curToken.Type != l.TokenListClose {
+		if p.curToken.Type != expected {
+			return nil, fmt.Errorf("field %s: unexpected %s in list of values", field.Name, p.curToken.Type)
+		}
+		values = append(values, p.curToken.Literal)
+		p.advanceToken() // consume value
+
+		if p.curToken.Type == l.TokenComma {
+			p.advanceToken() // consume ','
+		}
+	}
+	p.advanceToken() // consume ')'
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("field %s: empty list of values", field.Name)
+	}
+
+	enum := types.InlineEnum(field.DataType, values)
+	if err := enum.Finalize(); err != nil {
+		return nil, fmt.Errorf("field %s: %w", field.Name, err)
 	}
 
-	return attributes, nil
+	return enum, nil
 }
 
 func parseReferenceTarget(p *Parser) (*types.ReferenceTarget, error) {
@@ -0,0 +1,347 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+func TestParseComputedField(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *types.Field
+	}{
+		{
+			name:  "text concatenation",
+			input: `full_name text = first_name || " " || last_name`,
+			expected: &types.Field{
+				Name:     "full_name",
+				Kind:     types.FieldComputed,
+				DataType: types.DataText,
+				Computed: &types.Expr{
+					Kind:  types.ExprBinary,
+					Value: "||",
+					Args: []*types.Expr{
+						{
+							Kind:  types.ExprBinary,
+							Value: "||",
+							Args: []*types.Expr{
+								{Kind: types.ExprField, Value: "first_name"},
+								{Kind: types.ExprString, Value: " "},
+							},
+						},
+						{Kind: types.ExprField, Value: "last_name"},
+					},
+				},
+			},
+		},
+		{
+			name:  "aggregate over a reference",
+			input: `note_count int = count(@note.owner)`,
+			expected: &types.Field{
+				Name:     "note_count",
+				Kind:     types.FieldComputed,
+				DataType: types.DataInt,
+				Computed: &types.Expr{
+					Kind:  types.ExprCall,
+					Value: "count",
+					Args: []*types.Expr{
+						{Kind: types.ExprReference, Target: &types.ReferenceTarget{Entity: "note", Field: "owner"}},
+					},
+				},
+			},
+		},
+		{
+			name:  "precedence",
+			input: `total float = base + price * quantity`,
+			expected: &types.Field{
+				Name:     "total",
+				Kind:     types.FieldComputed,
+				DataType: types.DataReal,
+				Computed: &types.Expr{
+					Kind:  types.ExprBinary,
+					Value: "+",
+					Args: []*types.Expr{
+						{Kind: types.ExprField, Value: "base"},
+						{
+							Kind:  types.ExprBinary,
+							Value: "*",
+							Args: []*types.Expr{
+								{Kind: types.ExprField, Value: "price"},
+								{Kind: types.ExprField, Value: "quantity"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:     "unknown function",
+			input:    `shout text = yell(name)`,
+			expected: nil,
+		},
+		{
+			name:     "unclosed call",
+			input:    `shout text = upper(name`,
+			expected: nil,
+		},
+		{
+			name:     "dangling operator",
+			input:    `full_name text = first_name ||`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(lexer.New(tt.input))
+			actual, err := parseField(p)
+			if err != nil {
+				actual = nil
+			}
+
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("for test %s:\nexpected:\n%#v\ngot:\n%#v", tt.name, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestComputedExprString(t *testing.T) {
+	p := NewParser(lexer.New(`total float = (base + price) * quantity`))
+	f, err := parseField(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := f.Computed.String(), "(base + price) * quantity"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestParseFieldDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *types.Field
+	}{
+		{
+			name:  "function default and on_update",
+			input: `updated_at timestamp [default:now() on_update:now()]`,
+			expected: &types.Field{
+				Name:       "updated_at",
+				DataType:   types.DataTimestamp,
+				Attributes: types.AttrDefault | types.AttrOnUpdate,
+				Default:    &types.DefaultValue{Kind: types.DefaultFunc, Value: "now"},
+				OnUpdate:   &types.DefaultValue{Kind: types.DefaultFunc, Value: "now"},
+			},
+		},
+		{
+			name:  "named sequence",
+			input: `number int [required default:sequence("invoices")]`,
+			expected: &types.Field{
+				Name:       "number",
+				DataType:   types.DataInt,
+				Attributes: types.AttrRequired | types.AttrDefault,
+				Default:    &types.DefaultValue{Kind: types.DefaultFunc, Value: "sequence", Args: []string{"invoices"}},
+			},
+		},
+		{
+			name:  "literal default",
+			input: `age int [default:"18"]`,
+			expected: &types.Field{
+				Name:       "age",
+				DataType:   types.DataInt,
+				Attributes: types.AttrDefault,
+				Default:    &types.DefaultValue{Kind: types.DefaultLiteral, Value: "18"},
+			},
+		},
+		{
+			name:     "unknown function",
+			input:    `id uuid [default:uuid_v9()]`,
+			expected: nil,
+		},
+		{
+			name:     "value on an attribute that doesn't take one",
+			input:    `id uuid [unique:"yes"]`,
+			expected: nil,
+		},
+		{
+			name:     "unclosed attributes",
+			input:    `id uuid [unique`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(lexer.New(tt.input))
+			actual, err := parseField(p)
+			if err != nil {
+				actual = nil
+			}
+
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("for test %s:\nexpected:\n%#v\ngot:\n%#v", tt.name, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseFieldEnums(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *types.Field
+	}{
+		{
+			name:  "inline text list",
+			input: `category text ("minor" "adult")`,
+			expected: &types.Field{
+				Name:     "category",
+				DataType: types.DataText,
+				Enum: &types.EnumNode{
+					Members: []types.EnumMember{
+						{Name: "minor", Value: "minor"},
+						{Name: "adult", Value: "adult"},
+					},
+					Backing: types.DataText,
+				},
+			},
+		},
+		{
+			name:  "inline int list",
+			input: `level int (1, 2, 3)`,
+			expected: &types.Field{
+				Name:     "level",
+				DataType: types.DataInt,
+				Enum: &types.EnumNode{
+					Members: []types.EnumMember{
+						{Name: "1", Value: "1"},
+						{Name: "2", Value: "2"},
+						{Name: "3", Value: "3"},
+					},
+					Backing: types.DataInt,
+				},
+			},
+		},
+		{
+			name:  "enum reference",
+			input: `role &user_role`,
+			expected: &types.Field{
+				Name:     "role",
+				DataType: types.DataEnum,
+				Enum:     &types.EnumNode{Name: "user_role"},
+			},
+		},
+		{
+			name:     "duplicate inline values",
+			input:    `category text ("minor" "minor")`,
+			expected: nil,
+		},
+		{
+			name:     "wrong value type",
+			input:    `level int ("high")`,
+			expected: nil,
+		},
+		{
+			name:     "list on a type that can't take one",
+			input:    `at timestamp ("now")`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(lexer.New(tt.input))
+			actual, err := parseField(p)
+			if err != nil {
+				actual = nil
+			}
+
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("for test %s:\nexpected:\n%#v\ngot:\n%#v", tt.name, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseFieldRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *types.Field
+	}{
+		{
+			name:  "length and pattern",
+			input: `username text [required length:3,20 pattern:"^[a-z_]+$"]`,
+			expected: &types.Field{
+				Name:       "username",
+				DataType:   types.DataText,
+				Attributes: types.AttrRequired | types.AttrLength | types.AttrPattern,
+				Length:     &types.Length{Min: 3, Max: 20},
+				Pattern:    "^[a-z_]+$",
+			},
+		},
+		{
+			name:  "open ended length",
+			input: `bio text [length:,280]`,
+			expected: &types.Field{
+				Name:       "bio",
+				DataType:   types.DataText,
+				Attributes: types.AttrLength,
+				Length:     &types.Length{Max: 280},
+			},
+		},
+		{
+			name:  "check binds looser than comparisons",
+			input: `age int [check:age >= 18 and age < 150 required]`,
+			expected: &types.Field{
+				Name:       "age",
+				DataType:   types.DataInt,
+				Attributes: types.AttrCheck | types.AttrRequired,
+				Check: &types.Expr{
+					Kind:  types.ExprBinary,
+					Value: "and",
+					Args: []*types.Expr{
+						{Kind: types.ExprBinary, Value: ">=", Args: []*types.Expr{
+							{Kind: types.ExprField, Value: "age"},
+							{Kind: types.ExprNumber, Value: "18"},
+						}},
+						{Kind: types.ExprBinary, Value: "<", Args: []*types.Expr{
+							{Kind: types.ExprField, Value: "age"},
+							{Kind: types.ExprNumber, Value: "150"},
+						}},
+					},
+				},
+			},
+		},
+		{
+			name:     "length without a comma",
+			input:    `bio text [length:280]`,
+			expected: nil,
+		},
+		{
+			name:     "unquoted pattern",
+			input:    `bio text [pattern:abc]`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(lexer.New(tt.input))
+			actual, err := parseField(p)
+			if err != nil {
+				actual = nil
+			}
+
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("for test %s:\nexpected:\n%#v\ngot:\n%#v", tt.name, tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"fmt"
+
+	"willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// mixin timestamps ->
+// template audit_log<T> [soft_delete] ->
+func handleMixin(p *Parser) node {
+	defer p.resetContext()
+
+	if !expectTokOf(p.curToken, lexer.TokenMixin) && !expectTokOf(p.curToken, lexer.TokenTemplate) {
+		p.addError(ParserLogError,
+			fmt.Sprintf("expected mixin or template, got %s", p.curToken.Type))
+		return (*types.MixinNode)(nil)
+	}
+	kind := p.curToken.Literal
+	mixin := &types.MixinNode{
+		Pos: tokenPos(p.curToken),
+	}
+	p.advanceToken() // consume mixin/template
+
+	if !expectTokOf(p.curToken, lexer.TokenIdent) {
+		p.addError(ParserLogError,
+			fmt.Sprintf("expected %s name, got %s", kind, p.curToken.Type))
+		return (*types.MixinNode)(nil)
+	}
+	mixin.Name = p.curToken.Literal
+	p.advanceToken() // consume name
+
+	if p.curToken.Type == lexer.TokenLess {
+		params, err := parseIdentList(p, lexer.TokenGreater)
+		if err != nil {
+			p.addError(ParserLogError, fmt.Sprintf("%s %s: %s", kind, mixin.Name, err.Error()))
+			return (*types.MixinNode)(nil)
+		}
+		mixin.Params = params
+	}
+
+	if p.curToken.Type == lexer.TokenEnumOpen {
+		opts, err := parseEntityOptions(p)
+		if err != nil {
+			p.addError(ParserLogError, fmt.Sprintf("%s %s: %s", kind, mixin.Name, err.Error()))
+			return (*types.MixinNode)(nil)
+		}
+		for _, opt := range opts {
+			if !types.IsEntityOption(opt) {
+				p.addError(ParserLogError,
+					fmt.Sprintf("%s %s: unknown entity option %s", kind, mixin.Name, opt))
+			}
+		}
+		mixin.Options = opts
+	}
+
+	if !expectTokOf(p.curToken, lexer.TokenArrow) {
+		p.addError(ParserLogError,
+			fmt.Sprintf("expected -> after %s name, got %s", kind, p.curToken.Type))
+		return (*types.MixinNode)(nil)
+	}
+	p.advanceToken() // consume '->'
+
+	fields, uses, ok := parseEntityBody(p, mixin.Name)
+	if !ok {
+		return (*types.MixinNode)(nil)
+	}
+	mixin.Fields = fields
+	mixin.Uses = uses
+
+	if p.invalidParsing {
+		return (*types.MixinNode)(nil)
+	}
+
+	return mixin
+}
+
+// use timestamps, audit_log<note>
+// index is how many fields the body has declared so far
+func parseUse(p *Parser, index int) ([]*types.MixinUse, error) {
+	p.advanceToken() // consume use
+
+	var uses []*types.MixinUse
+	for {
+		u, err := parseMixinRef(p)
+		if err != nil {
+			return nil, err
+		}
+		u.Index = index
+		uses = append(uses, u)
+
+		if p.curToken.Type != lexer.TokenComma {
+			break
+		}
+		p.advanceToken() // consume ','
+	}
+
+	switch p.curToken.Type {
+	case lexer.TokenNewline, lexer.TokenComment, lexer.TokenEnd, lexer.TokenEOF:
+		return uses, nil
+	}
+
+	return nil, fmt.Errorf("unexpected token at end of use: %s", p.curToken.Literal)
+}
+
+// audit_log<note>
+func parseMixinRef(p *Parser) (*types.MixinUse, error) {
+	if p.curToken.Type != lexer.TokenIdent {
+		return nil, fmt.Errorf("expected mixin name, got %s", p.curToken.Literal)
+	}
+	use := &types.MixinUse{
+		Name: p.curToken.Literal,
+		Pos:  tokenPos(p.curToken),
+	}
+	p.advanceToken() // consume name
+
+	if p.curToken.Type == lexer.TokenLess {
+		args, err := parseIdentList(p, lexer.TokenGreater)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", use.Name, err)
+		}
+		use.Args = args
+	}
+
+	return use, nil
+}
+
+// parseIdentList reads `<a, b>` starting on the opening token
+func parseIdentList(p *Parser, closing lexer.TokenType) ([]string, error) {
+	p.advanceToken() // consume opening token
+
+	var idents []string
+	for p.curToken.Type != closing {
+		if p.curToken.Type != lexer.TokenIdent {
+			return nil, fmt.Errorf("expected name, got %s", p.curToken.Literal)
+		}
+		idents = append(idents, p.curToken.Literal)
+		p.advanceToken() // consume name
+
+		switch p.curToken.Type {
+		case lexer.TokenComma:
+			p.advanceToken() // consume ','
+		case closing:
+		default:
+			return nil, fmt.Errorf("expected , or %s, got %s", closing, p.curToken.Literal)
+		}
+	}
+	p.advanceToken() // consume closing token
+
+	if len(idents) == 0 {
+		return nil, fmt.Errorf("empty parameter list")
+	}
+
+	return idents, nil
+}
+
+func tokenPos(tok lexer.Token) types.Pos {
+	return types.Pos{File: tok.FileName, Line: tok.LineNum}
+}
@@ -0,0 +1,185 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+func TestMixinHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *types.MixinNode
+	}{
+		{
+			name: "plain mixin",
+			input: `mixin timestamps ->
+	created_at timestamp [default:now()]
+end`,
+			expected: &types.MixinNode{
+				Name: "timestamps",
+				Fields: []*types.Field{{
+					Name:       "created_at",
+					DataType:   types.DataTimestamp,
+					Attributes: types.AttrDefault,
+					Default:    &types.DefaultValue{Kind: types.DefaultFunc, Value: types.FuncNow},
+				}},
+				Pos: types.Pos{File: "base.mime", Line: 1},
+			},
+		},
+		{
+			name: "template with params, options and uses",
+			input: `template audit_log<T, U> [soft_delete] ->
+	target @T.id
+	use timestamps, owned<U>
+	action text
+end`,
+			expected: &types.MixinNode{
+				Name:   "audit_log",
+				Params: []string{"T", "U"},
+				Fields: []*types.Field{
+					{Name: "target", Kind: types.FieldReference, Target: &types.ReferenceTarget{Entity: "T", Field: "id"}},
+					{Name: "action", DataType: types.DataText},
+				},
+				Uses: []*types.MixinUse{
+					{Name: "timestamps", Index: 1, Pos: types.Pos{File: "base.mime", Line: 3}},
+					{Name: "owned", Args: []string{"U"}, Index: 1, Pos: types.Pos{File: "base.mime", Line: 3}},
+				},
+				Options: []string{"soft_delete"},
+				Pos:     types.Pos{File: "base.mime", Line: 1},
+			},
+		},
+		{
+			name: "empty params",
+			input: `template audit_log<> ->
+end`,
+			expected: nil,
+		},
+		{
+			name: "unknown option",
+			input: `mixin trash [archived] ->
+end`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(lexer.NewFile("base.mime", tt.input))
+			actual := handleMixin(p)
+
+			if tt.expected == nil {
+				if n, ok := actual.(*types.MixinNode); !ok || n != nil {
+					t.Fatalf("for test %s: expected nil, got %#v", tt.name, actual)
+				}
+				return
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("for test %s:\nexpected:\n%#v\ngot:\n%#v", tt.name, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSchemaExpandsMixins(t *testing.T) {
+	input := `mixin timestamps ->
+	created_at timestamp [default:now()]
+	updated_at timestamp [default:now() on_update:now()]
+end
+
+template audit_log<T> ->
+	target @T.id
+	action text
+	use timestamps
+end
+
+entity note ->
+	id uuid [primary unique required]
+	use timestamps, soft_delete
+	title text
+end
+
+entity note_audit = audit_log<note>
+`
+	s, errs := NewParser(lexer.NewFile("notes.mime", input)).Schema()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	fieldNames := func(e *types.EntityNode) []string {
+		var names []string
+		for _, f := range e.Fields {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+
+	note := s.Entity("note")
+	if got, want := fieldNames(note), []string{"id", "created_at", "updated_at", "title", "deleted_at"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected note fields %v, got %v", want, got)
+	}
+	if !note.SoftDelete {
+		t.Fatalf("expected use soft_delete to turn soft deletes on")
+	}
+
+	audit := s.Entity("note_audit")
+	if got, want := fieldNames(audit), []string{"target", "action", "created_at", "updated_at"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected note_audit fields %v, got %v", want, got)
+	}
+	if target := audit.Field("target").Target; target.Entity != "note" {
+		t.Fatalf("expected target to point at note, got %s", target.Entity)
+	}
+
+	want := "from mixin timestamps declared at notes.mime:1, used at notes.mime:9"
+	if got := audit.Field("created_at").Origin.String(); got != want {
+		t.Fatalf("expected origin %q, got %q", want, got)
+	}
+}
+
+func TestSchemaMixinErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name: "clash points at both places",
+			input: `mixin named ->
+	title text
+end
+
+entity note ->
+	title text
+	use named
+end`,
+			wantErr: "notes.mime:7: field title from mixin named (declared at notes.mime:1) clashes with title in the entity",
+		},
+		{
+			name: "template argument isn't an entity",
+			input: `template owned<T> ->
+	owner @T.id
+end
+
+entity note ->
+	use owned<ghost>
+end`,
+			wantErr: "from mixin owned declared at notes.mime:1, used at notes.mime:6",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := NewParser(lexer.NewFile("notes.mime", tt.input)).Schema()
+			if len(errs) == 0 {
+				t.Fatalf("for test %s: expected an error", tt.name)
+			}
+			if !strings.Contains(errs[0].Error(), tt.wantErr) {
+				t.Fatalf("for test %s: expected error containing %q, got %q", tt.name, tt.wantErr, errs[0])
+			}
+		})
+	}
+}
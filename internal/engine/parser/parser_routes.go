@@ -0,0 +1,226 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+
+	"willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+var routeMethods = map[lexer.TokenType]string{
+	lexer.TokenGet:    "GET",
+	lexer.TokenPost:   "POST",
+	lexer.TokenPut:    "PUT",
+	lexer.TokenPatch:  "PATCH",
+	lexer.TokenDelete: "DELETE",
+}
+
+func handleRoutes(p *Parser) node {
+	defer p.resetContext()
+
+	if !expectTokOf(p.curToken, lexer.TokenTypeRoutes) {
+		p.addError(ParserLogError,
+			fmt.Sprintf("expected routes, got %s", p.curToken.Type))
+	}
+	p.advanceToken() // consume routes
+
+	routes := &types.RoutesNode{}
+
+	// routes can be scoped to an entity which `self` then refers to
+	if p.curToken.Type == lexer.TokenAtSymbol {
+		p.advanceToken() // consume @
+		if !expectTokOf(p.curToken, lexer.TokenIdent) {
+			p.addError(ParserLogError,
+				fmt.Sprintf("expected entity name after @, got %s", p.curToken.Type))
+		}
+		routes.Scope = p.curToken.Literal
+		p.advanceToken() // consume entity name
+	}
+
+	if !expectTokOf(p.curToken, lexer.TokenArrow) {
+		p.addError(ParserLogError,
+			fmt.Sprintf("expected -> after routes, got %s", p.curToken.Type))
+	}
+	p.advanceToken() // consume '->'
+
+	for p.curToken.Type != lexer.TokenEnd {
+		switch p.curToken.Type {
+		case lexer.TokenEOF:
+			// unexpected end to file with no end keyword
+			p.addError(ParserLogError, "expected end keyword at end of routes")
+			return (*types.RoutesNode)(nil)
+		case lexer.TokenNewline, lexer.TokenComment:
+			p.advanceToken() // skip newlines and comments
+			continue
+		}
+
+		r, err := parseRoute(p, routes.Scope)
+		if err != nil {
+			p.addError(ParserLogError, err.Error())
+			skipToTok(p, lexer.TokenNewline, lexer.TokenEnd)
+			continue
+		}
+		routes.Routes = append(routes.Routes, r)
+	}
+	p.advanceToken() // consume 'end'
+
+	if p.invalidParsing {
+		return (*types.RoutesNode)(nil)
+	}
+
+	return routes
+}
+
+// example routes
+// GET /notes/:id -> @note.id == :id || respond 404 "note not found"
+// GET /admin/notes [admin] -> @note == params
+// DELETE /notes/:id -> delete @note.id == :id || respond 400 "delete failed"
+func parseRoute(p *Parser, scope string) (*types.Route, error) {
+	method, ok := routeMethods[p.curToken.Type]
+	if !ok {
+		return nil, fmt.Errorf("expected http verb, got %s", p.curToken.Literal)
+	}
+	r := &types.Route{
+		Doc:    p.takeDoc(),
+		Method: method,
+		Action: types.ActionFind,
+	}
+	p.advanceToken() // consume verb
+
+	if p.curToken.Type != lexer.TokenEndpoint {
+		return nil, fmt.Errorf("expected path after %s, got %s", method, p.curToken.Literal)
+	}
+	r.Path = p.curToken.Literal
+	p.advanceToken() // consume path
+
+	if p.curToken.Type == lexer.TokenEnumOpen {
+		p.advanceToken() // consume '['
+		for p.curToken.Type != lexer.TokenEnumClose {
+			if p.curToken.Type == lexer.TokenNewline || p.curToken.Type == lexer.TokenEOF {
+				return nil, fmt.Errorf("unclosed options for %s %s: expected ]", method, r.Path)
+			}
+			opt, err := types.StringToRouteOption(p.curToken.Literal)
+			if err != nil {
+				return nil, err
+			}
+			r.Options |= opt
+			p.advanceToken() // consume option
+		}
+		p.advanceToken() // consume ']'
+	}
+
+	if p.curToken.Type != lexer.TokenArrow {
+		return nil, fmt.Errorf("expected -> after %s %s, got %s", method, r.Path, p.curToken.Literal)
+	}
+	p.advanceToken() // consume '->'
+
+	// no action means we're looking rows up
+	if p.curToken.Type == lexer.TokenIdent {
+		action, ok := types.StringToRouteAction(p.curToken.Literal)
+		if !ok {
+			return nil, fmt.Errorf("unknown route action %s", p.curToken.Literal)
+		}
+		r.Action = action
+		p.advanceToken() // consume action
+	}
+
+	switch p.curToken.Type {
+	case lexer.TokenSelf:
+		if scope == "" {
+			return nil, fmt.Errorf("self used in routes that aren't scoped to an entity")
+		}
+		r.Entity = scope
+		p.advanceToken() // consume self
+	case lexer.TokenAtSymbol:
+		p.advanceToken() // consume @
+		if p.curToken.Type != lexer.TokenIdent {
+			return nil, fmt.Errorf("expected entity name after @, got %s", p.curToken.Literal)
+		}
+		r.Entity = p.curToken.Literal
+		p.advanceToken() // consume entity name
+	default:
+		return nil, fmt.Errorf("expected self or @entity, got %s", p.curToken.Literal)
+	}
+
+	var matchField string
+	if p.curToken.Type == lexer.TokenDot {
+		p.advanceToken() // consume '.'
+		if p.curToken.Type != lexer.TokenIdent {
+			return nil, fmt.Errorf("expected field name after '.', got %s", p.curToken.Literal)
+		}
+		matchField = p.curToken.Literal
+		p.advanceToken() // consume field name
+	}
+
+	if p.curToken.Type == lexer.TokenEquals {
+		p.advanceToken() // consume '=='
+		source, err := parseMatchSource(p)
+		if err != nil {
+			return nil, err
+		}
+		r.Match = &types.RouteMatch{Field: matchField, Source: source}
+	} else if matchField != "" {
+		return nil, fmt.Errorf("expected == after @%s.%s", r.Entity, matchField)
+	}
+
+	if p.curToken.Type == lexer.TokenPipes {
+		p.advanceToken() // consume '||'
+		resp, err := parseRespond(p)
+		if err != nil {
+			return nil, err
+		}
+		r.Fallback = resp
+	}
+
+	// make sure line ends correctly
+	switch p.curToken.Type {
+	case lexer.TokenNewline, lexer.TokenComment, lexer.TokenEOF, lexer.TokenEnd:
+		return r, nil
+	}
+
+	return nil, fmt.Errorf("unexpected token at end of route: %s", p.curToken.Literal)
+}
+
+func parseMatchSource(p *Parser) (string, error) {
+	switch {
+	case p.curToken.Type == lexer.TokenColon:
+		p.advanceToken() // consume ':'
+		if p.curToken.Type != lexer.TokenIdent {
+			return "", fmt.Errorf("expected path capture name after ':', got %s", p.curToken.Literal)
+		}
+		source := ":" + p.curToken.Literal
+		p.advanceToken() // consume capture name
+		return source, nil
+	case p.curToken.Type == lexer.TokenIdent && p.curToken.Literal == types.ParamsSource:
+		p.advanceToken() // consume params
+		return types.ParamsSource, nil
+	}
+
+	return "", fmt.Errorf("expected :capture or params after ==, got %s", p.curToken.Literal)
+}
+
+// respond 404 "note not found"
+func parseRespond(p *Parser) (*types.Response, error) {
+	if p.curToken.Type != lexer.TokenIdent || p.curToken.Literal != "respond" {
+		return nil, fmt.Errorf("expected respond after ||, got %s", p.curToken.Literal)
+	}
+	p.advanceToken() // consume respond
+
+	if p.curToken.Type != lexer.TokenDigits {
+		return nil, fmt.Errorf("expected status code after respond, got %s", p.curToken.Literal)
+	}
+	status, err := strconv.Atoi(p.curToken.Literal)
+	if err != nil || status < 100 || status > 599 {
+		return nil, fmt.Errorf("invalid status code %s", p.curToken.Literal)
+	}
+	resp := &types.Response{Status: status}
+	p.advanceToken() // consume status
+
+	if p.curToken.Type == lexer.TokenString {
+		resp.Message = p.curToken.Literal
+		p.advanceToken() // consume message
+	}
+
+	return resp, nil
+}
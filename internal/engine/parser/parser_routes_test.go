@@ -0,0 +1,183 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+func TestRoutesHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *types.RoutesNode
+	}{
+		{
+			name: "scoped routes with fallbacks",
+			input: `routes @user ->
+	POST /signup -> create self || respond 400 "signup failed"
+	GET /notes/:id -> @note.id == :id || respond 404 "note not found"
+	GET /admin/notes [admin] -> @note == params
+	DELETE /notes/:id -> delete @note.id == :id
+	POST /notes/:id/restore -> restore @note.id == :id # bring it back
+end`,
+			expected: &types.RoutesNode{
+				Scope: "user",
+				Routes: []*types.Route{
+					{
+						Method:   "POST",
+						Path:     "/signup",
+						Action:   types.ActionCreate,
+						Entity:   "user",
+						Fallback: &types.Response{Status: 400, Message: "signup failed"},
+					},
+					{
+						Method:   "GET",
+						Path:     "/notes/:id",
+						Action:   types.ActionFind,
+						Entity:   "note",
+						Match:    &types.RouteMatch{Field: "id", Source: ":id"},
+						Fallback: &types.Response{Status: 404, Message: "note not found"},
+					},
+					{
+						Method:  "GET",
+						Path:    "/admin/notes",
+						Action:  types.ActionFind,
+						Entity:  "note",
+						Match:   &types.RouteMatch{Source: "params"},
+						Options: types.RouteAdmin,
+					},
+					{
+						Method: "DELETE",
+						Path:   "/notes/:id",
+						Action: types.ActionDelete,
+						Entity: "note",
+						Match:  &types.RouteMatch{Field: "id", Source: ":id"},
+					},
+					{
+						Method: "POST",
+						Path:   "/notes/:id/restore",
+						Action: types.ActionRestore,
+						Entity: "note",
+						Match:  &types.RouteMatch{Field: "id", Source: ":id"},
+					},
+				},
+			},
+		},
+		{
+			name: "self without a scope",
+			input: `routes ->
+	POST /signup -> create self
+end`,
+			expected: nil,
+		},
+		{
+			name: "unknown action",
+			input: `routes ->
+	POST /notes -> destroy @note
+end`,
+			expected: nil,
+		},
+		{
+			name: "field match without a source",
+			input: `routes ->
+	GET /notes/:id -> @note.id
+end`,
+			expected: nil,
+		},
+		{
+			name: "missing end",
+			input: `routes ->
+	GET /notes -> @note == params`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(lexer.New(tt.input))
+			actual := handleRoutes(p)
+
+			if tt.expected == nil {
+				if n, ok := actual.(*types.RoutesNode); !ok || n != nil {
+					t.Fatalf("for test %s: expected nil, got %#v", tt.name, actual)
+				}
+				return
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("for test %s:\nexpected:\n%#v\ngot:\n%#v", tt.name, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestEntitySoftDeleteOption(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *types.EntityNode
+	}{
+		{
+			name: "declared deleted_at becomes readonly",
+			input: `entity note [soft_delete] ->
+	title text
+	deleted_at timestamp
+end`,
+			expected: &types.EntityNode{
+				Name:       "note",
+				SoftDelete: true,
+				Fields: []*types.Field{
+					{Name: "title", DataType: types.DataText},
+					{Name: "deleted_at", DataType: types.DataTimestamp, Attributes: types.AttrReadonly},
+				},
+			},
+		},
+		{
+			name: "missing deleted_at is added",
+			input: `entity note [soft_delete] ->
+	title text
+end`,
+			expected: &types.EntityNode{
+				Name:       "note",
+				SoftDelete: true,
+				Fields: []*types.Field{
+					{Name: "title", DataType: types.DataText},
+					{Name: "deleted_at", DataType: types.DataTimestamp, Attributes: types.AttrReadonly},
+				},
+			},
+		},
+		{
+			name: "deleted_at with the wrong type",
+			input: `entity note [soft_delete] ->
+	deleted_at text
+end`,
+			expected: nil,
+		},
+		{
+			name: "unknown option",
+			input: `entity note [archived] ->
+	title text
+end`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(lexer.New(tt.input))
+			actual := handleEntity(p)
+
+			if tt.expected == nil {
+				if n, ok := actual.(*types.EntityNode); ok && n != nil {
+					t.Fatalf("for test %s: expected nil, got %#v", tt.name, actual)
+				}
+				return
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("for test %s:\nexpected:\n%#v\ngot:\n%#v", tt.name, tt.expected, actual)
+			}
+		})
+	}
+}
@@ -19,6 +19,10 @@ type parserError struct {
 	msg        string
 }
 
+func (e parserError) Error() string {
+	return e.msg
+}
+
 type shortField struct {
 	name *string
 	dt   *dataType
@@ -0,0 +1,93 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/ddl"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// the query package turns route actions into parameterised sql. it doesn't
+// talk to a database itself; the store runs whatever comes out of here which
+// keeps the rules about what a route is allowed to touch in one place
+
+type Statement struct {
+	SQL  string
+	Args []any
+}
+
+type Options struct {
+	// only honoured on soft deleted entities; see IncludeDeleted
+	IncludeDeleted bool
+	// when a soft delete happened; the current time when left unset
+	Now time.Time
+}
+
+func quote(ident string) string {
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+
+// where builds the conditions for the filters in a stable order so the same
+// request always produces the same sql
+func where(e *types.EntityNode, filters map[string]any, extra ...string) (string, []any, error) {
+	keys := make([]string, 0, len(filters))
+	for k := range filters {
+		if e.Field(k) == nil {
+			return "", nil, fmt.Errorf("entity '%s' has no field '%s'", e.Name, k)
+		}
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	conds := make([]string, 0, len(keys)+len(extra))
+	args := make([]any, 0, len(keys))
+	for _, k := range keys {
+		conds = append(conds, quote(k)+" = ?")
+		args = append(args, filters[k])
+	}
+	conds = append(conds, extra...)
+
+	if len(conds) == 0 {
+		return "", nil, nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+// Find selects the entity's response fields for every row matching filters.
+// computed fields with a column are read like any other and aggregates are
+// computed by a subquery, so the schema is needed to find the rows they read
+func Find(s *types.Schema, e *types.EntityNode, filters map[string]any, opts Options) (Statement, error) {
+	var extra []string
+	if e.SoftDelete && !opts.IncludeDeleted {
+		extra = append(extra, quote(types.SoftDeleteField)+" IS NULL")
+	}
+
+	w, args, err := where(e, filters, extra...)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	cols := make([]string, 0, len(e.Fields))
+	for _, f := range e.ResponseFields() {
+		if f.Stored() {
+			cols = append(cols, quote(f.Name))
+			continue
+		}
+		expr, err := ddl.Computed(s, ddl.SQLite, e, f)
+		if err != nil {
+			return Statement{}, fmt.Errorf("entity '%s': %w", e.Name, err)
+		}
+		cols = append(cols, expr+" AS "+quote(f.Name))
+	}
+	if len(cols) == 0 {
+		return Statement{}, fmt.Errorf("entity '%s' has nothing to select", e.Name)
+	}
+
+	return Statement{
+		SQL:  fmt.Sprintf("SELECT %s FROM %s%s", strings.Join(cols, ", "), quote(e.Name), w),
+		Args: args,
+	}, nil
+}
@@ -0,0 +1,75 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"willofdaedalus/mime/internal/engine/ir"
+)
+
+// the engine keeps a little key/value table next to the user's tables. the
+// schema's fingerprint lives in it so a deploy can compare what's in the
+// database against what's about to be served and only migrate when something
+// meaningful changed
+
+const MetaTable = "_mime_meta"
+
+const (
+	metaFingerprint = "fingerprint"
+	// one row per entity e.g. fingerprint:note
+	metaEntityPrefix = "fingerprint:"
+)
+
+func CreateMeta() Statement {
+	return Statement{
+		SQL: fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s TEXT PRIMARY KEY, %s TEXT NOT NULL)",
+			quote(MetaTable), quote("key"), quote("value")),
+	}
+}
+
+// StoreFingerprint replaces whatever fingerprint was stored before. the rows
+// for entities that no longer exist go with it
+func StoreFingerprint(fp ir.Fingerprint) []Statement {
+	stmts := []Statement{{
+		SQL:  fmt.Sprintf("DELETE FROM %s WHERE %s LIKE ?", quote(MetaTable), quote("key")),
+		Args: []any{metaFingerprint + "%"},
+	}}
+
+	insert := fmt.Sprintf("INSERT INTO %s (%s, %s) VALUES (?, ?)", quote(MetaTable), quote("key"), quote("value"))
+	stmts = append(stmts, Statement{SQL: insert, Args: []any{metaFingerprint, fp.Schema}})
+
+	names := make([]string, 0, len(fp.Entities))
+	for name := range fp.Entities {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		stmts = append(stmts, Statement{SQL: insert, Args: []any{metaEntityPrefix + name, fp.Entities[name]}})
+	}
+
+	return stmts
+}
+
+// LoadFingerprint selects the stored rows; ScanFingerprint puts them back
+// together
+func LoadFingerprint() Statement {
+	return Statement{
+		SQL: fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s LIKE ?",
+			quote("key"), quote("value"), quote(MetaTable), quote("key")),
+		Args: []any{metaFingerprint + "%"},
+	}
+}
+
+func ScanFingerprint(rows map[string]string) ir.Fingerprint {
+	fp := ir.Fingerprint{
+		Schema:   rows[metaFingerprint],
+		Entities: make(map[string]string),
+	}
+	for k, v := range rows {
+		if name, ok := strings.CutPrefix(k, metaEntityPrefix); ok {
+			fp.Entities[name] = v
+		}
+	}
+	return fp
+}
@@ -0,0 +1,107 @@
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/ddl"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+const IncludeDeletedParam = "include_deleted"
+
+// IncludeDeleted reads ?include_deleted=true off a request. only admin routes
+// are trusted with it; anywhere else it's rejected rather than ignored so a
+// client never thinks it's seeing deleted rows when it isn't
+func IncludeDeleted(r *types.Route, params url.Values) (bool, error) {
+	if !params.Has(IncludeDeletedParam) {
+		return false, nil
+	}
+	if r.Options&types.RouteAdmin == 0 {
+		return false, fmt.Errorf("%s is only allowed on admin routes", IncludeDeletedParam)
+	}
+
+	v, err := strconv.ParseBool(params.Get(IncludeDeletedParam))
+	if err != nil {
+		return false, fmt.Errorf("%s: %q is not a bool", IncludeDeletedParam, params.Get(IncludeDeletedParam))
+	}
+	return v, nil
+}
+
+// Delete marks matching rows as deleted on soft deleted entities and removes
+// them everywhere else. rows that are already deleted are left alone so the
+// original deletion time sticks
+func Delete(e *types.EntityNode, filters map[string]any, opts Options) (Statement, error) {
+	if !e.SoftDelete {
+		w, args, err := where(e, filters)
+		if err != nil {
+			return Statement{}, err
+		}
+		return Statement{
+			SQL:  fmt.Sprintf("DELETE FROM %s%s", quote(e.Name), w),
+			Args: args,
+		}, nil
+	}
+
+	w, args, err := where(e, filters, quote(types.SoftDeleteField)+" IS NULL")
+	if err != nil {
+		return Statement{}, err
+	}
+
+	// a zero time would make the row old enough to purge straight away
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return Statement{
+		SQL:  fmt.Sprintf("UPDATE %s SET %s = ?%s", quote(e.Name), quote(types.SoftDeleteField), w),
+		Args: append([]any{now.UTC()}, args...),
+	}, nil
+}
+
+// Restore brings soft deleted rows back
+func Restore(e *types.EntityNode, filters map[string]any) (Statement, error) {
+	if !e.SoftDelete {
+		return Statement{}, fmt.Errorf("can't restore '%s'; it isn't soft deleted", e.Name)
+	}
+
+	w, args, err := where(e, filters, quote(types.SoftDeleteField)+" IS NOT NULL")
+	if err != nil {
+		return Statement{}, err
+	}
+
+	return Statement{
+		SQL:  fmt.Sprintf("UPDATE %s SET %s = NULL%s", quote(e.Name), quote(types.SoftDeleteField), w),
+		Args: args,
+	}, nil
+}
+
+// Purge permanently removes rows that were soft deleted before the cutoff.
+// names are quoted for d so the statement can be run outside the runtime
+func Purge(e *types.EntityNode, d ddl.Dialect, olderThan time.Duration, now time.Time) (Statement, error) {
+	if !e.SoftDelete {
+		return Statement{}, fmt.Errorf("can't purge '%s'; it isn't soft deleted", e.Name)
+	}
+
+	return Statement{
+		SQL: fmt.Sprintf("DELETE FROM %s WHERE %s IS NOT NULL AND %s < ?",
+			d.Quote(e.Name), d.Quote(types.SoftDeleteField), d.Quote(types.SoftDeleteField)),
+		Args: []any{now.UTC().Add(-olderThan)},
+	}, nil
+}
+
+// PurgeAll purges every soft deleted entity in the schema; `mime purge
+// -older-than 30` prints these with the days converted to a duration
+func PurgeAll(s *types.Schema, d ddl.Dialect, olderThan time.Duration, now time.Time) []Statement {
+	var stmts []Statement
+	for _, e := range s.Entities {
+		if !e.SoftDelete {
+			continue
+		}
+		stmt, _ := Purge(e, d, olderThan, now)
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
@@ -0,0 +1,155 @@
+package query
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/ddl"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+func noteEntity(softDelete bool) *types.EntityNode {
+	e := &types.EntityNode{
+		Name: "note",
+		Fields: []*types.Field{
+			{Name: "id", DataType: types.DataInt, Attributes: types.AttrPrimary | types.AttrUnique | types.AttrRequired},
+			{Name: "slug", DataType: types.DataText, Attributes: types.AttrUnique},
+			{Name: "title", DataType: types.DataText},
+		},
+	}
+	if softDelete {
+		e.EnableSoftDelete()
+	}
+	return e
+}
+
+func TestSoftDeleteStatements(t *testing.T) {
+	now := time.Date(2025, 5, 23, 12, 0, 0, 0, time.UTC)
+	byID := map[string]any{"id": 7}
+
+	tests := []struct {
+		name     string
+		build    func() (Statement, error)
+		expected Statement
+	}{
+		{
+			name:  "find hides deleted rows",
+			build: func() (Statement, error) { return Find(nil, noteEntity(true), byID, Options{}) },
+			expected: Statement{
+				SQL:  `SELECT "id", "slug", "title", "deleted_at" FROM "note" WHERE "id" = ? AND "deleted_at" IS NULL`,
+				Args: []any{7},
+			},
+		},
+		{
+			name:  "find including deleted rows",
+			build: func() (Statement, error) { return Find(nil, noteEntity(true), byID, Options{IncludeDeleted: true}) },
+			expected: Statement{
+				SQL:  `SELECT "id", "slug", "title", "deleted_at" FROM "note" WHERE "id" = ?`,
+				Args: []any{7},
+			},
+		},
+		{
+			name:  "delete marks the row",
+			build: func() (Statement, error) { return Delete(noteEntity(true), byID, Options{Now: now}) },
+			expected: Statement{
+				SQL:  `UPDATE "note" SET "deleted_at" = ? WHERE "id" = ? AND "deleted_at" IS NULL`,
+				Args: []any{now, 7},
+			},
+		},
+		{
+			name:  "delete without soft delete",
+			build: func() (Statement, error) { return Delete(noteEntity(false), byID, Options{Now: now}) },
+			expected: Statement{
+				SQL:  `DELETE FROM "note" WHERE "id" = ?`,
+				Args: []any{7},
+			},
+		},
+		{
+			name:  "restore",
+			build: func() (Statement, error) { return Restore(noteEntity(true), byID) },
+			expected: Statement{
+				SQL:  `UPDATE "note" SET "deleted_at" = NULL WHERE "id" = ? AND "deleted_at" IS NOT NULL`,
+				Args: []any{7},
+			},
+		},
+		{
+			name:  "purge after thirty days",
+			build: func() (Statement, error) { return Purge(noteEntity(true), ddl.SQLite, 30*24*time.Hour, now) },
+			expected: Statement{
+				SQL:  `DELETE FROM "note" WHERE "deleted_at" IS NOT NULL AND "deleted_at" < ?`,
+				Args: []any{time.Date(2025, 4, 23, 12, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:  "purge on mysql",
+			build: func() (Statement, error) { return Purge(noteEntity(true), ddl.MySQL, 30*24*time.Hour, now) },
+			expected: Statement{
+				SQL:  "DELETE FROM `note` WHERE `deleted_at` IS NOT NULL AND `deleted_at` < ?",
+				Args: []any{time.Date(2025, 4, 23, 12, 0, 0, 0, time.UTC)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := tt.build()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("for test %s:\nexpected:\n%#v\ngot:\n%#v", tt.name, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRestoreNeedsSoftDelete(t *testing.T) {
+	if _, err := Restore(noteEntity(false), map[string]any{"id": 1}); err == nil {
+		t.Fatalf("expected restore to fail on a hard deleted entity")
+	}
+}
+
+func TestDeleteWithoutNow(t *testing.T) {
+	before := time.Now().UTC()
+	stmt, err := Delete(noteEntity(true), map[string]any{"id": 1}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if at := stmt.Args[0].(time.Time); at.Before(before) || at.After(time.Now().UTC()) {
+		t.Fatalf("expected the row to be deleted now, got %v", at)
+	}
+}
+
+func TestIncludeDeleted(t *testing.T) {
+	admin := &types.Route{Method: "GET", Path: "/admin/notes", Options: types.RouteAdmin}
+	public := &types.Route{Method: "GET", Path: "/notes"}
+
+	tests := []struct {
+		name     string
+		route    *types.Route
+		query    string
+		expected bool
+		wantErr  bool
+	}{
+		{name: "absent", route: public, query: "", expected: false},
+		{name: "admin opts in", route: admin, query: "include_deleted=true", expected: true},
+		{name: "admin opts out", route: admin, query: "include_deleted=false", expected: false},
+		{name: "public route", route: public, query: "include_deleted=true", wantErr: true},
+		{name: "not a bool", route: admin, query: "include_deleted=maybe", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+			actual, err := IncludeDeleted(tt.route, q)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("for test %s: expected error %v, got %v", tt.name, tt.wantErr, err)
+			}
+			if actual != tt.expected {
+				t.Fatalf("for test %s: expected %v, got %v", tt.name, tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package query
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/engine/ddl"
+	"willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/parser"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+const notesSchema = `entity user ->
+	id int [primary unique required]
+	first_name text [required]
+	last_name text [required]
+	full_name text = first_name || " " || last_name
+	note_count int = count(@note.owner)
+end
+
+entity note [soft_delete] ->
+	id int [primary unique required]
+	owner @user.id [required]
+	words int
+end
+`
+
+func notes(t *testing.T) *types.Schema {
+	t.Helper()
+	s, errs := parser.NewParser(lexer.New(notesSchema)).Schema()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected parse errors: %v", errs)
+	}
+	return s
+}
+
+func TestFindComputed(t *testing.T) {
+	s := notes(t)
+	actual, err := Find(s, s.Entity("user"), map[string]any{"id": 1}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Statement{
+		SQL: `SELECT "id", "first_name", "last_name", "full_name", ` +
+			`(SELECT count("r"."owner") FROM "note" AS "r" WHERE "r"."owner" = "user"."id" AND "r"."deleted_at" IS NULL) AS "note_count" ` +
+			`FROM "user" WHERE "id" = ?`,
+		Args: []any{1},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected:\n%#v\ngot:\n%#v", expected, actual)
+	}
+}
+
+// the statement is run against a real sqlite database made from the ddl the
+// schema generates so the computed values are the ones a read gets back
+func TestFindComputedValues(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 isn't installed")
+	}
+	s := notes(t)
+	tables, _, err := ddl.Generate(s, ddl.SQLite)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	find, err := Find(s, s.Entity("user"), map[string]any{"id": 1}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	script := tables + `
+INSERT INTO "user" ("id", "first_name", "last_name") VALUES (1, 'Ada', 'Lovelace'), (2, 'Alan', 'Turing');
+INSERT INTO "note" ("id", "owner", "deleted_at") VALUES
+  (1, 1, NULL), (2, 1, NULL), (3, 1, '2025-01-01T00:00:00Z'), (4, 2, NULL);
+.parameter set ?1 1
+` + find.SQL + ";\n"
+	cmd := exec.Command("sqlite3", "-batch", ":memory:")
+	cmd.Stdin = strings.NewReader(script)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("sqlite3 failed: %v\n%s", err, out)
+	}
+	if got, want := strings.TrimSpace(string(out)), "1|Ada|Lovelace|Ada Lovelace|2"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,366 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/query"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// path captures and query params only ever arrive as strings and json
+// numbers decode as float64, so anything that reaches the store or a filter
+// goes through a Coercer first. values come out as
+//
+//	text       string
+//	int        int64
+//	float      float64
+//	decimal    string in canonical form, see toDecimal
+//	bool       bool
+//	uuid       string in the canonical lowercase 8-4-4-4-12 form
+//	timestamp  time.Time in utc
+//	enum       the member's stored value; int64 for int backed enums
+//
+// strict mode only takes the canonical spelling of each type. lenient mode
+// also takes what people tend to type into a url: padding, yes/no, 1e3,
+// uuids without dashes, timestamps without an offset and so on
+
+type Mode int
+
+const (
+	Strict Mode = iota
+	Lenient
+)
+
+type Coercer struct {
+	Schema *types.Schema
+	Mode   Mode
+	// lenient mode reads timestamps that don't carry an offset in this
+	// location; nil means utc. strict mode rejects them
+	Location *time.Location
+}
+
+func NewCoercer(s *types.Schema, mode Mode) *Coercer {
+	return &Coercer{Schema: s, Mode: mode}
+}
+
+// Field coerces a value for f. errors read like `age: "abc" is not an int`
+func (c *Coercer) Field(f *types.Field, raw any) (any, error) {
+	dt, enum := c.Schema.FieldType(f)
+	v, err := c.Value(dt, enum, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return v, nil
+}
+
+// Value coerces raw into the go type used for dt. enum is only needed for
+// enum fields and fields with an inline list of values
+func (c *Coercer) Value(dt types.DataType, enum *types.EnumNode, raw any) (any, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	if c.Mode == Lenient {
+		if s, ok := raw.(string); ok {
+			raw = strings.TrimSpace(s)
+		}
+	}
+
+	if dt == types.DataEnum {
+		if enum == nil {
+			return nil, fmt.Errorf("%s can't be checked against an enum that isn't linked", describe(raw))
+		}
+		return c.member(enum, raw)
+	}
+
+	v, err := c.scalar(dt, raw)
+	if err != nil {
+		return nil, err
+	}
+	if enum != nil {
+		if _, ok := memberValue(enum, v); !ok {
+			return nil, notMember(enum, raw)
+		}
+	}
+	return v, nil
+}
+
+func (c *Coercer) scalar(dt types.DataType, raw any) (any, error) {
+	var (
+		v  any
+		ok bool
+	)
+	switch dt {
+	case types.DataText:
+		v, ok = c.text(raw)
+	case types.DataInt:
+		v, ok = c.int(raw)
+	case types.DataReal:
+		v, ok = c.real(raw)
+	case types.DataDecimal:
+		// lenient mode takes 1e3 the way it does for floats
+		v, ok = toDecimal(raw, c.Mode == Lenient)
+	case types.DataBool:
+		v, ok = c.bool(raw)
+	case types.DataUUID:
+		v, ok = c.uuid(raw)
+	case types.DataTimestamp:
+		return c.timestamp(raw)
+	default:
+		return nil, fmt.Errorf("values of type %d can't be coerced", dt)
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("%s is not %s", describe(raw), typeName(dt))
+	}
+	return v, nil
+}
+
+func (c *Coercer) text(raw any) (string, bool) {
+	switch v := raw.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), c.Mode == Lenient
+	}
+	if c.Mode == Lenient {
+		if n, ok := toInt(raw); ok {
+			return strconv.FormatInt(n, 10), true
+		}
+		if f, ok := toFloat(raw); ok {
+			return strconv.FormatFloat(f, 'f', -1, 64), true
+		}
+	}
+	return "", false
+}
+
+func (c *Coercer) int(raw any) (int64, bool) {
+	s, ok := raw.(string)
+	if !ok {
+		return toInt(raw)
+	}
+
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, true
+	}
+	if c.Mode == Lenient {
+		// 4.0 and 1e3 are whole numbers even if they aren't spelled like it
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return toInt(f)
+		}
+	}
+	return 0, false
+}
+
+func (c *Coercer) real(raw any) (float64, bool) {
+	s, ok := raw.(string)
+	if !ok {
+		f, ok := toFloat(raw)
+		return f, ok && !math.IsNaN(f) && !math.IsInf(f, 0)
+	}
+
+	// exponents, hex floats, inf and nan are lenient only
+	if c.Mode == Strict && !plainDecimal.MatchString(s) {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
+
+func (c *Coercer) bool(raw any) (bool, bool) {
+	switch v := raw.(type) {
+	case bool:
+		return v, true
+	case string:
+		if c.Mode == Strict {
+			return v == "true", v == "true" || v == "false"
+		}
+		switch strings.ToLower(v) {
+		case "true", "t", "1", "yes", "y", "on":
+			return true, true
+		case "false", "f", "0", "no", "n", "off":
+			return false, true
+		}
+		return false, false
+	}
+
+	if c.Mode == Lenient {
+		if n, ok := toInt(raw); ok && (n == 0 || n == 1) {
+			return n == 1, true
+		}
+	}
+	return false, false
+}
+
+func (c *Coercer) uuid(raw any) (string, bool) {
+	s, ok := raw.(string)
+	if !ok {
+		return "", false
+	}
+
+	if c.Mode == Lenient {
+		s = strings.TrimPrefix(strings.ToLower(s), "urn:uuid:")
+		s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+		if len(s) == 32 {
+			s = s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+		}
+	}
+	u, err := parseUUID(s)
+	return u, err == nil
+}
+
+// lenient mode also takes these, read in the coercer's location
+var localLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// timestamps always come out in utc. strict mode needs an explicit offset
+// since a wall clock time without one could be any of 24 instants; lenient
+// mode assumes Location and also takes unix seconds
+func (c *Coercer) timestamp(raw any) (time.Time, error) {
+	bad := fmt.Errorf("%s is not %s", describe(raw), typeName(types.DataTimestamp))
+
+	switch v := raw.(type) {
+	case time.Time:
+		return v.UTC(), nil
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t.UTC(), nil
+		}
+		if c.Mode == Strict {
+			for _, layout := range localLayouts {
+				if _, err := time.Parse(layout, v); err == nil {
+					return time.Time{}, fmt.Errorf("%s has no timezone offset", describe(raw))
+				}
+			}
+			return time.Time{}, bad
+		}
+
+		loc := c.Location
+		if loc == nil {
+			loc = time.UTC
+		}
+		// a space instead of the T is common enough to take with an offset too
+		if t, err := time.Parse(time.RFC3339Nano, strings.Replace(v, " ", "T", 1)); err == nil {
+			return t.UTC(), nil
+		}
+		for _, layout := range localLayouts {
+			if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+				return t.UTC(), nil
+			}
+		}
+		return time.Time{}, bad
+	}
+
+	if c.Mode == Lenient {
+		if n, ok := toInt(raw); ok {
+			return time.Unix(n, 0).UTC(), nil
+		}
+	}
+	return time.Time{}, bad
+}
+
+func (c *Coercer) member(enum *types.EnumNode, raw any) (any, error) {
+	m, ok := memberValue(enum, raw)
+	if !ok && c.Mode == Lenient {
+		if s, isString := raw.(string); isString {
+			for i := range enum.Members {
+				if strings.EqualFold(enum.Members[i].Name, s) {
+					m, ok = &enum.Members[i], true
+					break
+				}
+			}
+		}
+	}
+	if !ok {
+		if _, isString := raw.(string); !isString {
+			if _, err := c.scalar(enum.Backing, raw); err != nil {
+				return nil, err
+			}
+		}
+		return nil, notMember(enum, raw)
+	}
+
+	if enum.Backing == types.DataInt {
+		n, err := strconv.ParseInt(m.Value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("enum %s: %s isn't an integer", enum.Name, m.Value)
+		}
+		return n, nil
+	}
+	return m.Value, nil
+}
+
+// Match coerces what a route matches on into filters for the query package.
+// `@note.id == :id` takes the value of the :id capture and `@note == params`
+// takes every query param, which all have to be fields of the entity
+func (c *Coercer) Match(r *types.Route, captures map[string]string, params url.Values) (map[string]any, error) {
+	if r.Match == nil {
+		return nil, nil
+	}
+	e := c.Schema.Entity(r.Entity)
+	if e == nil {
+		return nil, fmt.Errorf("unknown entity %s", r.Entity)
+	}
+
+	if r.Match.Source != types.ParamsSource {
+		f := e.Field(r.Match.Field)
+		if f == nil {
+			return nil, fmt.Errorf("%s has no field %s", e.Name, r.Match.Field)
+		}
+		raw, ok := captures[strings.TrimPrefix(r.Match.Source, ":")]
+		if !ok {
+			return nil, fmt.Errorf("nothing was captured for %s", r.Match.Source)
+		}
+		v, err := c.Field(f, raw)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{f.Name: v}, nil
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		if name != query.IncludeDeletedParam {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	filters := make(map[string]any, len(names))
+	var errs []error
+	for _, name := range names {
+		f := e.Field(name)
+		if f == nil || f.Kind == types.FieldComputed || f.Kind == types.FieldEmbedded {
+			errs = append(errs, fmt.Errorf("%s: %s has no field to match on", name, e.Name))
+			continue
+		}
+		if len(params[name]) > 1 {
+			errs = append(errs, fmt.Errorf("%s: given more than once", name))
+			continue
+		}
+		v, err := c.Field(f, params.Get(name))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		filters[name] = v
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return filters, nil
+}
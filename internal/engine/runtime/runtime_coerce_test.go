@@ -0,0 +1,163 @@
+package runtime
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+func TestCoerce(t *testing.T) {
+	plan := &types.EnumNode{Name: "plan", Members: []types.EnumMember{
+		{Name: "free", Value: "1"}, {Name: "pro", Value: "2"},
+	}, Backing: types.DataInt}
+	size := types.InlineEnum(types.DataText, []string{"small", "large"})
+	est := time.FixedZone("EST", -5*60*60)
+
+	tests := []struct {
+		name    string
+		mode    Mode
+		dt      types.DataType
+		enum    *types.EnumNode
+		raw     any
+		want    any
+		wantErr string
+	}{
+		{name: "int from a capture", dt: types.DataInt, raw: "42", want: int64(42)},
+		{name: "int from json", dt: types.DataInt, raw: 42.0, want: int64(42)},
+		{name: "not an int", dt: types.DataInt, raw: "abc", wantErr: `"abc" is not an int`},
+		{name: "fractional int", dt: types.DataInt, raw: 4.5, wantErr: "4.5 is not an int"},
+		{name: "strict int spelled as a float", dt: types.DataInt, raw: "4.0", wantErr: `"4.0" is not an int`},
+		{name: "lenient int spelled as a float", mode: Lenient, dt: types.DataInt, raw: " 1e3 ", want: int64(1000)},
+		{name: "int overflow", dt: types.DataInt, raw: "9223372036854775808", wantErr: `"9223372036854775808" is not an int`},
+
+		{name: "decimal", dt: types.DataReal, raw: "19.99", want: 19.99},
+		{name: "strict exponent", dt: types.DataReal, raw: "1e3", wantErr: `"1e3" is not a number`},
+		{name: "lenient exponent", mode: Lenient, dt: types.DataReal, raw: "1e3", want: 1000.0},
+		{name: "nan is never a number", mode: Lenient, dt: types.DataReal, raw: "NaN", wantErr: `"NaN" is not a number`},
+
+		{name: "decimal is made canonical", dt: types.DataDecimal, raw: "+019.90", want: "19.9"},
+		{name: "whole decimal", dt: types.DataDecimal, raw: "-0.000", want: "0"},
+		{name: "decimal from json", dt: types.DataDecimal, raw: 0.1, want: "0.1"},
+		{name: "decimal from a json number", dt: types.DataDecimal, raw: json.Number("12345678901234567890.123456789"), want: "12345678901234567890.123456789"},
+		{name: "strict decimal exponent", dt: types.DataDecimal, raw: "1.5e3", wantErr: `"1.5e3" is not a decimal`},
+		{name: "lenient decimal exponent", mode: Lenient, dt: types.DataDecimal, raw: "1.5e-3", want: "0.0015"},
+		{name: "decimal fraction", mode: Lenient, dt: types.DataDecimal, raw: "1/3", wantErr: `"1/3" is not a decimal`},
+
+		{name: "strict bool", dt: types.DataBool, raw: "true", want: true},
+		{name: "strict yes", dt: types.DataBool, raw: "yes", wantErr: `"yes" is not a bool`},
+		{name: "lenient yes", mode: Lenient, dt: types.DataBool, raw: "Yes", want: true},
+		{name: "lenient zero", mode: Lenient, dt: types.DataBool, raw: 0.0, want: false},
+
+		{name: "uuid is made canonical", dt: types.DataUUID, raw: "0196F3A2-7C1E-7B3A-9D2E-4F5A6B7C8D9E", want: "0196f3a2-7c1e-7b3a-9d2e-4f5a6b7c8d9e"},
+		{name: "strict uuid without dashes", dt: types.DataUUID, raw: "0196f3a27c1e7b3a9d2e4f5a6b7c8d9e", wantErr: `"0196f3a27c1e7b3a9d2e4f5a6b7c8d9e" is not a uuid`},
+		{name: "lenient urn", mode: Lenient, dt: types.DataUUID, raw: "urn:uuid:{0196f3a27c1e7b3a9d2e4f5a6b7c8d9e}", want: "0196f3a2-7c1e-7b3a-9d2e-4f5a6b7c8d9e"},
+
+		{name: "timestamp in utc", dt: types.DataTimestamp, raw: "2025-05-23T14:30:00+02:00", want: time.Date(2025, 5, 23, 12, 30, 0, 0, time.UTC)},
+		{name: "strict timestamp without offset", dt: types.DataTimestamp, raw: "2025-05-23T14:30:00", wantErr: `"2025-05-23T14:30:00" has no timezone offset`},
+		{name: "lenient timestamp without offset", mode: Lenient, dt: types.DataTimestamp, raw: "2025-05-23 14:30:00", want: time.Date(2025, 5, 23, 19, 30, 0, 0, time.UTC)},
+		{name: "lenient date", mode: Lenient, dt: types.DataTimestamp, raw: "2025-05-23", want: time.Date(2025, 5, 23, 5, 0, 0, 0, time.UTC)},
+		{name: "lenient unix seconds", mode: Lenient, dt: types.DataTimestamp, raw: 1748010600.0, want: time.Date(2025, 5, 23, 14, 30, 0, 0, time.UTC)},
+
+		{name: "enum by name", dt: types.DataEnum, enum: plan, raw: "pro", want: int64(2)},
+		{name: "enum by value", dt: types.DataEnum, enum: plan, raw: "1", want: int64(1)},
+		{name: "strict enum case", dt: types.DataEnum, enum: plan, raw: "PRO", wantErr: `"PRO" is not one of free, pro`},
+		{name: "lenient enum case", mode: Lenient, dt: types.DataEnum, enum: plan, raw: "PRO", want: int64(2)},
+		{name: "enum of the wrong type", dt: types.DataEnum, enum: plan, raw: true, wantErr: "true is not an int"},
+		{name: "inline enum", dt: types.DataText, enum: size, raw: "medium", wantErr: `"medium" is not one of small, large`},
+
+		{name: "strict text from a number", dt: types.DataText, raw: 12.0, wantErr: "12 is not text"},
+		{name: "lenient text from a number", mode: Lenient, dt: types.DataText, raw: 12.0, want: "12"},
+		{name: "null stays null", dt: types.DataInt, raw: nil, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Coercer{Mode: tt.mode, Location: est}
+			got, err := c.Value(tt.dt, tt.enum, tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("for test %s: expected error %q, got %v (%v)", tt.name, tt.wantErr, err, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("for test %s: unexpected error: %v", tt.name, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("for test %s: expected %#v, got %#v", tt.name, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCoerceMatch(t *testing.T) {
+	s := validator(t).schema
+	find := func(path string) *types.Route {
+		return &types.Route{Method: "GET", Path: path, Action: types.ActionFind, Entity: "account"}
+	}
+
+	byID := find("/accounts/:id")
+	byID.Match = &types.RouteMatch{Field: "id", Source: ":id"}
+	byParams := find("/accounts")
+	byParams.Match = &types.RouteMatch{Source: types.ParamsSource}
+
+	tests := []struct {
+		name     string
+		route    *types.Route
+		captures map[string]string
+		params   string
+		want     map[string]any
+		wantErr  string
+	}{
+		{
+			name:     "path capture",
+			route:    byID,
+			captures: map[string]string{"id": "0196F3A2-7C1E-7B3A-9D2E-4F5A6B7C8D9E"},
+			want:     map[string]any{"id": "0196f3a2-7c1e-7b3a-9d2e-4f5a6b7c8d9e"},
+		},
+		{
+			name:     "bad capture",
+			route:    byID,
+			captures: map[string]string{"id": "7"},
+			wantErr:  `id: "7" is not a uuid`,
+		},
+		{
+			name:   "query params",
+			route:  byParams,
+			params: "age=30&plan=pro&active=true&include_deleted=true",
+			want:   map[string]any{"age": int64(30), "plan": int64(2), "active": true},
+		},
+		{
+			name:    "params that aren't fields are rejected",
+			route:   byParams,
+			params:  "age=abc&nickname=x",
+			wantErr: "age: \"abc\" is not an int\nnickname: account has no field to match on",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := url.ParseQuery(tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := NewCoercer(s, Strict).Match(tt.route, tt.captures, params)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("for test %s: expected error %q, got %v", tt.name, tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("for test %s: unexpected error: %v", tt.name, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("for test %s: expected %v, got %v", tt.name, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package runtime
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// SequenceSource hands out the next value of a named counter. an app backs
+// this with a table so counters survive restarts; tests can get away with
+// the in-memory one
+type SequenceSource interface {
+	Next(name string) (int64, error)
+}
+
+type memorySequences struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func NewMemorySequences() SequenceSource {
+	return &memorySequences{counts: make(map[string]int64)}
+}
+
+func (m *memorySequences) Next(name string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counts[name]++
+	return m.counts[name], nil
+}
+
+// Defaults fills in field defaults when a record is written. it's for apps
+// writing through their own store: the sql mime generates leaves out every
+// default its database can't generate with a warning, and these are what
+// fill them. the clock and the sequences are swappable so output can be
+// made deterministic
+type Defaults struct {
+	Now       func() time.Time
+	Sequences SequenceSource
+}
+
+func NewDefaults() *Defaults {
+	return &Defaults{
+		Now:       time.Now,
+		Sequences: NewMemorySequences(),
+	}
+}
+
+// Insert fills every field the record leaves out that has a default. values
+// the client sent are never overwritten
+func (d *Defaults) Insert(entity *types.EntityNode, record map[string]any) error {
+	now := d.Now()
+
+	for _, f := range entity.Fields {
+		if f.Default == nil {
+			continue
+		}
+		if v, ok := record[f.Name]; ok && v != nil {
+			continue
+		}
+
+		v, err := d.eval(entity, f, f.Default, now)
+		if err != nil {
+			return fmt.Errorf("default for %s.%s: %w", entity.Name, f.Name, err)
+		}
+		record[f.Name] = v
+	}
+
+	return nil
+}
+
+// Update refreshes every on_update field regardless of what was sent; an
+// updated_at the client can spoof isn't worth much
+func (d *Defaults) Update(entity *types.EntityNode, record map[string]any) error {
+	now := d.Now()
+
+	for _, f := range entity.Fields {
+		if f.OnUpdate == nil {
+			continue
+		}
+
+		v, err := d.eval(entity, f, f.OnUpdate, now)
+		if err != nil {
+			return fmt.Errorf("on_update for %s.%s: %w", entity.Name, f.Name, err)
+		}
+		record[f.Name] = v
+	}
+
+	return nil
+}
+
+func (d *Defaults) eval(entity *types.EntityNode, f *types.Field, dv *types.DefaultValue, now time.Time) (any, error) {
+	if dv.Kind == types.DefaultLiteral {
+		return literalDefault(f.DataType, dv.Value)
+	}
+
+	switch dv.Value {
+	case types.FuncNow:
+		return now.UTC(), nil
+	case types.FuncToday:
+		y, m, day := now.UTC().Date()
+		today := time.Date(y, m, day, 0, 0, 0, 0, time.UTC)
+		if f.DataType == types.DataText {
+			return today.Format(time.DateOnly), nil
+		}
+		return today, nil
+	case types.FuncUUIDv4:
+		return newUUIDv4()
+	case types.FuncUUIDv7:
+		return newUUIDv7(now)
+	case types.FuncSequence:
+		name := types.SequenceName(entity, f, dv)
+		if d.Sequences == nil {
+			return nil, fmt.Errorf("no sequence source for %s", name)
+		}
+		return d.Sequences.Next(name)
+	}
+
+	return nil, fmt.Errorf("unknown default function %s()", dv.Value)
+}
+
+func literalDefault(dt types.DataType, v string) (any, error) {
+	switch dt {
+	case types.DataInt:
+		return strconv.ParseInt(v, 10, 64)
+	case types.DataReal:
+		return strconv.ParseFloat(v, 64)
+	case types.DataDecimal:
+		if d, ok := toDecimal(v, false); ok {
+			return d, nil
+		}
+		return nil, fmt.Errorf("%q is not %s", v, typeName(types.DataDecimal))
+	case types.DataBool:
+		return strconv.ParseBool(v)
+	case types.DataTimestamp:
+		return time.Parse(time.RFC3339, v)
+	}
+
+	return v, nil
+}
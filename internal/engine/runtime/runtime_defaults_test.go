@@ -0,0 +1,123 @@
+package runtime
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+var uuidRe = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-([47])[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func modelEntity() *types.EntityNode {
+	fn := func(name string, args ...string) *types.DefaultValue {
+		return &types.DefaultValue{Kind: types.DefaultFunc, Value: name, Args: args}
+	}
+
+	return &types.EntityNode{
+		Name: "model",
+		Fields: []*types.Field{
+			{Name: "id", DataType: types.DataUUID, Attributes: types.AttrDefault, Default: fn(types.FuncUUIDv7)},
+			{Name: "token", DataType: types.DataUUID, Attributes: types.AttrDefault, Default: fn(types.FuncUUIDv4)},
+			{Name: "number", DataType: types.DataInt, Attributes: types.AttrDefault, Default: fn(types.FuncSequence)},
+			{Name: "invoice", DataType: types.DataInt, Attributes: types.AttrDefault, Default: fn(types.FuncSequence, "invoices")},
+			{Name: "age", DataType: types.DataInt, Attributes: types.AttrDefault,
+				Default: &types.DefaultValue{Kind: types.DefaultLiteral, Value: "18"}},
+			{Name: "day", DataType: types.DataText, Attributes: types.AttrDefault, Default: fn(types.FuncToday)},
+			{Name: "created_at", DataType: types.DataTimestamp, Attributes: types.AttrDefault, Default: fn(types.FuncNow)},
+			{Name: "updated_at", DataType: types.DataTimestamp, Attributes: types.AttrDefault | types.AttrOnUpdate,
+				Default: fn(types.FuncNow), OnUpdate: fn(types.FuncNow)},
+		},
+	}
+}
+
+func TestDefaultsInsert(t *testing.T) {
+	now := time.Date(2025, 5, 23, 14, 30, 0, 0, time.UTC)
+	d := &Defaults{
+		Now:       func() time.Time { return now },
+		Sequences: NewMemorySequences(),
+	}
+	entity := modelEntity()
+
+	first := map[string]any{"age": int64(30)}
+	if err := d.Insert(entity, first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second := map[string]any{}
+	if err := d.Insert(entity, second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, rec := range []map[string]any{first, second} {
+		for field, version := range map[string]string{"id": "7", "token": "4"} {
+			m := uuidRe.FindStringSubmatch(rec[field].(string))
+			if m == nil || m[1] != version {
+				t.Fatalf("%s: expected a v%s uuid, got %v", field, version, rec[field])
+			}
+		}
+	}
+
+	expected := map[string]any{
+		"number":     int64(2),
+		"invoice":    int64(2),
+		"age":        int64(18),
+		"day":        "2025-05-23",
+		"created_at": now,
+		"updated_at": now,
+	}
+	for field, want := range expected {
+		if got := second[field]; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: expected %#v, got %#v", field, want, got)
+		}
+	}
+
+	if first["age"] != int64(30) {
+		t.Fatalf("default overwrote a value the client sent: %v", first["age"])
+	}
+}
+
+// names records which sequences were asked for
+type names []string
+
+func (n *names) Next(name string) (int64, error) {
+	*n = append(*n, name)
+	return 1, nil
+}
+
+// the runtime counts with the sequences the ddl declares
+func TestDefaultsSequenceNames(t *testing.T) {
+	var got names
+	d := &Defaults{Now: time.Now, Sequences: &got}
+	if err := d.Insert(modelEntity(), map[string]any{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (names{"model_number_seq", "invoices"}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected sequences %q, got %q", want, got)
+	}
+}
+
+func TestDefaultsUpdate(t *testing.T) {
+	later := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	d := &Defaults{Now: func() time.Time { return later }}
+
+	record := map[string]any{"updated_at": time.Unix(0, 0)}
+	if err := d.Update(modelEntity(), record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]any{"updated_at": later}
+	if !reflect.DeepEqual(record, expected) {
+		t.Fatalf("expected %v, got %v", expected, record)
+	}
+}
+
+func TestUUIDv7Ordering(t *testing.T) {
+	a, _ := newUUIDv7(time.UnixMilli(1_700_000_000_000))
+	b, _ := newUUIDv7(time.UnixMilli(1_700_000_000_001))
+
+	if a >= b {
+		t.Fatalf("expected %s to sort before %s", a, b)
+	}
+}
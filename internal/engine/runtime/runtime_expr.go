@@ -0,0 +1,340 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// evaluator runs check expressions against a row of typed values. null
+// behaves the way it does in sql: anything it touches is null and a check
+// that comes out null passes, so `age >= 18` doesn't fail a row without an
+// age. that keeps the runtime and the database's CHECK constraints in step.
+// decimals are read as a *big.Rat so they add up and compare exactly; mixed
+// with a float they become a float the way they do in sql
+type evaluator struct {
+	schema *types.Schema
+	entity *types.EntityNode
+	row    map[string]any
+}
+
+func (ev evaluator) eval(e *types.Expr) (any, error) {
+	switch e.Kind {
+	case types.ExprField:
+		return ev.field(e.Value)
+	case types.ExprString:
+		return e.Value, nil
+	case types.ExprNumber:
+		if strings.Contains(e.Value, ".") {
+			return strconv.ParseFloat(e.Value, 64)
+		}
+		return strconv.ParseInt(e.Value, 10, 64)
+	case types.ExprBinary:
+		return ev.binary(e)
+	case types.ExprCall:
+		return ev.call(e)
+	}
+
+	return nil, fmt.Errorf("can't evaluate %s outside of the database", e)
+}
+
+func (ev evaluator) field(name string) (any, error) {
+	v := ev.row[name]
+	s, isString := v.(string)
+	f := ev.entity.Field(name)
+	if !isString || f == nil {
+		return v, nil
+	}
+	if dt, _ := ev.schema.FieldType(f); dt != types.DataDecimal {
+		return v, nil
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s is not %s", name, describe(s), typeName(types.DataDecimal))
+	}
+	return r, nil
+}
+
+func (ev evaluator) binary(e *types.Expr) (any, error) {
+	left, err := ev.eval(e.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	right, err := ev.eval(e.Args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.Value {
+	case "and", "or":
+		return logical(e.Value, left, right), nil
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	switch e.Value {
+	case "||":
+		return text(left) + text(right), nil
+	case "+", "-", "*", "/":
+		return arithmetic(e.Value, left, right)
+	}
+
+	right = ev.enumLiteral(e.Args[0], right)
+	left = ev.enumLiteral(e.Args[1], left)
+	c, ok := compare(left, right)
+	if !ok {
+		return nil, fmt.Errorf("can't compare %s with %s", describe(left), describe(right))
+	}
+	switch e.Value {
+	case "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	}
+
+	return nil, fmt.Errorf("unknown operator %s", e.Value)
+}
+
+// enum fields hold stored values but a check can name members either way
+// e.g. `status != "archived"`. when side is an enum field the literal on the
+// other side is swapped for the member's stored value
+func (ev evaluator) enumLiteral(side *types.Expr, other any) any {
+	if side.Kind != types.ExprField {
+		return other
+	}
+	f := ev.entity.Field(side.Value)
+	if f == nil || f.DataType != types.DataEnum || f.Enum == nil {
+		return other
+	}
+	if m, ok := memberValue(f.Enum, other); ok {
+		return m.Value
+	}
+	return other
+}
+
+// three valued logic: false and null is false, true or null is true and
+// everything else involving null is null
+func logical(op string, left, right any) any {
+	l, lok := left.(bool)
+	r, rok := right.(bool)
+
+	if op == "and" {
+		if (lok && !l) || (rok && !r) {
+			return false
+		}
+		if lok && rok {
+			return true
+		}
+		return nil
+	}
+
+	if (lok && l) || (rok && r) {
+		return true
+	}
+	if lok && rok {
+		return false
+	}
+	return nil
+}
+
+func arithmetic(op string, left, right any) (any, error) {
+	li, lint := left.(int64)
+	ri, rint := right.(int64)
+	if lint && rint {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		}
+		if ri == 0 {
+			return nil, nil
+		}
+		return li / ri, nil
+	}
+
+	if lr, rr, ok := rats(left, right); ok {
+		switch op {
+		case "+":
+			return new(big.Rat).Add(lr, rr), nil
+		case "-":
+			return new(big.Rat).Sub(lr, rr), nil
+		case "*":
+			return new(big.Rat).Mul(lr, rr), nil
+		}
+		if rr.Sign() == 0 {
+			return nil, nil
+		}
+		return new(big.Rat).Quo(lr, rr), nil
+	}
+
+	lf, lok := toFloat(left)
+	rf, rok := toFloat(right)
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator %s needs numbers, got %s and %s", op, describe(left), describe(right))
+	}
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	}
+	if rf == 0 {
+		return nil, nil
+	}
+	return lf / rf, nil
+}
+
+// compare orders two values of the same kind. bools are only ever equal or
+// not; the type checker doesn't let them be ordered
+func compare(a, b any) (int, bool) {
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		return strings.Compare(x, y), ok
+	case bool:
+		y, ok := b.(bool)
+		if x == y {
+			return 0, ok
+		}
+		return 1, ok
+	case time.Time:
+		y, ok := b.(time.Time)
+		return x.Compare(y), ok
+	}
+
+	if x, ok := a.(int64); ok {
+		if y, ok := b.(int64); ok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	if x, y, ok := rats(a, b); ok {
+		return x.Cmp(y), true
+	}
+	x, lok := toFloat(a)
+	y, rok := toFloat(b)
+	if !lok || !rok {
+		return 0, false
+	}
+	switch {
+	case x < y:
+		return -1, true
+	case x > y:
+		return 1, true
+	}
+	return 0, true
+}
+
+func (ev evaluator) call(e *types.Expr) (any, error) {
+	args := make([]any, 0, len(e.Args))
+	for _, a := range e.Args {
+		v, err := ev.eval(a)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+
+	if e.Value == "coalesce" {
+		for _, a := range args {
+			if a != nil {
+				return a, nil
+			}
+		}
+		return nil, nil
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("can't evaluate %s", e)
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	switch e.Value {
+	case "lower":
+		return strings.ToLower(text(args[0])), nil
+	case "upper":
+		return strings.ToUpper(text(args[0])), nil
+	case "trim":
+		return strings.TrimSpace(text(args[0])), nil
+	case "length":
+		return int64(utf8.RuneCountInString(text(args[0]))), nil
+	case "abs":
+		if i, ok := args[0].(int64); ok {
+			if i < 0 {
+				return -i, nil
+			}
+			return i, nil
+		}
+		if r, ok := args[0].(*big.Rat); ok {
+			return new(big.Rat).Abs(r), nil
+		}
+		if f, ok := toFloat(args[0]); ok {
+			return math.Abs(f), nil
+		}
+	case "round":
+		if r, ok := args[0].(*big.Rat); ok {
+			return roundRat(r)
+		}
+		if f, ok := toFloat(args[0]); ok {
+			return int64(math.Round(f)), nil
+		}
+	}
+
+	return nil, fmt.Errorf("can't evaluate %s", e)
+}
+
+// roundRat rounds halves away from zero like math.Round
+func roundRat(r *big.Rat) (any, error) {
+	// floor(|n|/d + 1/2) is floor((2|n| + d) / 2d)
+	n := new(big.Int).Abs(r.Num())
+	n.Add(n.Lsh(n, 1), r.Denom())
+	n.Quo(n, new(big.Int).Lsh(r.Denom(), 1))
+	if r.Sign() < 0 {
+		n.Neg(n)
+	}
+	if !n.IsInt64() {
+		return nil, fmt.Errorf("%s is too big to round to an int", text(r))
+	}
+	return n.Int64(), nil
+}
+
+func text(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case *big.Rat:
+		if s, ok := decimalString(x); ok {
+			return s
+		}
+		return x.FloatString(16)
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,88 @@
+package runtime
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// both generators return the canonical lowercase 8-4-4-4-12 form since that's
+// what ends up in the database and in responses
+
+func newUUIDv4() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", fmt.Errorf("couldn't generate uuid: %w", err)
+	}
+
+	u[6] = (u[6] & 0x0f) | 0x40 // version 4
+	u[8] = (u[8] & 0x3f) | 0x80 // rfc 4122 variant
+
+	return formatUUID(u), nil
+}
+
+// v7 puts the unix time in milliseconds up front so ids sort by creation
+// time which keeps primary key indexes happy
+func newUUIDv7(now time.Time) (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[6:]); err != nil {
+		return "", fmt.Errorf("couldn't generate uuid: %w", err)
+	}
+
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(now.UnixMilli()))
+	copy(u[:6], ms[2:])
+
+	u[6] = (u[6] & 0x0f) | 0x70 // version 7
+	u[8] = (u[8] & 0x3f) | 0x80 // rfc 4122 variant
+
+	return formatUUID(u), nil
+}
+
+func formatUUID(u [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+// parseUUID accepts the 8-4-4-4-12 form in either case and returns it in the
+// canonical form
+func parseUUID(s string) (string, error) {
+	if len(s) != 36 {
+		return "", fmt.Errorf("%q isn't a uuid", s)
+	}
+
+	var u [16]byte
+	j := 0
+	for i := 0; i < len(s); {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return "", fmt.Errorf("%q isn't a uuid", s)
+			}
+			i++
+			continue
+		}
+		hi, ok1 := fromHex(s[i])
+		lo, ok2 := fromHex(s[i+1])
+		if !ok1 || !ok2 {
+			return "", fmt.Errorf("%q isn't a uuid", s)
+		}
+		u[j] = hi<<4 | lo
+		i += 2
+		j++
+	}
+
+	return formatUUID(u), nil
+}
+
+func fromHex(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
@@ -0,0 +1,232 @@
+package runtime
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// the http server, the repl and the cli all check what a client sends with
+// the same Validator so a payload is never valid in one place and not in
+// another. only what can be decided from the payload alone is checked here;
+// uniqueness and references are left to the database
+
+// error codes callers can switch on
+const (
+	CodeEntity   = "entity"   // the entity doesn't exist
+	CodeRequired = "required" // a required field is missing or null
+	CodeUnknown  = "unknown"  // the entity has no such field
+	CodeReadonly = "readonly" // the field exists but isn't part of the payload
+	CodeType     = "type"     // the value isn't of the field's type
+	CodeEnum     = "enum"     // the value isn't a member of the field's enum
+	CodeLength   = "length"
+	CodePattern  = "pattern"
+	CodeCheck    = "check"
+)
+
+// FieldError is one problem with a payload. Pointer is a json pointer (rfc
+// 6901) to the offending member e.g. /person/name
+type FieldError struct {
+	Pointer string `json:"pointer"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pointer, e.Message)
+}
+
+type Validator struct {
+	schema *types.Schema
+	// compiled once up front; patterns were already checked when the schema
+	// was validated
+	patterns map[string]*regexp.Regexp
+}
+
+func NewValidator(s *types.Schema) *Validator {
+	v := &Validator{schema: s, patterns: make(map[string]*regexp.Regexp)}
+	for _, e := range s.Entities {
+		for _, f := range e.Fields {
+			if f.Pattern == "" {
+				continue
+			}
+			if re, err := regexp.Compile(f.Pattern); err == nil {
+				v.patterns[f.Pattern] = re
+			}
+		}
+	}
+	return v
+}
+
+// Validate checks a payload written to entity against its payload shape.
+// fields are reported in declaration order followed by unknown members in
+// alphabetical order; a valid payload gives nil
+func (v *Validator) Validate(entity string, payload map[string]any) []FieldError {
+	e := v.schema.Entity(entity)
+	if e == nil {
+		return []FieldError{{Code: CodeEntity, Message: fmt.Sprintf("unknown entity %s", entity)}}
+	}
+	return v.object(e, payload, "")
+}
+
+func (v *Validator) object(e *types.EntityNode, payload map[string]any, prefix string) []FieldError {
+	var errs []FieldError
+	failed := make(map[string]bool)
+	report := func(f, code, format string, args ...any) {
+		failed[f] = true
+		errs = append(errs, FieldError{
+			Pointer: prefix + "/" + escapePointer(f),
+			Code:    code,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	writable := e.PayloadFields()
+	row := make(map[string]any, len(payload))
+
+	for _, f := range writable {
+		raw, ok := payload[f.Name]
+		if !ok || raw == nil {
+			if f.Attributes&(types.AttrRequired|types.AttrPrimary) != 0 && f.Default == nil {
+				report(f.Name, CodeRequired, "%s is required", f.Name)
+			}
+			continue
+		}
+
+		if f.Kind == types.FieldEmbedded {
+			errs = append(errs, v.embedded(f, raw, prefix)...)
+			continue
+		}
+
+		value, code, err := v.value(f, raw)
+		if err != nil {
+			report(f.Name, code, "%s: %v", f.Name, err)
+			continue
+		}
+		row[f.Name] = value
+
+		if s, ok := value.(string); ok && f.Length != nil {
+			if n := utf8.RuneCountInString(s); n < f.Length.Min || (f.Length.Max != 0 && n > f.Length.Max) {
+				report(f.Name, CodeLength, "%s: has to be %s characters long, got %d", f.Name, lengthBounds(*f.Length), n)
+			}
+		}
+		if re := v.patterns[f.Pattern]; re != nil {
+			if s, ok := value.(string); ok && !re.MatchString(s) {
+				report(f.Name, CodePattern, "%s: %s doesn't match %s", f.Name, describe(s), f.Pattern)
+			}
+		}
+	}
+
+	// checks can look at other fields so they run once every value is typed.
+	// a value that didn't type is missing from row so checks reading it come
+	// out null instead of failing a second time
+	ev := evaluator{schema: v.schema, entity: e, row: row}
+	for _, f := range writable {
+		if f.Check == nil || failed[f.Name] {
+			continue
+		}
+		result, err := ev.eval(f.Check)
+		if err != nil {
+			report(f.Name, CodeCheck, "%s: %v", f.Name, err)
+		} else if result == false {
+			report(f.Name, CodeCheck, "%s: has to satisfy %s", f.Name, f.Check)
+		}
+	}
+
+	var extra []string
+	for name := range payload {
+		if !slices.ContainsFunc(writable, func(f *types.Field) bool { return f.Name == name }) {
+			extra = append(extra, name)
+		}
+	}
+	slices.Sort(extra)
+	for _, name := range extra {
+		if e.Field(name) != nil {
+			report(name, CodeReadonly, "%s can't be written", name)
+		} else {
+			report(name, CodeUnknown, "%s has no field %s", e.Name, name)
+		}
+	}
+
+	return errs
+}
+
+// an embedded entity is written as a nested object and checked the same way
+func (v *Validator) embedded(f *types.Field, raw any, prefix string) []FieldError {
+	pointer := prefix + "/" + escapePointer(f.Name)
+
+	obj, ok := raw.(map[string]any)
+	if !ok {
+		return []FieldError{{Pointer: pointer, Code: CodeType,
+			Message: fmt.Sprintf("%s: %s is not an object", f.Name, describe(raw))}}
+	}
+	e := v.schema.Entity(f.Name)
+	if e == nil {
+		return []FieldError{{Pointer: pointer, Code: CodeEntity,
+			Message: fmt.Sprintf("unknown entity %s", f.Name)}}
+	}
+	return v.object(e, obj, pointer)
+}
+
+// value types a field's value. references take the type of the field they
+// point at. the code says which check failed
+func (v *Validator) value(f *types.Field, raw any) (any, string, error) {
+	dt, enum := v.schema.FieldType(f)
+
+	if dt == types.DataEnum {
+		if enum == nil {
+			return nil, CodeType, fmt.Errorf("enum isn't linked")
+		}
+		m, ok := memberValue(enum, raw)
+		if ok {
+			return m.Value, "", nil
+		}
+		// members can always be given by name so any string is the right type
+		if _, isString := raw.(string); !isString {
+			if _, err := typed(enum.Backing, raw); err != nil {
+				return nil, CodeType, err
+			}
+		}
+		return nil, CodeEnum, notMember(enum, raw)
+	}
+
+	value, err := typed(dt, raw)
+	if err != nil {
+		return nil, CodeType, err
+	}
+	if enum != nil {
+		if _, ok := memberValue(enum, value); !ok {
+			return nil, CodeEnum, notMember(enum, raw)
+		}
+	}
+	return value, "", nil
+}
+
+func notMember(enum *types.EnumNode, raw any) error {
+	names := make([]string, 0, len(enum.Members))
+	for _, m := range enum.Members {
+		names = append(names, m.Name)
+	}
+	return fmt.Errorf("%s is not one of %s", describe(raw), strings.Join(names, ", "))
+}
+
+func lengthBounds(l types.Length) string {
+	switch {
+	case l.Max == 0:
+		return fmt.Sprintf("at least %d", l.Min)
+	case l.Min == 0:
+		return fmt.Sprintf("at most %d", l.Max)
+	case l.Min == l.Max:
+		return fmt.Sprintf("exactly %d", l.Min)
+	}
+	return fmt.Sprintf("%d to %d", l.Min, l.Max)
+}
+
+// escapePointer escapes a member name for use in a json pointer
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
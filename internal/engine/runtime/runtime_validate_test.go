@@ -0,0 +1,230 @@
+package runtime
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/parser"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+const accounts = `enum plan ->
+	free = 1
+	pro
+	team
+end
+
+entity person ->
+	name text [required length:1,40]
+	email text [pattern:"^[^@ ]+@[^@ ]+$"]
+end
+
+entity account ->
+	id uuid [primary unique required default:uuid_v7()]
+	username text [required unique length:3,20 pattern:"^[a-z0-9_]+$"]
+	age int [check:age >= 13 and age < 150]
+	score float
+	fee decimal [check:fee >= 0 and fee < 10]
+	active bool
+	plan &plan [default:"free"]
+	size text ("small" "large")
+	seats int [check:seats > 1 or plan == "free"]
+	joined timestamp
+	@person
+	display text = upper(username)
+	created_at timestamp [readonly default:now()]
+end
+
+entity invite ->
+	id uuid [primary unique required]
+	account @account.id [required]
+end
+`
+
+func validator(t *testing.T) *Validator {
+	t.Helper()
+	s, errs := parser.NewParser(lexer.NewFile("accounts.mime", accounts)).Schema()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected parse errors: %v", errs)
+	}
+	return NewValidator(s)
+}
+
+func TestValidateAccepts(t *testing.T) {
+	v := validator(t)
+
+	// the payload the http server would decode from a request body
+	var payload map[string]any
+	body := `{
+		"username": "ada_l",
+		"age": 36,
+		"score": 9.5,
+		"fee": "9.99",
+		"active": true,
+		"plan": "pro",
+		"size": "large",
+		"seats": 4,
+		"joined": "2025-05-23T14:30:00+02:00",
+		"person": {"name": "Ada", "email": "ada@example.com"}
+	}`
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if errs := v.Validate("account", payload); errs != nil {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+
+	// go values and int backed enums given by value work too
+	if errs := v.Validate("account", map[string]any{
+		"username": "grace",
+		"age":      int64(40),
+		"score":    3,
+		"plan":     2,
+		"seats":    uint8(3),
+	}); errs != nil {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+
+	if errs := v.Validate("invite", map[string]any{
+		"id":      "0196F3A2-7C1E-7B3A-9D2E-4F5A6B7C8D9E",
+		"account": "0196f3a2-7c1e-7b3a-9d2e-4f5a6b7c8d9e",
+	}); errs != nil {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+}
+
+func TestValidateRejects(t *testing.T) {
+	v := validator(t)
+
+	tests := []struct {
+		name    string
+		entity  string
+		payload map[string]any
+		want    []FieldError
+	}{
+		{
+			name:    "missing required",
+			entity:  "account",
+			payload: map[string]any{"age": 20.0},
+			want:    []FieldError{{"/username", CodeRequired, "username is required"}},
+		},
+		{
+			name:    "null counts as missing",
+			entity:  "account",
+			payload: map[string]any{"username": nil},
+			want:    []FieldError{{"/username", CodeRequired, "username is required"}},
+		},
+		{
+			name:    "unknown and readonly members",
+			entity:  "account",
+			payload: map[string]any{"username": "ada", "nickname": "a", "display": "ADA", "created_at": "2025-01-01T00:00:00Z"},
+			want: []FieldError{
+				{"/created_at", CodeReadonly, "created_at can't be written"},
+				{"/display", CodeReadonly, "display can't be written"},
+				{"/nickname", CodeUnknown, "account has no field nickname"},
+			},
+		},
+		{
+			name:   "types",
+			entity: "account",
+			payload: map[string]any{
+				"username": 12,
+				"age":      "abc",
+				"score":    "1.5",
+				"active":   "yes",
+				"joined":   "last tuesday",
+				"seats":    2.5,
+			},
+			want: []FieldError{
+				{"/username", CodeType, "username: 12 is not text"},
+				{"/age", CodeType, `age: "abc" is not an int`},
+				{"/score", CodeType, `score: "1.5" is not a number`},
+				{"/active", CodeType, `active: "yes" is not a bool`},
+				{"/seats", CodeType, "seats: 2.5 is not an int"},
+				{"/joined", CodeType, `joined: "last tuesday" is not an RFC 3339 timestamp`},
+			},
+		},
+		{
+			name:    "enums",
+			entity:  "account",
+			payload: map[string]any{"username": "ada", "plan": "enterprise", "size": "medium"},
+			want: []FieldError{
+				{"/plan", CodeEnum, `plan: "enterprise" is not one of free, pro, team`},
+				{"/size", CodeEnum, `size: "medium" is not one of small, large`},
+			},
+		},
+		{
+			name:    "length and pattern",
+			entity:  "account",
+			payload: map[string]any{"username": "Ad"},
+			want: []FieldError{
+				{"/username", CodeLength, "username: has to be 3 to 20 characters long, got 2"},
+				{"/username", CodePattern, `username: "Ad" doesn't match ^[a-z0-9_]+$`},
+			},
+		},
+		{
+			name:    "checks",
+			entity:  "account",
+			payload: map[string]any{"username": "ada", "age": 12, "fee": "10.00", "plan": "team", "seats": 1},
+			want: []FieldError{
+				{"/age", CodeCheck, "age: has to satisfy (age >= 13) and (age < 150)"},
+				{"/fee", CodeCheck, "fee: has to satisfy (fee >= 0) and (fee < 10)"},
+				{"/seats", CodeCheck, `seats: has to satisfy (seats > 1) or (plan == "free")`},
+			},
+		},
+		{
+			name:    "embedded",
+			entity:  "account",
+			payload: map[string]any{"username": "ada", "person": map[string]any{"email": "nope", "age": 3}},
+			want: []FieldError{
+				{"/person/name", CodeRequired, "name is required"},
+				{"/person/email", CodePattern, `email: "nope" doesn't match ^[^@ ]+@[^@ ]+$`},
+				{"/person/age", CodeUnknown, "person has no field age"},
+			},
+		},
+		{
+			name:    "reference takes its target's type",
+			entity:  "invite",
+			payload: map[string]any{"id": "0196f3a2-7c1e-7b3a-9d2e-4f5a6b7c8d9e", "account": "42"},
+			want:    []FieldError{{"/account", CodeType, `account: "42" is not a uuid`}},
+		},
+		{
+			name:    "unknown entity",
+			entity:  "nope",
+			payload: map[string]any{},
+			want:    []FieldError{{"", CodeEntity, "unknown entity nope"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := v.Validate(tt.entity, tt.payload)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("for test %s:\nexpected %v\ngot      %v", tt.name, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateChecksAreNullSafe(t *testing.T) {
+	v := validator(t)
+
+	// neither age nor seats are sent so their checks can't fail
+	if errs := v.Validate("account", map[string]any{"username": "ada", "plan": "team"}); errs != nil {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+}
+
+func TestEscapePointer(t *testing.T) {
+	s := &types.Schema{Entities: []*types.EntityNode{{Name: "x"}}}
+	errs := NewValidator(s).Validate("x", map[string]any{"a/b~c": 1})
+	if len(errs) != 1 || errs[0].Pointer != "/a~1b~0c" {
+		t.Fatalf("expected an escaped pointer, got %v", errs)
+	}
+	if !strings.HasPrefix(errs[0].Error(), "/a~1b~0c: ") {
+		t.Fatalf("unexpected error string %q", errs[0].Error())
+	}
+}
@@ -0,0 +1,259 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// values the engine works with are always one of int64, float64, string,
+// bool or time.Time whatever they were decoded from. uuids and decimals are
+// kept as strings in their canonical form and enum fields hold the member's
+// stored value
+
+// typed checks that v can hold a value of type dt and returns it as the go
+// type the engine uses for dt
+func typed(dt types.DataType, v any) (any, error) {
+	switch dt {
+	case types.DataText:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case types.DataInt:
+		if n, ok := toInt(v); ok {
+			return n, nil
+		}
+	case types.DataReal:
+		if n, ok := toFloat(v); ok {
+			return n, nil
+		}
+	case types.DataDecimal:
+		if d, ok := toDecimal(v, false); ok {
+			return d, nil
+		}
+	case types.DataBool:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case types.DataUUID:
+		if s, ok := v.(string); ok {
+			if u, err := parseUUID(s); err == nil {
+				return u, nil
+			}
+		}
+	case types.DataTimestamp:
+		switch t := v.(type) {
+		case time.Time:
+			return t, nil
+		case string:
+			if ts, err := time.Parse(time.RFC3339Nano, t); err == nil {
+				return ts, nil
+			}
+		}
+	default:
+		return nil, fmt.Errorf("values of type %d can't be checked", dt)
+	}
+
+	return nil, fmt.Errorf("%s is not %s", describe(v), typeName(dt))
+}
+
+func toInt(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint:
+		return int64(n), uint64(n) <= math.MaxInt64
+	case uint8:
+		return int64(n), true
+	case uint16:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	case uint64:
+		return int64(n), n <= math.MaxInt64
+	case float32:
+		return toInt(float64(n))
+	case float64:
+		// json numbers decode as float64 so 3.0 is as good as 3
+		if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case *big.Rat:
+		f, _ := n.Float64()
+		return f, true
+	}
+	if i, ok := toInt(v); ok {
+		return float64(i), true
+	}
+	return 0, false
+}
+
+// plain decimal notation, and the same with an exponent which json numbers
+// and lenient mode allow. exponents are kept short so nobody can ask for a
+// number with a billion zeros
+var (
+	plainDecimal    = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+	exponentDecimal = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)[eE][+-]?[0-9]{1,3}$`)
+)
+
+// toDecimal gives a decimal's canonical form: no plus sign, no leading or
+// trailing zeros and nothing after the point if it's whole e.g. +019.90 is
+// 19.9. numbers are taken at their shortest spelling so the float64 json
+// decodes 19.9 as reads back as 19.9
+func toDecimal(v any, exponent bool) (string, bool) {
+	switch n := v.(type) {
+	case string:
+		if !plainDecimal.MatchString(n) && !(exponent && exponentDecimal.MatchString(n)) {
+			return "", false
+		}
+		r, ok := new(big.Rat).SetString(n)
+		if !ok {
+			return "", false
+		}
+		return decimalString(r)
+	case json.Number:
+		return toDecimal(string(n), true)
+	case float64:
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return "", false
+		}
+		return toDecimal(strconv.FormatFloat(n, 'g', -1, 64), true)
+	case float32:
+		return toDecimal(float64(n), exponent)
+	case *big.Rat:
+		return decimalString(n)
+	}
+	if i, ok := toInt(v); ok {
+		return strconv.FormatInt(i, 10), true
+	}
+	return "", false
+}
+
+// decimalString writes r out in full, which it can only do when its
+// denominator has no prime factors but 2 and 5
+func decimalString(r *big.Rat) (string, bool) {
+	d := new(big.Int).Set(r.Denom())
+	scale := 0
+	for _, p := range []int64{2, 5} {
+		n, m, q := 0, new(big.Int), big.NewInt(p)
+		for {
+			quo, rem := new(big.Int).QuoRem(d, q, m)
+			if rem.Sign() != 0 {
+				break
+			}
+			d, n = quo, n+1
+		}
+		scale = max(scale, n)
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return "", false
+	}
+	return r.FloatString(scale), true
+}
+
+// toRat reads a decimal for arithmetic. ints are decimals too, floats aren't
+func toRat(v any) (*big.Rat, bool) {
+	switch n := v.(type) {
+	case *big.Rat:
+		return n, true
+	case int64:
+		return new(big.Rat).SetInt64(n), true
+	}
+	return nil, false
+}
+
+// rats reads both sides as decimals when one of them is one and the other
+// is a decimal or an int
+func rats(a, b any) (*big.Rat, *big.Rat, bool) {
+	_, ad := a.(*big.Rat)
+	_, bd := b.(*big.Rat)
+	if !ad && !bd {
+		return nil, nil, false
+	}
+	x, xok := toRat(a)
+	y, yok := toRat(b)
+	return x, y, xok && yok
+}
+
+// memberValue finds the enum member v stands for. members can be given by
+// their stored value or by name
+func memberValue(enum *types.EnumNode, v any) (*types.EnumMember, bool) {
+	if s, ok := v.(string); ok {
+		m := enum.Member(s)
+		return m, m != nil
+	}
+	if n, ok := toInt(v); ok && enum.Backing == types.DataInt {
+		m := enum.Member(strconv.FormatInt(n, 10))
+		return m, m != nil
+	}
+	return nil, false
+}
+
+func describe(v any) string {
+	switch x := v.(type) {
+	case string:
+		return strconv.Quote(x)
+	case nil:
+		return "null"
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "a list"
+	case *big.Rat:
+		return text(x)
+	}
+	return fmt.Sprint(v)
+}
+
+func typeName(dt types.DataType) string {
+	switch dt {
+	case types.DataText:
+		return "text"
+	case types.DataInt:
+		return "an int"
+	case types.DataReal:
+		return "a number"
+	case types.DataDecimal:
+		return "a decimal"
+	case types.DataBool:
+		return "a bool"
+	case types.DataUUID:
+		return "a uuid"
+	case types.DataTimestamp:
+		return "an RFC 3339 timestamp"
+	case types.DataEnum:
+		return "an enum member"
+	}
+	return "a value"
+}
@@ -0,0 +1,288 @@
+package seed
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"strings"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/ddl"
+	"willofdaedalus/mime/internal/engine/runtime"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// seeding fills a resolved schema's tables with fake rows for local
+// development and load tests. the same schema, options and seed always give
+// the same rows:
+//
+//   - tables are filled in the order ddl.Ordered creates them so every
+//     reference points at a row that was already generated
+//   - required and primary fields always hold a value; other fields are
+//     left null now and then
+//   - unique fields and primary keys never repeat
+//   - enum fields hold one of their members, preferring ones that aren't
+//     deprecated
+//   - length, pattern and check hold since every row goes through the same
+//     runtime.Validator the server uses and whatever it rejects is generated
+//     again
+//   - field names hint at what a value looks like e.g. email, first_name or
+//     dob; patterns are generated from the regular expression itself
+//   - computed fields are left to the database and the soft delete column
+//     stays null so every row is live
+
+// Options decide how much is generated and how it's written
+type Options struct {
+	// rows per entity
+	Count int
+	Seed  uint64
+	// the moment timestamps are generated relative to. it isn't the clock
+	// so output doesn't change from one run to the next
+	Now time.Time
+	// the database SQL writes for; see ddl.Dialects
+	Dialect string
+}
+
+// Formats lists every format by the name `mime seed -format` takes
+var Formats = map[string]func(s *types.Schema, opts Options) (string, error){
+	"jsonl": JSONLines,
+	"sql":   SQL,
+}
+
+// Table is an entity's generated rows. each row holds a value, possibly
+// nil, for every column in Columns
+type Table struct {
+	Entity  *types.EntityNode
+	Columns []*types.Field
+	Rows    []map[string]any
+}
+
+// how many times a rejected row is generated again before giving up
+const attempts = 100
+
+type generator struct {
+	schema    *types.Schema
+	opts      Options
+	rng       *rand.Rand
+	validator *runtime.Validator
+	patterns  map[string]*compiled
+	// every value generated so far by entity.field so references can point
+	// at rows that exist
+	values map[string][]any
+}
+
+type compiled struct {
+	re  *regexp.Regexp
+	gen *pattern
+}
+
+// Generate makes opts.Count rows for every entity, in dependency order
+func Generate(s *types.Schema, opts Options) ([]*Table, error) {
+	if opts.Count < 0 {
+		return nil, fmt.Errorf("count has to be 0 or more, got %d", opts.Count)
+	}
+	g := &generator{
+		schema:    s,
+		opts:      opts,
+		rng:       rand.New(rand.NewPCG(opts.Seed, 0)),
+		validator: runtime.NewValidator(s),
+		patterns:  make(map[string]*compiled),
+		values:    make(map[string][]any),
+	}
+	for _, e := range s.Entities {
+		for _, f := range e.Fields {
+			if f.Pattern == "" || g.patterns[f.Pattern] != nil {
+				continue
+			}
+			re, err := regexp.Compile(f.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", e.Name, f.Name, err)
+			}
+			gen, err := newPattern(f.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", e.Name, f.Name, err)
+			}
+			g.patterns[f.Pattern] = &compiled{re: re, gen: gen}
+		}
+	}
+
+	var out []*Table
+	for _, e := range ddl.Ordered(s) {
+		t, err := g.table(e)
+		if err != nil {
+			return nil, fmt.Errorf("entity '%s': %w", e.Name, err)
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// columns are the fields a row is inserted with. computed fields are
+// generated columns or read from other tables so they're left out
+func columns(e *types.EntityNode) []*types.Field {
+	var out []*types.Field
+	for _, f := range e.Fields {
+		if f.Kind != types.FieldComputed {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func (g *generator) table(e *types.EntityNode) (*Table, error) {
+	t := &Table{Entity: e, Columns: columns(e)}
+	taken := make(map[string]map[string]bool)
+	pk := e.PrimaryKey()
+
+	for i := range g.opts.Count {
+		row, err := g.row(e, t.Columns, i, taken, pk)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		for _, f := range t.Columns {
+			if v := row[f.Name]; v != nil {
+				g.values[e.Name+"."+f.Name] = append(g.values[e.Name+"."+f.Name], v)
+			}
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t, nil
+}
+
+// row generates every column then generates again whatever the validator,
+// a unique field or the primary key rejects. references come last so a row
+// can point at itself
+func (g *generator) row(e *types.EntityNode, cols []*types.Field, i int, taken map[string]map[string]bool, pk []*types.Field) (map[string]any, error) {
+	row := make(map[string]any, len(cols))
+	var refs []*types.Field
+	for _, f := range cols {
+		if f.Kind == types.FieldReference {
+			refs = append(refs, f)
+			continue
+		}
+		v, err := g.value(e, f, i, 0)
+		if err != nil {
+			return nil, err
+		}
+		row[f.Name] = v
+	}
+	for _, f := range refs {
+		v, err := g.reference(e, f, row)
+		if err != nil {
+			return nil, err
+		}
+		row[f.Name] = v
+	}
+
+	for attempt := 1; ; attempt++ {
+		rejected, problems := g.rejected(e, row, taken, pk)
+		if len(rejected) == 0 {
+			break
+		}
+		if attempt > attempts {
+			return nil, fmt.Errorf("couldn't generate a valid row after %d attempts: %s", attempts, strings.Join(problems, "; "))
+		}
+		for _, f := range cols {
+			if !rejected[f.Name] {
+				continue
+			}
+			var v any
+			var err error
+			if f.Kind == types.FieldReference {
+				v, err = g.reference(e, f, row)
+			} else {
+				v, err = g.value(e, f, i, attempt)
+			}
+			if err != nil {
+				return nil, err
+			}
+			row[f.Name] = v
+		}
+	}
+
+	for _, f := range cols {
+		if unique(f, pk) && row[f.Name] != nil {
+			if taken[f.Name] == nil {
+				taken[f.Name] = make(map[string]bool)
+			}
+			taken[f.Name][key(row[f.Name])] = true
+		}
+	}
+	if len(pk) > 1 {
+		if taken[""] == nil {
+			taken[""] = make(map[string]bool)
+		}
+		taken[""][tuple(row, pk)] = true
+	}
+	return row, nil
+}
+
+// rejected names the fields that have to be generated again and says why
+func (g *generator) rejected(e *types.EntityNode, row map[string]any, taken map[string]map[string]bool, pk []*types.Field) (map[string]bool, []string) {
+	out := make(map[string]bool)
+	var problems []string
+
+	payload := make(map[string]any)
+	for _, f := range e.PayloadFields() {
+		if v := row[f.Name]; v != nil {
+			payload[f.Name] = v
+		}
+	}
+	for _, err := range g.validator.Validate(e.Name, payload) {
+		name, _, _ := strings.Cut(strings.TrimPrefix(err.Pointer, "/"), "/")
+		out[name] = true
+		problems = append(problems, err.Message)
+	}
+
+	for _, f := range e.Fields {
+		if v := row[f.Name]; unique(f, pk) && v != nil && taken[f.Name][key(v)] {
+			out[f.Name] = true
+			problems = append(problems, fmt.Sprintf("%s: ran out of unique values", f.Name))
+		}
+	}
+	if len(pk) > 1 && taken[""][tuple(row, pk)] {
+		for _, f := range pk {
+			out[f.Name] = true
+		}
+		problems = append(problems, "ran out of unique primary keys")
+	}
+	return out, problems
+}
+
+// unique reports whether no two rows can share the field's value. a
+// composite primary key is only unique as a whole
+func unique(f *types.Field, pk []*types.Field) bool {
+	return f.Attributes&types.AttrUnique != 0 || len(pk) == 1 && pk[0] == f
+}
+
+func key(v any) string {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
+
+func tuple(row map[string]any, fields []*types.Field) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = key(row[f.Name])
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// reference points at a row that was already generated, or at the row
+// being generated when the entity refers to itself. a required reference
+// with nothing to point at is an error
+func (g *generator) reference(e *types.EntityNode, f *types.Field, row map[string]any) (any, error) {
+	candidates := g.values[f.Target.Entity+"."+f.Target.Field]
+	if own := row[f.Target.Field]; own != nil && f.Target.Entity == e.Name {
+		candidates = append(candidates[:len(candidates):len(candidates)], own)
+	}
+	if f.Nullable() && (len(candidates) == 0 || g.rng.IntN(10) == 0) {
+		return nil, nil
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%s has no %s rows to point at", f.Name, f.Target.Entity)
+	}
+	return candidates[g.rng.IntN(len(candidates))], nil
+}
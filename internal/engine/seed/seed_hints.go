@@ -0,0 +1,160 @@
+package seed
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// hints turn a text field's name into a value that looks the part. the
+// first hint whose names match wins; a field nothing matches gets a few
+// words
+var hints = []struct {
+	names []string
+	gen   func(g *generator, e *types.EntityNode) string
+}{
+	{[]string{"email", "mail"}, func(g *generator, _ *types.EntityNode) string {
+		return strings.ToLower(g.pick(firstNames)+"."+g.pick(lastNames)) + "@" + g.pick(domains)
+	}},
+	{[]string{"first_name", "firstname", "given_name", "forename"}, func(g *generator, _ *types.EntityNode) string {
+		return g.pick(firstNames)
+	}},
+	{[]string{"last_name", "lastname", "surname", "family_name"}, func(g *generator, _ *types.EntityNode) string {
+		return g.pick(lastNames)
+	}},
+	{[]string{"username", "handle", "login", "nickname"}, func(g *generator, _ *types.EntityNode) string {
+		return strings.ToLower(g.pick(firstNames) + g.pick(lastNames)[:1])
+	}},
+	{[]string{"full_name", "fullname", "display_name", "author"}, func(g *generator, _ *types.EntityNode) string {
+		return g.pick(firstNames) + " " + g.pick(lastNames)
+	}},
+	{birthHints, func(g *generator, _ *types.EntityNode) string {
+		return g.birthday().Format(time.DateOnly)
+	}},
+	{[]string{"phone", "mobile", "tel", "telephone"}, func(g *generator, _ *types.EntityNode) string {
+		// 555-01xx numbers are set aside for fiction
+		return fmt.Sprintf("+1-%03d-555-01%02d", 200+g.rng.IntN(800), g.rng.IntN(100))
+	}},
+	{[]string{"url", "website", "homepage", "link"}, func(g *generator, _ *types.EntityNode) string {
+		return "https://" + g.pick(domains) + "/" + strings.ReplaceAll(g.words(2), " ", "-")
+	}},
+	{[]string{"slug"}, func(g *generator, _ *types.EntityNode) string {
+		return strings.ReplaceAll(g.words(3), " ", "-")
+	}},
+	{[]string{"city", "town"}, func(g *generator, _ *types.EntityNode) string {
+		return g.pick(cities)
+	}},
+	{[]string{"country"}, func(g *generator, _ *types.EntityNode) string {
+		return g.pick(countries)
+	}},
+	{[]string{"street", "address"}, func(g *generator, _ *types.EntityNode) string {
+		return fmt.Sprintf("%d %s %s", 1+g.rng.IntN(999), g.pick(lastNames), g.pick(streets))
+	}},
+	{[]string{"zip", "zipcode", "postcode", "postal_code"}, func(g *generator, _ *types.EntityNode) string {
+		return fmt.Sprintf("%05d", g.rng.IntN(100000))
+	}},
+	{[]string{"password", "secret", "token"}, func(g *generator, _ *types.EntityNode) string {
+		b := make([]byte, 16)
+		for i := range b {
+			b[i] = alphanumeric[g.rng.IntN(len(alphanumeric))]
+		}
+		return string(b)
+	}},
+	{[]string{"color", "colour"}, func(g *generator, _ *types.EntityNode) string {
+		return g.pick(colors)
+	}},
+	{[]string{"title", "subject", "headline", "label"}, func(g *generator, _ *types.EntityNode) string {
+		return capitalise(g.words(2 + g.rng.IntN(4)))
+	}},
+	{[]string{"description", "bio", "body", "content", "summary", "comment", "message", "text", "note", "notes"}, func(g *generator, _ *types.EntityNode) string {
+		return g.sentence() + " " + g.sentence()
+	}},
+	{[]string{"name"}, func(g *generator, e *types.EntityNode) string {
+		if has(e.Name, people) {
+			return g.pick(firstNames) + " " + g.pick(lastNames)
+		}
+		return capitalise(g.pick(colors) + " " + g.pick(words))
+	}},
+}
+
+// people are entity names whose name field is a person's
+var people = []string{
+	"user", "users", "person", "people", "customer", "customers", "author", "authors",
+	"member", "members", "employee", "employees", "contact", "contacts", "student",
+	"students", "teacher", "teachers", "account", "accounts", "profile", "profiles",
+	"patient", "patients", "staff",
+}
+
+func (g *generator) hint(e *types.EntityNode, f *types.Field) string {
+	for _, h := range hints {
+		if has(f.Name, h.names) {
+			return h.gen(g, e)
+		}
+	}
+	return g.words(1 + g.rng.IntN(3))
+}
+
+func (g *generator) pick(from []string) string {
+	return from[g.rng.IntN(len(from))]
+}
+
+// words are n words of filler
+func (g *generator) words(n int) string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = g.pick(words)
+	}
+	return strings.Join(out, " ")
+}
+
+func (g *generator) sentence() string {
+	return capitalise(g.words(4+g.rng.IntN(8))) + "."
+}
+
+func capitalise(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+var firstNames = []string{
+	"Ada", "Alan", "Amara", "Ben", "Chen", "Dara", "Elena", "Farah", "Grace", "Hiro",
+	"Ines", "Jonas", "Kofi", "Lena", "Mateo", "Nadia", "Omar", "Priya", "Quinn", "Rosa",
+	"Sami", "Tariq", "Uma", "Victor", "Wen", "Yara", "Zoe",
+}
+
+var lastNames = []string{
+	"Adeyemi", "Bauer", "Castillo", "Dubois", "Eriksen", "Fischer", "Garcia", "Haddad",
+	"Ito", "Jensen", "Kowalski", "Lopez", "Mensah", "Novak", "Okafor", "Petrov", "Rossi",
+	"Silva", "Tanaka", "Usman", "Varga", "Wong", "Yilmaz", "Zhang",
+}
+
+// domains are reserved for documentation so nothing seeded can reach anyone
+var domains = []string{"example.com", "example.org", "example.net"}
+
+var cities = []string{
+	"Accra", "Berlin", "Bogotá", "Cairo", "Lagos", "Lisbon", "Lyon", "Melbourne",
+	"Montreal", "Nairobi", "Osaka", "Oslo", "Seoul", "Toronto", "Valencia",
+}
+
+var countries = []string{
+	"Argentina", "Canada", "Egypt", "France", "Germany", "Ghana", "India", "Japan",
+	"Kenya", "Mexico", "Nigeria", "Norway", "Portugal", "South Korea", "Spain",
+}
+
+var streets = []string{"Street", "Avenue", "Road", "Lane", "Way", "Close"}
+
+var colors = []string{
+	"amber", "azure", "coral", "crimson", "emerald", "indigo", "ivory", "jade",
+	"lilac", "olive", "saffron", "slate", "teal",
+}
+
+var words = []string{
+	"anchor", "atlas", "beacon", "bridge", "canvas", "cedar", "circle", "compass",
+	"delta", "ember", "falcon", "field", "garden", "harbor", "island", "lantern",
+	"ledger", "meadow", "north", "orbit", "pebble", "prairie", "quartz", "river",
+	"signal", "summit", "thread", "timber", "valley", "willow",
+}
@@ -0,0 +1,175 @@
+package seed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/ddl"
+	"willofdaedalus/mime/internal/engine/ir"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// JSONLines writes a line per row naming its entity and holding its columns
+// in declaration order, e.g.
+//
+//	{"entity":"user","row":{"id":"0190…","email":"ada.ito@example.com"}}
+func JSONLines(s *types.Schema, opts Options) (string, error) {
+	tables, err := Generate(s, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, t := range tables {
+		name, err := json.Marshal(t.Entity.Name)
+		if err != nil {
+			return "", err
+		}
+		for _, row := range t.Rows {
+			b.WriteString(`{"entity":` + string(name) + `,"row":{`)
+			for i, f := range t.Columns {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				k, err := json.Marshal(f.Name)
+				if err != nil {
+					return "", err
+				}
+				v, err := jsonValue(row[f.Name])
+				if err != nil {
+					return "", fmt.Errorf("%s.%s: %w", t.Entity.Name, f.Name, err)
+				}
+				b.Write(k)
+				b.WriteByte(':')
+				b.Write(v)
+			}
+			b.WriteString("}}\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// jsonValue writes timestamps the way the runtime does
+func jsonValue(v any) ([]byte, error) {
+	if t, ok := v.(time.Time); ok {
+		v = t.UTC().Format(time.RFC3339Nano)
+	}
+	if obj, ok := v.(map[string]any); ok {
+		out := make(map[string]any, len(obj))
+		for k, sub := range obj {
+			if t, ok := sub.(time.Time); ok {
+				sub = t.UTC().Format(time.RFC3339Nano)
+			}
+			out[k] = sub
+		}
+		v = out
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// SQL writes an INSERT per row in one transaction, tables in the order
+// their CREATE TABLEs come in, for opts.Dialect
+func SQL(s *types.Schema, opts Options) (string, error) {
+	d, ok := ddl.Dialects[opts.Dialect]
+	if !ok {
+		names := slices.Sorted(maps.Keys(ddl.Dialects))
+		return "", fmt.Errorf("unknown dialect %s; expected one of %s", opts.Dialect, strings.Join(names, ", "))
+	}
+	// timestamps are written in the form each database parses
+	layout := d.TimeLayout()
+
+	tables, err := Generate(s, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(ir.Header(s, "--", fmt.Sprintf("seed data, %d rows per entity from seed %d", opts.Count, opts.Seed)))
+	b.WriteString("\nBEGIN;\n")
+	for _, t := range tables {
+		if len(t.Rows) == 0 {
+			continue
+		}
+		cols := make([]string, len(t.Columns))
+		for i, f := range t.Columns {
+			cols[i] = d.Quote(f.Name)
+		}
+		prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES (", d.Quote(t.Entity.Name), strings.Join(cols, ", "))
+
+		b.WriteString("\n")
+		for _, row := range t.Rows {
+			values := make([]string, len(t.Columns))
+			for i, f := range t.Columns {
+				v, err := sqlValue(row[f.Name], layout)
+				if err != nil {
+					return "", fmt.Errorf("%s.%s: %w", t.Entity.Name, f.Name, err)
+				}
+				values[i] = v
+			}
+			b.WriteString(prefix + strings.Join(values, ", ") + ");\n")
+		}
+		// postgres identities and sequences don't move past values given
+		// explicitly so the next row inserted without one would collide
+		if opts.Dialect == "postgres" {
+			for _, f := range t.Columns {
+				switch {
+				case f.Attributes&types.AttrIncrement != 0:
+					fmt.Fprintf(&b, "SELECT setval(pg_get_serial_sequence(%s, %s), %d);\n",
+						quoteString(d.Quote(t.Entity.Name)), quoteString(f.Name), len(t.Rows))
+				case f.Default != nil && f.Default.Kind == types.DefaultFunc && f.Default.Value == types.FuncSequence:
+					// a sequence shared with a table seeded earlier is never
+					// moved back
+					seq := d.Quote(types.SequenceName(t.Entity, f, f.Default))
+					fmt.Fprintf(&b, "SELECT setval(%s, greatest(%d, (SELECT last_value FROM %s)));\n",
+						quoteString(seq), len(t.Rows), seq)
+				}
+			}
+		}
+	}
+	b.WriteString("\nCOMMIT;\n")
+	return b.String(), nil
+}
+
+func sqlValue(v any, layout string) (string, error) {
+	switch x := v.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return quoteString(x), nil
+	case int64:
+		return strconv.FormatInt(x, 10), nil
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), nil
+	case bool:
+		if x {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case time.Time:
+		return quoteString(x.UTC().Format(layout)), nil
+	case map[string]any:
+		// embedded entities are a json column
+		data, err := jsonValue(x)
+		if err != nil {
+			return "", err
+		}
+		return quoteString(string(data)), nil
+	}
+	return "", fmt.Errorf("can't write %T as sql", v)
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
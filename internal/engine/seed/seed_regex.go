@@ -0,0 +1,110 @@
+package seed
+
+import (
+	"math/rand/v2"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// pattern writes strings a regular expression matches by walking its syntax
+// tree and making a random choice wherever the expression allows one.
+// anchors and word boundaries match the empty string so they're skipped;
+// the rare string that still doesn't match is caught by the validator and
+// generated again
+type pattern struct {
+	re *syntax.Regexp
+	// how many times an unbounded repeat can go round
+	limit int
+}
+
+func newPattern(expr string) (*pattern, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	return &pattern{re: re.Simplify(), limit: 8}, nil
+}
+
+func (p *pattern) generate(rng *rand.Rand) string {
+	var b strings.Builder
+	p.walk(rng, p.re, &b)
+	return b.String()
+}
+
+func (p *pattern) walk(rng *rand.Rand, re *syntax.Regexp, b *strings.Builder) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 && rng.IntN(2) == 0 {
+				r = unicode.SimpleFold(r)
+			}
+			b.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		b.WriteRune(class(rng, re.Rune))
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		b.WriteByte(alphanumeric[rng.IntN(len(alphanumeric))])
+	case syntax.OpCapture:
+		p.walk(rng, re.Sub[0], b)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			p.walk(rng, sub, b)
+		}
+	case syntax.OpAlternate:
+		p.walk(rng, re.Sub[rng.IntN(len(re.Sub))], b)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		lo, hi := 0, p.limit
+		switch re.Op {
+		case syntax.OpPlus:
+			lo = 1
+		case syntax.OpQuest:
+			hi = 1
+		case syntax.OpRepeat:
+			lo, hi = re.Min, re.Max
+			if hi < 0 {
+				hi = lo + p.limit
+			}
+		}
+		for range lo + rng.IntN(hi-lo+1) {
+			p.walk(rng, re.Sub[0], b)
+		}
+	}
+	// OpEmptyMatch, OpNoMatch, anchors and word boundaries write nothing
+}
+
+const alphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// class picks a rune from a character class given as pairs of ranges.
+// printable ascii is preferred so a negated class like [^,] doesn't fill
+// the data with control characters and unassigned code points
+func class(rng *rand.Rand, ranges []rune) rune {
+	var printable []rune
+	for i := 0; i+1 < len(ranges); i += 2 {
+		lo, hi := max(ranges[i], ' '), min(ranges[i+1], '~')
+		if lo <= hi {
+			printable = append(printable, lo, hi)
+		}
+	}
+	if len(printable) > 0 {
+		ranges = printable
+	}
+	if len(ranges) == 0 {
+		return 'x'
+	}
+
+	// weigh each range by its size so every rune is equally likely
+	var total int
+	for i := 0; i+1 < len(ranges); i += 2 {
+		total += int(ranges[i+1]-ranges[i]) + 1
+	}
+	n := rng.IntN(total)
+	for i := 0; i+1 < len(ranges); i += 2 {
+		size := int(ranges[i+1]-ranges[i]) + 1
+		if n < size {
+			return ranges[i] + rune(n)
+		}
+		n -= size
+	}
+	return ranges[0]
+}
@@ -0,0 +1,166 @@
+package seed
+
+import (
+	"math/rand/v2"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/runtime"
+	"willofdaedalus/mime/internal/engine/types"
+	"willofdaedalus/mime/internal/testutil"
+)
+
+var now = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// a few rows of every format are compared with testdata/notes.<format>.golden
+// which also pins the output to the seed
+func TestFormats(t *testing.T) {
+	s := testutil.Load(t, filepath.Join(testutil.Shared, "notes.mime"))
+	for name, write := range Formats {
+		t.Run(name, func(t *testing.T) {
+			out, err := write(s, Options{Count: 3, Seed: 42, Now: now, Dialect: "postgres"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			testutil.Golden(t, "notes."+name+".golden", out)
+		})
+	}
+}
+
+// seed 3 leaves the first user's person embed null, which sqlite's json
+// check has to let through
+func TestNullEmbed(t *testing.T) {
+	s := testutil.Load(t, filepath.Join(testutil.Shared, "notes.mime"))
+	out, err := SQL(s, Options{Count: 3, Seed: 3, Now: now, Dialect: "sqlite"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testutil.Golden(t, "notes.sqlite.golden", out)
+}
+
+func TestDeterministic(t *testing.T) {
+	s := testutil.Load(t, filepath.Join(testutil.Shared, "shop.mime"))
+	opts := Options{Count: 20, Seed: 7, Now: now}
+	first, err := JSONLines(s, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, _ := JSONLines(s, opts)
+	if first != second {
+		t.Error("the same seed gave different rows")
+	}
+	opts.Seed++
+	if other, _ := JSONLines(s, opts); other == first {
+		t.Error("a different seed gave the same rows")
+	}
+}
+
+// every row has to be one the server accepts and the database can insert
+func TestConstraints(t *testing.T) {
+	for _, name := range []string{"notes", "shop"} {
+		t.Run(name, func(t *testing.T) {
+			s := testutil.Load(t, filepath.Join(testutil.Shared, name+".mime"))
+			tables, err := Generate(s, Options{Count: 200, Seed: 1, Now: now})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			v := runtime.NewValidator(s)
+			generated := make(map[string]map[any]bool)
+			for _, tbl := range tables {
+				e := tbl.Entity
+				if len(tbl.Rows) != 200 {
+					t.Fatalf("%s: expected 200 rows, got %d", e.Name, len(tbl.Rows))
+				}
+				seen := make(map[string]map[any]bool)
+				for _, row := range tbl.Rows {
+					payload := make(map[string]any)
+					for _, f := range e.PayloadFields() {
+						if row[f.Name] != nil {
+							payload[f.Name] = row[f.Name]
+						}
+					}
+					if errs := v.Validate(e.Name, payload); len(errs) > 0 {
+						t.Fatalf("%s: invalid row %v: %v", e.Name, row, errs)
+					}
+
+					for _, f := range tbl.Columns {
+						value := row[f.Name]
+						if f.Attributes&(types.AttrRequired|types.AttrPrimary) != 0 && value == nil {
+							t.Errorf("%s.%s: required but null", e.Name, f.Name)
+						}
+						if value == nil {
+							continue
+						}
+						if f.Kind == types.FieldReference && !generated[f.Target.Entity+"."+f.Target.Field][value] {
+							t.Errorf("%s.%s: %v isn't a generated %s", e.Name, f.Name, value, f.Target.Entity)
+						}
+						if f.Attributes&types.AttrUnique != 0 {
+							if seen[f.Name] == nil {
+								seen[f.Name] = make(map[any]bool)
+							}
+							if seen[f.Name][value] {
+								t.Errorf("%s.%s: %v is repeated", e.Name, f.Name, value)
+							}
+							seen[f.Name][value] = true
+						}
+					}
+				}
+				for _, row := range tbl.Rows {
+					for _, f := range tbl.Columns {
+						if f.Kind == types.FieldEmbedded {
+							continue
+						}
+						k := e.Name + "." + f.Name
+						if generated[k] == nil {
+							generated[k] = make(map[any]bool)
+						}
+						generated[k][row[f.Name]] = true
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPattern(t *testing.T) {
+	patterns := []string{
+		`^[a-z0-9-]+$`,
+		`^[A-Z]{3}-\d{4}$`,
+		`^(draft|live)_[a-f0-9]{8}$`,
+		`^\w+@\w+\.(com|org)$`,
+		`^[^,]{2,5}$`,
+		`(?i)^abc\.?x*$`,
+		`^\+?[0-9]{1,3} ?[0-9]{6,10}$`,
+	}
+	rng := rand.New(rand.NewPCG(1, 0))
+	for _, expr := range patterns {
+		p, err := newPattern(expr)
+		if err != nil {
+			t.Fatalf("%s: %v", expr, err)
+		}
+		re := regexp.MustCompile(expr)
+		for range 500 {
+			if s := p.generate(rng); !re.MatchString(s) {
+				t.Fatalf("%s: generated %q which doesn't match", expr, s)
+			}
+		}
+	}
+}
+
+func TestRequiredCycle(t *testing.T) {
+	a := &types.EntityNode{Name: "a", Fields: []*types.Field{
+		{Name: "id", DataType: types.DataInt, Attributes: types.AttrPrimary | types.AttrRequired},
+		{Name: "b", Kind: types.FieldReference, Target: &types.ReferenceTarget{Entity: "b", Field: "id"}, Attributes: types.AttrRequired},
+	}}
+	b := &types.EntityNode{Name: "b", Fields: []*types.Field{
+		{Name: "id", DataType: types.DataInt, Attributes: types.AttrPrimary | types.AttrRequired},
+		{Name: "a", Kind: types.FieldReference, Target: &types.ReferenceTarget{Entity: "a", Field: "id"}, Attributes: types.AttrRequired},
+	}}
+	_, err := Generate(&types.Schema{Entities: []*types.EntityNode{a, b}}, Options{Count: 1, Now: now})
+	if err == nil {
+		t.Fatal("expected an error for required references that can't both exist first")
+	}
+}
@@ -0,0 +1,292 @@
+package seed
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// value generates f's value for the i-th row. attempt counts how many times
+// the row has been rejected; hints use it to move away from values that
+// keep colliding
+func (g *generator) value(e *types.EntityNode, f *types.Field, i, attempt int) (any, error) {
+	switch {
+	case f.Attributes&types.AttrIncrement != 0:
+		return int64(i + 1), nil
+	case f.Default != nil && f.Default.Kind == types.DefaultFunc && f.Default.Value == types.FuncSequence:
+		return int64(i + 1), nil
+	case e.SoftDelete && f.Name == types.SoftDeleteField:
+		return nil, nil
+	case f.Nullable() && g.rng.IntN(10) == 0:
+		return nil, nil
+	case f.Kind == types.FieldEmbedded:
+		return g.embedded(f, i, attempt)
+	}
+
+	dt, enum := g.schema.FieldType(f)
+	if enum != nil {
+		return g.member(enum), nil
+	}
+	switch dt {
+	case types.DataText:
+		return g.text(e, f, i, attempt), nil
+	case types.DataInt:
+		lo, hi := g.intRange(f)
+		return lo + g.rng.Int64N(hi-lo+1), nil
+	case types.DataReal:
+		lo, hi := g.realRange(f)
+		return math.Round((lo+g.rng.Float64()*(hi-lo))*100) / 100, nil
+	case types.DataDecimal:
+		// whole cents written out the way the runtime keeps decimals
+		lo, hi := g.realRange(f)
+		cents := math.Round((lo + g.rng.Float64()*(hi-lo)) * 100)
+		return strconv.FormatFloat(cents/100, 'f', -1, 64), nil
+	case types.DataBool:
+		return g.rng.IntN(2) == 0, nil
+	case types.DataUUID:
+		return g.uuid(f, i), nil
+	case types.DataTimestamp:
+		if has(f.Name, birthHints) {
+			return g.birthday(), nil
+		}
+		// sometime in the year before Now
+		return g.opts.Now.UTC().Add(-time.Duration(g.rng.Int64N(365*24*60*60)) * time.Second), nil
+	}
+	return nil, fmt.Errorf("%s: can't generate values of type %d", f.Name, dt)
+}
+
+// embedded entities are stored as an object holding their payload
+func (g *generator) embedded(f *types.Field, i, attempt int) (any, error) {
+	e := g.schema.Entity(f.Name)
+	if e == nil {
+		return nil, fmt.Errorf("%s: entity '%s' doesn't exist", f.Name, f.Name)
+	}
+	obj := make(map[string]any)
+	for _, sub := range e.PayloadFields() {
+		v, err := g.value(e, sub, i, attempt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		if v != nil {
+			obj[sub.Name] = v
+		}
+	}
+	return obj, nil
+}
+
+// member picks one of the enum's members by its stored value. deprecated
+// members are only used when there's nothing else
+func (g *generator) member(enum *types.EnumNode) any {
+	var live []types.EnumMember
+	for _, m := range enum.Members {
+		if !m.Deprecated {
+			live = append(live, m)
+		}
+	}
+	if len(live) == 0 {
+		live = enum.Members
+	}
+	m := live[g.rng.IntN(len(live))]
+	if enum.Backing == types.DataInt {
+		if n, err := strconv.ParseInt(m.Value, 10, 64); err == nil {
+			return n
+		}
+	}
+	return m.Value
+}
+
+// uuids are random; a uuid_v7 default gets a v7 whose time counts up with
+// the rows so they sort the way they would have been inserted
+func (g *generator) uuid(f *types.Field, i int) string {
+	var u [16]byte
+	binary.BigEndian.PutUint64(u[:8], g.rng.Uint64())
+	binary.BigEndian.PutUint64(u[8:], g.rng.Uint64())
+	u[6] = (u[6] & 0x0f) | 0x40
+	if f.Default != nil && f.Default.Value == types.FuncUUIDv7 {
+		ms := g.opts.Now.Add(time.Duration(i-g.opts.Count) * time.Minute).UnixMilli()
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(ms))
+		copy(u[:6], b[2:])
+		u[6] = (u[6] & 0x0f) | 0x70
+	}
+	u[8] = (u[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
+// birthday is a date of birth of someone between 18 and 80
+func (g *generator) birthday() time.Time {
+	y, m, d := g.opts.Now.UTC().AddDate(-18-g.rng.IntN(62), 0, -g.rng.IntN(365)).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// bounds narrows lo and hi to what the field's check allows. only
+// comparisons of the field with a number joined by and are understood;
+// anything else is left to the validator
+func bounds(check *types.Expr, name string, lo, hi float64, step float64) (float64, float64) {
+	if check == nil || check.Kind != types.ExprBinary {
+		return lo, hi
+	}
+	if check.Value == "and" {
+		lo, hi = bounds(check.Args[0], name, lo, hi, step)
+		return bounds(check.Args[1], name, lo, hi, step)
+	}
+
+	op, field, num := check.Value, check.Args[0], check.Args[1]
+	if field.Kind == types.ExprNumber {
+		field, num = num, field
+		op = map[string]string{"<": ">", "<=": ">=", ">": "<", ">=": "<=", "==": "==", "!=": "!="}[op]
+	}
+	if field.Kind != types.ExprField || field.Value != name || num.Kind != types.ExprNumber {
+		return lo, hi
+	}
+	n, err := strconv.ParseFloat(num.Value, 64)
+	if err != nil {
+		return lo, hi
+	}
+
+	// a range the hint picked that the check doesn't overlap is dropped
+	// in favour of the check's
+	switch op {
+	case ">", ">=":
+		if op == ">" {
+			n += step
+		}
+		if hi < n {
+			hi = n + 1000*step
+		}
+		lo = max(lo, n)
+	case "<", "<=":
+		if op == "<" {
+			n -= step
+		}
+		if lo > n {
+			lo = n - 1000*step
+		}
+		hi = min(hi, n)
+	case "==":
+		lo, hi = n, n
+	}
+	return lo, hi
+}
+
+// intRange is where an int field's values come from: a range its name
+// suggests, narrowed by its check. unique fields get enough room for every
+// row
+func (g *generator) intRange(f *types.Field) (int64, int64) {
+	lo, hi := int64(1), int64(1000)
+	switch {
+	case has(f.Name, []string{"age"}):
+		lo, hi = 18, 80
+	case has(f.Name, []string{"year"}):
+		lo, hi = 1970, int64(g.opts.Now.Year())
+	case has(f.Name, []string{"quantity", "qty", "count", "seats", "stock"}):
+		lo, hi = 1, 50
+	case has(f.Name, []string{"priority", "rank", "rating", "stars", "level"}):
+		lo, hi = 1, 5
+	case has(f.Name, []string{"percent", "percentage", "score"}):
+		lo, hi = 0, 100
+	}
+	if f.Attributes&(types.AttrUnique|types.AttrPrimary) != 0 {
+		hi = max(hi, lo+int64(g.opts.Count)*10)
+	}
+	l, h := bounds(f.Check, f.Name, float64(lo), float64(hi), 1)
+	return int64(math.Ceil(l)), max(int64(math.Ceil(l)), int64(math.Floor(h)))
+}
+
+func (g *generator) realRange(f *types.Field) (float64, float64) {
+	lo, hi := 0.0, 1000.0
+	switch {
+	case has(f.Name, []string{"lat", "latitude"}):
+		lo, hi = -90, 90
+	case has(f.Name, []string{"lng", "lon", "longitude"}):
+		lo, hi = -180, 180
+	case has(f.Name, []string{"rating", "score"}):
+		lo, hi = 0, 5
+	}
+	lo, hi = bounds(f.Check, f.Name, lo, hi, 0.01)
+	return lo, max(lo, hi)
+}
+
+// has reports whether one of the words in name is one of hints, or the
+// whole name is
+func has(name string, hints []string) bool {
+	name = strings.ToLower(name)
+	if slices.Contains(hints, name) {
+		return true
+	}
+	for _, w := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' }) {
+		if slices.Contains(hints, w) {
+			return true
+		}
+	}
+	return false
+}
+
+var birthHints = []string{"dob", "birthday", "birthdate", "birth_date", "date_of_birth", "born"}
+
+// text generates a string for f. a value its name hints at is tried first;
+// a field with a pattern falls back to generating from the pattern when the
+// hint doesn't match. the result is cut or padded to the field's length
+func (g *generator) text(e *types.EntityNode, f *types.Field, i, attempt int) string {
+	s := g.hint(e, f)
+	if attempt > 0 && f.Attributes&(types.AttrUnique|types.AttrPrimary) != 0 {
+		s = salt(s, i+1+(attempt-1)*g.opts.Count)
+	}
+
+	if p := g.patterns[f.Pattern]; p != nil && !p.re.MatchString(s) {
+		for range 20 {
+			s = p.gen.generate(g.rng)
+			if fits(s, f.Length) {
+				break
+			}
+		}
+		return s
+	}
+	return fit(s, f.Length, g.words)
+}
+
+// salt makes a string unique by putting n in it: before the domain of an
+// email, as another word of text with spaces and on the end of anything else
+func salt(s string, n int) string {
+	if at := strings.LastIndex(s, "@"); at > 0 {
+		return s[:at] + strconv.Itoa(n) + s[at:]
+	}
+	if strings.Contains(s, " ") {
+		return s + " " + strconv.Itoa(n)
+	}
+	return s + strconv.Itoa(n)
+}
+
+func fits(s string, l *types.Length) bool {
+	if l == nil {
+		return true
+	}
+	n := utf8.RuneCountInString(s)
+	return n >= l.Min && (l.Max == 0 || n <= l.Max)
+}
+
+// fit pads a string that's too short with more words and cuts one that's
+// too long
+func fit(s string, l *types.Length, more func(n int) string) string {
+	if l == nil {
+		return s
+	}
+	for utf8.RuneCountInString(s) < l.Min {
+		s = strings.TrimSpace(s + " " + more(3))
+	}
+	if r := []rune(s); l.Max > 0 && len(r) > l.Max {
+		s = strings.TrimRight(string(r[:l.Max]), " ")
+		// trimming the space could leave it too short again
+		for utf8.RuneCountInString(s) < l.Min {
+			s += "x"
+		}
+	}
+	return s
+}
@@ -23,16 +23,21 @@ const (
 	AttrPrimary
 	AttrHidden
 	AttrReadonly
+	AttrOnUpdate
+	AttrLength
+	AttrPattern
+	AttrCheck
 )
 
 var allowedAttrsByType = map[DataType]Attribute{
-	DataText:      AttrDefault | AttrRequired | AttrUnique | AttrHash | AttrHidden | AttrReadonly,
-	DataInt:       AttrDefault | AttrRequired | AttrUnique | AttrIncrement | AttrHidden | AttrReadonly | AttrPrimary,
-	DataReal:      AttrDefault | AttrRequired | AttrUnique | AttrHidden | AttrReadonly,
-	DataUUID:      AttrDefault | AttrRequired | AttrUnique | AttrHidden | AttrReadonly | AttrPrimary,
-	DataTimestamp: AttrDefault | AttrRequired | AttrHidden | AttrReadonly,
-	DataBool:      AttrDefault | AttrRequired | AttrHidden | AttrReadonly,
-	DataEnum:      AttrDefault | AttrRequired | AttrHidden | AttrReadonly,
+	DataText:      AttrDefault | AttrRequired | AttrUnique | AttrHash | AttrHidden | AttrReadonly | AttrLength | AttrPattern | AttrCheck,
+	DataInt:       AttrDefault | AttrRequired | AttrUnique | AttrIncrement | AttrHidden | AttrReadonly | AttrPrimary | AttrCheck,
+	DataReal:      AttrDefault | AttrRequired | AttrUnique | AttrHidden | AttrReadonly | AttrCheck,
+	DataDecimal:   AttrDefault | AttrRequired | AttrUnique | AttrHidden | AttrReadonly | AttrCheck,
+	DataUUID:      AttrDefault | AttrRequired | AttrUnique | AttrHidden | AttrReadonly | AttrPrimary | AttrCheck,
+	DataTimestamp: AttrDefault | AttrRequired | AttrHidden | AttrReadonly | AttrOnUpdate | AttrCheck,
+	DataBool:      AttrDefault | AttrRequired | AttrHidden | AttrReadonly | AttrCheck,
+	DataEnum:      AttrDefault | AttrRequired | AttrHidden | AttrReadonly | AttrCheck,
 }
 
 // helper function to convert string to attribute
@@ -56,11 +61,53 @@ func StringToAttribute(s string) (Attribute, error) {
 		return AttrHidden, nil
 	case "readonly":
 		return AttrReadonly, nil
+	case "on_update":
+		return AttrOnUpdate, nil
+	case "length":
+		return AttrLength, nil
+	case "pattern":
+		return AttrPattern, nil
+	case "check":
+		return AttrCheck, nil
 	default:
 		return 0, fmt.Errorf("unknown attribute: %s", s)
 	}
 }
 
+// AttributeName is the inverse of StringToAttribute for a single attribute
+func AttributeName(a Attribute) string {
+	switch a {
+	case AttrDefault:
+		return "default"
+	case AttrHash:
+		return "hash"
+	case AttrUnique:
+		return "unique"
+	case AttrRequired:
+		return "required"
+	case AttrIncrement:
+		return "increment"
+	case AttrOverride:
+		return "override"
+	case AttrPrimary:
+		return "primary"
+	case AttrHidden:
+		return "hidden"
+	case AttrReadonly:
+		return "readonly"
+	case AttrOnUpdate:
+		return "on_update"
+	case AttrLength:
+		return "length"
+	case AttrPattern:
+		return "pattern"
+	case AttrCheck:
+		return "check"
+	default:
+		return "unknown"
+	}
+}
+
 // Validation helpers
 
 // ValidateFieldAttributes checks if the given attributes are valid for the field's data type
@@ -75,6 +122,14 @@ func ValidateFieldAttributes(field *Field) error {
 		return nil
 	}
 
+	if field.Kind == FieldComputed {
+		// computed fields are never written so only visibility makes sense
+		if field.Attributes & ^AttrHidden != 0 {
+			return fmt.Errorf("invalid attributes for computed field '%s'", field.Name)
+		}
+		return nil
+	}
+
 	// for primitive fields, check against the allowed attributes map
 	allowed, exists := allowedAttrsByType[field.DataType]
 	if !exists {
@@ -128,11 +183,34 @@ func validateAttributeCombinations(field *Field) error {
 		}
 	}
 
-	// readonly and default don't make sense together typically
-	if attrs&AttrReadonly != 0 && attrs&AttrDefault != 0 {
+	if attrs&AttrDefault != 0 {
+		if field.Default == nil {
+			return fmt.Errorf("default attribute on field '%s' needs a value", field.Name)
+		}
+		if err := ValidateDefault(field.DataType, field.Default); err != nil {
+			return fmt.Errorf("field '%s': %w", field.Name, err)
+		}
+	}
+
+	// readonly and default don't make sense together typically; the exception
+	// is a generated default like created_at [readonly default:now()]
+	if attrs&AttrReadonly != 0 && attrs&AttrDefault != 0 && field.Default.Kind != DefaultFunc {
 		return fmt.Errorf("readonly and default attributes conflict for field '%s'", field.Name)
 	}
 
+	if attrs&AttrOnUpdate != 0 {
+		if field.OnUpdate == nil || field.OnUpdate.Kind != DefaultFunc {
+			return fmt.Errorf("on_update for field '%s' must be a function like now()", field.Name)
+		}
+		if err := ValidateDefault(field.DataType, field.OnUpdate); err != nil {
+			return fmt.Errorf("field '%s': %w", field.Name, err)
+		}
+	}
+
+	if err := validateRules(field); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -145,6 +223,8 @@ func fieldKindToString(kind FieldKind) string {
 		return "embedded"
 	case FieldReference:
 		return "reference"
+	case FieldComputed:
+		return "computed"
 	default:
 		return "unknown"
 	}
@@ -159,6 +239,8 @@ func dataTypeToString(dt DataType) string {
 		return "int"
 	case DataReal:
 		return "real/float"
+	case DataDecimal:
+		return "decimal"
 	case DataUUID:
 		return "uuid"
 	case DataTimestamp:
@@ -0,0 +1,144 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaults come in two flavours; a literal the user typed out e.g.
+// default:"18" or a built-in function that's evaluated when the row is
+// written e.g. default:now(). functions are what make defaults useful for
+// timestamp and uuid fields
+
+type DefaultKind int
+
+const (
+	DefaultLiteral DefaultKind = iota + 1
+	DefaultFunc
+)
+
+type DefaultValue struct {
+	Kind DefaultKind
+	// the literal itself or the function name
+	Value string
+	// only sequence takes an argument; the name of the sequence
+	Args []string
+}
+
+const (
+	FuncNow      = "now"
+	FuncToday    = "today"
+	FuncUUIDv4   = "uuid_v4"
+	FuncUUIDv7   = "uuid_v7"
+	FuncSequence = "sequence"
+)
+
+// which data types each default function can fill
+var defaultFuncTypes = map[string][]DataType{
+	FuncNow:      {DataTimestamp},
+	FuncToday:    {DataTimestamp, DataText},
+	FuncUUIDv4:   {DataUUID, DataText},
+	FuncUUIDv7:   {DataUUID, DataText},
+	FuncSequence: {DataInt},
+}
+
+// decimals are written out in full; an exponent would hide how many digits
+// the value has
+var decimalPattern = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// SequenceName is the sequence a sequence() default on e.f counts with, the
+// same one whether the database or the runtime fills the value in. unnamed
+// sequences are scoped to the field they fill
+func SequenceName(e *EntityNode, f *Field, def *DefaultValue) string {
+	if len(def.Args) > 0 {
+		return def.Args[0]
+	}
+	return e.Name + "_" + f.Name + "_seq"
+}
+
+// DefaultFuncAccepts reports whether the function can produce a value for dt
+func DefaultFuncAccepts(name string, dt DataType) bool {
+	for _, t := range defaultFuncTypes[name] {
+		if t == dt {
+			return true
+		}
+	}
+	return false
+}
+
+func IsDefaultFunc(name string) bool {
+	_, ok := defaultFuncTypes[name]
+	return ok
+}
+
+func (d *DefaultValue) String() string {
+	if d == nil {
+		return ""
+	}
+	if d.Kind == DefaultFunc {
+		args := make([]string, 0, len(d.Args))
+		for _, a := range d.Args {
+			args = append(args, strconv.Quote(a))
+		}
+		return fmt.Sprintf("%s(%s)", d.Value, strings.Join(args, ", "))
+	}
+	return strconv.Quote(d.Value)
+}
+
+// ValidateDefault checks the value against the type of the field it fills
+func ValidateDefault(dt DataType, d *DefaultValue) error {
+	if d == nil {
+		return nil
+	}
+
+	if d.Kind == DefaultFunc {
+		if !IsDefaultFunc(d.Value) {
+			return fmt.Errorf("unknown default function %s()", d.Value)
+		}
+		if !DefaultFuncAccepts(d.Value, dt) {
+			return fmt.Errorf("%s() can't fill a %s field", d.Value, dataTypeToString(dt))
+		}
+		if d.Value == FuncSequence && len(d.Args) > 1 {
+			return fmt.Errorf("sequence() takes at most one name")
+		}
+		if d.Value != FuncSequence && len(d.Args) > 0 {
+			return fmt.Errorf("%s() doesn't take arguments", d.Value)
+		}
+		return nil
+	}
+
+	if d.Value == "" {
+		// see notes; an empty default is almost always a mistake
+		return fmt.Errorf("default value can't be empty")
+	}
+
+	var err error
+	switch dt {
+	case DataInt:
+		_, err = strconv.ParseInt(d.Value, 10, 64)
+	case DataReal:
+		_, err = strconv.ParseFloat(d.Value, 64)
+	case DataDecimal:
+		if !decimalPattern.MatchString(d.Value) {
+			err = fmt.Errorf("not a decimal")
+		}
+	case DataBool:
+		_, err = strconv.ParseBool(d.Value)
+	case DataTimestamp:
+		_, err = time.Parse(time.RFC3339, d.Value)
+	case DataUUID:
+		if !uuidPattern.MatchString(d.Value) {
+			err = fmt.Errorf("not a uuid")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("default %q is not a valid %s", d.Value, dataTypeToString(dt))
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package types
+
+import "testing"
+
+func TestValidateDefault(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   *Field
+		wantErr bool
+	}{
+		{
+			name: "readonly created_at filled by now()",
+			field: &Field{Name: "created_at", DataType: DataTimestamp, Attributes: AttrReadonly | AttrDefault,
+				Default: &DefaultValue{Kind: DefaultFunc, Value: FuncNow}},
+		},
+		{
+			name: "readonly with a literal default",
+			field: &Field{Name: "age", DataType: DataInt, Attributes: AttrReadonly | AttrDefault,
+				Default: &DefaultValue{Kind: DefaultLiteral, Value: "18"}},
+			wantErr: true,
+		},
+		{
+			name: "uuid function on an int",
+			field: &Field{Name: "id", DataType: DataInt, Attributes: AttrDefault,
+				Default: &DefaultValue{Kind: DefaultFunc, Value: FuncUUIDv7}},
+			wantErr: true,
+		},
+		{
+			name: "literal uuid",
+			field: &Field{Name: "id", DataType: DataUUID, Attributes: AttrDefault,
+				Default: &DefaultValue{Kind: DefaultLiteral, Value: "0190c1d2-7b3a-7c4d-8e5f-000000000000"}},
+		},
+		{
+			name: "decimal written out",
+			field: &Field{Name: "price", DataType: DataDecimal, Attributes: AttrDefault,
+				Default: &DefaultValue{Kind: DefaultLiteral, Value: "9.90"}},
+		},
+		{
+			name: "decimal with an exponent",
+			field: &Field{Name: "price", DataType: DataDecimal, Attributes: AttrDefault,
+				Default: &DefaultValue{Kind: DefaultLiteral, Value: "1e3"}},
+			wantErr: true,
+		},
+		{
+			name: "malformed timestamp",
+			field: &Field{Name: "at", DataType: DataTimestamp, Attributes: AttrDefault,
+				Default: &DefaultValue{Kind: DefaultLiteral, Value: "yesterday"}},
+			wantErr: true,
+		},
+		{
+			name: "on_update with a literal",
+			field: &Field{Name: "updated_at", DataType: DataTimestamp, Attributes: AttrOnUpdate,
+				OnUpdate: &DefaultValue{Kind: DefaultLiteral, Value: "2025-01-01T00:00:00Z"}},
+			wantErr: true,
+		},
+		{
+			name:    "default attribute without a value",
+			field:   &Field{Name: "age", DataType: DataInt, Attributes: AttrDefault},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFieldAttributes(tt.field)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("for test %s: expected error %v, got %v", tt.name, tt.wantErr, err)
+			}
+		})
+	}
+}
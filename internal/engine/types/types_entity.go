@@ -8,11 +8,19 @@ const (
 	FieldPrimitive FieldKind = iota // `name text`
 	FieldReference                  // `owner @user.id`
 	FieldEmbedded                   // `@person`
+	FieldComputed                   // `full_name text = first_name || " " || last_name`
 )
 
 type EntityNode struct {
-	Name   string
+	Name string
+	// the # comment lines right above the declaration, one per line. fields,
+	// enums and routes carry theirs the same way
+	Doc    string
 	Fields []*Field
+	// rows are marked with deleted_at instead of being removed
+	SoftDelete bool
+	// mixins waiting to be expanded; always empty once the schema is resolved
+	Uses []*MixinUse
 }
 
 type ReferenceTarget struct {
@@ -22,16 +30,23 @@ type ReferenceTarget struct {
 
 type Field struct {
 	Name       string
+	Doc        string
 	Kind       FieldKind
 	DataType   DataType
 	Target     *ReferenceTarget
 	Embedded   []*Field
 	Attributes Attribute
-}
-
-type EnumNode struct {
-	Name    string
-	Members []string
+	Computed   *Expr
+	Default    *DefaultValue
+	OnUpdate   *DefaultValue
+	// set for `&enum` references and inline lists like text ("a" "b")
+	Enum *EnumNode
+	// runtime rules a written value has to pass; see types_rules.go
+	Length  *Length
+	Pattern string
+	Check   *Expr
+	// only set on fields that came from a mixin
+	Origin *Origin
 }
 
 type (
@@ -66,6 +81,7 @@ const (
 	DataEnum
 	DataRef
 	DataTimestamp
+	DataDecimal
 	DataOther
 )
 
@@ -76,6 +92,67 @@ var TokenToDataType = map[lexer.TokenType]DataType{
 	lexer.TokenTypeTimestamp: DataTimestamp,
 	lexer.TokenTypeUuid:      DataUUID,
 	lexer.TokenTypeBool:      DataBool,
+	lexer.TokenTypeDecimal:   DataDecimal,
+}
+
+// flags decides which of the entity's shapes the field shows up in. computed
+// and auto-incremented fields are never writable and hidden fields are left
+// out of responses unless an alter overrides them
+func (f *Field) flags() fieldFlag {
+	var flags fieldFlag
+
+	if f.Kind != FieldComputed && f.Attributes&(AttrIncrement|AttrReadonly) == 0 {
+		flags |= flagPayload
+	}
+	if f.Attributes&AttrHidden == 0 || f.Attributes&AttrOverride != 0 {
+		flags |= flagResponse
+	}
+	if f.Attributes&(AttrRequired|AttrPrimary) == 0 {
+		flags |= flagNullable
+	}
+
+	return flags
+}
+
+func (f *Field) Nullable() bool {
+	return f.flags()&flagNullable != 0
+}
+
+func (e *EntityNode) shape(flag fieldFlag) []*Field {
+	var fields []*Field
+	for _, f := range e.Fields {
+		if f.flags()&flag != 0 {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Stored reports whether the field has a column. aggregates read other
+// tables so they're computed when the row is read instead
+func (f *Field) Stored() bool {
+	return f.Kind != FieldComputed || !f.Computed.IsAggregate()
+}
+
+// PrimaryKey lists the fields that make up the entity's primary key
+func (e *EntityNode) PrimaryKey() []*Field {
+	var pk []*Field
+	for _, f := range e.Fields {
+		if f.Attributes&AttrPrimary != 0 {
+			pk = append(pk, f)
+		}
+	}
+	return pk
+}
+
+// PayloadFields are the fields a client is allowed to write
+func (e *EntityNode) PayloadFields() []*Field {
+	return e.shape(flagPayload)
+}
+
+// ResponseFields are the fields returned to a client
+func (e *EntityNode) ResponseFields() []*Field {
+	return e.shape(flagResponse)
 }
 
 func (e EntityNode) NodeLiteral() string {
@@ -83,5 +160,5 @@ func (e EntityNode) NodeLiteral() string {
 }
 
 func (e EnumNode) NodeLiteral() string {
-	return "entity"
+	return "enum"
 }
@@ -0,0 +1,136 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// enums declared with `enum <name> ->` and inline lists on a field like
+// `category text ("minor" "adult")` share the same model. inline lists are
+// just enums without a name
+//
+// enum user_role ->
+//	admin = 1 "Administrator" "can do everything"
+//	user = 2
+//	guest [deprecated]
+// end
+
+type EnumMember struct {
+	Name string
+	// what's actually stored; the name unless given explicitly
+	Value       string
+	Label       string
+	Description string
+	Deprecated  bool
+}
+
+type EnumNode struct {
+	Name    string
+	Doc     string
+	Members []EnumMember
+	// DataText unless the members have explicit integer values
+	Backing DataType
+}
+
+// InlineEnum builds an anonymous enum out of a field's value list
+func InlineEnum(dt DataType, values []string) *EnumNode {
+	e := &EnumNode{
+		Members: make([]EnumMember, 0, len(values)),
+		Backing: dt,
+	}
+	for _, v := range values {
+		e.Members = append(e.Members, EnumMember{Name: v, Value: v})
+	}
+	return e
+}
+
+// Inline reports whether the enum came from a field's value list
+func (e *EnumNode) Inline() bool {
+	return e.Name == ""
+}
+
+// Finalize fills in implicit values and works out the backing type. members
+// after an explicit integer carry on counting from it the same way iota does
+// so `admin = 1` followed by `user` makes user 2. inline enums already know
+// their backing type from the field they're declared on
+func (e *EnumNode) Finalize() error {
+	if e.Backing == 0 {
+		intBacked, textBacked := false, false
+		for _, m := range e.Members {
+			if m.Value == "" {
+				continue
+			}
+			if _, err := strconv.ParseInt(m.Value, 10, 64); err == nil {
+				intBacked = true
+			} else {
+				textBacked = true
+			}
+		}
+		if intBacked && textBacked {
+			return fmt.Errorf("enum %s mixes integer and text values", e.Name)
+		}
+
+		e.Backing = DataText
+		if intBacked {
+			e.Backing = DataInt
+		}
+	}
+
+	var next int64 = 1
+	for i := range e.Members {
+		m := &e.Members[i]
+		if e.Backing != DataInt {
+			if m.Value == "" {
+				m.Value = m.Name
+			}
+			continue
+		}
+
+		if m.Value == "" {
+			m.Value = strconv.FormatInt(next, 10)
+		}
+		v, err := strconv.ParseInt(m.Value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("enum %s: %s isn't an integer", e.Name, m.Value)
+		}
+		next = v + 1
+	}
+
+	seen := make(map[string]string, len(e.Members))
+	for _, m := range e.Members {
+		if other, ok := seen[m.Value]; ok {
+			return fmt.Errorf("enum %s: %s and %s share the value %s", e.Name, other, m.Name, m.Value)
+		}
+		seen[m.Value] = m.Name
+	}
+
+	return nil
+}
+
+// Values returns the stored value of every member in declaration order
+func (e *EnumNode) Values() []string {
+	values := make([]string, 0, len(e.Members))
+	for _, m := range e.Members {
+		values = append(values, m.Value)
+	}
+	return values
+}
+
+// Member finds the member with the given stored value or name
+func (e *EnumNode) Member(v string) *EnumMember {
+	for i, m := range e.Members {
+		if m.Value == v || m.Name == v {
+			return &e.Members[i]
+		}
+	}
+	return nil
+}
+
+func StringToEnumOption(m *EnumMember, s string) error {
+	switch s {
+	case "deprecated":
+		m.Deprecated = true
+		return nil
+	}
+	return fmt.Errorf("unknown enum member option: %s", s)
+}
@@ -0,0 +1,43 @@
+package types
+
+import "testing"
+
+func TestResolveEnums(t *testing.T) {
+	role := &EnumNode{
+		Name:    "user_role",
+		Members: []EnumMember{{Name: "admin", Value: "1"}, {Name: "user", Value: "2"}},
+		Backing: DataInt,
+	}
+	field := &Field{
+		Name:       "role",
+		DataType:   DataEnum,
+		Enum:       &EnumNode{Name: "user_role"},
+		Attributes: AttrDefault,
+		Default:    &DefaultValue{Kind: DefaultLiteral, Value: "user"},
+	}
+	s := &Schema{
+		Entities: []*EntityNode{{Name: "user", Fields: []*Field{field}}},
+		Enums:    []*EnumNode{role},
+	}
+
+	if errs := s.Resolve(); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if field.Enum != role {
+		t.Fatalf("expected the field to point at the declared enum")
+	}
+	if errs := s.Validate(); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	field.Default.Value = "owner"
+	if errs := s.Validate(); len(errs) != 1 {
+		t.Fatalf("expected one error for a default outside the enum, got %v", errs)
+	}
+
+	s.Enums = nil
+	field.Enum = &EnumNode{Name: "user_role"}
+	if errs := s.Resolve(); len(errs) != 1 {
+		t.Fatalf("expected one error for an unknown enum, got %v", errs)
+	}
+}
@@ -0,0 +1,430 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// computed fields don't store a value; they carry an expression that derives
+// one from the entity's other fields (or from rows that reference it). the
+// expression is kept as a small tree so it can be type checked here, rendered
+// back into mime syntax and lowered into sql by the generators
+
+type ExprKind int
+
+const (
+	ExprField     ExprKind = iota + 1 // first_name
+	ExprString                        // " "
+	ExprNumber                        // 42 or 4.2
+	ExprBinary                        // first_name || last_name, price * quantity
+	ExprCall                          // lower(name), count(@note.owner)
+	ExprReference                     // @note.owner; only valid inside an aggregate
+)
+
+type Expr struct {
+	Kind ExprKind
+	// field name, literal, operator or function name depending on the kind
+	Value  string
+	Args   []*Expr
+	Target *ReferenceTarget
+}
+
+type exprFunc struct {
+	minArgs   int
+	maxArgs   int // -1 for variadic
+	aggregate bool
+	// zero means the function returns the type of its first argument
+	returns DataType
+	// zero means any primitive is accepted
+	accepts DataType
+}
+
+var exprFuncs = map[string]exprFunc{
+	"lower":    {minArgs: 1, maxArgs: 1, returns: DataText, accepts: DataText},
+	"upper":    {minArgs: 1, maxArgs: 1, returns: DataText, accepts: DataText},
+	"trim":     {minArgs: 1, maxArgs: 1, returns: DataText, accepts: DataText},
+	"length":   {minArgs: 1, maxArgs: 1, returns: DataInt, accepts: DataText},
+	"abs":      {minArgs: 1, maxArgs: 1},
+	"round":    {minArgs: 1, maxArgs: 1, returns: DataInt},
+	"coalesce": {minArgs: 2, maxArgs: -1},
+	"count":    {minArgs: 1, maxArgs: 1, aggregate: true, returns: DataInt},
+	"sum":      {minArgs: 1, maxArgs: 1, aggregate: true},
+	"avg":      {minArgs: 1, maxArgs: 1, aggregate: true, returns: DataReal},
+	"min":      {minArgs: 1, maxArgs: 1, aggregate: true},
+	"max":      {minArgs: 1, maxArgs: 1, aggregate: true},
+}
+
+var arithmeticOps = map[string]struct{}{
+	"+": {},
+	"-": {},
+	"*": {},
+	"/": {},
+}
+
+// comparisons and the logical operators only show up in check expressions
+var comparisonOps = map[string]struct{}{
+	"==": {},
+	"!=": {},
+	"<":  {},
+	"<=": {},
+	">":  {},
+	">=": {},
+}
+
+var logicalOps = map[string]struct{}{
+	"and": {},
+	"or":  {},
+}
+
+// IsExprFunc reports whether name is a function computed fields can call
+func IsExprFunc(name string) bool {
+	_, ok := exprFuncs[name]
+	return ok
+}
+
+// IsAggregate reports whether the expression reads rows from another entity.
+// these can't be stored as generated columns and have to be computed on read
+func (e *Expr) IsAggregate() bool {
+	if e == nil {
+		return false
+	}
+	if e.Kind == ExprCall && exprFuncs[e.Value].aggregate {
+		return true
+	}
+	for _, arg := range e.Args {
+		if arg.IsAggregate() {
+			return true
+		}
+	}
+	return false
+}
+
+// Fields returns the names of the entity's own fields the expression reads
+func (e *Expr) Fields() []string {
+	var names []string
+	e.walk(func(x *Expr) {
+		if x.Kind == ExprField {
+			names = append(names, x.Value)
+		}
+	})
+	return names
+}
+
+func (e *Expr) walk(fn func(*Expr)) {
+	if e == nil {
+		return
+	}
+	fn(e)
+	for _, arg := range e.Args {
+		arg.walk(fn)
+	}
+}
+
+// String renders the expression back into mime syntax
+func (e *Expr) String() string {
+	if e == nil {
+		return ""
+	}
+
+	switch e.Kind {
+	case ExprField, ExprNumber:
+		return e.Value
+	case ExprString:
+		return fmt.Sprintf("%q", e.Value)
+	case ExprReference:
+		return fmt.Sprintf("@%s.%s", e.Target.Entity, e.Target.Field)
+	case ExprBinary:
+		return fmt.Sprintf("%s %s %s", e.Args[0].operand(), e.Value, e.Args[1].operand())
+	case ExprCall:
+		args := make([]string, 0, len(e.Args))
+		for _, arg := range e.Args {
+			args = append(args, arg.String())
+		}
+		return fmt.Sprintf("%s(%s)", e.Value, strings.Join(args, ", "))
+	}
+
+	return "?"
+}
+
+// nested binary expressions are always parenthesised so the rendered form
+// never depends on operator precedence
+func (e *Expr) operand() string {
+	if e.Kind == ExprBinary {
+		return "(" + e.String() + ")"
+	}
+	return e.String()
+}
+
+// CheckComputed type checks a computed field's expression against the rest of
+// the entity. the schema is needed to resolve reference fields and aggregates;
+// when it's nil only entity-local expressions can be checked
+func CheckComputed(field *Field, entity *EntityNode, s *Schema) error {
+	if field.Kind != FieldComputed {
+		return nil
+	}
+	if field.Computed == nil {
+		return fmt.Errorf("computed field '%s' has no expression", field.Name)
+	}
+
+	c := exprChecker{entity: entity, schema: s}
+	got, err := c.infer(field.Computed)
+	if err != nil {
+		return fmt.Errorf("computed field '%s': %w", field.Name, err)
+	}
+
+	if !assignable(field.DataType, got) {
+		return fmt.Errorf("computed field '%s' is declared %s but its expression is %s",
+			field.Name, dataTypeToString(field.DataType), dataTypeToString(got))
+	}
+
+	return nil
+}
+
+// AggregateJoin returns the field on the child entity that links the rows an
+// aggregate reads back to the parent entity e.g. for count(@note.owner) on
+// user it's note.owner
+func (e *Expr) AggregateJoin(parent string, s *Schema) (*EntityNode, *Field, error) {
+	if e.Kind != ExprReference || e.Target == nil {
+		return nil, nil, fmt.Errorf("aggregates take a reference like @entity.field, got %s", e)
+	}
+	if s == nil {
+		return nil, nil, fmt.Errorf("can't resolve @%s outside of a schema", e.Target.Entity)
+	}
+
+	child := s.Entity(e.Target.Entity)
+	if child == nil {
+		return nil, nil, fmt.Errorf("entity '%s' doesn't exist", e.Target.Entity)
+	}
+	named := child.Field(e.Target.Field)
+	if named == nil {
+		return nil, nil, fmt.Errorf("entity '%s' has no field '%s'", child.Name, e.Target.Field)
+	}
+
+	// the named field is the join itself e.g. count(@note.owner)
+	if named.Kind == FieldReference && named.Target != nil && named.Target.Entity == parent {
+		return child, named, nil
+	}
+
+	// otherwise there has to be exactly one way back to the parent
+	var join *Field
+	for _, f := range child.Fields {
+		if f.Kind != FieldReference || f.Target == nil || f.Target.Entity != parent {
+			continue
+		}
+		if join != nil {
+			return nil, nil, fmt.Errorf("entity '%s' references '%s' more than once; aggregate over the reference field directly",
+				child.Name, parent)
+		}
+		join = f
+	}
+	if join == nil {
+		return nil, nil, fmt.Errorf("entity '%s' doesn't reference '%s'", child.Name, parent)
+	}
+
+	return child, join, nil
+}
+
+type exprChecker struct {
+	entity *EntityNode
+	schema *Schema
+}
+
+func (c exprChecker) infer(e *Expr) (DataType, error) {
+	switch e.Kind {
+	case ExprString:
+		return DataText, nil
+	case ExprNumber:
+		if strings.Contains(e.Value, ".") {
+			return DataReal, nil
+		}
+		return DataInt, nil
+	case ExprField:
+		return c.fieldType(e.Value)
+	case ExprReference:
+		return 0, fmt.Errorf("references like %s can only be used inside an aggregate", e)
+	case ExprBinary:
+		return c.inferBinary(e)
+	case ExprCall:
+		return c.inferCall(e)
+	}
+
+	return 0, fmt.Errorf("unknown expression")
+}
+
+func (c exprChecker) fieldType(name string) (DataType, error) {
+	f := c.entity.Field(name)
+	if f == nil {
+		return 0, fmt.Errorf("entity '%s' has no field '%s'", c.entity.Name, name)
+	}
+
+	switch f.Kind {
+	case FieldComputed:
+		return 0, fmt.Errorf("'%s' is computed and can't be used in another computed field", name)
+	case FieldEmbedded:
+		return 0, fmt.Errorf("'%s' is embedded and can't be used in an expression", name)
+	case FieldReference:
+		return c.referenceType(f)
+	}
+
+	return f.DataType, nil
+}
+
+// a reference field holds the value of the field it points at
+func (c exprChecker) referenceType(f *Field) (DataType, error) {
+	if c.schema == nil || f.Target == nil {
+		return 0, fmt.Errorf("can't resolve the type of reference field '%s'", f.Name)
+	}
+
+	target := c.schema.Entity(f.Target.Entity)
+	if target == nil {
+		return 0, fmt.Errorf("entity '%s' doesn't exist", f.Target.Entity)
+	}
+	tf := target.Field(f.Target.Field)
+	if tf == nil || tf.Kind != FieldPrimitive {
+		return 0, fmt.Errorf("entity '%s' has no primitive field '%s'", target.Name, f.Target.Field)
+	}
+
+	return tf.DataType, nil
+}
+
+func (c exprChecker) inferBinary(e *Expr) (DataType, error) {
+	left, err := c.infer(e.Args[0])
+	if err != nil {
+		return 0, err
+	}
+	right, err := c.infer(e.Args[1])
+	if err != nil {
+		return 0, err
+	}
+
+	// anything printable can be concatenated
+	if e.Value == "||" {
+		return DataText, nil
+	}
+	if _, ok := comparisonOps[e.Value]; ok {
+		return DataBool, checkComparison(e.Value, left, right)
+	}
+	if _, ok := logicalOps[e.Value]; ok {
+		if left != DataBool || right != DataBool {
+			return 0, fmt.Errorf("operator %s needs conditions, got %s and %s",
+				e.Value, dataTypeToString(left), dataTypeToString(right))
+		}
+		return DataBool, nil
+	}
+
+	if _, ok := arithmeticOps[e.Value]; !ok {
+		return 0, fmt.Errorf("unknown operator %s", e.Value)
+	}
+	if !isNumeric(left) || !isNumeric(right) {
+		return 0, fmt.Errorf("operator %s needs numbers, got %s and %s",
+			e.Value, dataTypeToString(left), dataTypeToString(right))
+	}
+	// floats win like they do in sql and decimals stay exact with ints
+	if left == DataReal || right == DataReal {
+		return DataReal, nil
+	}
+	if left == DataDecimal || right == DataDecimal {
+		return DataDecimal, nil
+	}
+
+	return DataInt, nil
+}
+
+func (c exprChecker) inferCall(e *Expr) (DataType, error) {
+	fn, ok := exprFuncs[e.Value]
+	if !ok {
+		return 0, fmt.Errorf("unknown function %s", e.Value)
+	}
+	if len(e.Args) < fn.minArgs || (fn.maxArgs >= 0 && len(e.Args) > fn.maxArgs) {
+		return 0, fmt.Errorf("wrong number of arguments to %s", e.Value)
+	}
+
+	if fn.aggregate {
+		return c.inferAggregate(e, fn)
+	}
+
+	var first DataType
+	for i, arg := range e.Args {
+		t, err := c.infer(arg)
+		if err != nil {
+			return 0, err
+		}
+		if i == 0 {
+			first = t
+		} else if !assignable(first, t) {
+			return 0, fmt.Errorf("arguments to %s must share a type, got %s and %s",
+				e.Value, dataTypeToString(first), dataTypeToString(t))
+		}
+		if fn.accepts != 0 && !assignable(fn.accepts, t) {
+			return 0, fmt.Errorf("%s expects %s, got %s",
+				e.Value, dataTypeToString(fn.accepts), dataTypeToString(t))
+		}
+	}
+
+	if (e.Value == "abs" || e.Value == "round") && !isNumeric(first) {
+		return 0, fmt.Errorf("%s expects a number, got %s", e.Value, dataTypeToString(first))
+	}
+	if fn.returns != 0 {
+		return fn.returns, nil
+	}
+
+	return first, nil
+}
+
+func (c exprChecker) inferAggregate(e *Expr, fn exprFunc) (DataType, error) {
+	arg := e.Args[0]
+	child, _, err := arg.AggregateJoin(c.entity.Name, c.schema)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", e.Value, err)
+	}
+	if fn.returns == DataInt {
+		// count doesn't care what it's counting
+		return fn.returns, nil
+	}
+
+	t, err := exprChecker{entity: child, schema: c.schema}.fieldType(arg.Target.Field)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", e.Value, err)
+	}
+	if (e.Value == "sum" || e.Value == "avg") && !isNumeric(t) {
+		return 0, fmt.Errorf("%s expects a number, got %s", e.Value, dataTypeToString(t))
+	}
+	if fn.returns != 0 && t != DataDecimal {
+		return fn.returns, nil
+	}
+
+	// the average of decimals is a decimal too
+	return t, nil
+}
+
+// numbers compare with each other and enums with literals of their backing
+// type; anything else has to be compared with its own type. only numbers,
+// text and timestamps have an order
+func checkComparison(op string, left, right DataType) error {
+	comparable := left == right ||
+		(isNumeric(left) && isNumeric(right)) ||
+		(left == DataEnum && (right == DataText || right == DataInt)) ||
+		(right == DataEnum && (left == DataText || left == DataInt))
+	if !comparable {
+		return fmt.Errorf("can't compare %s with %s", dataTypeToString(left), dataTypeToString(right))
+	}
+
+	if op == "==" || op == "!=" {
+		return nil
+	}
+	for _, t := range []DataType{left, right} {
+		if !isNumeric(t) && t != DataText && t != DataTimestamp {
+			return fmt.Errorf("operator %s can't order %s values", op, dataTypeToString(t))
+		}
+	}
+	return nil
+}
+
+func isNumeric(dt DataType) bool {
+	return dt == DataInt || dt == DataReal || dt == DataDecimal
+}
+
+// ints widen into reals and decimals; everything else has to match exactly
+func assignable(to, from DataType) bool {
+	return to == from || ((to == DataReal || to == DataDecimal) && from == DataInt)
+}
@@ -0,0 +1,165 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func field(name string, dt DataType) *Field {
+	return &Field{Name: name, Kind: FieldPrimitive, DataType: dt}
+}
+
+func computed(name string, dt DataType, expr *Expr) *Field {
+	return &Field{Name: name, Kind: FieldComputed, DataType: dt, Computed: expr}
+}
+
+func binary(op string, left, right *Expr) *Expr {
+	return &Expr{Kind: ExprBinary, Value: op, Args: []*Expr{left, right}}
+}
+
+func ident(name string) *Expr {
+	return &Expr{Kind: ExprField, Value: name}
+}
+
+func testSchema() *Schema {
+	user := &EntityNode{
+		Name: "user",
+		Fields: []*Field{
+			field("id", DataUUID),
+			field("first_name", DataText),
+			field("last_name", DataText),
+			field("age", DataInt),
+			field("balance", DataReal),
+			field("credit", DataDecimal),
+		},
+	}
+	note := &EntityNode{
+		Name: "note",
+		Fields: []*Field{
+			field("id", DataUUID),
+			field("words", DataInt),
+			{Name: "owner", Kind: FieldReference, Target: &ReferenceTarget{Entity: "user", Field: "id"}},
+		},
+	}
+
+	return &Schema{Entities: []*EntityNode{user, note}}
+}
+
+func TestCheckComputed(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   *Field
+		wantErr bool
+	}{
+		{
+			name: "text concatenation",
+			field: computed("full_name", DataText,
+				binary("||", binary("||", ident("first_name"), &Expr{Kind: ExprString, Value: " "}), ident("last_name"))),
+		},
+		{
+			name:  "int widens into real",
+			field: computed("total", DataReal, binary("+", ident("age"), ident("balance"))),
+		},
+		{
+			name:    "real doesn't narrow into int",
+			field:   computed("total", DataInt, binary("*", ident("age"), ident("balance"))),
+			wantErr: true,
+		},
+		{
+			name:  "ints stay exact with decimals",
+			field: computed("total", DataDecimal, binary("*", ident("age"), ident("credit"))),
+		},
+		{
+			name:    "floats win over decimals",
+			field:   computed("total", DataDecimal, binary("+", ident("credit"), ident("balance"))),
+			wantErr: true,
+		},
+		{
+			name: "round takes a decimal",
+			field: computed("whole", DataInt, &Expr{Kind: ExprCall, Value: "round", Args: []*Expr{
+				ident("credit"),
+			}}),
+		},
+		{
+			name:    "arithmetic on text",
+			field:   computed("total", DataInt, binary("+", ident("age"), ident("first_name"))),
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			field:   computed("nick", DataText, ident("nickname")),
+			wantErr: true,
+		},
+		{
+			name: "count over a back reference",
+			field: computed("note_count", DataInt, &Expr{Kind: ExprCall, Value: "count", Args: []*Expr{
+				{Kind: ExprReference, Target: &ReferenceTarget{Entity: "note", Field: "owner"}},
+			}}),
+		},
+		{
+			name: "sum finds the join itself",
+			field: computed("word_count", DataInt, &Expr{Kind: ExprCall, Value: "sum", Args: []*Expr{
+				{Kind: ExprReference, Target: &ReferenceTarget{Entity: "note", Field: "words"}},
+			}}),
+		},
+		{
+			name: "avg is always real",
+			field: computed("avg_words", DataInt, &Expr{Kind: ExprCall, Value: "avg", Args: []*Expr{
+				{Kind: ExprReference, Target: &ReferenceTarget{Entity: "note", Field: "words"}},
+			}}),
+			wantErr: true,
+		},
+		{
+			name:    "reference outside an aggregate",
+			field:   computed("owner", DataText, &Expr{Kind: ExprReference, Target: &ReferenceTarget{Entity: "note", Field: "owner"}}),
+			wantErr: true,
+		},
+		{
+			name: "function argument type",
+			field: computed("shout", DataText, &Expr{Kind: ExprCall, Value: "upper", Args: []*Expr{
+				ident("age"),
+			}}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testSchema()
+			user := s.Entity("user")
+			user.Fields = append(user.Fields, tt.field)
+
+			err := CheckComputed(tt.field, user, s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("for test %s: expected error %v, got %v", tt.name, tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestComputedShapes(t *testing.T) {
+	entity := &EntityNode{
+		Name: "user",
+		Fields: []*Field{
+			{Name: "id", Kind: FieldPrimitive, DataType: DataInt, Attributes: AttrPrimary | AttrIncrement},
+			field("first_name", DataText),
+			{Name: "password", Kind: FieldPrimitive, DataType: DataText, Attributes: AttrHash | AttrHidden},
+			computed("shout", DataText, &Expr{Kind: ExprCall, Value: "upper", Args: []*Expr{ident("first_name")}}),
+		},
+	}
+
+	names := func(fields []*Field) []string {
+		var out []string
+		for _, f := range fields {
+			out = append(out, f.Name)
+		}
+		return out
+	}
+
+	if got, want := names(entity.PayloadFields()), []string{"first_name", "password"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("payload: expected %v, got %v", want, got)
+	}
+	if got, want := names(entity.ResponseFields()), []string{"id", "first_name", "shout"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("response: expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,273 @@
+package types
+
+import (
+	"fmt"
+	"slices"
+)
+
+// mixins are reusable bundles of fields pulled into an entity with `use`.
+// templates are mixins with parameters; every parameter is substituted
+// wherever it names an entity so the same template can point at a different
+// entity each time it's used
+//
+// mixin timestamps ->
+//	created_at timestamp [default:now()]
+//	updated_at timestamp [default:now() on_update:now()]
+// end
+//
+// template audit_log<T> ->
+//	target @T.id
+//	action text
+// end
+//
+// entity note ->
+//	use timestamps, soft_delete
+//	title text
+// end
+//
+// entity note_audit = audit_log<note>
+//
+// everything is expanded before the schema is resolved and validated so the
+// rest of the engine only ever sees plain entities
+
+type Pos struct {
+	File string
+	Line int
+}
+
+func (p Pos) String() string {
+	if p.File == "" {
+		return fmt.Sprintf("line %d", p.Line)
+	}
+	return fmt.Sprintf("%s:%d", p.File, p.Line)
+}
+
+type MixinNode struct {
+	Name   string
+	Params []string
+	Fields []*Field
+	Uses   []*MixinUse
+	// entity options the mixin turns on e.g. mixin trash [soft_delete] ->
+	Options []string
+	Pos     Pos
+}
+
+func (m MixinNode) NodeLiteral() string {
+	return "mixin"
+}
+
+type MixinUse struct {
+	Name string
+	Args []string
+	// how many of the entity's own fields come before the use so the
+	// mixin's fields land where the use was written
+	Index int
+	Pos   Pos
+}
+
+// Origin records where an expanded field came from so diagnostics about it
+// can point at both the mixin and the use
+type Origin struct {
+	Mixin   string
+	Defined Pos
+	Used    Pos
+}
+
+func (o *Origin) String() string {
+	return fmt.Sprintf("from mixin %s declared at %s, used at %s", o.Mixin, o.Defined, o.Used)
+}
+
+func (s *Schema) Mixin(name string) *MixinNode {
+	for _, m := range s.Mixins {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// Expand replaces every `use` with the fields of the mixin it names
+func (s *Schema) Expand() []error {
+	var errs []error
+
+	for _, e := range s.Entities {
+		if len(e.Uses) == 0 {
+			continue
+		}
+
+		x := expander{schema: s}
+		fields, options := x.expand(e.Name, e.Fields, e.Uses, nil, nil)
+		errs = append(errs, x.errs...)
+
+		e.Fields = fields
+		e.Uses = nil
+		for _, opt := range options {
+			if err := StringToEntityOption(e, opt); err != nil {
+				errs = append(errs, fmt.Errorf("entity '%s': %w", e.Name, err))
+			}
+		}
+	}
+
+	return errs
+}
+
+type expander struct {
+	schema *Schema
+	errs   []error
+}
+
+func (x *expander) fail(format string, args ...any) {
+	x.errs = append(x.errs, fmt.Errorf(format, args...))
+}
+
+// expand splices the fields of every use into own. stack holds the mixins
+// being expanded so one that ends up using itself is caught instead of
+// recursing forever
+func (x *expander) expand(owner string, own []*Field, uses []*MixinUse, subst map[string]string, stack []string) ([]*Field, []string) {
+	var options []string
+	fields := make([]*Field, 0, len(own))
+	seen := make(map[string]*Field, len(own))
+	for _, f := range own {
+		seen[f.Name] = f
+	}
+
+	next := 0
+	for _, use := range uses {
+		fields = append(fields, own[next:min(use.Index, len(own))]...)
+		next = min(use.Index, len(own))
+
+		name := substitute(use.Name, subst)
+		args := make([]string, 0, len(use.Args))
+		for _, a := range use.Args {
+			args = append(args, substitute(a, subst))
+		}
+
+		m := x.schema.Mixin(name)
+		if m == nil {
+			// `use soft_delete` works without anyone declaring the mixin
+			if IsEntityOption(name) && len(args) == 0 {
+				options = append(options, name)
+				continue
+			}
+			x.fail("%s: '%s' uses unknown mixin %s", use.Pos, owner, name)
+			continue
+		}
+		if slices.Contains(stack, m.Name) {
+			x.fail("%s: mixin %s (declared at %s) ends up using itself", use.Pos, m.Name, m.Pos)
+			continue
+		}
+		if len(args) != len(m.Params) {
+			x.fail("%s: mixin %s (declared at %s) takes %d arguments, got %d",
+				use.Pos, m.Name, m.Pos, len(m.Params), len(args))
+			continue
+		}
+
+		inner := make(map[string]string, len(args))
+		for i, p := range m.Params {
+			inner[p] = args[i]
+		}
+
+		mixed, opts := x.expand(m.Name, m.Fields, m.Uses, inner, append(stack[:len(stack):len(stack)], m.Name))
+		options = append(options, m.Options...)
+		options = append(options, opts...)
+
+		for _, f := range mixed {
+			if prev, ok := seen[f.Name]; ok {
+				where := "in the entity"
+				if prev.Origin != nil {
+					where = prev.Origin.String()
+				}
+				x.fail("%s: field %s from mixin %s (declared at %s) clashes with %s %s",
+					use.Pos, f.Name, m.Name, m.Pos, f.Name, where)
+				continue
+			}
+
+			c := cloneField(f, nil)
+			// fields pulled in through nested mixins keep their innermost
+			// origin; it's the most specific thing to point at
+			if c.Origin == nil {
+				c.Origin = &Origin{Mixin: m.Name, Defined: m.Pos, Used: use.Pos}
+			}
+			seen[c.Name] = c
+			fields = append(fields, c)
+		}
+	}
+	fields = append(fields, own[next:]...)
+
+	if len(subst) > 0 {
+		for i, f := range fields {
+			if f.Origin == nil {
+				fields[i] = cloneField(f, subst)
+			}
+		}
+	}
+
+	return fields, options
+}
+
+func substitute(name string, subst map[string]string) string {
+	if v, ok := subst[name]; ok {
+		return v
+	}
+	return name
+}
+
+// cloneField deep copies a field so every entity using a mixin gets its own,
+// swapping template parameters for their arguments on the way
+func cloneField(f *Field, subst map[string]string) *Field {
+	c := *f
+
+	if f.Kind == FieldEmbedded {
+		c.Name = substitute(f.Name, subst)
+	}
+	if f.Target != nil {
+		c.Target = &ReferenceTarget{
+			Entity: substitute(f.Target.Entity, subst),
+			Field:  f.Target.Field,
+		}
+	}
+	if f.Embedded != nil {
+		c.Embedded = make([]*Field, 0, len(f.Embedded))
+		for _, e := range f.Embedded {
+			c.Embedded = append(c.Embedded, cloneField(e, subst))
+		}
+	}
+	if f.Default != nil {
+		d := *f.Default
+		c.Default = &d
+	}
+	if f.OnUpdate != nil {
+		d := *f.OnUpdate
+		c.OnUpdate = &d
+	}
+	if f.Length != nil {
+		l := *f.Length
+		c.Length = &l
+	}
+	c.Computed = cloneExpr(f.Computed, subst)
+	c.Check = cloneExpr(f.Check, subst)
+
+	return &c
+}
+
+func cloneExpr(e *Expr, subst map[string]string) *Expr {
+	if e == nil {
+		return nil
+	}
+
+	c := *e
+	if e.Target != nil {
+		c.Target = &ReferenceTarget{
+			Entity: substitute(e.Target.Entity, subst),
+			Field:  e.Target.Field,
+		}
+	}
+	if e.Args != nil {
+		c.Args = make([]*Expr, 0, len(e.Args))
+		for _, a := range e.Args {
+			c.Args = append(c.Args, cloneExpr(a, subst))
+		}
+	}
+
+	return &c
+}
@@ -0,0 +1,136 @@
+package types
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandMixins(t *testing.T) {
+	timestamps := &MixinNode{
+		Name: "timestamps",
+		Fields: []*Field{
+			{Name: "created_at", DataType: DataTimestamp},
+			{Name: "updated_at", DataType: DataTimestamp},
+		},
+		Pos: Pos{File: "base.mime", Line: 1},
+	}
+	owned := &MixinNode{
+		Name:   "owned",
+		Params: []string{"T"},
+		Fields: []*Field{{Name: "owner", Kind: FieldReference, Target: &ReferenceTarget{Entity: "T", Field: "id"}}},
+		Pos:    Pos{File: "base.mime", Line: 5},
+	}
+	used := Pos{File: "note.mime", Line: 3}
+
+	s := &Schema{
+		Mixins: []*MixinNode{timestamps, owned},
+		Entities: []*EntityNode{{
+			Name: "note",
+			Fields: []*Field{
+				{Name: "id", DataType: DataUUID},
+				{Name: "title", DataType: DataText},
+			},
+			Uses: []*MixinUse{
+				{Name: "timestamps", Index: 1, Pos: used},
+				{Name: "owned", Args: []string{"user"}, Index: 1, Pos: used},
+				{Name: "soft_delete", Index: 2, Pos: used},
+			},
+		}},
+	}
+
+	if errs := s.Expand(); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	e := s.Entities[0]
+	var names []string
+	for _, f := range e.Fields {
+		names = append(names, f.Name)
+	}
+	expected := []string{"id", "created_at", "updated_at", "owner", "title", "deleted_at"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected fields %v, got %v", expected, names)
+	}
+	if !e.SoftDelete || e.Uses != nil {
+		t.Fatalf("expected soft delete on and uses cleared, got %v %v", e.SoftDelete, e.Uses)
+	}
+
+	owner := e.Field("owner")
+	if owner.Target.Entity != "user" {
+		t.Fatalf("expected T to be replaced by user, got %s", owner.Target.Entity)
+	}
+	if owned.Fields[0].Target.Entity != "T" {
+		t.Fatalf("expanding changed the mixin itself")
+	}
+	want := &Origin{Mixin: "owned", Defined: owned.Pos, Used: used}
+	if !reflect.DeepEqual(owner.Origin, want) {
+		t.Fatalf("expected origin %v, got %v", want, owner.Origin)
+	}
+}
+
+func TestExpandMixinErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		mixins  []*MixinNode
+		entity  *EntityNode
+		wantErr string
+	}{
+		{
+			name:    "unknown mixin",
+			entity:  &EntityNode{Name: "note", Uses: []*MixinUse{{Name: "stamps", Pos: Pos{Line: 2}}}},
+			wantErr: "line 2: 'note' uses unknown mixin stamps",
+		},
+		{
+			name: "wrong number of arguments",
+			mixins: []*MixinNode{
+				{Name: "owned", Params: []string{"T"}, Pos: Pos{Line: 1}},
+			},
+			entity:  &EntityNode{Name: "note", Uses: []*MixinUse{{Name: "owned", Pos: Pos{Line: 4}}}},
+			wantErr: "takes 1 arguments, got 0",
+		},
+		{
+			name: "mixin using itself",
+			mixins: []*MixinNode{
+				{Name: "a", Uses: []*MixinUse{{Name: "b"}}},
+				{Name: "b", Uses: []*MixinUse{{Name: "a"}}},
+			},
+			entity:  &EntityNode{Name: "note", Uses: []*MixinUse{{Name: "a"}}},
+			wantErr: "mixin a (declared at line 0) ends up using itself",
+		},
+		{
+			name: "field clashes with the entity",
+			mixins: []*MixinNode{
+				{Name: "named", Fields: []*Field{{Name: "title", DataType: DataText}}, Pos: Pos{File: "base.mime", Line: 7}},
+			},
+			entity: &EntityNode{
+				Name:   "note",
+				Fields: []*Field{{Name: "title", DataType: DataText}},
+				Uses:   []*MixinUse{{Name: "named", Pos: Pos{File: "note.mime", Line: 2}}},
+			},
+			wantErr: "note.mime:2: field title from mixin named (declared at base.mime:7) clashes with title in the entity",
+		},
+		{
+			name: "field clashes between mixins",
+			mixins: []*MixinNode{
+				{Name: "a", Fields: []*Field{{Name: "title", DataType: DataText}}, Pos: Pos{Line: 1}},
+				{Name: "b", Fields: []*Field{{Name: "title", DataType: DataText}}, Pos: Pos{Line: 4}},
+			},
+			entity:  &EntityNode{Name: "note", Uses: []*MixinUse{{Name: "a", Pos: Pos{Line: 9}}, {Name: "b", Pos: Pos{Line: 9}}}},
+			wantErr: "clashes with title from mixin a declared at line 1, used at line 9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Schema{Mixins: tt.mixins, Entities: []*EntityNode{tt.entity}}
+			errs := s.Expand()
+			if len(errs) == 0 {
+				t.Fatalf("for test %s: expected an error", tt.name)
+			}
+			if !strings.Contains(errs[0].Error(), tt.wantErr) {
+				t.Fatalf("for test %s: expected error containing %q, got %q", tt.name, tt.wantErr, errs[0])
+			}
+		})
+	}
+}
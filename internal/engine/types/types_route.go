@@ -0,0 +1,143 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// routes map an http verb and path onto an action against an entity
+//
+// routes @user ->
+//	GET /notes/:id -> @note.id == :id || respond 404 "note not found"
+//	DELETE /notes/:id -> delete @note.id == :id || respond 400 "delete failed"
+// end
+
+type RouteAction int
+
+const (
+	ActionFind RouteAction = iota + 1
+	ActionCreate
+	ActionUpdate
+	ActionDelete
+	ActionRestore
+)
+
+type RouteOption int
+
+const (
+	// admin routes are trusted with ?include_deleted=true
+	RouteAdmin RouteOption = 1 << iota
+)
+
+type RouteMatch struct {
+	// field on the target entity; empty when matching the whole entity
+	// against the query params e.g. `@note == params`
+	Field string
+	// a path capture like `:id` or `params`
+	Source string
+}
+
+type Response struct {
+	Status  int
+	Message string
+}
+
+type Route struct {
+	Doc      string
+	Method   string
+	Path     string
+	Action   RouteAction
+	Entity   string
+	Match    *RouteMatch
+	Fallback *Response
+	Options  RouteOption
+}
+
+type RoutesNode struct {
+	// the entity `self` refers to inside the block
+	Scope  string
+	Routes []*Route
+}
+
+func (r RoutesNode) NodeLiteral() string {
+	return "routes"
+}
+
+const ParamsSource = "params"
+
+var actionNames = map[string]RouteAction{
+	"find":    ActionFind,
+	"create":  ActionCreate,
+	"update":  ActionUpdate,
+	"delete":  ActionDelete,
+	"restore": ActionRestore,
+}
+
+func StringToRouteAction(s string) (RouteAction, bool) {
+	a, ok := actionNames[s]
+	return a, ok
+}
+
+func (a RouteAction) String() string {
+	for name, action := range actionNames {
+		if action == a {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+func StringToRouteOption(s string) (RouteOption, error) {
+	switch s {
+	case "admin":
+		return RouteAdmin, nil
+	}
+	return 0, fmt.Errorf("unknown route option: %s", s)
+}
+
+// PathParams returns the names of the path's captures without the colon
+// e.g. /notes/:id -> [id]
+func (r *Route) PathParams() []string {
+	var params []string
+	for _, seg := range strings.Split(r.Path, "/") {
+		if strings.HasPrefix(seg, ":") {
+			params = append(params, seg[1:])
+		}
+	}
+	return params
+}
+
+// ReadsRows reports whether the route returns existing rows and so should
+// skip soft deleted ones
+func (r *Route) ReadsRows() bool {
+	return r.Action == ActionFind || r.Action == ActionUpdate
+}
+
+// ValidateRoute makes sure the route only talks about things that exist
+func ValidateRoute(r *Route, s *Schema) error {
+	e := s.Entity(r.Entity)
+	if e == nil {
+		return fmt.Errorf("route %s %s: entity '%s' doesn't exist", r.Method, r.Path, r.Entity)
+	}
+
+	if r.Match != nil && r.Match.Field != "" && e.Field(r.Match.Field) == nil {
+		return fmt.Errorf("route %s %s: entity '%s' has no field '%s'",
+			r.Method, r.Path, e.Name, r.Match.Field)
+	}
+	if r.Match != nil && strings.HasPrefix(r.Match.Source, ":") {
+		found := false
+		for _, p := range r.PathParams() {
+			found = found || p == r.Match.Source[1:]
+		}
+		if !found {
+			return fmt.Errorf("route %s %s: path has no %s capture", r.Method, r.Path, r.Match.Source)
+		}
+	}
+
+	if r.Action == ActionRestore && !e.SoftDelete {
+		return fmt.Errorf("route %s %s: can't restore '%s'; it isn't soft deleted",
+			r.Method, r.Path, e.Name)
+	}
+
+	return nil
+}
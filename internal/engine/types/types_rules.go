@@ -0,0 +1,84 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// rules are the checks a value has to pass before it's written, on top of
+// its type and enum. they're runtime checks; the sql generators may also turn
+// them into CHECK constraints where the database can express them
+//
+//	username text [length:3,20 pattern:"^[a-z0-9_]+$"]
+//	age int [check:age >= 0 and age < 150]
+
+// Length bounds a text value's length in characters. a zero Max leaves it
+// unbounded so `length:8,` only sets a minimum
+type Length struct {
+	Min int
+	Max int
+}
+
+func (l Length) String() string {
+	if l.Max == 0 {
+		return fmt.Sprintf("%d,", l.Min)
+	}
+	return fmt.Sprintf("%d,%d", l.Min, l.Max)
+}
+
+// validateRules makes sure the rules a field declares are usable on their own.
+// check expressions need the rest of the entity and are left to CheckRule
+func validateRules(field *Field) error {
+	attrs := field.Attributes
+
+	if attrs&AttrLength != 0 {
+		if field.Length == nil {
+			return fmt.Errorf("length attribute on field '%s' needs bounds like length:1,20", field.Name)
+		}
+		if field.Length.Min < 0 || field.Length.Max < 0 {
+			return fmt.Errorf("length bounds on field '%s' can't be negative", field.Name)
+		}
+		if field.Length.Max != 0 && field.Length.Min > field.Length.Max {
+			return fmt.Errorf("length on field '%s' has a minimum of %d above its maximum of %d",
+				field.Name, field.Length.Min, field.Length.Max)
+		}
+	}
+
+	if attrs&AttrPattern != 0 {
+		if field.Pattern == "" {
+			return fmt.Errorf("pattern attribute on field '%s' needs a regex", field.Name)
+		}
+		if _, err := regexp.Compile(field.Pattern); err != nil {
+			return fmt.Errorf("pattern on field '%s' doesn't compile: %w", field.Name, err)
+		}
+	}
+
+	if attrs&AttrCheck != 0 && field.Check == nil {
+		return fmt.Errorf("check attribute on field '%s' needs an expression", field.Name)
+	}
+
+	return nil
+}
+
+// CheckRule type checks a field's check expression against its entity. it
+// has to come out as a bool and can only read the row being written
+func CheckRule(field *Field, entity *EntityNode, s *Schema) error {
+	if field.Check == nil {
+		return nil
+	}
+	if field.Check.IsAggregate() {
+		return fmt.Errorf("check on field '%s' can't use an aggregate", field.Name)
+	}
+
+	c := exprChecker{entity: entity, schema: s}
+	got, err := c.infer(field.Check)
+	if err != nil {
+		return fmt.Errorf("check on field '%s': %w", field.Name, err)
+	}
+	if got != DataBool {
+		return fmt.Errorf("check on field '%s' has to be a condition but its expression is %s",
+			field.Name, dataTypeToString(got))
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package types
+
+import "testing"
+
+func TestCheckRule(t *testing.T) {
+	num := func(v string) *Expr { return &Expr{Kind: ExprNumber, Value: v} }
+	str := func(v string) *Expr { return &Expr{Kind: ExprString, Value: v} }
+
+	tests := []struct {
+		name    string
+		check   *Expr
+		wantErr bool
+	}{
+		{
+			name:  "comparison",
+			check: binary(">=", ident("age"), num("18")),
+		},
+		{
+			name:  "ints compare with reals",
+			check: binary("<", ident("age"), ident("balance")),
+		},
+		{
+			name: "and of comparisons",
+			check: binary("and",
+				binary(">", ident("age"), num("0")),
+				binary("!=", ident("first_name"), ident("last_name"))),
+		},
+		{
+			name:    "not a condition",
+			check:   binary("+", ident("age"), num("1")),
+			wantErr: true,
+		},
+		{
+			name:    "comparing text with a number",
+			check:   binary("==", ident("first_name"), num("1")),
+			wantErr: true,
+		},
+		{
+			name:    "ordering uuids",
+			check:   binary("<", ident("id"), str("a")),
+			wantErr: true,
+		},
+		{
+			name:    "and of non conditions",
+			check:   binary("and", ident("age"), binary(">", ident("age"), num("0"))),
+			wantErr: true,
+		},
+		{
+			name:    "aggregate",
+			check:   binary(">", &Expr{Kind: ExprCall, Value: "count", Args: []*Expr{{Kind: ExprReference, Target: &ReferenceTarget{Entity: "note", Field: "owner"}}}}, num("0")),
+			wantErr: true,
+		},
+	}
+
+	s := testSchema()
+	user := s.Entity("user")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Field{Name: "age", Kind: FieldPrimitive, DataType: DataInt, Attributes: AttrCheck, Check: tt.check}
+			err := CheckRule(f, user, s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("for test %s: expected error %v, got %v", tt.name, tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   *Field
+		wantErr bool
+	}{
+		{
+			name:  "bounded length",
+			field: &Field{Name: "bio", DataType: DataText, Attributes: AttrLength, Length: &Length{Min: 1, Max: 280}},
+		},
+		{
+			name:    "min above max",
+			field:   &Field{Name: "bio", DataType: DataText, Attributes: AttrLength, Length: &Length{Min: 10, Max: 2}},
+			wantErr: true,
+		},
+		{
+			name:    "bad regex",
+			field:   &Field{Name: "bio", DataType: DataText, Attributes: AttrPattern, Pattern: "(["},
+			wantErr: true,
+		},
+		{
+			name:    "length on an int",
+			field:   &Field{Name: "age", DataType: DataInt, Attributes: AttrLength, Length: &Length{Max: 3}},
+			wantErr: true,
+		},
+		{
+			name:    "check without an expression",
+			field:   &Field{Name: "age", DataType: DataInt, Attributes: AttrCheck},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFieldAttributes(tt.field)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("for test %s: expected error %v, got %v", tt.name, tt.wantErr, err)
+			}
+		})
+	}
+}
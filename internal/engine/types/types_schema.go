@@ -0,0 +1,151 @@
+package types
+
+import "fmt"
+
+// Schema is everything declared across a set of mime files once parsing is
+// done. semantic checks that need to look across entities live here since a
+// single entity can reference things declared after it
+type Schema struct {
+	Entities []*EntityNode
+	Enums    []*EnumNode
+	Routes   []*Route
+	Mixins   []*MixinNode
+}
+
+func (s *Schema) Entity(name string) *EntityNode {
+	for _, e := range s.Entities {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+func (s *Schema) Enum(name string) *EnumNode {
+	for _, e := range s.Enums {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+func (e *EntityNode) Field(name string) *Field {
+	for _, f := range e.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// FieldType is the type of the values a field holds. references hold the
+// value of the field they point at so they take its type and enum
+func (s *Schema) FieldType(f *Field) (DataType, *EnumNode) {
+	if f.Kind == FieldReference && f.Target != nil {
+		if target := s.Entity(f.Target.Entity); target != nil {
+			if tf := target.Field(f.Target.Field); tf != nil {
+				return tf.DataType, tf.Enum
+			}
+		}
+	}
+	return f.DataType, f.Enum
+}
+
+// Resolve links everything that's referred to by name e.g. `role &user_role`
+// starts out pointing at a placeholder enum. it has to run before Validate
+func (s *Schema) Resolve() []error {
+	var errs []error
+
+	for _, e := range s.Entities {
+		for _, f := range e.Fields {
+			if err := s.checkTarget(f, e); err != nil {
+				errs = append(errs, withOrigin(err, f))
+			}
+			if f.DataType != DataEnum || f.Enum == nil || f.Enum.Inline() {
+				continue
+			}
+
+			named := s.Enum(f.Enum.Name)
+			if named == nil {
+				errs = append(errs, fmt.Errorf("field '%s' on '%s' references unknown enum '%s'",
+					f.Name, e.Name, f.Enum.Name))
+				continue
+			}
+			f.Enum = named
+		}
+	}
+
+	return errs
+}
+
+// checkTarget makes sure `owner @user.id` points at something that exists
+func (s *Schema) checkTarget(f *Field, e *EntityNode) error {
+	if f.Kind != FieldReference || f.Target == nil {
+		return nil
+	}
+
+	target := s.Entity(f.Target.Entity)
+	if target == nil {
+		return fmt.Errorf("field '%s' on '%s' references unknown entity '%s'",
+			f.Name, e.Name, f.Target.Entity)
+	}
+	if target.Field(f.Target.Field) == nil {
+		return fmt.Errorf("field '%s' on '%s' references unknown field '%s.%s'",
+			f.Name, e.Name, f.Target.Entity, f.Target.Field)
+	}
+	return nil
+}
+
+// Validate runs every check that needs the whole schema to be known
+func (s *Schema) Validate() []error {
+	var errs []error
+
+	for _, e := range s.Entities {
+		for _, f := range e.Fields {
+			for _, err := range []error{
+				ValidateFieldAttributes(f),
+				CheckComputed(f, e, s),
+				CheckRule(f, e, s),
+				checkEnumDefault(f),
+			} {
+				if err != nil {
+					errs = append(errs, withOrigin(err, f))
+				}
+			}
+		}
+
+		if e.SoftDelete {
+			if err := checkSoftDeleteField(e); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for _, r := range s.Routes {
+		if err := ValidateRoute(r, s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+func checkEnumDefault(f *Field) error {
+	if f.Enum == nil || f.Default == nil || f.Default.Kind != DefaultLiteral {
+		return nil
+	}
+	if f.Enum.Member(f.Default.Value) == nil {
+		return fmt.Errorf("default %q for field '%s' isn't a member of its enum", f.Default.Value, f.Name)
+	}
+	return nil
+}
+
+// errors about fields that came from a mixin point back at it since the
+// field isn't written anywhere in the entity
+func withOrigin(err error, f *Field) error {
+	if f.Origin == nil {
+		return err
+	}
+	return fmt.Errorf("%w (%s)", err, f.Origin)
+}
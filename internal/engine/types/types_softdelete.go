@@ -0,0 +1,65 @@
+package types
+
+import "fmt"
+
+// soft deleted entities never lose rows through a route; deleting sets
+// deleted_at, reads skip rows where it's set and restoring clears it.
+// rows only go away for good when they're purged
+
+const SoftDeleteField = "deleted_at"
+
+// EnableSoftDelete turns soft deletes on for the entity, adding deleted_at if
+// it isn't declared. the field is always readonly since clients have to go
+// through the delete and restore routes to change it
+func (e *EntityNode) EnableSoftDelete() error {
+	e.SoftDelete = true
+
+	f := e.Field(SoftDeleteField)
+	if f == nil {
+		e.Fields = append(e.Fields, &Field{
+			Name:       SoftDeleteField,
+			Kind:       FieldPrimitive,
+			DataType:   DataTimestamp,
+			Attributes: AttrReadonly,
+		})
+		return nil
+	}
+
+	if err := checkSoftDeleteField(e); err != nil {
+		return err
+	}
+	f.Attributes |= AttrReadonly
+
+	return nil
+}
+
+func checkSoftDeleteField(e *EntityNode) error {
+	f := e.Field(SoftDeleteField)
+	if f == nil {
+		return fmt.Errorf("soft deleted entity '%s' has no %s field", e.Name, SoftDeleteField)
+	}
+	if f.Kind != FieldPrimitive || f.DataType != DataTimestamp {
+		return fmt.Errorf("%s on soft deleted entity '%s' must be a timestamp", SoftDeleteField, e.Name)
+	}
+	if f.Attributes&(AttrRequired|AttrDefault) != 0 {
+		return fmt.Errorf("%s on soft deleted entity '%s' must start out empty", SoftDeleteField, e.Name)
+	}
+	return nil
+}
+
+var entityOptions = map[string]func(*EntityNode) error{
+	"soft_delete": (*EntityNode).EnableSoftDelete,
+}
+
+func IsEntityOption(s string) bool {
+	_, ok := entityOptions[s]
+	return ok
+}
+
+func StringToEntityOption(e *EntityNode, s string) error {
+	apply, ok := entityOptions[s]
+	if !ok {
+		return fmt.Errorf("unknown entity option: %s", s)
+	}
+	return apply(e)
+}
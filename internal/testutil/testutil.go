@@ -0,0 +1,32 @@
+package testutil
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// go test ./internal/engine/<package> -update rewrites the golden files of
+// any package whose tests compare with Golden
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// Golden compares got against testdata/<name> or rewrites it with -update
+func Golden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v; run with -update to create it", err)
+	}
+	if got != string(want) {
+		t.Fatalf("%s is out of date; run with -update and check the diff\ngot:\n%s", path, got)
+	}
+}
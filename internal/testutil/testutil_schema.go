@@ -0,0 +1,43 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"willofdaedalus/mime/internal/engine/lexer"
+	"willofdaedalus/mime/internal/engine/parser"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+// Shared holds the schemas the generators' tests have in common so a change
+// to one shows up in every package's goldens at once
+var Shared = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "testdata")
+}()
+
+// Load parses the schema at path and fails the test on any error
+func Load(t *testing.T, path string) *types.Schema {
+	t.Helper()
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, errs := parser.NewParser(lexer.NewFile(filepath.Base(path), string(src))).Schema()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected parse errors in %s: %v", path, errs)
+	}
+	return s
+}
+
+// Schemas lists the schemas in dir
+func Schemas(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.mime"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no schemas in %s: %v", dir, err)
+	}
+	return files
+}
@@ -1,7 +1,69 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"willofdaedalus/mime/internal/engine/cache"
+	"willofdaedalus/mime/internal/engine/types"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"ir":          {usage: "ir <schema.mime>\tprint the resolved schema as json", run: runIR},
+	"compile":     {usage: "compile [-o schema.mimec] <schema.mime>...\tcache the compiled schema for fast startup", run: runCompile},
+	"diagram":     {usage: "diagram [-format mermaid|dot] [-focus entity] [-depth 1] [-o file] <schema.mime>\tdraw the schema's entities and how they refer to each other", run: runDiagram},
+	"docs":        {usage: "docs [-format html|markdown] [-out site] <schema.mime>\twrite browsable pages for the schema's entities, enums and routes", run: runDocs},
+	"gen":         {usage: "gen go|graphql|jsonschema|openapi|proto|python|ts [-o file|dir] [target flags] <schema.mime>\tgenerate code from the schema", run: runGen},
+	"import":      {usage: "import sql|jsonschema|openapi [-o schema.mime] <file>\tturn another tool's schema into a .mime file", run: runImport},
+	"fingerprint": {usage: "fingerprint [-entities] [-json] <schema.mime>\tprint the schema's hash", run: runFingerprint},
+	"purge":       {usage: "purge [-older-than 30] [-dialect sqlite|postgres|mysql] [-now time] <schema.mime>\tprint the statements that remove rows soft deleted more than the given days ago", run: runPurge},
+	"seed":        {usage: "seed [-count 10] [-seed 1] [-format jsonl|sql] [-dialect sqlite] [-now time] [-o file] <schema.mime>\tgenerate fake rows that satisfy the schema's constraints", run: runSeed},
+	"sql":         {usage: "sql [-dialect sqlite|postgres|mysql] <schema.mime>\tprint the statements that create the schema's tables", run: runSQL},
+	"validate":    {usage: "validate [-json] <schema.mime> <entity> [payload.json]\tcheck a payload against an entity", run: runValidate},
+}
 
 func main() {
-	fmt.Println("hello mime")
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "mime: unknown command %s\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "mime %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: mime <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "\t%s\n", commands[name].usage)
+	}
+}
+
+// loadSchema parses, expands, resolves and validates a .mime file. a fresh
+// cache written next to it by mime compile is used instead when there is one
+func loadSchema(path string) (*types.Schema, error) {
+	s, _, err := cache.Load(path)
+	return s, err
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"willofdaedalus/mime/internal/engine/ddl"
+)
+
+// every example has to load the way the commands load it
+func TestExamples(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("examples", "*.mime"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no examples found")
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			if _, err := loadSchema(path); err != nil {
+				t.Errorf("%s: %v", path, err)
+			}
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	s, err := loadSchema(filepath.Join("examples", "user.mime"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		dialect string
+		want    string
+	}{
+		{
+			dialect: "sqlite",
+			want:    `DELETE FROM "student" WHERE "deleted_at" IS NOT NULL AND "deleted_at" < '2025-01-02T12:00:00Z';`,
+		},
+		{
+			dialect: "postgres",
+			want:    `DELETE FROM "student" WHERE "deleted_at" IS NOT NULL AND "deleted_at" < '2025-01-02T12:00:00Z';`,
+		},
+		{
+			// the cutoff is in the layout DATETIME columns are seeded with
+			dialect: "mysql",
+			want:    "DELETE FROM `student` WHERE `deleted_at` IS NOT NULL AND `deleted_at` < '2025-01-02 12:00:00.000000';",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			var b strings.Builder
+			if err := purge(&b, s, ddl.Dialects[tt.dialect], 30*24*time.Hour, now); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if b.String() != tt.want+"\n" {
+				t.Fatalf("expected:\n%s\ngot:\n%s", tt.want, b.String())
+			}
+		})
+	}
+
+	s, err = loadSchema(filepath.Join("internal", "engine", "ddl", "testdata", "defaults.mime"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := purge(io.Discard, s, ddl.SQLite, 0, now); err == nil {
+		t.Fatalf("expected an error for a schema without soft deleted entities")
+	}
+}